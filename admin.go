@@ -0,0 +1,399 @@
+package configurator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldSchema describes a configuration field and where it can be set.
+type FieldSchema struct {
+	Path     string   `json:"path"`
+	Key      string   `json:"key"`
+	Type     string   `json:"type"`
+	ENV      string   `json:"env,omitempty"`
+	Flag     string   `json:"flag,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Secret   bool     `json:"secret,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Dynamic  bool     `json:"dynamic,omitempty"`
+	Restart  bool     `json:"restart,omitempty"`
+	Enum     []string `json:"enum,omitempty"`
+}
+
+func fieldSchema(fi FieldInfo) FieldSchema {
+	s := FieldSchema{
+		Path:    fi.Path(),
+		Key:     fileKey(fi),
+		Type:    leaf(fi.Value()).Type().String(),
+		ENV:     fi.ENVKey(),
+		Flag:    fi.FlagKey(),
+		Default: fi.DefVal(),
+		Secret:  fi.Secret(),
+		Dynamic: isDynamic(fi.Value().Type()),
+	}
+	if f, ok := fi.(*fieldInfo); ok {
+		s.Required = f.required()
+		s.Restart = f.restart()
+		s.Enum = f.allowed()
+	}
+	return s
+}
+
+// fileKey is the dotted path of the field in YAML config files.
+func fileKey(fi FieldInfo) string {
+	if f, ok := fi.(*fieldInfo); ok {
+		return strings.Join(f.fileKeys("yaml"), ".")
+	}
+	return fi.Path()
+}
+
+// Describe returns the schema of the configuration struct v points to,
+// without loading it.
+func Describe(v interface{}) ([]FieldSchema, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidConfig
+	}
+	si, err := getStructInfo(reflect.New(rv.Elem().Type()).Interface(), nil)
+	if err != nil {
+		return nil, err
+	}
+	schema := make([]FieldSchema, len(si.Fields()))
+	for i, fi := range si.Fields() {
+		schema[i] = fieldSchema(fi)
+	}
+	return schema, nil
+}
+
+type adminReport struct {
+	Config     map[string]string `json:"config"`
+	Provenance map[string]string `json:"provenance"`
+	Schema     []FieldSchema     `json:"schema"`
+	Status     adminStatus       `json:"status"`
+	Findings   []Finding         `json:"findings,omitempty"`
+	Restarts   []Change          `json:"restarts,omitempty"`
+}
+
+type adminStatus struct {
+	Time     time.Time     `json:"time"`
+	Duration string        `json:"duration"`
+	Degraded bool          `json:"degraded"`
+	Checksum string        `json:"checksum,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Sources  []adminSource `json:"sources,omitempty"`
+}
+
+type adminSource struct {
+	Name      string `json:"name"`
+	Required  bool   `json:"required"`
+	Reachable bool   `json:"reachable"`
+	Keys      int    `json:"keys"`
+	Version   string `json:"version,omitempty"`
+	Fetch     string `json:"fetch"`
+	Apply     string `json:"apply"`
+	Error     string `json:"error,omitempty"`
+}
+
+type handlerOptions struct {
+	patch interface{}
+	store OverrideStore
+}
+
+type HandlerOption func(*handlerOptions)
+
+// WithPatch enables PATCH requests on the handler. The body is a JSON object
+// of field paths to values, null removing a previous override. Only Dynamic
+// fields of v, the struct loaded by the configurator, can be overridden.
+// The overrides are applied by reloading v with them as the last provider;
+// when the reload fails nothing changes. If store isn't nil the overrides
+// are also saved to it. Bodies over 1 MiB are rejected.
+func WithPatch(v interface{}, store OverrideStore) HandlerOption {
+	return func(o *handlerOptions) {
+		o.patch = v
+		o.store = store
+	}
+}
+
+// Handler serves, as JSON, the configuration applied by the last successful
+// load with secrets masked, its provenance and schema, and the status of the
+// last load, successful or not. With the history query parameter, it serves
+// the History of the configurator instead, and with WithPatch, a POST with
+// the rollback parameter, such as ?rollback=3, rolls back to that revision.
+// Mount it for operators, e.g. under /debug/config, behind the same access
+// control as other debug endpoints.
+func (c *Configurator) Handler(options ...HandlerOption) http.Handler {
+	opts := &handlerOptions{}
+	for _, fn := range options {
+		fn(opts)
+	}
+	allow := "GET, HEAD"
+	if opts.patch != nil {
+		allow += ", PATCH, POST"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case (r.Method == http.MethodGet || r.Method == http.MethodHead) && r.URL.Query().Has("history"):
+			writeJSON(w, http.StatusOK, c.History())
+		case r.Method == http.MethodGet || r.Method == http.MethodHead:
+			writeJSON(w, http.StatusOK, c.report())
+		case r.Method == http.MethodPatch && opts.patch != nil:
+			c.servePatch(w, r, opts)
+		case r.Method == http.MethodPost && opts.patch != nil && r.URL.Query().Has("rollback"):
+			c.serveRollback(w, r, opts)
+		default:
+			w.Header().Set("Allow", allow)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// maxPatchBytes bounds the body of a PATCH.
+const maxPatchBytes = 1 << 20
+
+func (c *Configurator) servePatch(w http.ResponseWriter, r *http.Request, opts *handlerOptions) {
+	var patch map[string]*json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPatchBytes)).Decode(&patch); err != nil {
+		code := http.StatusBadRequest
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	c.patchMu.Lock()
+	defer c.patchMu.Unlock()
+
+	si, err := getStructInfo(reflect.New(reflect.TypeOf(opts.patch).Elem()).Interface(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fields := make(map[string]FieldInfo, len(si.Fields()))
+	for _, fi := range si.Fields() {
+		fields[fi.Path()] = fi
+	}
+
+	c.mu.RLock()
+	dynamic := make(map[string]bool, len(c.schema))
+	for _, s := range c.schema {
+		dynamic[s.Path] = s.Dynamic
+	}
+	secret := secretPaths(c.schema)
+	prev := c.overrides
+	c.mu.RUnlock()
+
+	next := make(overrides, len(prev)+len(patch))
+	for k, v := range prev {
+		next[k] = v
+	}
+	for path, raw := range patch {
+		if !dynamic[path] {
+			http.Error(w, fmt.Sprintf("%s is not a dynamic field", path), http.StatusUnprocessableEntity)
+			return
+		}
+		if raw == nil {
+			delete(next, path)
+			continue
+		}
+		val, err := patchValue(fields[path], *raw)
+		if err != nil {
+			if secret[path] {
+				err = fmt.Errorf("%w, value of %s", ErrInvalidValue, path)
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next[path] = val
+	}
+
+	// save first, the store may also be a provider of the reload
+	if err := c.saveOverrides(r.Context(), opts.store, next); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := c.Reload(r.Context(), opts.patch); err != nil {
+		if err := c.saveOverrides(r.Context(), opts.store, prev); err != nil {
+			c.logger.Error("configurator: restoring overrides failed", "error", err)
+		}
+		http.Error(w, redactError(err, secret), http.StatusUnprocessableEntity)
+		return
+	}
+	for path := range patch {
+		c.logger.Info("configurator: override applied", "field", path, "remote", r.RemoteAddr)
+	}
+	writeJSON(w, http.StatusOK, c.report())
+}
+
+// patchValue converts the JSON value of a PATCH to the string the field
+// parses: arrays are joined with the list separator, and objects and
+// arrays are kept as JSON for format=json fields.
+func patchValue(fi FieldInfo, raw json.RawMessage) (string, error) {
+	var val interface{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&val); err != nil {
+		return "", err
+	}
+	if f, ok := fi.(*fieldInfo); ok && f.options().format == "json" {
+		var b bytes.Buffer
+		if err := json.Compact(&b, raw); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+	switch val := val.(type) {
+	case []interface{}:
+		if leaf(fi.Value()).Kind() != reflect.Slice {
+			return "", fmt.Errorf("%w, %s is not a list", ErrInvalidValue, fi.Path())
+		}
+		parts := make([]string, len(val))
+		for i, e := range val {
+			switch e.(type) {
+			case []interface{}, map[string]interface{}:
+				return "", fmt.Errorf("%w, nested value in %s, which has no format=json", ErrInvalidValue, fi.Path())
+			}
+			parts[i] = fmt.Sprint(e)
+			if strings.Contains(parts[i], sliceSeparator) {
+				return "", fmt.Errorf("%w, %q in %s contains the list separator %q", ErrInvalidValue, parts[i], fi.Path(), sliceSeparator)
+			}
+		}
+		return strings.Join(parts, sliceSeparator), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("%w, object for %s, which has no format=json", ErrInvalidValue, fi.Path())
+	}
+	return fmt.Sprint(val), nil
+}
+
+func (c *Configurator) serveRollback(w http.ResponseWriter, r *http.Request, opts *handlerOptions) {
+	id, err := strconv.Atoi(r.URL.Query().Get("rollback"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := c.rollback(r.Context(), opts.patch, id, opts.store); err != nil {
+		code := http.StatusUnprocessableEntity
+		if errors.Is(err, ErrNoRevision) {
+			code = http.StatusNotFound
+		}
+		c.mu.RLock()
+		secret := secretPaths(c.schema)
+		c.mu.RUnlock()
+		http.Error(w, redactError(err, secret), code)
+		return
+	}
+	writeJSON(w, http.StatusOK, c.report())
+}
+
+func (c *Configurator) saveOverrides(ctx context.Context, store OverrideStore, o overrides) error {
+	if store != nil {
+		if err := store.SaveOverrides(ctx, o); err != nil {
+			return err
+		}
+	}
+	c.mu.Lock()
+	c.overrides = o
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Configurator) report() adminReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	rep := adminReport{
+		Config:     make(map[string]string, len(c.values)),
+		Provenance: make(map[string]string, len(c.origins)),
+		Schema:     append([]FieldSchema{}, c.schema...),
+		Findings:   append([]Finding{}, c.findings...),
+		Restarts:   append([]Change(nil), c.restarts...),
+		Status: adminStatus{
+			Time:     c.last.Start,
+			Duration: c.last.Duration.String(),
+			Degraded: c.last.Degraded,
+		},
+	}
+	for _, s := range c.schema {
+		if v, ok := c.values[s.Path]; ok {
+			rep.Config[s.Path] = formatValue(v)
+		}
+		if s.Secret {
+			rep.Config[s.Path] = secretMask
+		}
+	}
+	for k, v := range c.origins {
+		rep.Provenance[k] = v
+	}
+	if c.values != nil {
+		rep.Status.Checksum = c.checksum(false)
+	}
+	secret := secretPaths(c.schema)
+	if c.last.Err != nil {
+		rep.Status.Error = redactError(c.last.Err, secret)
+	}
+	for _, s := range c.sources {
+		src := adminSource{
+			Name:      s.Name,
+			Required:  s.Required,
+			Reachable: s.Reachable,
+			Keys:      s.Keys,
+			Version:   s.Version,
+			Fetch:     s.Fetch.String(),
+			Apply:     s.Apply.String(),
+		}
+		if s.Err != nil {
+			src.Error = redactError(s.Err, secret)
+		}
+		rep.Status.Sources = append(rep.Status.Sources, src)
+	}
+	return rep
+}
+
+// redactError returns the message of err with the values of secret fields
+// that it quotes, such as parse errors do, masked.
+func redactError(err error, secret map[string]bool) string {
+	msg := err.Error()
+	var walk func(error)
+	walk = func(err error) {
+		if fe, ok := err.(*fieldError); ok {
+			for _, path := range fe.paths {
+				if !secret[path] {
+					continue
+				}
+				msg = strings.ReplaceAll(msg, fe.err.Error(), fmt.Sprintf("%v %s", fe.kind, secretMask))
+				if fe.value != "" {
+					msg = strings.ReplaceAll(msg, fe.value, secretMask)
+				}
+				break
+			}
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			if e := u.Unwrap(); e != nil {
+				walk(e)
+			}
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				walk(e)
+			}
+		}
+	}
+	walk(err)
+	return msg
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,194 @@
+package configurator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Port     int    `config:"env,default=8080"`
+		Password string `config:"env,secret,required"`
+	}
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"PASSWORD=hunter2"}), WithDefaultProvider())
+	assert.NoError(t, c.Load(&example{}))
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.NotContains(t, rec.Body.String(), "hunter2")
+
+	var rep adminReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rep))
+	assert.Equal(t, map[string]string{"Port": "8080", "Password": secretMask}, rep.Config)
+	assert.Equal(t, map[string]string{"Port": "default", "Password": "env"}, rep.Provenance)
+	assert.Equal(t, []FieldSchema{
+		{Path: "Port", Key: "port", Type: "int", ENV: "PORT", Default: "8080"},
+		{Path: "Password", Key: "password", Type: "string", ENV: "PASSWORD", Secret: true, Required: true},
+	}, rep.Schema)
+	assert.Empty(t, rep.Status.Error)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(nil))
+	assert.Error(t, c.Load(&example{}))
+	rec = httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rep))
+	assert.Contains(t, rep.Status.Error, "Password")
+
+	rec = httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHandler_Patch(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Port  int              `config:"default=8080"`
+		Level Dynamic[string]  `config:"default=info"`
+		Rate  Dynamic[float64] `config:"default=0.5"`
+	}
+
+	store := NewOverrideFile(filepath.Join(t.TempDir(), "overrides.json"))
+	c := NewConfigurator(WithFileProvider(""), WithProvider(store), WithDefaultProvider())
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	h := c.Handler(WithPatch(cfg, store))
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := patch(`{"Level":"debug","Rate":0.75}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "debug", cfg.Level.Get())
+	assert.Equal(t, 0.75, cfg.Rate.Get())
+	assert.Equal(t, "override", c.Provenance()["Level"])
+
+	assert.Equal(t, http.StatusUnprocessableEntity, patch(`{"Port":9090}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, patch(`{"Rate":"fast"}`).Code)
+	assert.Equal(t, 0.75, cfg.Rate.Get())
+	assert.Equal(t, http.StatusBadRequest, patch(`{`).Code)
+
+	// a restarted app picks the saved overrides up
+	restarted := &example{}
+	assert.NoError(t, NewConfigurator(WithFileProvider(""), WithProvider(store), WithDefaultProvider()).Load(restarted))
+	assert.Equal(t, "debug", restarted.Level.Get())
+
+	assert.Equal(t, http.StatusOK, patch(`{"Level":null}`).Code)
+	assert.Equal(t, "info", cfg.Level.Get())
+	assert.Equal(t, 0.75, cfg.Rate.Get())
+
+	rec = httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHandler_PatchSecret(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Pin  Dynamic[int]      `config:"secret"`
+		Keys Dynamic[[]string] `config:"secret"`
+	}
+	c := NewConfigurator(WithFileProvider(""))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	h := c.Handler(WithPatch(cfg, nil))
+	patch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := patch(`{"Pin":"hunter2"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "hunter2")
+	assert.Contains(t, rec.Body.String(), "Pin")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, rec.Body.String(), `"error"`)
+	assert.NotContains(t, rec.Body.String(), "hunter2")
+
+	rec = patch(`{"Keys":["hunter,2"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "hunter")
+
+	rec = patch(`{"Pin":"` + strings.Repeat("1", maxPatchBytes) + `"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestHandler_PatchValues(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Tags   Dynamic[[]string]
+		Ports  Dynamic[[]int]
+		Limits Dynamic[map[string]int] `config:"format=json"`
+		Level  Dynamic[string]
+	}
+
+	store := NewOverrideFile(filepath.Join(t.TempDir(), "overrides.json"))
+	c := NewConfigurator(WithFileProvider(""), WithProvider(store))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	h := c.Handler(WithPatch(cfg, store))
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := patch(`{"Tags":["x","y"],"Ports":[80,443],"Limits":{"a":1, "b":2}}`)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []string{"x", "y"}, cfg.Tags.Get())
+	assert.Equal(t, []int{80, 443}, cfg.Ports.Get())
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, cfg.Limits.Get())
+
+	restarted := &example{}
+	assert.NoError(t, NewConfigurator(WithFileProvider(""), WithProvider(store)).Load(restarted))
+	assert.Equal(t, []string{"x", "y"}, restarted.Tags.Get())
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, restarted.Limits.Get())
+
+	for _, body := range []string{
+		`{"Tags":[["x"]]}`,
+		`{"Tags":["x,y"]}`,
+		`{"Level":["x"]}`,
+		`{"Level":{"a":"b"}}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, patch(body).Code, body)
+	}
+	assert.Equal(t, []string{"x", "y"}, cfg.Tags.Get())
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		MySQL *struct {
+			Host string `yaml:"hostname" config:"env,flag"`
+		} `json:"db"`
+		Level Dynamic[string] `config:"default=info"`
+	}
+
+	cfg := &example{}
+	schema, err := Describe(cfg)
+	assert.NoError(t, err)
+	assert.Nil(t, cfg.MySQL)
+	assert.Equal(t, []FieldSchema{
+		{Path: "MySQL.Host", Key: "mysql.hostname", Type: "string", ENV: "MYSQL_HOST", Flag: "mysql-host"},
+		{Path: "Level", Key: "level", Type: "string", Default: "info", Dynamic: true},
+	}, schema)
+
+	_, err = Describe(example{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
@@ -0,0 +1,83 @@
+package configurator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// AuditRecord describes the configuration applied by a successful load.
+// Changes are relative to the previous load of the same configurator.
+type AuditRecord struct {
+	Time     time.Time
+	Changes  []Change
+	Checksum string
+}
+
+// Change is a field whose value differs from the previous load. Values of
+// fields tagged secret are masked.
+type Change struct {
+	Key    string
+	Old    string
+	New    string
+	Source string
+}
+
+type AuditSink interface {
+	Audit(AuditRecord)
+}
+
+// AuditFunc adapts a function to an AuditSink.
+type AuditFunc func(AuditRecord)
+
+func (f AuditFunc) Audit(r AuditRecord) {
+	f(r)
+}
+
+// diff compares the fields with the values of the previous load and returns
+// the changes sorted by key.
+func diff(fields []FieldInfo, prev map[string]reflect.Value, origins map[string]string) []Change {
+	var changes []Change
+	for _, fi := range fields {
+		cur := leaf(fi.Value())
+		old, ok := prev[fi.Path()]
+		if !ok {
+			old = reflect.Zero(cur.Type())
+		}
+		if reflect.DeepEqual(old.Interface(), cur.Interface()) {
+			continue
+		}
+		c := Change{
+			Key:    fi.Path(),
+			Old:    formatValue(old),
+			New:    formatValue(cur),
+			Source: origins[fi.Path()],
+		}
+		if fi.Secret() {
+			c.Old, c.New = secretMask, secretMask
+		}
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// checksum is a stable hash of the values by path but the excluded ones,
+// independent of field order.
+func checksum(values map[string]reflect.Value, exclude map[string]bool) string {
+	lines := make([]string, 0, len(values))
+	for path, v := range values {
+		if !exclude[path] {
+			lines = append(lines, path+"="+formatValue(v))
+		}
+	}
+	sort.Strings(lines)
+	h := sha256.New()
+	for _, l := range lines {
+		h.Write([]byte(l))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
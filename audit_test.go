@@ -0,0 +1,50 @@
+package configurator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAuditSink(t *testing.T) {
+	type example struct {
+		Name     string   `config:"env"`
+		Password string   `config:"env,secret"`
+		Tags     []string `config:"env"`
+		Port     int      `config:"default=80"`
+	}
+	now := time.Date(2020, 9, 30, 22, 51, 49, 0, time.UTC)
+	var records []AuditRecord
+	environ := []string{"NAME=Tom", "PASSWORD=foo", "TAGS=a,b"}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithLookupEnv(func(k string) (string, bool) { return environLookup(environ)(k) }),
+		WithDefaultProvider(),
+		WithClock(func() time.Time { return now }),
+		WithAuditSink(AuditFunc(func(r AuditRecord) { records = append(records, r) })),
+	)
+
+	assert.NoError(t, c.Load(&example{}))
+	assert.Len(t, records, 1)
+	assert.Equal(t, now, records[0].Time)
+	assert.Equal(t, []Change{
+		{Key: "Name", Old: "", New: "Tom", Source: "env"},
+		{Key: "Password", Old: "******", New: "******", Source: "env"},
+		{Key: "Port", Old: "0", New: "80", Source: "default"},
+		{Key: "Tags", Old: "", New: "a,b", Source: "env"},
+	}, records[0].Changes)
+
+	environ = []string{"NAME=Tom", "PASSWORD=bar", "TAGS=a,b"}
+	assert.NoError(t, c.Load(&example{}))
+	assert.Len(t, records, 2)
+	assert.Equal(t, []Change{
+		{Key: "Password", Old: "******", New: "******", Source: "env"},
+	}, records[1].Changes)
+	assert.NotEqual(t, records[0].Checksum, records[1].Checksum)
+
+	assert.NoError(t, c.Load(&example{}))
+	assert.Empty(t, records[2].Changes)
+	assert.Equal(t, records[1].Checksum, records[2].Checksum)
+}
@@ -0,0 +1,128 @@
+package configurator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// Cassette returns a provider standing in for the remote source p in
+// tests. With record, it applies p and writes the values p set, by field
+// path, to the fixture file filename; without, it sets them from the file
+// and never calls p, so that tests of loading run without the live
+// backend and always see the same values. Fixtures hold secrets in clear.
+func Cassette(p Provider, filename string, record bool) *cassette {
+	return &cassette{p: p, filename: filename, record: record}
+}
+
+type cassette struct {
+	p        Provider
+	filename string
+	record   bool
+}
+
+// tape is the content of a fixture file.
+type tape struct {
+	Source  string            `json:"source"`
+	Version string            `json:"version,omitempty"`
+	Values  map[string]string `json:"values"`
+}
+
+func (c *cassette) Provide(v interface{}, si StructInfo) error {
+	return c.ProvideContext(context.Background(), v, si)
+}
+
+func (c *cassette) ProvideContext(ctx context.Context, v interface{}, si StructInfo) error {
+	p, err := c.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	return provide(ctx, p, v, si, 0)
+}
+
+// Fetch fetches p when recording, and reads the fixture file otherwise.
+func (c *cassette) Fetch(ctx context.Context) (Provider, error) {
+	if c.record {
+		p := c.p
+		if f, ok := p.(Fetcher); ok {
+			fetched, err := f.Fetch(ctx)
+			if err != nil {
+				return nil, err
+			}
+			p = fetched
+		}
+		return &recorder{c: c, p: p}, nil
+	}
+	b, err := os.ReadFile(c.filename)
+	if err != nil {
+		return nil, fmt.Errorf("cassette/Fetch: %w", err)
+	}
+	var t tape
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("cassette/Fetch: %w [%s]", err, c.filename)
+	}
+	return &replayTape{replaySource: replaySource{name: c.String(), values: t.Values}, version: t.Version}, nil
+}
+
+func (c *cassette) String() string {
+	return providerName(c.p)
+}
+
+// recorder applies a provider and writes what it set to the fixture file.
+type recorder struct {
+	c *cassette
+	p Provider
+}
+
+func (r *recorder) Provide(v interface{}, si StructInfo) error {
+	return r.ProvideContext(context.Background(), v, si)
+}
+
+func (r *recorder) ProvideContext(ctx context.Context, v interface{}, si StructInfo) error {
+	fields := si.Fields()
+	before := make([]reflect.Value, len(fields))
+	for i, fi := range fields {
+		before[i] = copyValue(leaf(fi.Value()))
+		if f, ok := fi.(*fieldInfo); ok {
+			f.explicit, f.raw = false, nil
+		}
+	}
+	if err := provide(ctx, r.p, v, si, 0); err != nil {
+		return err
+	}
+	sv := SourceValues{Name: r.c.String(), Values: map[string]string{}}
+	for i, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if ok && f.explicit || !reflect.DeepEqual(before[i].Interface(), leaf(fi.Value()).Interface()) {
+			sv.add(fi)
+		}
+	}
+	t := tape{Source: sv.Name, Values: sv.Values, Version: r.Version()}
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.c.filename, append(b, '\n'), 0o600); err != nil {
+		return fmt.Errorf("cassette/Provide: %w", err)
+	}
+	return nil
+}
+
+func (r *recorder) Version() string {
+	if ver, ok := r.p.(Versioner); ok {
+		return ver.Version()
+	}
+	return ""
+}
+
+// replayTape sets the values of a fixture file.
+type replayTape struct {
+	replaySource
+	version string
+}
+
+func (t *replayTape) Version() string {
+	return t.version
+}
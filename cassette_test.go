@@ -0,0 +1,73 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// remoteSource is a Fetcher serving values by field path, as a config
+// center would.
+type remoteSource struct {
+	values  map[string]string
+	version string
+	err     error
+	fetches int
+}
+
+func (s *remoteSource) Provide(v interface{}, si StructInfo) error {
+	p, err := s.Fetch(context.Background())
+	if err != nil {
+		return err
+	}
+	return p.Provide(v, si)
+}
+
+func (s *remoteSource) Fetch(context.Context) (Provider, error) {
+	s.fetches++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &replayTape{replaySource: replaySource{name: "remote", values: s.values}, version: s.version}, nil
+}
+
+func (s *remoteSource) String() string {
+	return "remote"
+}
+
+func TestCassette(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Name  string
+		Port  int
+		Hosts []string
+		Local string `config:"default=x"`
+	}
+	filename := filepath.Join(t.TempDir(), "remote.json")
+	live := &remoteSource{values: map[string]string{"Name": "web", "Port": "80", "Hosts": "a,b"}, version: "42"}
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithProvider(Cassette(live, filename, true)))
+	want := &example{}
+	assert.NoError(t, c.Load(want))
+	assert.Equal(t, example{Name: "web", Port: 80, Hosts: []string{"a", "b"}, Local: "x"}, *want)
+	assert.Equal(t, 1, live.fetches)
+	b, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"source":"remote","version":"42","values":{"Name":"web","Port":"80","Hosts":"a,b"}}`, string(b))
+
+	// the backend is down, the fixture answers for it
+	down := &remoteSource{err: errors.New("connection refused")}
+	c = NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithProvider(Cassette(down, filename, false)))
+	got := &example{}
+	assert.NoError(t, c.Load(got))
+	assert.Equal(t, want, got)
+	assert.Equal(t, 0, down.fetches)
+	assert.Equal(t, "remote", c.Provenance()["Name"])
+	assert.Equal(t, "42", c.Health().Sources[0].Version)
+
+	c = NewConfigurator(WithFileProvider(""), WithProvider(Cassette(down, filepath.Join(t.TempDir(), "missing.json"), false)))
+	assert.True(t, errors.Is(c.Load(&example{}), os.ErrNotExist))
+}
@@ -0,0 +1,144 @@
+package configurator
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// cgroupRoot is where the cgroup file system of the container is mounted.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupUnlimited is what cgroup v1 reports as the memory limit of
+// containers without one, rounded to pages.
+const cgroupUnlimited = math.MaxInt64 / 4096 * 4096
+
+// cpuQuota returns the CPUs the cgroup of the process may use, rounded
+// down and at least 1, or the CPUs of the host without a quota.
+func cpuQuota() (string, error) {
+	quota, period, err := readCPUMax()
+	if err != nil {
+		return "", err
+	}
+	if quota <= 0 || period <= 0 {
+		return strconv.Itoa(runtime.NumCPU()), nil
+	}
+	n := int(quota / period)
+	if n < 1 {
+		n = 1
+	}
+	return strconv.Itoa(n), nil
+}
+
+// readCPUMax reads the CPU quota and period of cgroup v2, or else v1, a
+// quota of 0 meaning none.
+func readCPUMax() (quota, period float64, err error) {
+	b, err := os.ReadFile(filepath.Join(cgroupRoot, "cpu.max"))
+	if err == nil {
+		fields := strings.Fields(string(b))
+		if len(fields) != 2 {
+			return 0, 0, fmt.Errorf("%w, cpu.max: %q", ErrInvalidValue, b)
+		}
+		if fields[0] == "max" {
+			return 0, 0, nil
+		}
+		if quota, err = strconv.ParseFloat(fields[0], 64); err == nil {
+			period, err = strconv.ParseFloat(fields[1], 64)
+		}
+		return quota, period, err
+	}
+	q, qerr := readCgroupInt("cpu/cpu.cfs_quota_us")
+	p, perr := readCgroupInt("cpu/cpu.cfs_period_us")
+	if qerr != nil || perr != nil || q < 0 {
+		// no cgroup, or no quota
+		return 0, 0, nil
+	}
+	return float64(q), float64(p), nil
+}
+
+// memoryLimit returns the memory limit of the cgroup of the process in
+// bytes, or the memory of the host without a limit.
+func memoryLimit() (string, error) {
+	if b, err := os.ReadFile(filepath.Join(cgroupRoot, "memory.max")); err == nil {
+		if s := strings.TrimSpace(string(b)); s != "max" {
+			return s, nil
+		}
+	} else if n, err := readCgroupInt("memory/memory.limit_in_bytes"); err == nil && n < cgroupUnlimited {
+		return strconv.FormatInt(n, 10), nil
+	}
+	return hostMemory()
+}
+
+func readCgroupInt(name string) (int64, error) {
+	b, err := os.ReadFile(filepath.Join(cgroupRoot, name))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+}
+
+// procMeminfo lists the memory of the host.
+var procMeminfo = "/proc/meminfo"
+
+func hostMemory() (string, error) {
+	f, err := os.Open(procMeminfo)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// MemTotal:       16318412 kB
+		fields := strings.Fields(s.Text())
+		if len(fields) == 3 && fields[0] == "MemTotal:" && fields[2] == "kB" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return "", err
+			}
+			return strconv.FormatInt(kb*1024, 10), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%w, no MemTotal in %s", ErrNotFound, procMeminfo)
+}
+
+// splitDefaultFn splits `defaultFn=memoryLimit*0.25` into the name of the
+// func and the factor its value is scaled by, 1 without one.
+func splitDefaultFn(s string) (string, float64, error) {
+	name, factor, ok := strings.Cut(s, "*")
+	if !ok {
+		return s, 1, nil
+	}
+	f, err := strconv.ParseFloat(factor, 64)
+	if err != nil || f <= 0 || math.IsInf(f, 0) {
+		return "", 0, fmt.Errorf("%w, `defaultFn=%s` needs a positive factor", ErrInvalidTagFormat, s)
+	}
+	return name, f, nil
+}
+
+// scaleDefault multiplies v, a number, by factor. Integers stay integers,
+// rounded down and at least 1 unless v is 0.
+func scaleDefault(v string, factor float64) (string, error) {
+	if factor == 1 {
+		return v, nil
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		scaled := int64(float64(n) * factor)
+		if scaled < 1 && n > 0 {
+			scaled = 1
+		}
+		return strconv.FormatInt(scaled, 10), nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w, %q can't be scaled, not a number", ErrInvalidValue, v)
+	}
+	return strconv.FormatFloat(f*factor, 'g', -1, 64), nil
+}
@@ -0,0 +1,82 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCgroupDefaults(t *testing.T) {
+	root := t.TempDir()
+	cgroupRoot = root
+	defer func() { cgroupRoot = "/sys/fs/cgroup" }()
+	write := func(name, content string) {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o700))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0o600))
+	}
+	type example struct {
+		Workers    int   `config:"defaultFn=cpuQuota*2"`
+		CPUs       int   `config:"defaultFn=CPUQuota"`
+		CacheBytes int64 `config:"defaultFn=memoryLimit*0.25"`
+		Limit      int64 `config:"defaultFn=MemoryLimit"`
+	}
+	load := func() example {
+		cfg := example{}
+		assert.NoError(t, NewConfigurator(WithFileProvider(""), WithDefaultProvider()).Load(&cfg))
+		return cfg
+	}
+
+	// cgroup v2
+	write("cpu.max", "250000 100000\n")
+	write("memory.max", "1073741824\n")
+	assert.Equal(t, example{Workers: 4, CPUs: 2, CacheBytes: 268435456, Limit: 1073741824}, load())
+
+	// cgroup v1, a quota under one CPU
+	assert.NoError(t, os.Remove(filepath.Join(root, "cpu.max")))
+	assert.NoError(t, os.Remove(filepath.Join(root, "memory.max")))
+	write("cpu/cpu.cfs_quota_us", "50000\n")
+	write("cpu/cpu.cfs_period_us", "100000\n")
+	write("memory/memory.limit_in_bytes", "536870912\n")
+	assert.Equal(t, example{Workers: 2, CPUs: 1, CacheBytes: 134217728, Limit: 536870912}, load())
+
+	// no limits, the resources of the host
+	write("cpu/cpu.cfs_quota_us", "-1\n")
+	write("memory/memory.limit_in_bytes", strconv.FormatInt(cgroupUnlimited, 10))
+	cfg := load()
+	assert.Equal(t, runtime.NumCPU(), cfg.CPUs)
+	if _, err := os.Stat(procMeminfo); err == nil {
+		assert.Greater(t, cfg.Limit, int64(0))
+		assert.Equal(t, cfg.Limit/4, cfg.CacheBytes)
+	}
+}
+
+func TestDefaultFnFactor(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Ratio float64 `config:"defaultFn=half*0.5"`
+		Name  string  `config:"defaultFn=name*2"`
+	}
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider(),
+		WithDefaultFunc("half", func() (string, error) { return "0.5", nil }),
+		WithDefaultFunc("name", func() (string, error) { return "web", nil }))
+	err := c.Load(&example{})
+	if assert.True(t, errors.Is(err, ErrInvalidValue)) {
+		assert.Contains(t, err.Error(), `"web" can't be scaled, not a number [Name]`)
+	}
+	type ratio struct {
+		Ratio float64 `config:"defaultFn=half*0.5"`
+	}
+	cfg := &ratio{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, 0.25, cfg.Ratio)
+
+	type invalid struct {
+		Workers int `config:"defaultFn=cpuQuota*-1"`
+	}
+	assert.True(t, errors.Is(c.Load(&invalid{}), ErrInvalidTagFormat))
+}
@@ -0,0 +1,138 @@
+// Package cli runs the configurator command line tool against a
+// configuration struct. cmd/configurator generates a small program calling
+// Main with the struct of the package it is pointed at; programs may also
+// embed it behind a hidden subcommand of their own:
+//
+//	func main() {
+//		cli.Main(&config.Config{})
+//	}
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/ruosing/configurator"
+)
+
+var ErrUsage = errors.New("usage: configurator lint|doc|schema|example|validate FILE")
+
+// Main runs the command in os.Args against v and exits non-zero on failure.
+func Main(v interface{}) {
+	if err := Run(os.Args[1:], v, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Run runs one command against the configuration struct v points to:
+//
+//	lint      check the struct tags
+//	doc       print a markdown table of the fields
+//	schema    print a JSON Schema of config files
+//	example   print an example YAML config file with the defaults
+//	validate  check that a config file decodes and has no unknown keys
+func Run(args []string, v interface{}, w io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	if args[0] == "lint" {
+		return lint(w, v)
+	}
+	schema, err := configurator.Describe(v)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "doc":
+		return doc(w, schema)
+	case "schema":
+		return jsonSchema(w, schema)
+	case "example":
+		return example(w, schema)
+	case "validate":
+		if len(args) != 2 {
+			return ErrUsage
+		}
+		return validate(w, v, schema, args[1])
+	}
+	return ErrUsage
+}
+
+func lint(w io.Writer, v interface{}) error {
+	var problems []string
+	for _, p := range configurator.LintTags(v) {
+		problems = append(problems, p.String())
+	}
+	// fields can't be described when tags don't parse, LintTags reported it
+	schema, _ := configurator.Describe(v)
+	envs := make(map[string]string)
+	flags := make(map[string]string)
+	for _, s := range schema {
+		if s.ENV != "" {
+			if other, ok := envs[s.ENV]; ok {
+				problems = append(problems, fmt.Sprintf("%s: env %s already used by %s", s.Path, s.ENV, other))
+			}
+			envs[s.ENV] = s.Path
+		}
+		if s.Flag != "" {
+			if other, ok := flags[s.Flag]; ok {
+				problems = append(problems, fmt.Sprintf("%s: flag %s already used by %s", s.Path, s.Flag, other))
+			}
+			flags[s.Flag] = s.Path
+		}
+	}
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("lint: %d problem(s)", len(problems))
+	}
+	return nil
+}
+
+func doc(w io.Writer, schema []configurator.FieldSchema) error {
+	fmt.Fprintln(w, "| Key | Type | Env | Flag | Default | Notes |")
+	fmt.Fprintln(w, "|-----|------|-----|------|---------|-------|")
+	for _, s := range schema {
+		var notes []string
+		if s.Required {
+			notes = append(notes, "required")
+		}
+		if s.Secret {
+			notes = append(notes, "secret")
+		}
+		if s.Dynamic {
+			notes = append(notes, "dynamic")
+		}
+		if len(s.Enum) > 0 {
+			allowed := make([]string, len(s.Enum))
+			for i, e := range s.Enum {
+				allowed[i] = code(e)
+			}
+			notes = append(notes, "one of "+strings.Join(allowed, "/"))
+		}
+		flag := ""
+		if s.Flag != "" {
+			flag = "`--" + s.Flag + "`"
+		}
+		fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s | %s |\n",
+			s.Key, s.Type, code(s.ENV), flag, code(s.Default), strings.Join(notes, ", "))
+	}
+	return nil
+}
+
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+func newOf(v interface{}) interface{} {
+	return reflect.New(reflect.TypeOf(v).Elem()).Interface()
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+type config struct {
+	Name  string `config:"env,flag,default=api"`
+	MySQL struct {
+		Host     string        `yaml:"hostname" config:"env,required"`
+		Password string        `config:"env,secret"`
+		Timeout  time.Duration `config:"default=5s"`
+	}
+	Tags  []string `config:"default=a"`
+	Debug configurator.Dynamic[bool]
+	Level string `config:"enum=debug,info,default=info"`
+}
+
+func run(t *testing.T, v interface{}, args ...string) (string, error) {
+	var buf bytes.Buffer
+	err := Run(args, v, &buf)
+	return buf.String(), err
+}
+
+func TestRun_Usage(t *testing.T) {
+	_, err := run(t, &config{})
+	assert.True(t, errors.Is(err, ErrUsage))
+	_, err = run(t, &config{}, "unknown")
+	assert.True(t, errors.Is(err, ErrUsage))
+	_, err = run(t, &config{}, "validate")
+	assert.True(t, errors.Is(err, ErrUsage))
+}
+
+func TestLint(t *testing.T) {
+	out, err := run(t, &config{}, "lint")
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+
+	type conflict struct {
+		A string `config:"env=KEY,flag=key"`
+		B string `config:"env=KEY,flag=key"`
+		C string `config:"env="`
+	}
+	out, err = run(t, &conflict{}, "lint")
+	assert.Error(t, err)
+	assert.Contains(t, out, "invalid tag format")
+
+	type duplicate struct {
+		A string `config:"env=KEY,flag=key"`
+		B string `config:"env=KEY,flag=key"`
+	}
+	out, err = run(t, &duplicate{}, "lint")
+	assert.Error(t, err)
+	assert.Contains(t, out, "B: env KEY already used by A")
+	assert.Contains(t, out, "B: flag key already used by A")
+
+	type malformed struct {
+		Port int    `config:"flag,default=eighty"`
+		Host string `config:"env=db_host,required,default=localhost,secrt"`
+	}
+	out, err = run(t, &malformed{}, "lint")
+	assert.Error(t, err)
+	assert.Contains(t, out, "Port: default \"eighty\"")
+	assert.Contains(t, out, "Host: env db_host has lower case letters")
+	assert.Contains(t, out, "Host: required field has a default")
+	assert.Contains(t, out, "Host: unknown tag option \"secrt\"")
+}
+
+func TestDoc(t *testing.T) {
+	out, err := run(t, &config{}, "doc")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "| `name` | string | `NAME` | `--name` | `api` |  |\n")
+	assert.Contains(t, out, "| `mysql.hostname` | string | `MYSQL_HOST` |  |  | required |\n")
+	assert.Contains(t, out, "| `debug` | bool |  |  |  | dynamic |\n")
+	assert.Contains(t, out, "| `level` | string |  |  | `info` | one of `debug`/`info` |\n")
+}
+
+func TestSchema(t *testing.T) {
+	out, err := run(t, &config{}, "schema")
+	assert.NoError(t, err)
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &doc))
+	props := doc["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string", "default": "api", "description": "env NAME, flag --name"}, props["name"])
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "default": []interface{}{"a"}}, props["tags"])
+	mysql := props["mysql"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"hostname"}, mysql["required"])
+	assert.Equal(t, "5s", mysql["properties"].(map[string]interface{})["timeout"].(map[string]interface{})["default"])
+	assert.Equal(t, []interface{}{"debug", "info"}, props["level"].(map[string]interface{})["enum"])
+}
+
+func TestExample(t *testing.T) {
+	out, err := run(t, &config{}, "example")
+	assert.NoError(t, err)
+	assert.Equal(t, `name: api # env NAME, flag --name
+mysql:
+  hostname: "" # env MYSQL_HOST
+  password: "" # env MYSQL_PASSWORD, secret
+  timeout: 5s
+tags: [a]
+debug: false
+level: info # one of debug|info
+`, out)
+
+	// the example is itself a valid config file
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte(out), 0o600))
+	_, err = run(t, &config{}, "validate", filename)
+	assert.NoError(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("name: api\nmysql:\n  hostname: db\n  port: 3306\n  timeuot: 1s\nextra: 1\n"), 0o600))
+	out, err := run(t, &config{}, "validate", filename)
+	assert.Error(t, err)
+	assert.Contains(t, out, "unknown key extra\n")
+	assert.Contains(t, out, "unknown key mysql.port\n")
+	assert.Contains(t, out, "unknown key mysql.timeuot; did you mean mysql.timeout?\n")
+
+	assert.NoError(t, os.WriteFile(filename, []byte("mysql:\n  timeout: soon\n"), 0o600))
+	_, err = run(t, &config{}, "validate", filename)
+	assert.Error(t, err)
+}
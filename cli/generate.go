@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ruosing/configurator"
+	"gopkg.in/yaml.v3"
+)
+
+// jsonType maps a Go type name to a JSON Schema type, and the item type for
+// arrays.
+func jsonType(typ string) (string, string) {
+	typ = strings.TrimLeft(typ, "*")
+	switch {
+	case typ == "[]uint8":
+		return "string", ""
+	case strings.HasPrefix(typ, "[]"):
+		item, _ := jsonType(strings.TrimPrefix(typ, "[]"))
+		return "array", item
+	case strings.HasPrefix(typ, "map["):
+		return "object", ""
+	case typ == "bool":
+		return "boolean", ""
+	case strings.HasPrefix(typ, "int"), strings.HasPrefix(typ, "uint"):
+		return "integer", ""
+	case strings.HasPrefix(typ, "float"):
+		return "number", ""
+	}
+	return "string", ""
+}
+
+// typedDefault converts a default literal to the JSON value of its type.
+func typedDefault(typ, def string) interface{} {
+	switch t, item := jsonType(typ); t {
+	case "boolean":
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	case "integer":
+		if i, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(def, 64); err == nil {
+			return f
+		}
+	case "array":
+		var items []interface{}
+		for _, s := range strings.Split(def, ",") {
+			items = append(items, typedDefault(item, strings.TrimSpace(s)))
+		}
+		return items
+	}
+	return def
+}
+
+type object struct {
+	Schema     string                 `json:"$schema,omitempty"`
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Required   []string               `json:"required,omitempty"`
+}
+
+type property struct {
+	Type        string            `json:"type"`
+	Items       map[string]string `json:"items,omitempty"`
+	Default     interface{}       `json:"default,omitempty"`
+	Enum        []interface{}     `json:"enum,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+func jsonSchema(w io.Writer, schema []configurator.FieldSchema) error {
+	root := &object{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Type:       "object",
+		Properties: map[string]interface{}{},
+	}
+	for _, s := range schema {
+		keys := strings.Split(s.Key, ".")
+		o := root
+		for _, k := range keys[:len(keys)-1] {
+			next, ok := o.Properties[k].(*object)
+			if !ok {
+				next = &object{Type: "object", Properties: map[string]interface{}{}}
+				o.Properties[k] = next
+			}
+			o = next
+		}
+		typ, item := jsonType(s.Type)
+		p := &property{Type: typ, Description: description(s)}
+		if item != "" {
+			p.Items = map[string]string{"type": item}
+		}
+		if s.Default != "" {
+			p.Default = typedDefault(s.Type, s.Default)
+		}
+		for _, e := range s.Enum {
+			p.Enum = append(p.Enum, typedDefault(s.Type, e))
+		}
+		last := keys[len(keys)-1]
+		o.Properties[last] = p
+		if s.Required {
+			o.Required = append(o.Required, last)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(root)
+}
+
+func description(s configurator.FieldSchema) string {
+	var parts []string
+	if s.ENV != "" {
+		parts = append(parts, "env "+s.ENV)
+	}
+	if s.Flag != "" {
+		parts = append(parts, "flag --"+s.Flag)
+	}
+	if s.Secret {
+		parts = append(parts, "secret")
+	}
+	if len(s.Enum) > 0 {
+		parts = append(parts, "one of "+strings.Join(s.Enum, "|"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func example(w io.Writer, schema []configurator.FieldSchema) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range schema {
+		keys := strings.Split(s.Key, ".")
+		m := root
+		for _, k := range keys[:len(keys)-1] {
+			m = mapping(m, k)
+		}
+		val := &yaml.Node{Kind: yaml.ScalarNode, Value: s.Default, LineComment: description(s)}
+		if s.Default == "" {
+			switch t, _ := jsonType(s.Type); t {
+			case "boolean":
+				val.Value = "false"
+			case "integer", "number":
+				val.Value = "0"
+			case "array":
+				val.Kind, val.Style = yaml.SequenceNode, yaml.FlowStyle
+			case "object":
+				val = &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle, LineComment: val.LineComment}
+			default:
+				val.Style = yaml.DoubleQuotedStyle
+			}
+		}
+		if t, _ := jsonType(s.Type); t == "array" && s.Default != "" {
+			val.Kind, val.Style, val.Value = yaml.SequenceNode, yaml.FlowStyle, ""
+			for _, item := range strings.Split(s.Default, ",") {
+				val.Content = append(val.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(item)})
+			}
+		}
+		if s.Secret {
+			val.Value, val.Style = "", yaml.DoubleQuotedStyle
+		}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: keys[len(keys)-1]}, val)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// mapping returns the mapping under key in m, adding it if needed.
+func mapping(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, n)
+	return n
+}
+
+// validate loads filename into a new value of v's type with defaults and
+// reports keys that match no field. Missing required fields aren't reported
+// as they may be set by env vars or flags.
+func validate(w io.Writer, v interface{}, schema []configurator.FieldSchema, filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	// keys of fields and their sections, and keys under a field such as the
+	// entries of maps
+	sections := map[string]bool{}
+	fields := map[string]bool{"version": true, "profiles": true}
+	for _, s := range schema {
+		keys := strings.Split(strings.ToLower(s.Key), ".")
+		for i := range keys[:len(keys)-1] {
+			sections[strings.Join(keys[:i+1], ".")] = true
+		}
+		fields[strings.Join(keys, ".")] = true
+	}
+	var unknown []string
+	for _, k := range flatten("", raw) {
+		if !sections[strings.ToLower(k)] && !isKnown(fields, k) {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	known := make([]string, 0, len(fields))
+	for k := range fields {
+		known = append(known, k)
+	}
+	for _, k := range unknown {
+		if near := configurator.Suggest(k, known); len(near) > 0 {
+			fmt.Fprintf(w, "%s: unknown key %s; did you mean %s?\n", filename, k, strings.Join(near, " or "))
+			continue
+		}
+		fmt.Fprintf(w, "%s: unknown key %s\n", filename, k)
+	}
+
+	c := configurator.NewConfigurator(configurator.WithFileProvider(filename), configurator.WithDefaultProvider())
+	if err := c.Load(newOf(v)); err != nil && !errors.Is(err, configurator.ErrRequired) {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%s: %d unknown key(s)", filename, len(unknown))
+	}
+	return nil
+}
+
+func flatten(prefix string, m map[string]interface{}) []string {
+	var keys []string
+	for k, v := range m {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]interface{}); ok && len(sub) > 0 {
+			keys = append(keys, flatten(k, sub)...)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// isKnown reports whether key or one of its parents is a field.
+func isKnown(known map[string]bool, key string) bool {
+	key = strings.ToLower(key)
+	for {
+		if known[key] {
+			return true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
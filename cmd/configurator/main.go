@@ -0,0 +1,87 @@
+// Command configurator lints, documents and validates configuration structs
+// in CI. It generates a program calling cli.Main with the struct named by
+// -type and runs it with go run from the current module:
+//
+//	configurator -type=example.com/app/config.Config lint
+//	configurator -type=example.com/app/config.Config doc > CONFIG.md
+//	configurator -type=example.com/app/config.Config schema > config.schema.json
+//	configurator -type=example.com/app/config.Config example > config.example.yaml
+//	configurator -type=example.com/app/config.Config validate config/config.yaml
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+func main() {
+	typ := flag.String("type", "", "configuration struct, as import/path.Type")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: configurator -type=import/path.Type lint|doc|schema|example|validate FILE")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typ == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*typ, flag.Args()); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.ExitCode())
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(typ string, args []string) error {
+	src, err := program(typ)
+	if err != nil {
+		return err
+	}
+	// the program must live in the current module to import the struct
+	dir, err := os.MkdirTemp(".", ".configurator-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), src, 0o600); err != nil {
+		return err
+	}
+	cmd := exec.Command("go", append([]string{"run", "./" + filepath.Base(dir)}, args...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+var tmpl = template.Must(template.New("main").Parse(`// Code generated by configurator. DO NOT EDIT.
+
+package main
+
+import (
+	"github.com/ruosing/configurator/cli"
+	target {{printf "%q" .Package}}
+)
+
+func main() {
+	cli.Main(&target.{{.Type}}{})
+}
+`))
+
+// program returns the source of the program running the cli for typ.
+func program(typ string) ([]byte, error) {
+	i := strings.LastIndex(typ, ".")
+	if i <= 0 || i == len(typ)-1 || strings.Contains(typ[i:], "/") {
+		return nil, fmt.Errorf("invalid -type %q, want import/path.Type", typ)
+	}
+	var b bytes.Buffer
+	err := tmpl.Execute(&b, struct{ Package, Type string }{typ[:i], typ[i+1:]})
+	return b.Bytes(), err
+}
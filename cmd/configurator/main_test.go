@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgram(t *testing.T) {
+	src, err := program("example.com/app/config.Config")
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), `target "example.com/app/config"`)
+	assert.Contains(t, string(src), "cli.Main(&target.Config{})")
+
+	for _, typ := range []string{"Config", "example.com/app/config.", "example.com/app.v2/config"} {
+		_, err := program(typ)
+		assert.Error(t, err, typ)
+	}
+}
@@ -0,0 +1,222 @@
+package configurator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// Command is a subcommand of a tool, such as "serve" or "migrate", with its
+// own configuration struct.
+type Command struct {
+	Name string
+	// Usage is the one line summary listed by help.
+	Usage string
+	// Config points to the configuration struct of the command, nil when it
+	// has none. Its fields are loaded along with those of the root
+	// configuration, as if they were one struct.
+	Config interface{}
+	// Run runs the command with the arguments left after the flags.
+	Run func(ctx context.Context, args []string) error
+}
+
+// Dispatch runs the command named by the first argument that isn't a flag,
+// as in "tool -verbose serve -port=8080", after loading root, the
+// configuration shared by all commands, and the configuration of the
+// command. Flags of both may come before or after the command name, and
+// -h prints them with the list of commands. Without a command, root is
+// loaded and Dispatch fails with ErrCommand.
+func (c *Configurator) Dispatch(ctx context.Context, root interface{}, commands ...Command) error {
+	rv := reflect.ValueOf(root)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidConfig
+	}
+	cmd, args, err := findCommand(rv.Elem().Type(), os.Args[1:], commands)
+	if err != nil {
+		return err
+	}
+	fp := c.flagProvider()
+	if fp != nil {
+		fp.args = args
+	}
+	flag.CommandLine.Usage = commandUsage(cmd, commands)
+	if cmd == nil {
+		if err := c.LoadContext(ctx, root); err != nil {
+			return err
+		}
+		return fmt.Errorf("configurator/Dispatch: %w, expected one of %s", ErrCommand, commandNames(commands))
+	}
+
+	configs := []reflect.Value{rv.Elem()}
+	if cmd.Config != nil {
+		cv := reflect.ValueOf(cmd.Config)
+		if cv.Kind() != reflect.Ptr || cv.IsNil() || cv.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("configurator/Dispatch: %w [%s]", ErrInvalidConfig, cmd.Name)
+		}
+		configs = append(configs, cv.Elem())
+	}
+	merged, err := mergeStructs(configs)
+	if err != nil {
+		return fmt.Errorf("configurator/Dispatch: %w [%s]", err, cmd.Name)
+	}
+	if err := c.LoadContext(ctx, merged.Interface()); err != nil {
+		return err
+	}
+	merged.copyBack()
+	for _, v := range configs {
+		if val, ok := v.Addr().Interface().(Validator); ok {
+			if err := val.Validate(); err != nil {
+				return fmt.Errorf("configurator/Dispatch: %w [%s]", err, v.Type())
+			}
+		}
+	}
+	if fp != nil {
+		args = flag.CommandLine.Args()
+	}
+	if cmd.Run == nil {
+		return nil
+	}
+	return cmd.Run(ctx, args)
+}
+
+func (c *Configurator) flagProvider() *flagProvider {
+	for _, p := range c.providers {
+		if fp, ok := p.(*flagProvider); ok {
+			return fp
+		}
+	}
+	return nil
+}
+
+// findCommand returns the command named in args and args without its name.
+// Flag values are told from the command name by the flags of root: a flag
+// that isn't a bool takes the next argument unless given as -flag=value.
+func findCommand(root reflect.Type, args []string, commands []Command) (*Command, []string, error) {
+	bools := make(map[string]bool)
+	if si, err := getStructInfo(reflect.New(root).Interface(), nil); err == nil {
+		for _, fi := range si.Fields() {
+			if k := fi.FlagKey(); k != "" {
+				bools[k] = leaf(fi.Value()).Kind() == reflect.Bool
+			}
+		}
+	}
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			break
+		}
+		if strings.HasPrefix(a, "-") {
+			name := strings.TrimLeft(a, "-")
+			if !strings.Contains(name, "=") && !bools[name] {
+				i++
+			}
+			continue
+		}
+		for j := range commands {
+			if commands[j].Name == a {
+				rest := append(append([]string{}, args[:i]...), args[i+1:]...)
+				return &commands[j], rest, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("configurator/Dispatch: %w, expected one of %s [%s]", ErrCommand, commandNames(commands), a)
+	}
+	return nil, args, nil
+}
+
+func commandNames(commands []Command) string {
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// commandUsage prints the flags of the root configuration and of cmd, and
+// the commands when none was given.
+func commandUsage(cmd *Command, commands []Command) func() {
+	return func() {
+		w := flag.CommandLine.Output()
+		name := flag.CommandLine.Name()
+		if cmd != nil {
+			fmt.Fprintf(w, "Usage: %s %s [flags] [args]\n", name, cmd.Name)
+			if cmd.Usage != "" {
+				fmt.Fprintf(w, "\n%s\n", cmd.Usage)
+			}
+		} else {
+			fmt.Fprintf(w, "Usage: %s [flags] <command> [flags] [args]\n\nCommands:\n", name)
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			for _, sub := range commands {
+				fmt.Fprintf(tw, "  %s\t%s\n", sub.Name, sub.Usage)
+			}
+			tw.Flush()
+		}
+		fmt.Fprintln(w, "\nFlags:")
+		flag.PrintDefaults()
+	}
+}
+
+// mergedStruct is a struct with the fields of several structs, loaded in
+// their place.
+type mergedStruct struct {
+	reflect.Value
+	// targets are the fields the fields of the merged struct came from.
+	targets []reflect.Value
+}
+
+// mergeStructs returns a pointer to a struct holding copies of the fields
+// of configs, embedded structs flattened, so that they are loaded as one.
+func mergeStructs(configs []reflect.Value) (*mergedStruct, error) {
+	m := &mergedStruct{}
+	var fields []reflect.StructField
+	seen := make(map[string]bool)
+	var add func(v reflect.Value) error
+	add = func(v reflect.Value) error {
+		for i := 0; i < v.NumField(); i++ {
+			ft := v.Type().Field(i)
+			if !ft.IsExported() || ft.Tag.Get(tagName) == ignoreTag {
+				continue
+			}
+			fv := v.Field(i)
+			if ft.Anonymous && indirect(ft.Type).Kind() == reflect.Struct {
+				for fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						fv.Set(reflect.New(fv.Type().Elem()))
+					}
+					fv = fv.Elem()
+				}
+				if err := add(fv); err != nil {
+					return err
+				}
+				continue
+			}
+			if seen[ft.Name] {
+				return fmt.Errorf("%w, %s is in more than one configuration", ErrConflictKey, ft.Name)
+			}
+			seen[ft.Name] = true
+			ft.Index, ft.Offset = nil, 0
+			fields = append(fields, ft)
+			m.targets = append(m.targets, fv)
+		}
+		return nil
+	}
+	for _, v := range configs {
+		if err := add(v); err != nil {
+			return nil, err
+		}
+	}
+	m.Value = reflect.New(reflect.StructOf(fields))
+	for i, t := range m.targets {
+		m.Elem().Field(i).Set(t)
+	}
+	return m, nil
+}
+
+func (m *mergedStruct) copyBack() {
+	for i, t := range m.targets {
+		t.Set(m.Elem().Field(i))
+	}
+}
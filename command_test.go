@@ -0,0 +1,118 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type commandRoot struct {
+	Verbose bool   `config:"flag"`
+	Region  string `config:"flag,default=eu"`
+}
+
+type serveConfig struct {
+	Port int `config:"flag,default=8080"`
+}
+
+func (s *serveConfig) Validate() error {
+	if s.Port == 0 {
+		return errors.New("port is 0")
+	}
+	return nil
+}
+
+func TestDispatch(t *testing.T) {
+	var (
+		root  commandRoot
+		serve serveConfig
+		ran   []string
+	)
+	commands := []Command{
+		{Name: "serve", Usage: "start the server", Config: &serve, Run: func(_ context.Context, args []string) error {
+			ran = append([]string{"serve"}, args...)
+			return nil
+		}},
+		{Name: "version", Usage: "print the version", Run: func(context.Context, []string) error {
+			ran = []string{"version"}
+			return nil
+		}},
+	}
+
+	resetForTesting()
+	os.Args = []string{"tool", "-verbose", "-region", "us", "serve", "-port=9090", "extra"}
+	c := NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithDefaultProvider())
+	assert.NoError(t, c.Dispatch(context.Background(), &root, commands...))
+	assert.Equal(t, commandRoot{Verbose: true, Region: "us"}, root)
+	assert.Equal(t, serveConfig{Port: 9090}, serve)
+	assert.Equal(t, []string{"serve", "extra"}, ran)
+	assert.Equal(t, "flag", c.Provenance()["Port"])
+
+	resetForTesting()
+	os.Args = []string{"tool", "version"}
+	root = commandRoot{}
+	c = NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithDefaultProvider())
+	assert.NoError(t, c.Dispatch(context.Background(), &root, commands...))
+	assert.Equal(t, []string{"version"}, ran)
+	assert.Equal(t, "eu", root.Region)
+
+	resetForTesting()
+	os.Args = []string{"tool", "serve", "-port=0"}
+	c = NewConfigurator(WithFileProvider(""), WithFlagProvider())
+	assert.Error(t, c.Dispatch(context.Background(), &root, commands...))
+
+	for _, args := range [][]string{{"tool", "-verbose"}, {"tool", "deploy"}} {
+		resetForTesting()
+		os.Args = args
+		c = NewConfigurator(WithFileProvider(""), WithFlagProvider())
+		err := c.Dispatch(context.Background(), &root, commands...)
+		assert.True(t, errors.Is(err, ErrCommand), args)
+	}
+
+	type conflict struct {
+		Port int
+	}
+	resetForTesting()
+	os.Args = []string{"tool", "serve"}
+	c = NewConfigurator(WithFileProvider(""), WithFlagProvider())
+	err := c.Dispatch(context.Background(), &conflict{}, commands...)
+	assert.True(t, errors.Is(err, ErrConflictKey))
+}
+
+func TestDispatch_Help(t *testing.T) {
+	var root commandRoot
+	commands := []Command{
+		{Name: "serve", Usage: "start the server", Config: &serveConfig{}},
+		{Name: "version", Usage: "print the version"},
+	}
+
+	resetForTesting()
+	var help strings.Builder
+	flag.CommandLine.SetOutput(&help)
+	os.Args = []string{"tool", "-h"}
+	c := NewConfigurator(WithFileProvider(""), WithFlagProvider())
+	err := c.Dispatch(context.Background(), &root, commands...)
+	assert.True(t, errors.Is(err, flag.ErrHelp))
+	assert.Contains(t, help.String(), "<command>")
+	assert.Contains(t, help.String(), "  serve    start the server\n")
+	assert.Contains(t, help.String(), "-region string")
+	assert.NotContains(t, help.String(), "-port")
+
+	resetForTesting()
+	help.Reset()
+	flag.CommandLine.SetOutput(&help)
+	os.Args = []string{"tool", "serve", "-h"}
+	c = NewConfigurator(WithFileProvider(""), WithFlagProvider())
+	err = c.Dispatch(context.Background(), &root, commands...)
+	assert.True(t, errors.Is(err, flag.ErrHelp))
+	assert.Contains(t, help.String(), "serve [flags]")
+	assert.Contains(t, help.String(), "-region string")
+	assert.Contains(t, help.String(), "-port int")
+	flag.CommandLine.SetOutput(io.Discard)
+}
@@ -0,0 +1,79 @@
+package configurator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// enabled reports whether f and every section around it pass their `if=`
+// conditions. A condition `if=Feature.Enabled` holds when the field at that
+// path is non-zero, `if=Mode=advanced` when it formats to "advanced".
+func (f *fieldInfo) enabled(byPath map[string]*fieldInfo) (bool, error) {
+	for p := f; p != nil; p = p.parent {
+		if ok, err := p.holds(byPath); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// holds reports whether the own `if=` condition of f, if any, holds.
+func (f *fieldInfo) holds(byPath map[string]*fieldInfo) (bool, error) {
+	if f.tag.cond == "" {
+		return true, nil
+	}
+	path, want, hasWant := strings.Cut(f.tag.cond, "=")
+	ctl, ok := byPath[path]
+	if !ok {
+		return false, fmt.Errorf("%w, `if=%s` refers to an unknown field [%s]", ErrInvalidTagFormat, f.tag.cond, f.Path())
+	}
+	v := leaf(ctl.val)
+	if hasWant {
+		return formatValue(v) == want, nil
+	}
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return !v.IsZero(), nil
+}
+
+// prune resets the fields of disabled sections to their zero value and
+// drops them from origins, so that providers can't leave values behind in a
+// subsystem that is switched off.
+func (c *Configurator) prune(fields []FieldInfo, origins map[string]string) error {
+	byPath := make(map[string]*fieldInfo, len(fields))
+	for _, fi := range fields {
+		if f, ok := fi.(*fieldInfo); ok {
+			byPath[f.Path()] = f
+		}
+	}
+	for _, f := range byPath {
+		ok, err := f.enabled(byPath)
+		if err != nil {
+			return err
+		}
+		f.disabled = !ok
+		// sections are marked too, for callValidate
+		for p := f.parent; p != nil; p = p.parent {
+			if ok, err := p.holds(byPath); err == nil {
+				p.disabled = !ok
+			}
+		}
+	}
+	for _, f := range byPath {
+		if !f.disabled {
+			continue
+		}
+		if d, ok := asWrapper(f.val); ok {
+			d.store(reflect.Zero(d.elemType()))
+		} else {
+			f.val.Set(reflect.Zero(f.val.Type()))
+		}
+		if _, ok := origins[f.Path()]; ok {
+			delete(origins, f.Path())
+			c.logger.Debug("configurator: field disabled", "field", f.Path())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package configurator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionalSection(t *testing.T) {
+	t.Parallel()
+	type cache struct {
+		Enabled bool   `config:"env"`
+		Addr    string `config:"env,default=localhost:6379"`
+	}
+	type example struct {
+		Mode  string `config:"env"`
+		Cache cache
+		Redis struct {
+			Addr string `config:"env,default=localhost:6379"`
+		} `config:"if=Cache.Enabled"`
+		Tuning struct {
+			Workers int `config:"env,default=8"`
+		} `config:"if=Mode=advanced"`
+	}
+
+	tests := []struct {
+		name    string
+		environ []string
+		expect  func(*example)
+	}{
+		{
+			name:    "disabled",
+			environ: []string{"REDIS_ADDR=redis:6379"},
+			expect:  func(e *example) { e.Cache.Addr = "localhost:6379" },
+		},
+		{
+			name:    "enabled",
+			environ: []string{"CACHE_ENABLED=true", "REDIS_ADDR=redis:6379", "MODE=advanced"},
+			expect: func(e *example) {
+				e.Mode = "advanced"
+				e.Cache = cache{Enabled: true, Addr: "localhost:6379"}
+				e.Redis.Addr = "redis:6379"
+				e.Tuning.Workers = 8
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(tt.environ), WithDefaultProvider())
+			cfg := &example{}
+			assert.NoError(t, c.Load(cfg))
+			expect := &example{}
+			tt.expect(expect)
+			assert.Equal(t, expect, cfg)
+			if tt.name == "disabled" {
+				assert.NotContains(t, c.Provenance(), "Redis.Addr")
+			}
+		})
+	}
+}
+
+func TestConditionalSection_UnknownField(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Redis struct {
+			Addr string
+		} `config:"if=Cache.Enabled"`
+	}
+
+	err := NewConfigurator(WithFileProvider("")).Load(&example{})
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+}
+
+type tlsSection struct {
+	Cert string `config:"env"`
+}
+
+func (s *tlsSection) Validate() error {
+	if s.Cert == "" {
+		return errors.New("cert is required")
+	}
+	return nil
+}
+
+func TestConditionalSection_Validate(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		TLSEnabled bool       `config:"env"`
+		TLS        tlsSection `config:"if=TLSEnabled"`
+	}
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(nil))
+	assert.NoError(t, c.Load(&example{}))
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"TLSENABLED=true"}))
+	err := c.Load(&example{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "cert is required")
+	}
+}
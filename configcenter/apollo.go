@@ -0,0 +1,335 @@
+package configcenter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ruosing/configurator"
+)
+
+// Apollo reads the namespaces of an app from the config service of Apollo,
+// later namespaces overriding earlier ones. Namespaces named with a .yaml,
+// .yml or .json extension are read as documents, others as properties.
+type Apollo struct {
+	server     string
+	appID      string
+	cluster    string
+	namespaces []string
+	secret     string
+	client     *http.Client
+	identity   configurator.Identity
+	verifier   configurator.Verifier
+
+	mu            sync.Mutex
+	notifications map[string]int64
+}
+
+var (
+	_ configurator.Fetcher         = &Apollo{}
+	_ configurator.ContextProvider = &Apollo{}
+	_ configurator.Watcher         = &Apollo{}
+)
+
+type ApolloOption func(*Apollo)
+
+// WithCluster sets the cluster, "default" if not set.
+func WithCluster(name string) ApolloOption {
+	return func(a *Apollo) {
+		a.cluster = name
+	}
+}
+
+// WithNamespaces sets the namespaces to read, "application" if not set.
+func WithNamespaces(namespaces ...string) ApolloOption {
+	return func(a *Apollo) {
+		a.namespaces = namespaces
+	}
+}
+
+// WithAccessKey signs requests with the access key secret of the app.
+func WithAccessKey(secret string) ApolloOption {
+	return func(a *Apollo) {
+		a.secret = secret
+	}
+}
+
+// WithApolloIdentity makes the config and notification requests with an HTTP
+// client built from id, replacing an earlier WithApolloHTTPClient.
+func WithApolloIdentity(id configurator.Identity) ApolloOption {
+	return func(a *Apollo) {
+		a.identity = id
+		a.client = nil
+	}
+}
+
+// WithApolloHTTPClient sets the HTTP client, http.DefaultClient if not set.
+// Its timeout must allow for the 60s long polls of Watch.
+func WithApolloHTTPClient(c *http.Client) ApolloOption {
+	return func(a *Apollo) {
+		a.client = c
+	}
+}
+
+// WithApolloVerifier verifies each namespace fetched with v against its
+// detached signature before applying it. The signature is the key
+// "signature" of the namespace of the same name with ".sig" appended, such
+// as application.sig. Documents are signed as published, properties as
+// their key=value lines sorted by key.
+func WithApolloVerifier(v configurator.Verifier) ApolloOption {
+	return func(a *Apollo) {
+		a.verifier = v
+	}
+}
+
+// NewApollo returns a source reading appID from the config service at
+// server, e.g. http://apollo-config:8080.
+func NewApollo(server, appID string, opts ...ApolloOption) *Apollo {
+	a := &Apollo{
+		server:     server,
+		appID:      appID,
+		cluster:    "default",
+		namespaces: []string{"application"},
+		client:     http.DefaultClient,
+	}
+	for _, fn := range opts {
+		fn(a)
+	}
+	a.notifications = make(map[string]int64, len(a.namespaces))
+	for _, ns := range a.namespaces {
+		a.notifications[ns] = -1
+	}
+	return a
+}
+
+func (a *Apollo) Provide(v interface{}, si configurator.StructInfo) error {
+	return a.ProvideContext(context.Background(), v, si)
+}
+
+func (a *Apollo) ProvideContext(ctx context.Context, v interface{}, si configurator.StructInfo) error {
+	p, err := a.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	return p.Provide(v, si)
+}
+
+func (a *Apollo) Fetch(ctx context.Context) (configurator.Provider, error) {
+	if err := a.seed(ctx); err != nil {
+		return nil, fmt.Errorf("configcenter/Apollo.Fetch: %w [%s]", err, a.appID)
+	}
+	props := properties{}
+	keys := make([]string, len(a.namespaces))
+	for i, ns := range a.namespaces {
+		body, err := a.configs(ctx, ns)
+		if err != nil {
+			return nil, fmt.Errorf("configcenter/Apollo.Fetch: %w [%s]", err, ns)
+		}
+		if err := a.verify(ctx, ns, body.Configurations); err != nil {
+			return nil, fmt.Errorf("configcenter/Apollo.Fetch: %w [%s]", err, ns)
+		}
+		keys[i] = ns + "=" + body.ReleaseKey
+		if len(a.namespaces) == 1 {
+			keys[i] = body.ReleaseKey
+		}
+		if f := format(ns); f != "properties" {
+			if err := props.parse(body.Configurations["content"], f); err != nil {
+				return nil, fmt.Errorf("configcenter/Apollo.Fetch: %w [%s]", err, ns)
+			}
+			continue
+		}
+		for k, v := range body.Configurations {
+			props.set(k, v)
+		}
+	}
+	return release{props, strings.Join(keys, ",")}, nil
+}
+
+type apolloConfigs struct {
+	Configurations map[string]string `json:"configurations"`
+	ReleaseKey     string            `json:"releaseKey"`
+}
+
+// configs reads the latest release of the namespace ns.
+func (a *Apollo) configs(ctx context.Context, ns string) (apolloConfigs, error) {
+	path := fmt.Sprintf("/configs/%s/%s/%s", url.PathEscape(a.appID), url.PathEscape(a.cluster), url.PathEscape(ns))
+	var body apolloConfigs
+	_, err := a.get(ctx, path, &body)
+	return body, err
+}
+
+// verify checks the configurations of the namespace ns against its
+// signature, if a verifier is set.
+func (a *Apollo) verify(ctx context.Context, ns string, configurations map[string]string) error {
+	if a.verifier == nil {
+		return nil
+	}
+	sig, err := a.configs(ctx, ns+".sig")
+	if err != nil {
+		return fmt.Errorf("%w: %v", configurator.ErrSignature, err)
+	}
+	var doc []byte
+	if format(ns) != "properties" {
+		doc = []byte(configurations["content"])
+	} else {
+		keys := make([]string, 0, len(configurations))
+		for k := range configurations {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			doc = append(doc, k+"="+configurations[k]+"\n"...)
+		}
+	}
+	return a.verifier.Verify(doc, []byte(sig.Configurations["signature"]))
+}
+
+// seed reads the notification IDs of the namespaces not known yet before
+// their first fetch, so that Watch reports releases made since.
+func (a *Apollo) seed(ctx context.Context) error {
+	ns := a.pending(func(id int64) bool { return id == -1 })
+	if len(ns) == 0 {
+		return nil
+	}
+	changed, err := a.poll(ctx, ns)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, n := range changed {
+		if a.notifications[n.NamespaceName] == -1 {
+			a.notifications[n.NamespaceName] = n.NotificationID
+		}
+	}
+	return nil
+}
+
+// pending returns the namespaces with the notification IDs matching fn.
+func (a *Apollo) pending(fn func(int64) bool) []apolloNotification {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var ns []apolloNotification
+	for _, name := range sortedKeys(a.notifications) {
+		if fn(a.notifications[name]) {
+			ns = append(ns, apolloNotification{NamespaceName: name, NotificationID: a.notifications[name]})
+		}
+	}
+	return ns
+}
+
+// poll asks the config service which of ns were released since their
+// notification IDs, waiting up to 60s if none were.
+func (a *Apollo) poll(ctx context.Context, ns []apolloNotification) ([]apolloNotification, error) {
+	b, err := json.Marshal(ns)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"appId": {a.appID}, "cluster": {a.cluster}, "notifications": {string(b)}}
+	var changed []apolloNotification
+	if _, err := a.get(ctx, "/notifications/v2?"+query.Encode(), &changed); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+type apolloNotification struct {
+	NamespaceName  string `json:"namespaceName"`
+	NotificationID int64  `json:"notificationId"`
+}
+
+// Watch long polls the config service and calls onChange every time a
+// namespace is released after it was fetched, or after the first poll if
+// it wasn't. It returns when ctx is done or a poll fails.
+func (a *Apollo) Watch(ctx context.Context, onChange func()) error {
+	for {
+		changed, err := a.poll(ctx, a.pending(func(int64) bool { return true }))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("configcenter/Apollo.Watch: %w [%s]", err, a.appID)
+		}
+		released := false
+		a.mu.Lock()
+		for _, n := range changed {
+			if a.notifications[n.NamespaceName] != -1 {
+				released = true
+			}
+			a.notifications[n.NamespaceName] = n.NotificationID
+		}
+		a.mu.Unlock()
+		if released {
+			onChange()
+		}
+	}
+}
+
+func (a *Apollo) String() string {
+	return "apollo"
+}
+
+// get decodes the JSON response to path into v, unless the status is 304.
+func (a *Apollo) get(ctx context.Context, path string, v interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.server+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	if a.secret != "" {
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha1.New, []byte(a.secret))
+		mac.Write([]byte(ts + "\n" + path))
+		req.Header.Set("Authorization", "Apollo "+a.appID+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		req.Header.Set("Timestamp", ts)
+	}
+	client, err := a.httpClient()
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusNotModified:
+		return resp.StatusCode, nil
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, b)
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (a *Apollo) httpClient() (*http.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.client == nil {
+		c, err := configurator.NewHTTPClient(a.identity)
+		if err != nil {
+			return nil, err
+		}
+		a.client = c
+	}
+	return a.client, nil
+}
@@ -0,0 +1,192 @@
+package configcenter
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+type config struct {
+	Name  string
+	MySQL struct {
+		Host     string
+		MaxConns int
+	}
+	Tags    []string
+	Timeout time.Duration
+}
+
+func TestApollo(t *testing.T) {
+	var release atomic.Int64
+	polled := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" || r.Header.Get("Timestamp") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/configs/api/prod/application":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"configurations": map[string]string{"name": "api", "mysql.max_conns": "10", "timeout": "5s"},
+				"releaseKey":     "20240102-1",
+			})
+		case "/configs/api/prod/db.yaml":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"configurations": map[string]string{"content": "mysql:\n  host: db\n  max-conns: 20\ntags: [a, b]\n"},
+				"releaseKey":     "20240102-2",
+			})
+		case "/notifications/v2":
+			var ns, changed []apolloNotification
+			_ = json.Unmarshal([]byte(r.URL.Query().Get("notifications")), &ns)
+			for _, n := range ns {
+				if n.NotificationID != release.Load() {
+					changed = append(changed, apolloNotification{NamespaceName: n.NamespaceName, NotificationID: release.Load()})
+				}
+			}
+			if len(changed) > 0 {
+				_ = json.NewEncoder(w).Encode(changed)
+				return
+			}
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+			time.Sleep(10 * time.Millisecond)
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	a := NewApollo(srv.URL, "api", WithCluster("prod"), WithNamespaces("application", "db.yaml"), WithAccessKey("s3cret"))
+	c := configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithProvider(a))
+	var cfg config
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, "db", cfg.MySQL.Host)
+	assert.Equal(t, 20, cfg.MySQL.MaxConns)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, "application=20240102-1,db.yaml=20240102-2", c.Version().Sources["apollo"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() { done <- a.Watch(ctx, func() { changes <- struct{}{} }) }()
+
+	<-polled
+	select {
+	case <-changes:
+		t.Fatal("the first poll is not a change")
+	default:
+	}
+	release.Store(2)
+	<-changes
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+
+	// a release between the fetch and the first poll is a change
+	b := NewApollo(srv.URL, "api", WithCluster("prod"), WithNamespaces("application", "db.yaml"), WithAccessKey("s3cret"))
+	_, err := b.Fetch(context.Background())
+	assert.NoError(t, err)
+	release.Store(3)
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() { done <- b.Watch(ctx, func() { changes <- struct{}{} }) }()
+	<-changes
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+
+	_, err = NewApollo(srv.URL, "missing").Fetch(context.Background())
+	assert.Error(t, err)
+}
+
+func TestApollo_Verifier(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	sign := func(doc string) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(doc)))
+	}
+	name := "api"
+	content := "tags: [a, b]\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var configurations map[string]string
+		switch r.URL.Path {
+		case "/notifications/v2":
+			_, _ = w.Write([]byte(`[]`))
+			return
+		case "/configs/api/default/application", "/configs/api/default/unsigned":
+			configurations = map[string]string{"name": name, "timeout": "5s"}
+		case "/configs/api/default/application.sig":
+			configurations = map[string]string{"signature": sign("name=api\ntimeout=5s\n")}
+		case "/configs/api/default/db.yaml":
+			configurations = map[string]string{"content": content}
+		case "/configs/api/default/db.yaml.sig":
+			configurations = map[string]string{"signature": sign("tags: [a, b]\n")}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"configurations": configurations})
+	}))
+	defer srv.Close()
+
+	a := NewApollo(srv.URL, "api", WithNamespaces("application", "db.yaml"), WithApolloVerifier(configurator.Ed25519Verifier(pub)))
+	c := configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithProvider(a))
+	var cfg config
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+
+	name = "evil"
+	assert.True(t, errors.Is(c.Load(&config{}), configurator.ErrSignature))
+	name, content = "api", "tags: [evil]\n"
+	assert.True(t, errors.Is(c.Load(&config{}), configurator.ErrSignature))
+
+	_, err = NewApollo(srv.URL, "api", WithNamespaces("unsigned"), WithApolloVerifier(configurator.Ed25519Verifier(pub))).Fetch(context.Background())
+	assert.True(t, errors.Is(err, configurator.ErrSignature))
+}
+
+type identityFunc func() (*tls.Config, error)
+
+func (f identityFunc) ClientTLSConfig() (*tls.Config, error) { return f() }
+
+func TestApolloIdentity(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/notifications/v2" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"configurations": {"name": "api"}}`))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer srv.Close()
+
+	_, err := NewApollo(srv.URL, "api").Fetch(context.Background())
+	assert.Error(t, err)
+
+	id := identityFunc(func() (*tls.Config, error) {
+		return srv.Client().Transport.(*http.Transport).TLSClientConfig, nil
+	})
+	p, err := NewApollo(srv.URL, "api", WithApolloIdentity(id)).Fetch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, release{properties{"name": "api"}, ""}, p)
+
+	failing := identityFunc(func() (*tls.Config, error) { return nil, errors.New("no SVID") })
+	_, err = NewNacos(srv.URL, "api.yaml", WithNacosIdentity(failing)).Fetch(context.Background())
+	assert.Error(t, err)
+}
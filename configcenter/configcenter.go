@@ -0,0 +1,120 @@
+// Package configcenter reads configuration from the Apollo and Nacos config
+// centers over their HTTP APIs. Both are configurator providers fetching
+// concurrently with the other sources, and both are Watchers long polling
+// for changes, so that Configurator.Watch reloads on every release.
+//
+// Keys are matched to fields by their path ignoring case, "_" and "-", so
+// that mysql.max_conns sets MySQL.MaxConns. Documents in YAML or JSON are
+// flattened to such keys first.
+package configcenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"gopkg.in/yaml.v3"
+)
+
+// properties is a fetched set of normalized keys, applied to the fields
+// they name.
+type properties map[string]string
+
+func (p properties) set(key, value string) {
+	p[normalize(key)] = value
+}
+
+func (p properties) Provide(_ interface{}, si configurator.StructInfo) error {
+	for _, fi := range si.Fields() {
+		v, ok := p[normalize(fi.Path())]
+		if !ok {
+			continue
+		}
+		if err := fi.Set(v); err != nil {
+			return fmt.Errorf("configcenter/Provide: %w [%s]", err, fi.Path())
+		}
+	}
+	return nil
+}
+
+// release is fetched properties with the version the config center
+// released them as.
+type release struct {
+	properties
+	version string
+}
+
+func (r release) Version() string {
+	return r.version
+}
+
+func normalize(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// parse reads a document of format into p: yaml, json, or properties for
+// anything else.
+func (p properties) parse(content, format string) error {
+	switch format {
+	case "yaml", "yml", "json":
+		var doc map[string]interface{}
+		var err error
+		if format == "json" {
+			dec := json.NewDecoder(strings.NewReader(content))
+			dec.UseNumber()
+			err = dec.Decode(&doc)
+		} else {
+			err = yaml.Unmarshal([]byte(content), &doc)
+		}
+		if err != nil {
+			return err
+		}
+		p.flatten("", doc)
+	default:
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || line[0] == '#' || line[0] == '!' {
+				continue
+			}
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				k, v, _ = strings.Cut(line, ":")
+			}
+			p.set(strings.TrimSpace(k), strings.TrimSpace(v))
+		}
+	}
+	return nil
+}
+
+func (p properties) flatten(prefix string, doc map[string]interface{}) {
+	for k, v := range doc {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			p.flatten(k, v)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			p.set(k, strings.Join(items, ","))
+		case time.Time:
+			p.set(k, v.Format(time.RFC3339))
+		case nil:
+		default:
+			p.set(k, fmt.Sprint(v))
+		}
+	}
+}
+
+// format is the format of a document named name, from its extension.
+func format(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return strings.ToLower(name[i+1:])
+	}
+	return "properties"
+}
@@ -0,0 +1,36 @@
+package configcenter
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		format  string
+		content string
+		want    properties
+	}{
+		{"properties", "# comment\nname = api\nMySQL.max_conns: 10\n\n", properties{"name": "api", "mysql.maxconns": "10"}},
+		{"yaml", "a:\n  b: 1\n  c: [x, y]\nd: ~\nsince: 2024-01-02T03:04:05Z\n", properties{"a.b": "1", "a.c": "x,y", "since": "2024-01-02T03:04:05Z"}},
+		{"json", `{"a": {"b": 1000000}, "c": true}`, properties{"a.b": "1000000", "c": "true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			p := properties{}
+			assert.NoError(t, p.parse(tt.content, tt.format))
+			assert.Equal(t, tt.want, p)
+		})
+	}
+	assert.Error(t, properties{}.parse("{", "json"))
+	assert.Equal(t, "yaml", format("api.YAML"))
+	assert.Equal(t, "properties", format("application"))
+}
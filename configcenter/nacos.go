@@ -0,0 +1,351 @@
+package configcenter
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ruosing/configurator"
+)
+
+// Nacos reads a config of Nacos, identified by its dataId, group and
+// namespace. Its format is taken from the extension of the dataId unless
+// set with WithFormat.
+type Nacos struct {
+	server    string
+	dataID    string
+	group     string
+	namespace string
+	format    string
+	username  string
+	password  string
+	client    *http.Client
+	identity  configurator.Identity
+	verifier  configurator.Verifier
+	rollout   bool
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+var (
+	_ configurator.Fetcher         = &Nacos{}
+	_ configurator.ContextProvider = &Nacos{}
+	_ configurator.Watcher         = &Nacos{}
+)
+
+type NacosOption func(*Nacos)
+
+// WithGroup sets the group, "DEFAULT_GROUP" if not set.
+func WithGroup(group string) NacosOption {
+	return func(n *Nacos) {
+		n.group = group
+	}
+}
+
+// WithNamespace sets the namespace ID, the public namespace if not set.
+func WithNamespace(id string) NacosOption {
+	return func(n *Nacos) {
+		n.namespace = id
+	}
+}
+
+// WithFormat sets the format of the config: yaml, json or properties.
+func WithFormat(format string) NacosOption {
+	return func(n *Nacos) {
+		n.format = format
+	}
+}
+
+// WithCredentials logs in with username and password when the server has
+// authentication enabled.
+func WithCredentials(username, password string) NacosOption {
+	return func(n *Nacos) {
+		n.username = username
+		n.password = password
+	}
+}
+
+// WithNacosIdentity makes the requests to the Nacos server with an HTTP
+// client built from id, replacing an earlier WithNacosHTTPClient.
+func WithNacosIdentity(id configurator.Identity) NacosOption {
+	return func(n *Nacos) {
+		n.identity = id
+		n.client = nil
+	}
+}
+
+// WithNacosHTTPClient sets the HTTP client, http.DefaultClient if not set.
+// Its timeout must allow for the 30s long polls of Watch.
+func WithNacosHTTPClient(c *http.Client) NacosOption {
+	return func(n *Nacos) {
+		n.client = c
+	}
+}
+
+// WithNacosVerifier verifies each config fetched with v against its
+// detached signature, published as the config of the same group and
+// namespace with ".sig" appended to its dataId, before applying it.
+func WithNacosVerifier(v configurator.Verifier) NacosOption {
+	return func(n *Nacos) {
+		n.verifier = v
+	}
+}
+
+// WithNacosRollout stages the releases of the config with the rollout
+// percentage published as the config of the same group and namespace with
+// ".rollout" appended to its dataId, such as "25" or "25%", for a
+// configurator with WithRollout. A missing percentage rolls the release out
+// to the whole fleet.
+func WithNacosRollout() NacosOption {
+	return func(n *Nacos) {
+		n.rollout = true
+	}
+}
+
+// NewNacos returns a source reading dataID from the server, e.g.
+// http://nacos:8848.
+func NewNacos(server, dataID string, opts ...NacosOption) *Nacos {
+	n := &Nacos{
+		server: server,
+		dataID: dataID,
+		group:  "DEFAULT_GROUP",
+		client: http.DefaultClient,
+	}
+	for _, fn := range opts {
+		fn(n)
+	}
+	if n.format == "" {
+		n.format = format(dataID)
+	}
+	return n
+}
+
+func (n *Nacos) Provide(v interface{}, si configurator.StructInfo) error {
+	return n.ProvideContext(context.Background(), v, si)
+}
+
+func (n *Nacos) ProvideContext(ctx context.Context, v interface{}, si configurator.StructInfo) error {
+	p, err := n.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	return p.Provide(v, si)
+}
+
+func (n *Nacos) Fetch(ctx context.Context) (configurator.Provider, error) {
+	content, err := n.content(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("configcenter/Nacos.Fetch: %w [%s]", err, n.dataID)
+	}
+	if n.verifier != nil {
+		sig, err := n.config(ctx, n.dataID+".sig")
+		if err != nil {
+			return nil, fmt.Errorf("configcenter/Nacos.Fetch: %w: %v [%s]", configurator.ErrSignature, err, n.dataID)
+		}
+		if err := n.verifier.Verify([]byte(content), []byte(sig)); err != nil {
+			return nil, fmt.Errorf("configcenter/Nacos.Fetch: %w [%s]", err, n.dataID)
+		}
+	}
+	props := properties{}
+	if err := props.parse(content, n.format); err != nil {
+		return nil, fmt.Errorf("configcenter/Nacos.Fetch: %w [%s]", err, n.dataID)
+	}
+	sum := md5.Sum([]byte(content))
+	r := release{props, hex.EncodeToString(sum[:])}
+	if !n.rollout {
+		return r, nil
+	}
+	percent, err := n.percent(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("configcenter/Nacos.Fetch: %w [%s]", err, n.dataID+".rollout")
+	}
+	return stagedRelease{r, percent}, nil
+}
+
+// percent returns the rollout percentage of the config.
+func (n *Nacos) percent(ctx context.Context) (float64, error) {
+	content, err := n.config(ctx, n.dataID+".rollout")
+	var se *statusError
+	if errors.As(err, &se) && se.code == http.StatusNotFound {
+		return 100, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(content), "%"), 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid rollout percentage %q", content)
+	}
+	return percent, nil
+}
+
+// Watch long polls the server and calls onChange every time the config
+// changes. It returns when ctx is done or a poll fails.
+func (n *Nacos) Watch(ctx context.Context, onChange func()) error {
+	content, err := n.content(ctx)
+	if err != nil {
+		return fmt.Errorf("configcenter/Nacos.Watch: %w [%s]", err, n.dataID)
+	}
+	sum := md5.Sum([]byte(content))
+	for {
+		listening := strings.Join([]string{n.dataID, n.group, hex.EncodeToString(sum[:])}, "\x02")
+		if n.namespace != "" {
+			listening += "\x02" + n.namespace
+		}
+		form := url.Values{"Listening-Configs": {listening + "\x01"}}
+		b, err := n.do(ctx, http.MethodPost, "/nacos/v1/cs/configs/listener", nil, form)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("configcenter/Nacos.Watch: %w [%s]", err, n.dataID)
+		}
+		if strings.TrimSpace(b) == "" {
+			continue
+		}
+		if content, err = n.content(ctx); err != nil {
+			return fmt.Errorf("configcenter/Nacos.Watch: %w [%s]", err, n.dataID)
+		}
+		sum = md5.Sum([]byte(content))
+		onChange()
+	}
+}
+
+func (n *Nacos) String() string {
+	return "nacos"
+}
+
+func (n *Nacos) content(ctx context.Context) (string, error) {
+	return n.config(ctx, n.dataID)
+}
+
+func (n *Nacos) config(ctx context.Context, dataID string) (string, error) {
+	query := url.Values{"dataId": {dataID}, "group": {n.group}}
+	if n.namespace != "" {
+		query.Set("tenant", n.namespace)
+	}
+	return n.do(ctx, http.MethodGet, "/nacos/v1/cs/configs", query, nil)
+}
+
+func (n *Nacos) do(ctx context.Context, method, path string, query, form url.Values) (string, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	if n.username != "" {
+		token, err := n.login(ctx)
+		if err != nil {
+			return "", err
+		}
+		query.Set("accessToken", token)
+	}
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, n.server+path+"?"+query.Encode(), body)
+	if err != nil {
+		return "", err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Long-Pulling-Timeout", "30000")
+	}
+	client, err := n.httpClient()
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &statusError{code: resp.StatusCode, msg: fmt.Sprintf("%s: %.512s", resp.Status, b)}
+	}
+	return string(b), nil
+}
+
+// statusError is an answer of the server other than 200 OK.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+// stagedRelease is a release rolled out to a percentage of the fleet.
+type stagedRelease struct {
+	release
+	percent float64
+}
+
+func (r stagedRelease) Rollout() float64 {
+	return r.percent
+}
+
+// login returns an access token, logging in again shortly before the last
+// one expires.
+func (n *Nacos) login(ctx context.Context) (string, error) {
+	client, err := n.httpClient()
+	if err != nil {
+		return "", err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.token != "" && time.Now().Before(n.expires) {
+		return n.token, nil
+	}
+	form := url.Values{"username": {n.username}, "password": {n.password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.server+"/nacos/v1/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("login: %s", resp.Status)
+	}
+	var body struct {
+		AccessToken string `json:"accessToken"`
+		TokenTTL    int64  `json:"tokenTtl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	n.token = body.AccessToken
+	n.expires = time.Now().Add(time.Duration(body.TokenTTL)*time.Second - time.Minute)
+	return n.token, nil
+}
+
+func (n *Nacos) httpClient() (*http.Client, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.client == nil {
+		c, err := configurator.NewHTTPClient(n.identity)
+		if err != nil {
+			return nil, err
+		}
+		n.client = c
+	}
+	return n.client, nil
+}
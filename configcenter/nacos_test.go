@@ -0,0 +1,169 @@
+package configcenter
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNacos(t *testing.T) {
+	var mu sync.Mutex
+	content := "name: api\nmysql:\n  host: db\n  max_conns: 10\n"
+	var logins int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nacos/v1/auth/login" {
+			mu.Lock()
+			logins++
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"accessToken":"token","tokenTtl":18000}`))
+			return
+		}
+		if r.URL.Query().Get("accessToken") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/nacos/v1/cs/configs":
+			q := r.URL.Query()
+			if q.Get("dataId") != "api.yaml" || q.Get("group") != "APP" || q.Get("tenant") != "prod" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(content))
+		case "/nacos/v1/cs/configs/listener":
+			// reports a change once the md5 differs from that of content
+			listening := r.FormValue("Listening-Configs")
+			if strings.Contains(listening, "\x02"+md5Hex(content)+"\x02") {
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				return
+			}
+			_, _ = w.Write([]byte("api.yaml%02APP%02prod%01\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	n := NewNacos(srv.URL, "api.yaml", WithGroup("APP"), WithNamespace("prod"), WithCredentials("nacos", "nacos"))
+	c := configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithProvider(n))
+	var cfg config
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, "db", cfg.MySQL.Host)
+	assert.Equal(t, 10, cfg.MySQL.MaxConns)
+	assert.Equal(t, md5Hex(content), c.Version().Sources["nacos"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() { done <- n.Watch(ctx, func() { changes <- struct{}{} }) }()
+
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	content = "name: api\nmysql:\n  host: replica\n"
+	mu.Unlock()
+	<-changes
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "replica", cfg.MySQL.Host)
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+	assert.Equal(t, 1, logins)
+
+	_, err := NewNacos(srv.URL, "missing.yaml", WithCredentials("nacos", "nacos")).Fetch(context.Background())
+	assert.Error(t, err)
+}
+
+func TestNacos_Verifier(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	content := "name: api\n"
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(content)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("dataId") {
+		case "api.yaml", "unsigned.yaml":
+			_, _ = w.Write([]byte(content))
+		case "api.yaml.sig":
+			_, _ = w.Write([]byte(sig))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	n := NewNacos(srv.URL, "api.yaml", WithNacosVerifier(configurator.Ed25519Verifier(pub)))
+	c := configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithProvider(n))
+	var cfg config
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "api", cfg.Name)
+
+	content = "name: evil\n"
+	assert.True(t, errors.Is(c.Load(&config{}), configurator.ErrSignature))
+
+	_, err = NewNacos(srv.URL, "unsigned.yaml", WithNacosVerifier(configurator.Ed25519Verifier(pub))).Fetch(context.Background())
+	assert.True(t, errors.Is(err, configurator.ErrSignature))
+}
+
+func TestNacos_Rollout(t *testing.T) {
+	var mu sync.Mutex
+	content, rollout := "name: v1\n", ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Query().Get("dataId") {
+		case "api.yaml":
+			_, _ = w.Write([]byte(content))
+		case "api.yaml.rollout":
+			if rollout == "" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(rollout))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	n := NewNacos(srv.URL, "api.yaml", WithNacosRollout())
+	c := configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithProvider(n), configurator.WithRollout("pod-1"))
+	var cfg config
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "v1", cfg.Name)
+
+	mu.Lock()
+	content, rollout = "name: v2\n", "0%"
+	mu.Unlock()
+	fresh, err := c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", fresh.(*config).Name)
+
+	mu.Lock()
+	rollout = "100"
+	mu.Unlock()
+	fresh, err = c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", fresh.(*config).Name)
+
+	mu.Lock()
+	rollout = "half"
+	mu.Unlock()
+	_, err = c.Reload(context.Background(), &cfg)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid rollout percentage")
+	}
+}
@@ -0,0 +1,215 @@
+package configservice
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type config struct {
+	Name   string    `yaml:"name"`
+	Port   int       `yaml:"port"`
+	Since  time.Time `yaml:"since"`
+	Region string    `config:"env"`
+}
+
+func serve(t *testing.T, files map[string]string) *grpc.ClientConn {
+	return serveWith(t, files, nil, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+func serveWith(t *testing.T, files map[string]string, serverOpts []grpc.ServerOption, creds grpc.DialOption) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(serverOpts...)
+	RegisterConfigServiceServer(s, NewServer(files, 10*time.Millisecond))
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		creds,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestSource(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "api.yaml")
+	if err := os.WriteFile(filename, []byte("name: api\nport: 8080\nsince: 2024-01-02T03:04:05Z\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conn := serve(t, map[string]string{"api": filename})
+
+	c := configurator.NewConfigurator(
+		configurator.WithFileProvider(""),
+		configurator.WithProvider(NewSource(conn, "api")),
+		configurator.WithENVProvider(""),
+		configurator.WithEnviron([]string{"REGION=eu"}),
+	)
+	cfg := &config{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &config{
+		Name:   "api",
+		Port:   8080,
+		Since:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Region: "eu",
+	}, cfg)
+	assert.Equal(t, "configservice", c.Provenance()["Port"])
+}
+
+func TestSource_NotFound(t *testing.T) {
+	conn := serve(t, nil)
+	err := configurator.NewConfigurator(
+		configurator.WithFileProvider(""),
+		configurator.WithProvider(NewSource(conn, "api")),
+	).Load(&config{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSource_Verifier(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(`{"name":"api","port":8080}`)))
+	conn := serve(t, map[string]string{
+		"api":      write("api.yaml", "port: 8080\nname: api\n"),
+		"api.sig":  write("api.sig.yaml", "signature: "+sig+"\n"),
+		"unsigned": write("unsigned.json", `{"name":"api","port":8080}`),
+	})
+
+	cfg := &config{}
+	src := NewSource(conn, "api", WithVerifier(configurator.Ed25519Verifier(pub)))
+	assert.NoError(t, configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithProvider(src)).Load(cfg))
+	assert.Equal(t, 8080, cfg.Port)
+
+	write("api.yaml", "port: 9090\nname: api\n")
+	_, err = src.Fetch(context.Background())
+	assert.True(t, errors.Is(err, configurator.ErrSignature))
+
+	_, err = NewSource(conn, "unsigned", WithVerifier(configurator.Ed25519Verifier(pub))).Fetch(context.Background())
+	assert.True(t, errors.Is(err, configurator.ErrSignature))
+}
+
+func TestSource_Watch(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "api.json")
+	if err := os.WriteFile(filename, []byte(`{"port":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conn := serve(t, map[string]string{"api": filename})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	changed := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewSource(conn, "api").Watch(ctx, func() { changed <- struct{}{} })
+	}()
+
+	// keep changing the file, the watch may not have started yet
+	for port := 2; ; port++ {
+		if err := os.WriteFile(filename, []byte(fmt.Sprintf(`{"port":%d}`, port)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			t.Fatal("no change reported")
+		case <-time.After(20 * time.Millisecond):
+			continue
+		}
+		break
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestSource_WatchFetched(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "api.json")
+	if err := os.WriteFile(filename, []byte(`{"port":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conn := serve(t, map[string]string{"api": filename})
+	src := NewSource(conn, "api")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := src.Fetch(ctx)
+	assert.NoError(t, err)
+
+	// a change between the fetch and the watch is reported
+	if err := os.WriteFile(filename, []byte(`{"port":2}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- src.Watch(ctx, func() { changed <- struct{}{} })
+	}()
+	select {
+	case <-changed:
+	case <-ctx.Done():
+		t.Fatal("no change reported")
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+type identityFunc func() (*tls.Config, error)
+
+func (f identityFunc) ClientTLSConfig() (*tls.Config, error) { return f() }
+
+func TestDialIdentity(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "api.json")
+	if err := os.WriteFile(filename, []byte(`{"port":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// borrow the certificate of an httptest TLS server, valid for example.com
+	ts := httptest.NewTLSServer(nil)
+	ts.Close()
+	serverCreds := grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: ts.TLS.Certificates}))
+	id := identityFunc(func() (*tls.Config, error) {
+		cfg := ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+		cfg.ServerName = "example.com"
+		return cfg, nil
+	})
+
+	opt, err := DialIdentity(id)
+	assert.NoError(t, err)
+	conn := serveWith(t, map[string]string{"api": filename}, []grpc.ServerOption{serverCreds}, opt)
+	cfg := &config{}
+	assert.NoError(t, configurator.NewConfigurator(
+		configurator.WithFileProvider(""),
+		configurator.WithProvider(NewSource(conn, "api")),
+	).Load(cfg))
+	assert.Equal(t, 1, cfg.Port)
+
+	_, err = DialIdentity(identityFunc(func() (*tls.Config, error) { return nil, errors.New("no SVID") }))
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package configservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/yaml.v3"
+)
+
+// Server is a reference ConfigServiceServer serving JSON and YAML files.
+// Watchers are sent a file again when its content changed, checked every
+// interval.
+type Server struct {
+	files    map[string]string
+	interval time.Duration
+}
+
+var _ ConfigServiceServer = &Server{}
+
+// NewServer serves files, a map of document names to file names.
+func NewServer(files map[string]string, interval time.Duration) *Server {
+	return &Server{files: files, interval: interval}
+}
+
+func (s *Server) GetConfig(_ context.Context, name *wrapperspb.StringValue) (*structpb.Struct, error) {
+	return s.read(name.GetValue())
+}
+
+func (s *Server) WatchConfig(name *wrapperspb.StringValue, stream grpc.ServerStreamingServer[structpb.Struct]) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	var last *structpb.Struct
+	for {
+		doc, err := s.read(name.GetValue())
+		if err != nil {
+			return err
+		}
+		if last == nil || !proto.Equal(doc, last) {
+			if err := stream.Send(doc); err != nil {
+				return err
+			}
+			last = doc
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) read(name string) (*structpb.Struct, error) {
+	filename, ok := s.files[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "config %q not found", name)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "reading config %q: %v", name, err)
+	}
+	doc := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		err = json.Unmarshal(b, &doc)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &doc)
+	default:
+		err = fmt.Errorf("unsupported file %s", filename)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "decoding config %q: %v", name, err)
+	}
+	st, err := structpb.NewStruct(plain(doc).(map[string]interface{}))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "encoding config %q: %v", name, err)
+	}
+	return st, nil
+}
+
+// plain converts the values YAML decodes to that Struct can't hold.
+func plain(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = plain(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = plain(e)
+		}
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return v
+}
@@ -0,0 +1,108 @@
+// Package configservice lets a fleet of services pull and stream their
+// configuration from a central daemon. The API is defined in config.proto
+// using only well-known types, so the service description below is written
+// by hand instead of generated. Server serves documents from files and
+// Source is the configurator provider reading from it.
+//
+// It lives in its own module to keep gRPC out of the core dependencies.
+package configservice
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	serviceName       = "configurator.v1.ConfigService"
+	getConfigMethod   = "/" + serviceName + "/GetConfig"
+	watchConfigMethod = "/" + serviceName + "/WatchConfig"
+)
+
+// ConfigServiceServer is the server side of config.proto.
+type ConfigServiceServer interface {
+	GetConfig(context.Context, *wrapperspb.StringValue) (*structpb.Struct, error)
+	WatchConfig(*wrapperspb.StringValue, grpc.ServerStreamingServer[structpb.Struct]) error
+}
+
+// RegisterConfigServiceServer registers srv with s.
+func RegisterConfigServiceServer(s grpc.ServiceRegistrar, srv ConfigServiceServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*ConfigServiceServer)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "GetConfig",
+		Handler:    getConfigHandler,
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "WatchConfig",
+		Handler:       watchConfigHandler,
+		ServerStreams: true,
+	}},
+	Metadata: "config.proto",
+}
+
+func getConfigHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigServiceServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: getConfigMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigServiceServer).GetConfig(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func watchConfigHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(wrapperspb.StringValue)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(ConfigServiceServer).WatchConfig(in, &grpc.GenericServerStream[wrapperspb.StringValue, structpb.Struct]{ServerStream: stream})
+}
+
+// ConfigServiceClient is the client side of config.proto.
+type ConfigServiceClient interface {
+	GetConfig(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*structpb.Struct, error)
+	WatchConfig(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (grpc.ServerStreamingClient[structpb.Struct], error)
+}
+
+type configServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConfigServiceClient(cc grpc.ClientConnInterface) ConfigServiceClient {
+	return &configServiceClient{cc: cc}
+}
+
+func (c *configServiceClient) GetConfig(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	if err := c.cc.Invoke(ctx, getConfigMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configServiceClient) WatchConfig(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (grpc.ServerStreamingClient[structpb.Struct], error) {
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], watchConfigMethod, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[wrapperspb.StringValue, structpb.Struct]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
@@ -0,0 +1,154 @@
+package configservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ruosing/configurator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Source is a configurator provider reading the named document from a
+// ConfigService. Fields are decoded from it as from a JSON file.
+type Source struct {
+	client   ConfigServiceClient
+	name     string
+	verifier configurator.Verifier
+
+	mu sync.Mutex
+	// last is the document of the last fetch, for Watch.
+	last []byte
+}
+
+var (
+	_ configurator.Fetcher         = &Source{}
+	_ configurator.ContextProvider = &Source{}
+	_ configurator.Watcher         = &Source{}
+)
+
+type SourceOption func(*Source)
+
+// WithVerifier verifies the document with v against its detached
+// signature before applying it. The signature is the string field
+// "signature" of the document of the same name with ".sig" appended, over
+// the document as compact JSON with sorted keys, such as jq -cS prints.
+func WithVerifier(v configurator.Verifier) SourceOption {
+	return func(s *Source) {
+		s.verifier = v
+	}
+}
+
+func NewSource(cc grpc.ClientConnInterface, name string, opts ...SourceOption) *Source {
+	s := &Source{client: NewConfigServiceClient(cc), name: name}
+	for _, fn := range opts {
+		fn(s)
+	}
+	return s
+}
+
+// DialIdentity returns the transport credentials of id as a dial option for
+// the connection to the config service.
+func DialIdentity(id configurator.Identity) (grpc.DialOption, error) {
+	cfg, err := id.ClientTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("configservice/DialIdentity: %w", err)
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(cfg)), nil
+}
+
+func (s *Source) Provide(v interface{}, si configurator.StructInfo) error {
+	return s.ProvideContext(context.Background(), v, si)
+}
+
+func (s *Source) ProvideContext(ctx context.Context, v interface{}, si configurator.StructInfo) error {
+	p, err := s.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	return p.Provide(v, si)
+}
+
+func (s *Source) Fetch(ctx context.Context) (configurator.Provider, error) {
+	doc, err := s.client.GetConfig(ctx, wrapperspb.String(s.name))
+	if err != nil {
+		return nil, fmt.Errorf("configservice/Fetch: %w [%s]", err, s.name)
+	}
+	b, err := canonical(doc)
+	if err != nil {
+		return nil, fmt.Errorf("configservice/Fetch: %w [%s]", err, s.name)
+	}
+	if s.verifier != nil {
+		sig, err := s.client.GetConfig(ctx, wrapperspb.String(s.name+".sig"))
+		if err != nil {
+			return nil, fmt.Errorf("configservice/Fetch: %w: %v [%s]", configurator.ErrSignature, err, s.name)
+		}
+		if err := s.verifier.Verify(b, []byte(sig.GetFields()["signature"].GetStringValue())); err != nil {
+			return nil, fmt.Errorf("configservice/Fetch: %w [%s]", err, s.name)
+		}
+	}
+	s.mu.Lock()
+	s.last = b
+	s.mu.Unlock()
+	return document(b), nil
+}
+
+// Watch streams the document and calls onChange every time the server
+// reports a change, typically to Reload. The first version streamed is a
+// change only if it differs from the last fetched. It returns when ctx is
+// done or the stream fails.
+func (s *Source) Watch(ctx context.Context, onChange func()) error {
+	stream, err := s.client.WatchConfig(ctx, wrapperspb.String(s.name))
+	if err != nil {
+		return fmt.Errorf("configservice/Watch: %w [%s]", err, s.name)
+	}
+	for first := true; ; first = false {
+		doc, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("configservice/Watch: %w [%s]", err, s.name)
+		}
+		if !first {
+			onChange()
+			continue
+		}
+		b, err := canonical(doc)
+		if err != nil {
+			return fmt.Errorf("configservice/Watch: %w [%s]", err, s.name)
+		}
+		s.mu.Lock()
+		changed := s.last != nil && !bytes.Equal(s.last, b)
+		s.mu.Unlock()
+		if changed {
+			onChange()
+		}
+	}
+}
+
+func (s *Source) String() string {
+	return "configservice"
+}
+
+type document []byte
+
+func (d document) Provide(v interface{}, _ configurator.StructInfo) error {
+	return json.Unmarshal(d, v)
+}
+
+// canonical encodes doc as compact JSON with sorted keys.
+func canonical(doc *structpb.Struct) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc.AsMap()); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
@@ -1,7 +1,16 @@
 package configurator
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 type ConfiguratorOptions struct {
@@ -9,8 +18,53 @@ type ConfiguratorOptions struct {
 	filename      string
 	enableENV     bool
 	envPrefix     string
+	profile       string
+	migrations    *Migrations
+	renames       renames
+	viper         bool
+	envconfig     bool
+	parse         parseOptions
+	defaultFuncs  map[string]DefaultFunc
 	enableFlag    bool
 	enableDefault bool
+	lookupEnv     func(string) (string, bool)
+	environ       []string
+	customLookup  bool
+	envFold       bool
+	now           func() time.Time
+	providers     []Provider
+	scopes        []string
+	timeout       time.Duration
+	concurrency   int
+	limits        walkLimits
+	warn          func(error)
+	snapshot      *snapshot
+	logger        *slog.Logger
+	metrics       Metrics
+	tracer        Tracer
+	audit         AuditSink
+	modules       map[string]interface{}
+	prompt        *promptProvider
+	resolvers     map[string]SecretResolver
+	pathBase      string
+	filePerm      filePerm
+	seed          *seedProvider
+	preLoad       []func(*LoadPlan) error
+	postLoad      []func(interface{}) error
+	groups        []fieldGroup
+	gates         map[string]bool
+	gateWarnings  bool
+	runtime       *Runtime
+	messages      func(Message) string
+	rules         []func(interface{}) []Finding
+	policies      []namedPolicy
+	verifier      Verifier
+	instance      string
+	onExpire      func(Lease, error)
+	history       int
+	onRestart     func([]Change)
+	banner        *banner
+	help          *flagHelp
 }
 
 type ConfiguratorOption func(*ConfiguratorOptions)
@@ -41,8 +95,223 @@ func WithDefaultProvider() ConfiguratorOption {
 	}
 }
 
-type Provider interface {
-	Provide(interface{}, StructInfo) error
+// WithProfile selects a named profile such as "staging" or a tenant ID. The
+// file provider overlays the profiles.<name> section of the file on the base
+// values and the env provider prefers keys suffixed with _<NAME>, falling
+// back to the unsuffixed key. WithLoadProfile selects one for a single load.
+func WithProfile(name string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.profile = name
+	}
+}
+
+// WithMigrations upgrades config files written for older schema versions
+// with m before they are decoded.
+func WithMigrations(m *Migrations) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.migrations = m
+	}
+}
+
+// WithRenames keeps deprecated keys working after fields are moved. renames
+// maps old dotted paths to new ones, e.g. "Database.Host" to "MySQL.Host".
+// File documents have values at old paths moved to the new path, and env
+// keys derived from the old path are read for fields without a value under
+// the new key. Every use of an old key is logged as a warning.
+func WithRenames(renames map[string]string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.renames = renames
+	}
+}
+
+// WithViperCompat eases migrating from Viper. Files may use flat dotted keys
+// such as "mysql.host", and the env provider is enabled with prefix and
+// looks up every field, tagged or not, the way Viper's AutomaticEnv does
+// with a "." to "_" key replacer: MySQL.Host is read from PREFIX_MYSQL_HOST.
+func WithViperCompat(prefix string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.viper = true
+		co.enableENV = true
+		co.envPrefix = prefix
+	}
+}
+
+// WithEnvconfigTags also reads the tags of github.com/kelseyhightower/envconfig
+// so that structs shared with services using it load without extra tags:
+// `envconfig:"KEY"` names the env key, `default:"value"` and
+// `required:"true"` behave like the default and required options. As with
+// envconfig, untagged fields are looked up in the environment under a key
+// derived from their path. The config tag wins where both are set. It
+// enables the default provider; the env provider still needs WithENVProvider.
+func WithEnvconfigTags() ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.envconfig = true
+		co.enableDefault = true
+	}
+}
+
+// WithTrimSpace drops the whitespace surrounding values read as strings,
+// such as env values rendered by templates, and each item of list values.
+func WithTrimSpace() ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.parse.trim = true
+	}
+}
+
+// WithCaseInsensitive parses booleans and enum values ignoring case, and
+// also accepts yes/no, y/n and on/off as booleans.
+func WithCaseInsensitive() ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.parse.fold = true
+	}
+}
+
+// WithLenientNumbers accepts "_" and "," as digit separators in numbers,
+// so that 1_000_000 and 1,048,576 parse. Items of list values are still
+// separated by ",".
+func WithLenientNumbers() ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.parse.numbers = true
+	}
+}
+
+// WithDefaultFunc makes fn available to `defaultFn=name` tags of this
+// configurator only, taking precedence over RegisterDefaultFunc.
+func WithDefaultFunc(name string, fn DefaultFunc) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		if co.defaultFuncs == nil {
+			co.defaultFuncs = make(map[string]DefaultFunc)
+		}
+		co.defaultFuncs[strings.ToLower(name)] = fn
+	}
+}
+
+// WithLookupEnv replaces os.LookupEnv for the env provider. As lookup can't
+// list the env, `envPrefix=` fields aren't read.
+func WithLookupEnv(lookup func(string) (string, bool)) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.lookupEnv = lookup
+		co.environ = nil
+		co.customLookup = true
+	}
+}
+
+// WithEnviron makes the env provider read from a fixed snapshot of
+// "KEY=value" pairs, as returned by os.Environ, instead of the process env.
+func WithEnviron(environ []string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.lookupEnv = environLookup(environ)
+		co.environ = environ
+		co.customLookup = false
+	}
+}
+
+// WithEnvCaseInsensitive matches env keys ignoring case, as Windows does:
+// Path is read for PATH. The env, or the snapshot of WithEnviron, is
+// indexed once when the configurator is created. A key spelled exactly
+// wins, then the first spelling in byte order, so that the result doesn't
+// depend on the order of the env. It has no effect with WithLookupEnv.
+func WithEnvCaseInsensitive() ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.envFold = true
+	}
+}
+
+// WithClock replaces time.Now for time based defaults.
+func WithClock(now func() time.Time) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.now = now
+	}
+}
+
+// WithProviderTimeout bounds the time each context aware provider may take
+// during LoadContext. Use ProviderWithTimeout for a per provider bound.
+func WithProviderTimeout(d time.Duration) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.timeout = d
+	}
+}
+
+// WithMaxDepth fails loads of structs nested deeper than n levels, the
+// configuration struct being the first. Defaults to 32, 0 is no limit.
+func WithMaxDepth(n int) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.limits.depth = n
+	}
+}
+
+// WithMaxFields fails loads of configurations with more than n fields.
+// Defaults to 10000, 0 is no limit.
+func WithMaxFields(n int) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.limits.fields = n
+	}
+}
+
+// WithFetchConcurrency limits how many Fetcher providers are fetched at the
+// same time. Defaults to 4.
+func WithFetchConcurrency(n int) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.concurrency = n
+	}
+}
+
+// WithWarningHandler receives failures of FailureOptional and FailureCached
+// providers that didn't fail the load. Defaults to logging them as warnings.
+func WithWarningHandler(fn func(error)) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.warn = fn
+	}
+}
+
+// WithSnapshot keeps an AES-GCM encrypted copy of what the remote Fetcher
+// providers, all but the config file, set in the last fully successful load
+// in filename. When a Fetcher provider fails, the load falls back to the
+// snapshot in place of the remote providers and reports a warning. key must
+// be 16, 24 or 32 bytes long.
+func WithSnapshot(filename string, key []byte) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.snapshot = &snapshot{filename: filename, key: key}
+	}
+}
+
+// WithLogger sets the logger, slog.Default() by default. At debug level it
+// records every lookup, which provider set each field and load events.
+func WithLogger(logger *slog.Logger) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.logger = logger
+	}
+}
+
+// WithMetrics reports load and provider timings and failures to m.
+func WithMetrics(m Metrics) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.metrics = m
+	}
+}
+
+// WithTracer traces loads: one span for the load, one per Fetcher fetch and
+// one for merging the providers into the struct.
+func WithTracer(t Tracer) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.tracer = t
+	}
+}
+
+// WithAuditSink sends an AuditRecord to sink after every successful load.
+func WithAuditSink(sink AuditSink) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.audit = sink
+	}
+}
+
+// WithProvider adds a custom provider. Custom providers run after the
+// built-in file, env and flag providers and before the default provider.
+func WithProvider(p Provider) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.providers = append(co.providers, p)
+		co.scopes = append(co.scopes, "")
+	}
 }
 
 func NewConfigurator(options ...ConfiguratorOption) *Configurator {
@@ -53,43 +322,659 @@ func NewConfigurator(options ...ConfiguratorOption) *Configurator {
 		envPrefix:     "",
 		enableFlag:    false,
 		enableDefault: false,
+		lookupEnv:     os.LookupEnv,
+		now:           time.Now,
+		concurrency:   4,
+		limits:        defaultWalkLimits,
+		logger:        slog.Default(),
+		metrics:       nopMetrics{},
+		tracer:        nopTracer{},
 	}
 	for _, fn := range options {
 		fn(opts)
 	}
+	if opts.envFold && !opts.customLookup {
+		environ := opts.environ
+		if environ == nil {
+			environ = os.Environ()
+		}
+		opts.lookupEnv = foldEnvironLookup(environ)
+	}
+	opts.parse.lookupEnv = opts.lookupEnv
+	if opts.runtime != nil {
+		r := *opts.runtime
+		opts.parse.runtime = func() Runtime { return r }
+	} else if opts.customLookup || opts.environ != nil {
+		lookup := opts.lookupEnv
+		opts.parse.runtime = sync.OnceValue(func() Runtime { return detectRuntime(lookup) })
+	}
+	if opts.warn == nil {
+		logger := opts.logger
+		opts.warn = func(err error) {
+			logger.Warn("configurator: degraded load", "error", err)
+		}
+	}
 
-	providers := make([]Provider, 0, 4)
+	providers := make([]Provider, 0, 4+len(opts.providers))
 	if opts.enableFile && strings.TrimSpace(opts.filename) != "" {
-		providers = append(providers, NewFileProvider(opts.filename))
+		fp := NewFileProvider(opts.filename)
+		fp.profile = opts.profile
+		fp.migrations = opts.migrations
+		fp.renames = opts.renames
+		fp.viper = opts.viper
+		fp.parse = opts.parse
+		fp.perm = opts.filePerm
+		fp.verify = opts.verifier
+		fp.logger = opts.logger
+		providers = append(providers, fp)
 	}
+	var ep *envProvider
 	if opts.enableENV {
-		providers = append(providers, NewENVProvider(opts.envPrefix))
+		ep = NewENVProvider(opts.envPrefix)
+		ep.profile = opts.profile
+		ep.renames = opts.renames
+		ep.automatic = opts.viper
+		ep.lookup = opts.lookupEnv
+		ep.environ = environFunc(opts)
+		ep.logger = opts.logger
+		providers = append(providers, ep)
 	}
 	if opts.enableFlag {
-		providers = append(providers, NewFlagProvider())
+		fp := NewFlagProvider()
+		fp.logger = opts.logger
+		if opts.help != nil {
+			fp.help = &flagHelp{tmpl: opts.help.tmpl, env: ep}
+		}
+		providers = append(providers, fp)
+	}
+	scopes := make([]string, len(providers), cap(providers))
+	providers = append(providers, opts.providers...)
+	scopes = append(scopes, opts.scopes...)
+	if opts.seed != nil {
+		providers = append(providers, opts.seed)
+		scopes = append(scopes, "")
 	}
 	if opts.enableDefault {
-		providers = append(providers, NewDefaultProvider())
+		dp := NewDefaultProvider()
+		dp.now = opts.now
+		dp.funcs = opts.defaultFuncs
+		providers = append(providers, dp)
+		scopes = append(scopes, "")
+	}
+	if opts.prompt != nil {
+		providers = append(providers, opts.prompt)
+		scopes = append(scopes, "")
+	}
+
+	if opts.concurrency < 1 {
+		opts.concurrency = 1
 	}
 
 	return &Configurator{
-		providers: providers,
+		providers:   providers,
+		scopes:      scopes,
+		timeout:     opts.timeout,
+		concurrency: opts.concurrency,
+		limits:      opts.limits,
+		warn:        opts.warn,
+		snapshot:    opts.snapshot,
+		logger:      opts.logger,
+		now:         opts.now,
+		metrics:     opts.metrics,
+		tracer:      opts.tracer,
+		audit:       opts.audit,
+		envconfig:   opts.envconfig,
+		parse:       opts.parse,
+		modules:     opts.modules,
+		resolvers:   opts.resolvers,
+		lookupEnv:   opts.lookupEnv,
+		environ:     environFunc(opts),
+		envFold:     opts.envFold,
+		pathBase:    opts.pathBase,
+		preLoad:     opts.preLoad,
+		postLoadFns: opts.postLoad,
+		groups:      opts.groups,
+		gates:       opts.gates,
+		gateWarn:    opts.gateWarnings,
+		messages:    opts.messages,
+		rules:       opts.rules,
+		policies:    opts.policies,
+		instance:    opts.instance,
+		onExpire:    opts.onExpire,
+		history:     opts.history,
+		onRestart:   opts.onRestart,
+		banner:      opts.banner,
+		profile:     opts.profile,
+		active:      opts.profile,
 	}
 }
 
 type Configurator struct {
-	providers []Provider
+	providers   []Provider
+	scopes      []string
+	timeout     time.Duration
+	concurrency int
+	limits      walkLimits
+	warn        func(error)
+	snapshot    *snapshot
+	logger      *slog.Logger
+	now         func() time.Time
+	metrics     Metrics
+	tracer      Tracer
+	audit       AuditSink
+	envconfig   bool
+	parse       parseOptions
+	modules     map[string]interface{}
+	resolvers   map[string]SecretResolver
+	lookupEnv   func(string) (string, bool)
+	environ     func() []string
+	envFold     bool
+	pathBase    string
+	preLoad     []func(*LoadPlan) error
+	postLoadFns []func(interface{}) error
+	groups      []fieldGroup
+	gates       map[string]bool
+	gateWarn    bool
+	messages    func(Message) string
+	rules       []func(interface{}) []Finding
+	policies    []namedPolicy
+	instance    string
+	onExpire    func(Lease, error)
+	history     int
+	onRestart   func([]Change)
+	banner      *banner
+	profile     string
+
+	mu        sync.RWMutex
+	origins   map[string]string
+	values    map[string]reflect.Value
+	schema    []FieldSchema
+	last      LoadEvent
+	sources   []SourceHealth
+	versions  map[string]string
+	contribs  []SourceValues
+	findings  []Finding
+	overrides overrides
+	// applied are the releases of the last load, for WithRollout.
+	applied map[string]Provider
+	// leases are those of the values of the last load, by expiry.
+	leases []lease
+	// rotations are the callbacks of OnRotate.
+	rotations []rotation
+	// revisions are the history kept by WithHistory, oldest first.
+	revisions []revision
+	// parties are the participants of reloads.
+	parties []Participant
+	// restarts are the changes waiting for a restart.
+	restarts []Change
+	// loaded is the struct of the last successful load, for Shred.
+	loaded interface{}
+	// active is the profile of the last successful load.
+	active string
+
+	// patchMu serializes runtime mutations through the admin handler.
+	patchMu sync.Mutex
+}
+
+// LoadOption changes a single call of Load or LoadContext.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	profile *string
+}
+
+// WithLoadProfile loads with the named profile in place of the one of
+// WithProfile, for this call only, such as Load(&cfg,
+// WithLoadProfile("staging")). Reload and Drift keep the profile of the
+// last load.
+func WithLoadProfile(name string) LoadOption {
+	return func(lo *loadOptions) {
+		lo.profile = &name
+	}
+}
+
+type profileKey struct{}
+
+// withLoadOptions returns ctx carrying opts to load.
+func withLoadOptions(ctx context.Context, opts []LoadOption) context.Context {
+	var lo loadOptions
+	for _, fn := range opts {
+		fn(&lo)
+	}
+	if lo.profile != nil {
+		ctx = context.WithValue(ctx, profileKey{}, *lo.profile)
+	}
+	return ctx
 }
 
-func (c *Configurator) Load(v interface{}) error {
-	si, err := getStructInfo(v, nil)
+func (c *Configurator) Load(v interface{}, opts ...LoadOption) error {
+	return c.LoadContext(context.Background(), v, opts...)
+}
+
+// LoadContext is like Load, but stops between providers once ctx is done
+// and passes ctx to providers implementing ContextProvider. Providers
+// implementing Fetcher are fetched concurrently before any value is applied.
+func (c *Configurator) LoadContext(ctx context.Context, v interface{}, opts ...LoadOption) error {
+	_, _, err := c.load(withLoadOptions(ctx, opts), v, false)
+	if err == nil && c.banner != nil {
+		c.banner.once.Do(func() {
+			if err := c.WriteSummary(c.banner.w, c.banner.format); err != nil {
+				c.logger.Warn("configurator: writing summary failed", "error", err)
+			}
+		})
+	}
+	return err
+}
+
+// load resolves the sources into v and returns its fields and the source
+// that set each. A dry load records nothing: neither the status nor the
+// values of the configurator, nor snapshots or audit records.
+func (c *Configurator) load(ctx context.Context, v interface{}, dry bool) (fields []FieldInfo, origins map[string]string, err error) {
+	start := c.now()
+	degraded := false
+	plan, err := c.plan()
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	if r := replayFrom(ctx); r != nil {
+		plan.providers, plan.scopes = r, make([]string, len(r))
+	}
+	profile := c.profile
+	if p, ok := ctx.Value(profileKey{}).(string); ok && p != profile {
+		profile = p
+		plan.providers = withProfile(plan.providers, p)
+	}
+	sources := newSources(plan.providers)
+	c.logger.Debug("configurator: load started", "type", fmt.Sprintf("%T", v), "providers", len(plan.providers))
+	ctx, span := c.tracer.Start(ctx, "configurator.Load")
+	defer func() {
+		err = c.word(err)
+		span.End(err)
+		d := c.now().Sub(start)
+		if err != nil {
+			c.logger.Debug("configurator: load failed", "error", err, "duration", d)
+		}
+		if dry {
+			return
+		}
+		e := LoadEvent{Start: start, Duration: d, Degraded: degraded, Err: err}
+		c.mu.Lock()
+		c.last = e
+		c.sources = sources
+		c.mu.Unlock()
+		c.metrics.ObserveLoad(e)
+	}()
+
+	si, err := getStructInfoWithin(v, nil, c.limits)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.envconfig {
+		if err := envconfigTags(si.Fields()); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, fi := range si.Fields() {
+		if f, ok := fi.(*fieldInfo); ok {
+			f.parse = c.parse
+			f.parse.lookupEnv = plan.lookupEnv
+			if err := f.checkDefault(); err != nil {
+				return nil, nil, fmt.Errorf("configurator/LoadContext: %w, default %q: %w [%s]", ErrInvalidTagFormat, f.tag.defVal, err, f.Path())
+			}
+		}
+	}
+	steps, degraded, err := c.fetchAll(ctx, plan, sources)
+	if err != nil {
+		if c.snapshot == nil || ctx.Err() != nil {
+			return nil, nil, err
+		}
+		var w *warning
+		if steps, w = c.snapshot.fallback(plan.providers, steps, err); steps == nil {
+			return nil, nil, err
+		}
+		c.degrade(w)
+		degraded = true
+	}
+	c.mu.RLock()
+	if len(c.overrides) > 0 {
+		steps = append(steps, step{name: c.overrides.String(), provider: c.overrides, shared: true})
+	}
+	c.mu.RUnlock()
+	ctx, merge := c.tracer.Start(ctx, "configurator.merge")
+	defer func() { merge.End(err) }()
+	fields = si.Fields()
+	origins = make(map[string]string)
+	before := make([]reflect.Value, len(fields))
+	var contribs []SourceValues
+	var remotes []savedSource
+	var leases []lease
+	for j, s := range steps {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, fmt.Errorf("configurator/LoadContext: %w", err)
+		}
+		if s.provider == nil {
+			continue
+		}
+		for i, fi := range fields {
+			before[i] = copyValue(leaf(fi.Value()))
+			if f, ok := fi.(*fieldInfo); ok {
+				f.explicit, f.raw = false, nil
+			}
+		}
+		t := c.now()
+		var err error
+		if s.scope != "" {
+			err = c.provideScoped(ctx, s, v, fields)
+		} else {
+			err = provide(ctx, s.provider, v, si, c.timeout)
+		}
+		c.metrics.ObserveProvider(ProviderEvent{Provider: s.name, Phase: PhaseApply, Duration: c.now().Sub(t), Err: err})
+		if s.health != nil {
+			s.health.Apply = c.now().Sub(t)
+			if err != nil {
+				s.health.Err = err
+			}
+			s.health.Reachable = s.health.Err == nil
+			if v, ok := s.provider.(Versioner); ok && err == nil {
+				s.health.Version = v.Version()
+			}
+		}
+		if err != nil {
+			var fe *fieldError
+			if errors.As(err, &fe) && fe.source == "" {
+				fe.source = s.name
+			}
+			if col := collectorFrom(ctx); col != nil {
+				col.add(s.name, err)
+				continue
+			}
+			if !c.degrade(err) {
+				return nil, nil, err
+			}
+			degraded = true
+			continue
+		}
+		c.restrictScopes(s, fields, before)
+		c.restrictPriority(s, fields, before, origins)
+		contrib := SourceValues{Name: s.name}
+		for i, fi := range fields {
+			f, ok := fi.(*fieldInfo)
+			if ok && f.explicit {
+				f.set = true
+			}
+			if ok && f.explicit || !reflect.DeepEqual(before[i].Interface(), leaf(fi.Value()).Interface()) {
+				origins[fi.Path()] = s.name
+				contrib.add(fi)
+				if s.health != nil {
+					s.health.Keys++
+				}
+				c.logger.Debug("configurator: field set", "field", fi.Path(), "provider", s.name)
+			}
+		}
+		if !dry && len(contrib.Values) > 0 {
+			contribs = append(contribs, contrib)
+			if s.remote {
+				remotes = append(remotes, savedSource{Index: j, SourceValues: contrib})
+			}
+		}
+		if e, ok := s.provider.(Expirer); ok && e.TTL() > 0 {
+			leases = append(leases, newLease(Lease{Source: s.name}, t, e.TTL()))
+		}
+	}
+
+	if err := c.prune(fields, origins); err != nil {
+		return nil, nil, err
+	}
+	col := collectorFrom(ctx)
+	if col != nil {
+		col.fields = fields
+	}
+	if err := col.check(c.checkGates(fields, origins)); err != nil {
+		return nil, nil, err
+	}
+	secretLeases, err := c.resolveSecrets(ctx, fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	leases = append(leases, secretLeases...)
+	if err := c.expandPaths(fields, plan.lookupEnv); err != nil {
+		return nil, nil, err
+	}
+	if err := c.derive(v, fields, origins); err != nil {
+		return nil, nil, err
 	}
-	for _, p := range c.providers {
-		if err := p.Provide(v, si); err != nil {
-			return err
+	if err := c.postLoad(v); err != nil {
+		return nil, nil, err
+	}
+	if err := col.check(checkRequired(fields)); err != nil {
+		return nil, nil, err
+	}
+	if err := col.check(checkGroups(fields, c.groups)); err != nil {
+		return nil, nil, err
+	}
+	if err := col.check(checkEnums(fields, c.parse.fold)); err != nil {
+		return nil, nil, err
+	}
+	if err := col.err(); err != nil {
+		return nil, nil, err
+	}
+	if err := callValidate(reflect.ValueOf(v).Elem(), fields); err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkPolicies(ctx, fields); err != nil {
+		return nil, nil, err
+	}
+
+	if dry {
+		return fields, origins, nil
+	}
+	c.holdRestarts(ctx, fields, origins)
+	if err := c.prepare(ctx, v); err != nil {
+		return nil, nil, err
+	}
+	findings := c.checkRules(v)
+	for _, f := range findings {
+		c.logger.Warn("configurator: rule finding", "rule", f.Rule, "field", f.Path, "finding", f.Message)
+	}
+	if c.snapshot != nil && !degraded {
+		if err := c.snapshot.save(remotes); err != nil {
+			c.degrade(&warning{reason: "saving snapshot", err: err})
 		}
 	}
-	return nil
+
+	values := make(map[string]reflect.Value, len(fields))
+	for _, fi := range fields {
+		values[fi.Path()] = copyValue(leaf(fi.Value()))
+	}
+
+	schema := make([]FieldSchema, len(fields))
+	for i, fi := range fields {
+		schema[i] = fieldSchema(fi)
+	}
+
+	versions := make(map[string]string)
+	for _, s := range sources {
+		if s.Version != "" {
+			versions[s.Name] = s.Version
+		}
+	}
+
+	c.mu.Lock()
+	prev := c.values
+	c.origins = origins
+	c.values = values
+	c.schema = schema
+	c.versions = versions
+	c.contribs = contribs
+	c.findings = findings
+	c.loaded = v
+	c.active = profile
+	sort.Slice(leases, func(i, j int) bool { return leases[i].Expires.Before(leases[j].Expires) })
+	c.leases = leases
+	for _, s := range steps {
+		if _, ok := s.provider.(Rollout); ok {
+			if c.applied == nil {
+				c.applied = make(map[string]Provider)
+			}
+			c.applied[s.name] = s.provider
+		}
+	}
+	if c.history > 0 {
+		c.record(Revision{Time: c.now(), Checksum: c.checksum(false), Changes: diff(fields, prev, origins)}, values, contribs)
+	}
+	c.mu.Unlock()
+
+	if c.audit != nil {
+		c.audit.Audit(AuditRecord{
+			Time:     c.now(),
+			Changes:  diff(fields, prev, origins),
+			Checksum: checksum(values, nil),
+		})
+	}
+	c.rotate(prev, values)
+	// nothing reads the replaced copies anymore
+	shredValues(prev, secretPaths(schema))
+	c.logger.Debug("configurator: load finished", "fields", len(origins), "degraded", degraded, "checksum", c.Checksum(false))
+	return fields, origins, nil
+}
+
+// step is a provider ready to be applied, named after the configured
+// provider it stands in for.
+type step struct {
+	name     string
+	provider Provider
+	// scope is the subtree a scoped source is bound to.
+	scope string
+	// shared steps may set fields of the subtrees claimed by scopes.
+	shared bool
+	// health is the entry of the source in the health report.
+	health *SourceHealth
+	// remote steps are those kept by WithSnapshot.
+	remote bool
+}
+
+// fetchAll runs Fetch on every Fetcher with at most c.concurrency in flight
+// and returns the providers to apply, in the configured order, recording
+// the outcome in sources.
+func (c *Configurator) fetchAll(ctx context.Context, plan loadPlan, sources []SourceHealth) ([]step, bool, error) {
+	steps := make([]step, len(plan.providers))
+	errs := make([]error, len(plan.providers))
+
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, p := range plan.providers {
+		_, shared := p.(*defaultProvider)
+		steps[i] = step{name: providerName(p), provider: p, scope: plan.scopes[i], shared: shared, health: &sources[i], remote: remote(p)}
+		f, ok := p.(Fetcher)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, f Fetcher) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			ctx, span := c.tracer.Start(ctx, "configurator.fetch "+steps[i].name)
+			t := c.now()
+			steps[i].provider, errs[i] = fetch(ctx, f, c.timeout)
+			span.End(errs[i])
+			d := c.now().Sub(t)
+			c.metrics.ObserveProvider(ProviderEvent{Provider: steps[i].name, Phase: PhaseFetch, Duration: d, Err: errs[i]})
+			sources[i].Fetch = d
+			sources[i].Reachable = errs[i] == nil
+			sources[i].Err = errs[i]
+		}(i, f)
+	}
+	wg.Wait()
+
+	// on a failure, the steps fetched still go back for WithSnapshot
+	var failed error
+	degraded := false
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if !c.degrade(err) {
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+		var w *warning
+		errors.As(err, &w)
+		steps[i].provider = w.fallback
+		degraded = true
+	}
+	for i, s := range steps {
+		if errs[i] == nil && s.provider != nil {
+			steps[i].provider = c.stage(s.name, s.provider)
+		}
+	}
+	return steps, degraded, failed
+}
+
+// degrade reports whether err is a warning, passing it to the handler.
+func (c *Configurator) degrade(err error) bool {
+	var w *warning
+	if !errors.As(err, &w) {
+		return false
+	}
+	if c.warn != nil {
+		c.warn(w)
+	}
+	return true
+}
+
+// IsSet reports whether a source set the field at path, such as
+// "Server.Port", in the last successful Load, even to its zero value. Fields
+// holding their zero value or a default aren't set.
+func (c *Configurator) IsSet(path string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	origin, ok := c.origins[path]
+	return ok && origin != defaultProviderName
+}
+
+// Provenance reports, for the last successful Load, which provider set each
+// field. Keys are field paths such as "MySQL.Host", values are provider names.
+func (c *Configurator) Provenance() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	m := make(map[string]string, len(c.origins))
+	for k, v := range c.origins {
+		m[k] = v
+	}
+	return m
+}
+
+// withProfile returns providers with the file and env providers copied to
+// read the named profile.
+func withProfile(providers []Provider, name string) []Provider {
+	out := make([]Provider, len(providers))
+	for i, p := range providers {
+		switch p := p.(type) {
+		case *fileProvider:
+			cp := *p
+			cp.profile = name
+			out[i] = &cp
+		case *envProvider:
+			cp := *p
+			cp.profile = name
+			out[i] = &cp
+		default:
+			out[i] = p
+		}
+	}
+	return out
+}
+
+// activeProfile returns ctx carrying the profile of the last load, for
+// loads redoing it.
+func (c *Configurator) activeProfile(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(profileKey{}).(string); ok {
+		return ctx
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return context.WithValue(ctx, profileKey{}, c.active)
 }
@@ -0,0 +1,282 @@
+package configurator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type panicProvider struct{}
+
+func (panicProvider) Provide(interface{}, StructInfo) error {
+	panic("boom")
+}
+
+func TestLoad_RecoverPanic(t *testing.T) {
+	type example struct {
+		Name string
+	}
+	c := NewConfigurator(WithFileProvider(""), WithProvider(panicProvider{}))
+	err := c.Load(&example{})
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrPanic))
+}
+
+type slowProvider struct {
+	delay time.Duration
+}
+
+func (p slowProvider) Provide(v interface{}, si StructInfo) error {
+	return p.ProvideContext(context.Background(), v, si)
+}
+
+func (p slowProvider) ProvideContext(ctx context.Context, _ interface{}, _ StructInfo) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(p.delay):
+		return nil
+	}
+}
+
+func TestLoadContext_Canceled(t *testing.T) {
+	type example struct {
+		Name string
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := NewConfigurator(WithFileProvider("not_exist_file.json"))
+	err := c.LoadContext(ctx, &example{})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestLoadContext_ProviderTimeout(t *testing.T) {
+	type example struct {
+		Name string
+	}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithProviderTimeout(10*time.Millisecond),
+		WithProvider(slowProvider{delay: time.Minute}),
+	)
+	err := c.LoadContext(context.Background(), &example{})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	c = NewConfigurator(
+		WithFileProvider(""),
+		WithProviderTimeout(10*time.Millisecond),
+		WithProvider(ProviderWithTimeout(slowProvider{delay: 20 * time.Millisecond}, time.Minute)),
+	)
+	assert.NoError(t, c.LoadContext(context.Background(), &example{}))
+}
+
+type barrierFetcher struct {
+	name    string
+	started *sync.WaitGroup
+}
+
+func (f barrierFetcher) Provide(interface{}, StructInfo) error {
+	return nil
+}
+
+func (f barrierFetcher) Fetch(ctx context.Context) (Provider, error) {
+	f.started.Done()
+	done := make(chan struct{})
+	go func() {
+		f.started.Wait()
+		close(done)
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-done:
+		return fetchedName(f.name), nil
+	}
+}
+
+type fetchedName string
+
+func (n fetchedName) Provide(v interface{}, _ StructInfo) error {
+	name := reflect.ValueOf(v).Elem().FieldByName("Name")
+	name.SetString(name.String() + string(n))
+	return nil
+}
+
+func TestLoadContext_ConcurrentFetch(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(2)
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithProviderTimeout(time.Second),
+		WithFetchConcurrency(2),
+		WithProvider(barrierFetcher{name: "a", started: &started}),
+		WithProvider(barrierFetcher{name: "b", started: &started}),
+	)
+	cfg := &struct{ Name string }{}
+	// both fetches must be in flight at once for either to finish
+	assert.NoError(t, c.LoadContext(context.Background(), cfg))
+	assert.Equal(t, "ab", cfg.Name)
+}
+
+func TestWithLogger(t *testing.T) {
+	type example struct {
+		Name string `config:"env"`
+		Port int    `config:"env"`
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{"NAME=Tom"}),
+		WithLogger(logger),
+	)
+	assert.NoError(t, c.Load(&example{}))
+
+	out := buf.String()
+	assert.Contains(t, out, `msg="configurator: env lookup" key=NAME field=Name found=true`)
+	assert.Contains(t, out, `msg="configurator: env lookup" key=PORT field=Port found=false`)
+	assert.Contains(t, out, `msg="configurator: field set" field=Name provider=env`)
+	assert.Contains(t, out, `msg="configurator: load finished" fields=1`)
+}
+
+func TestIsSet(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Server struct {
+			Port    int  `yaml:"port" config:"default=8080"`
+			Debug   bool `yaml:"debug" config:"env"`
+			Workers int  `yaml:"workers" config:"env"`
+		} `yaml:"server"`
+		Name string `config:"default=api"`
+	}
+
+	f, err := os.CreateTemp("", "*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString("server:\n  port: 0\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfigurator(
+		WithFileProvider(f.Name()),
+		WithENVProvider(""),
+		WithEnviron([]string{"SERVER_DEBUG=false"}),
+		WithDefaultProvider(),
+	)
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.True(t, c.IsSet("Server.Port"))
+	assert.Equal(t, 0, cfg.Server.Port)
+	assert.True(t, c.IsSet("Server.Debug"))
+	assert.Equal(t, "env", c.Provenance()["Server.Debug"])
+	assert.False(t, c.IsSet("Server.Workers"))
+	assert.False(t, c.IsSet("Name"))
+	assert.Equal(t, "api", cfg.Name)
+	assert.False(t, c.IsSet("Unknown"))
+}
+
+func TestParseOptionsLoad(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Debug bool      `config:"env"`
+		Port  int       `config:"env"`
+		Mode  string    `config:"env,enum=debug,info"`
+		Level testLevel `config:"env"`
+	}
+	env := []string{"DEBUG=Yes ", "PORT= 8080", "MODE=INFO", "LEVEL= Debug"}
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(env))
+	assert.Error(t, c.Load(&example{}))
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(env), WithTrimSpace(), WithCaseInsensitive())
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, example{Debug: true, Port: 8080, Mode: "info", Level: testDebug}, cfg)
+}
+
+func TestLenientNumbers(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Buffer    int     `config:"env"`
+		Limit     int64   `config:"default=1_000_000"`
+		Threshold float64 `config:"env,unit=percent,default=90%"`
+		Ratio     float64 `config:"unit=percent"`
+		Timeout   time.Duration
+	}
+
+	f := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(f, []byte("buffer: 4,096\nratio: 12.5%\ntimeout: 1s\n"), 0o600))
+
+	c := NewConfigurator(WithFileProvider(f), WithENVProvider(""), WithDefaultProvider(), WithEnviron(nil))
+	assert.Error(t, c.Load(&example{}))
+
+	c = NewConfigurator(WithFileProvider(f), WithENVProvider(""), WithDefaultProvider(), WithEnviron([]string{"BUFFER=8,192"}), WithLenientNumbers())
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, example{Buffer: 8192, Limit: 1000000, Threshold: 0.9, Ratio: 0.125, Timeout: time.Second}, cfg)
+
+	_, err := parseTag(reflect.StructField{Tag: `config:"unit=bytes"`})
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+}
+
+// wideConfig returns a pointer to a fresh struct of sections sections of 10
+// fields each, and the env setting half of them.
+func wideConfig(sections int) (func() interface{}, []string) {
+	kinds := []struct {
+		typ reflect.Type
+		def string
+		env string
+	}{
+		{reflect.TypeOf(""), "localhost", "db.internal"},
+		{reflect.TypeOf(0), "8080", "9090"},
+		{reflect.TypeOf(false), "true", "false"},
+		{reflect.TypeOf(time.Duration(0)), "5s", "1m"},
+		{reflect.TypeOf([]string(nil)), "a,b", "c,d,e"},
+	}
+	var environ []string
+	fields := make([]reflect.StructField, sections)
+	for s := range fields {
+		inner := make([]reflect.StructField, 10)
+		for i := range inner {
+			k := kinds[i%len(kinds)]
+			name := fmt.Sprintf("Field%d", i)
+			inner[i] = reflect.StructField{
+				Name: name,
+				Type: k.typ,
+				Tag:  reflect.StructTag(fmt.Sprintf(`yaml:"field%d" config:"env,default=%s"`, i, strings.ReplaceAll(k.def, ",", `\,`))),
+			}
+			if i%2 == 0 {
+				environ = append(environ, fmt.Sprintf("SECTION%d_FIELD%d=%s", s, i, k.env))
+			}
+		}
+		fields[s] = reflect.StructField{Name: fmt.Sprintf("Section%d", s), Type: reflect.StructOf(inner)}
+	}
+	typ := reflect.StructOf(fields)
+	return func() interface{} { return reflect.New(typ).Interface() }, environ
+}
+
+func BenchmarkLoad(b *testing.B) {
+	newConfig, environ := wideConfig(30)
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(environ))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.Load(newConfig()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package configuratortest
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ruosing/configurator"
+)
+
+// ErrChaos is the error Chaos injects when Faults.Err is nil.
+var ErrChaos = errors.New("configuratortest: injected fault")
+
+// Faults are the failures Chaos injects in a source. Rates are
+// probabilities from 0 to 1.
+type Faults struct {
+	// Latency delays each fetch, by up to Jitter more, or until the
+	// context of the load is done.
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate of the fetches fail with Err, ErrChaos if nil.
+	ErrorRate float64
+	Err       error
+	// StaleRate of the fetches return the data of the previous successful
+	// fetch instead of calling the source. Only Fetchers go stale.
+	StaleRate float64
+	// Seed makes the faults of a run reproducible.
+	Seed int64
+}
+
+// Chaos wraps p with faults, to check how loads, reloads and Watch behave,
+// with their retry policies and fallbacks, when the backend of p is slow,
+// flaky or serves stale data. Sources that aren't Fetchers are delayed
+// and failed on Provide.
+func Chaos(p configurator.Provider, f Faults) configurator.Provider {
+	c := &chaos{p: p, faults: f, rand: rand.New(rand.NewSource(f.Seed))}
+	if _, ok := p.(configurator.Fetcher); ok {
+		return &chaosFetcher{c}
+	}
+	return c
+}
+
+type chaos struct {
+	p      configurator.Provider
+	faults Faults
+
+	mu   sync.Mutex
+	rand *rand.Rand
+	last configurator.Provider
+}
+
+func (c *chaos) Provide(v interface{}, si configurator.StructInfo) error {
+	return c.ProvideContext(context.Background(), v, si)
+}
+
+func (c *chaos) ProvideContext(ctx context.Context, v interface{}, si configurator.StructInfo) error {
+	if err := c.inject(ctx); err != nil {
+		return err
+	}
+	if cp, ok := c.p.(configurator.ContextProvider); ok {
+		return cp.ProvideContext(ctx, v, si)
+	}
+	return c.p.Provide(v, si)
+}
+
+func (c *chaos) String() string {
+	if s, ok := c.p.(interface{ String() string }); ok {
+		return s.String()
+	}
+	return "chaos"
+}
+
+// roll reports whether an event of probability rate happens.
+func (c *chaos) roll(rate float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return rate > 0 && c.rand.Float64() < rate
+}
+
+// inject waits the latency and returns the error of a failing call.
+func (c *chaos) inject(ctx context.Context) error {
+	d := c.faults.Latency
+	if c.faults.Jitter > 0 {
+		c.mu.Lock()
+		d += time.Duration(c.rand.Int63n(int64(c.faults.Jitter)))
+		c.mu.Unlock()
+	}
+	if d > 0 {
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	if !c.roll(c.faults.ErrorRate) {
+		return nil
+	}
+	if c.faults.Err != nil {
+		return c.faults.Err
+	}
+	return ErrChaos
+}
+
+type chaosFetcher struct {
+	*chaos
+}
+
+func (c *chaosFetcher) Fetch(ctx context.Context) (configurator.Provider, error) {
+	c.mu.Lock()
+	last := c.last
+	c.mu.Unlock()
+	if last != nil && c.roll(c.faults.StaleRate) {
+		return last, nil
+	}
+	if err := c.inject(ctx); err != nil {
+		return nil, err
+	}
+	p, err := c.p.(configurator.Fetcher).Fetch(ctx)
+	if err == nil {
+		c.mu.Lock()
+		c.last = p
+		c.mu.Unlock()
+	}
+	return p, err
+}
+
+// ProvideContext fetches and applies the result, faults included.
+func (c *chaosFetcher) ProvideContext(ctx context.Context, v interface{}, si configurator.StructInfo) error {
+	p, err := c.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	return p.Provide(v, si)
+}
+
+func (c *chaosFetcher) Provide(v interface{}, si configurator.StructInfo) error {
+	return c.ProvideContext(context.Background(), v, si)
+}
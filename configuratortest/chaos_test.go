@@ -0,0 +1,68 @@
+package configuratortest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+// remote is a Fetcher serving the name it holds at each fetch.
+type remote struct {
+	name    string
+	fetches int
+}
+
+func (r *remote) Provide(v interface{}, si configurator.StructInfo) error {
+	p, _ := r.Fetch(context.Background())
+	return p.Provide(v, si)
+}
+
+func (r *remote) Fetch(context.Context) (configurator.Provider, error) {
+	r.fetches++
+	return NewProvider(map[string]string{"Name": r.name}), nil
+}
+
+func TestChaos(t *testing.T) {
+	load := func(p configurator.Provider, options ...configurator.ConfiguratorOption) (*example, error) {
+		cfg := &example{}
+		options = append(options, configurator.WithFileProvider(""), configurator.WithProvider(p))
+		return cfg, configurator.NewConfigurator(options...).Load(cfg)
+	}
+
+	_, err := load(Chaos(&remote{name: "Tom"}, Faults{ErrorRate: 1}))
+	assert.True(t, errors.Is(err, ErrChaos))
+	boom := errors.New("503")
+	_, err = load(Chaos(NewProvider(nil), Faults{ErrorRate: 1, Err: boom}))
+	assert.True(t, errors.Is(err, boom))
+
+	// a best effort source failing leaves the load going
+	var warned error
+	cfg, err := load(configurator.BestEffort(Chaos(&remote{name: "Tom"}, Faults{ErrorRate: 1})),
+		configurator.WithWarningHandler(func(err error) { warned = err }))
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.Name)
+	assert.True(t, errors.Is(warned, ErrChaos))
+
+	_, err = load(Chaos(&remote{name: "Tom"}, Faults{Latency: time.Second}), configurator.WithProviderTimeout(10*time.Millisecond))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// stale fetches serve the data of the first one
+	r := &remote{name: "Tom"}
+	p := Chaos(r, Faults{StaleRate: 1})
+	cfg, err = load(p)
+	assert.NoError(t, err)
+	assert.Equal(t, "Tom", cfg.Name)
+	r.name = "Jerry"
+	cfg, err = load(p)
+	assert.NoError(t, err)
+	assert.Equal(t, "Tom", cfg.Name)
+	assert.Equal(t, 1, r.fetches)
+	_, ok := p.(configurator.Fetcher)
+	assert.True(t, ok)
+	_, ok = Chaos(NewProvider(nil), Faults{}).(configurator.Fetcher)
+	assert.False(t, ok)
+}
@@ -0,0 +1,133 @@
+// Package configuratortest provides helpers for testing code that loads
+// its configuration with configurator.
+package configuratortest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ruosing/configurator"
+)
+
+// update reports whether the tests run with UPDATE_GOLDEN set, to rewrite
+// golden and fixture files. It is no flag, as a library registering one on
+// the command line of the tests clashes with the tests' own.
+func update() bool {
+	v, _ := strconv.ParseBool(os.Getenv("UPDATE_GOLDEN"))
+	return v
+}
+
+// Provider is a fake configurator.Provider that sets fields from a map keyed
+// by field path, e.g. "MySQL.Host".
+type Provider struct {
+	Name   string
+	Values map[string]string
+	Err    error
+	Calls  int
+}
+
+var _ configurator.Provider = &Provider{}
+
+func NewProvider(values map[string]string) *Provider {
+	return &Provider{
+		Name:   "fake",
+		Values: values,
+	}
+}
+
+func (p *Provider) Provide(_ interface{}, si configurator.StructInfo) error {
+	p.Calls++
+	if p.Err != nil {
+		return p.Err
+	}
+	for _, fi := range si.Fields() {
+		v, ok := p.Values[fi.Path()]
+		if !ok {
+			continue
+		}
+		if err := fi.Set(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Provider) String() string {
+	return p.Name
+}
+
+// Load runs v through a configurator that has the file provider disabled and
+// a fake provider serving values, failing the test on error.
+func Load(tb testing.TB, v interface{}, values map[string]string, options ...configurator.ConfiguratorOption) *configurator.Configurator {
+	tb.Helper()
+	opts := append([]configurator.ConfiguratorOption{
+		configurator.WithFileProvider(""),
+		configurator.WithProvider(NewProvider(values)),
+	}, options...)
+	c := configurator.NewConfigurator(opts...)
+	if err := c.Load(v); err != nil {
+		tb.Fatalf("configuratortest: load: %v", err)
+	}
+	return c
+}
+
+// AssertProvenance checks that the field at path was set by the named provider
+// in the last load of c.
+func AssertProvenance(tb testing.TB, c *configurator.Configurator, path, provider string) bool {
+	tb.Helper()
+	got, ok := c.Provenance()[path]
+	if !ok {
+		tb.Errorf("configuratortest: field %s was not set by any provider, want %s", path, provider)
+		return false
+	}
+	if got != provider {
+		tb.Errorf("configuratortest: field %s was set by %s, want %s", path, got, provider)
+		return false
+	}
+	return true
+}
+
+// Golden compares got with testdata/<name>.golden. Run the tests with
+// UPDATE_GOLDEN=1 to rewrite the golden file.
+func Golden(tb testing.TB, name string, got []byte) bool {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if update() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			tb.Fatal(err)
+		}
+		return true
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("configuratortest: %v (run with UPDATE_GOLDEN=1 to create it)", err)
+	}
+	if !bytes.Equal(want, got) {
+		tb.Errorf("configuratortest: %s mismatch\n--- want\n%s\n--- got\n%s", path, want, got)
+		return false
+	}
+	return true
+}
+
+// Record stands in for the remote source p with the fixture file
+// testdata/<name>.json: the values p sets are recorded there when the
+// tests run with UPDATE_GOLDEN=1, or the file doesn't exist yet, and replayed
+// without calling p otherwise, such as in CI.
+func Record(tb testing.TB, p configurator.Provider, name string) configurator.Provider {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".json")
+	_, err := os.Stat(path)
+	record := update() || os.IsNotExist(err)
+	if record {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return configurator.Cassette(p, path, record)
+}
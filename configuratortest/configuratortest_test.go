@@ -0,0 +1,62 @@
+package configuratortest
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+type example struct {
+	Name  string `config:"env"`
+	MySQL struct {
+		Host string `config:"default=localhost"`
+		Port int    `config:"default=3306"`
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cfg := &example{}
+	c := Load(t, cfg, map[string]string{"Name": "Tom", "MySQL.Port": "3307"},
+		configurator.WithENVProvider(""),
+		configurator.WithEnviron([]string{"NAME=Jerry"}),
+		configurator.WithDefaultProvider(),
+	)
+
+	assert.Equal(t, "Tom", cfg.Name)
+	assert.Equal(t, "localhost", cfg.MySQL.Host)
+	assert.Equal(t, 3307, cfg.MySQL.Port)
+	AssertProvenance(t, c, "Name", "fake")
+	AssertProvenance(t, c, "MySQL.Host", "default")
+	AssertProvenance(t, c, "MySQL.Port", "fake")
+}
+
+func TestProvider_Err(t *testing.T) {
+	p := NewProvider(nil)
+	p.Err = errors.New("boom")
+	c := configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithProvider(p))
+	assert.Equal(t, p.Err, c.Load(&example{}))
+	assert.Equal(t, 1, p.Calls)
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, "golden", []byte("Name=Tom\n"))
+	// the tests importing the package define their own flags
+	assert.Nil(t, flag.Lookup("update"))
+}
+
+func TestRecord(t *testing.T) {
+	// testdata/remote.json was recorded from this provider, which fails now
+	p := NewProvider(nil)
+	p.Name = "remote"
+	p.Err = errors.New("unreachable")
+	cfg := &example{}
+	c := configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithProvider(Record(t, p, "remote")))
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "Tom", cfg.Name)
+	assert.Equal(t, 3307, cfg.MySQL.Port)
+	assert.Equal(t, 0, p.Calls)
+	AssertProvenance(t, c, "Name", "remote")
+}
@@ -1,11 +1,117 @@
 package configurator
 
-type defaultProvider struct{}
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	defaultNow          = "now"
+	defaultProviderName = "default"
+)
+
+type defaultProvider struct {
+	now   func() time.Time
+	funcs map[string]DefaultFunc
+}
 
 func NewDefaultProvider() *defaultProvider {
-	return &defaultProvider{}
+	return &defaultProvider{
+		now: time.Now,
+	}
 }
 
 func (p defaultProvider) Provide(v interface{}, si StructInfo) error {
+	for _, fi := range si.Fields() {
+		def := fi.DefVal()
+		f, ok := fi.(*fieldInfo)
+		if def == "" && (!ok || f.tag.defFn == "") || ok && f.set {
+			continue
+		}
+		val := fi.Value()
+		lv := leaf(val)
+		if !lv.IsZero() {
+			continue
+		}
+		if ok && f.tag.defFn != "" {
+			name, factor, err := splitDefaultFn(f.tag.defFn)
+			if err != nil {
+				return fmt.Errorf("defaultProvider/Provide: %w [%s]", err, fi.Name())
+			}
+			fn, ok := lookupDefaultFunc(p.funcs, name)
+			if !ok {
+				return fmt.Errorf("defaultProvider/Provide: %w, unknown defaultFn %s [%s]", ErrInvalidTagFormat, name, fi.Name())
+			}
+			if def, err = fn(); err != nil {
+				return fmt.Errorf("defaultProvider/Provide: %w [%s]", err, fi.Name())
+			}
+			if def, err = scaleDefault(def, factor); err != nil {
+				return fmt.Errorf("defaultProvider/Provide: %w [%s]", err, fi.Name())
+			}
+		}
+		if def == defaultNow && (lv.Type() == timeType || lv.Type() == timePtrType) {
+			now := reflect.New(timeType)
+			now.Elem().Set(reflect.ValueOf(p.now()))
+			if lv.Type() == timeType {
+				now = now.Elem()
+			}
+			if d, ok := asWrapper(val); ok {
+				d.store(now)
+			} else {
+				val.Set(now)
+			}
+			continue
+		}
+		var o parseOptions
+		if ok {
+			o = f.options()
+			var err error
+			if def, err = o.expandRuntime(def); err != nil {
+				return fmt.Errorf("defaultProvider/Provide: %w [%s]", err, fi.Name())
+			}
+			if def, err = o.transform(def); err != nil {
+				return fmt.Errorf("defaultProvider/Provide: %w [%s]", err, fi.Name())
+			}
+			if o.fold {
+				def = f.canonical(def)
+			}
+		}
+		if err := o.set(val, val.Type(), def); err != nil {
+			return fmt.Errorf("defaultProvider/Provide: %w [%s]", err, fi.Name())
+		}
+	}
+	return structDefaults(v, si)
+}
+
+func (p defaultProvider) String() string {
+	return defaultProviderName
+}
+
+// checkDefault parses the default of f into a scratch value of the field's
+// type, so that a broken default fails every load, not only the ones
+// needing it.
+func (f *fieldInfo) checkDefault() error {
+	def := f.tag.defVal
+	t := leaf(f.val).Type()
+	if def == "" || def == defaultNow && (t == timeType || t == timePtrType) {
+		return nil
+	}
+	o := f.options()
+	def, err := o.expandRuntime(def)
+	if err != nil {
+		return err
+	}
+	if def, err = o.transform(def); err != nil {
+		return err
+	}
+	scratch := reflect.New(f.val.Type()).Elem()
+	if err := o.set(scratch, scratch.Type(), def); err != nil {
+		return err
+	}
+	if len(f.tag.enum) > 0 && !contains(f.tag.enum, def) && !(f.parse.fold && f.canonical(def) != def) {
+		return fmt.Errorf("%w %q, allowed [%s]", ErrEnum, def, strings.Join(f.tag.enum, ", "))
+	}
 	return nil
 }
@@ -0,0 +1,138 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultProvider(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Name    string        `config:"default=Tom"`
+		Age     *int          `config:"default=24"`
+		Timeout time.Duration `config:"default=3s"`
+		Tags    []string      `config:"default=foo"`
+		Set     string        `config:"default=Foo"`
+		StartAt time.Time     `config:"default=now"`
+		Expire  *time.Time    `config:"default=now"`
+	}
+
+	now := time.Date(2020, 9, 30, 22, 51, 49, 0, time.UTC)
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithDefaultProvider(),
+		WithClock(func() time.Time { return now }),
+	)
+
+	cfg := &example{Set: "Bar"}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{
+		Name:    "Tom",
+		Age:     i(24),
+		Timeout: 3 * time.Second,
+		Tags:    []string{"foo"},
+		Set:     "Bar",
+		StartAt: now,
+		Expire:  timePtr(now),
+	}, cfg)
+}
+
+func TestDefaultProvider_DefaultFn(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Host    string `config:"defaultFn=Hostname"`
+		Workers int    `config:"defaultFn=numCPU"`
+		Port    int    `config:"defaultFn=RandomPort"`
+		ID      string `config:"defaultFn=uuid"`
+		Region  string `config:"defaultFn=region"`
+		Set     string `config:"defaultFn=region"`
+	}
+
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithDefaultProvider(),
+		WithDefaultFunc("Region", func() (string, error) { return "eu-west-1", nil }),
+	)
+	cfg := &example{Set: "us"}
+	assert.NoError(t, c.Load(cfg))
+	host, _ := os.Hostname()
+	assert.Equal(t, host, cfg.Host)
+	assert.Equal(t, runtime.NumCPU(), cfg.Workers)
+	assert.NotZero(t, cfg.Port)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, cfg.ID)
+	assert.Equal(t, "eu-west-1", cfg.Region)
+	assert.Equal(t, "us", cfg.Set)
+
+	type unknown struct {
+		Name string `config:"defaultFn=nope"`
+	}
+	err := NewConfigurator(WithFileProvider(""), WithDefaultProvider()).Load(&unknown{})
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+}
+
+type withDefaults struct {
+	Name    string `config:"env"`
+	Timeout time.Duration
+	Level   Dynamic[string]
+	DB      *dbDefaults
+}
+
+func (c *withDefaults) SetDefaults() {
+	c.Name = "api"
+	c.Timeout = time.Second
+	c.Level.Set("info")
+}
+
+type dbDefaults struct {
+	Port int
+}
+
+func (d *dbDefaults) SetDefaults() {
+	d.Port = 5432
+}
+
+func TestDefaultProvider_SetDefaults(t *testing.T) {
+	t.Parallel()
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{"NAME=web"}),
+		WithDefaultProvider(),
+	)
+	cfg := &withDefaults{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "web", cfg.Name)
+	assert.Equal(t, time.Second, cfg.Timeout)
+	assert.Equal(t, "info", cfg.Level.Get())
+	assert.Equal(t, 5432, cfg.DB.Port)
+	assert.Equal(t, "default", c.Provenance()["DB.Port"])
+}
+
+func TestDefaultProvider_CheckDefaults(t *testing.T) {
+	type example struct {
+		Name string `config:"env,default=api"`
+		Port int    `config:"env,default=abc"`
+	}
+	// fails even though a source sets the field
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron([]string{"PORT=80"}))
+	err := c.Load(&example{})
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+	assert.Contains(t, err.Error(), `default "abc"`)
+	assert.Contains(t, err.Error(), "[Port]")
+
+	type enum struct {
+		Level string `config:"enum=debug,info,default=Info"`
+	}
+	c = NewConfigurator(WithFileProvider(""), WithDefaultProvider())
+	err = c.Load(&enum{})
+	assert.True(t, errors.Is(err, ErrEnum))
+	c = NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithCaseInsensitive())
+	cfg := &enum{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "info", cfg.Level)
+}
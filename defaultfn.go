@@ -0,0 +1,176 @@
+package configurator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultFunc computes a default value, in the form setFieldValue parses,
+// for fields tagged `defaultFn=Name`.
+type DefaultFunc func() (string, error)
+
+var (
+	defaultFuncsMu sync.RWMutex
+	defaultFuncs   = map[string]DefaultFunc{
+		"hostname":    os.Hostname,
+		"numcpu":      func() (string, error) { return strconv.Itoa(runtime.NumCPU()), nil },
+		"randomport":  randomPort,
+		"uuid":        newUUID,
+		"cpuquota":    cpuQuota,
+		"memorylimit": memoryLimit,
+	}
+)
+
+// RegisterDefaultFunc makes fn available to `defaultFn=name` tags of every
+// configurator. Names are case insensitive; Hostname, NumCPU, RandomPort,
+// UUID, CPUQuota, the CPUs the cgroup of the container may use, and
+// MemoryLimit, its memory limit in bytes, are built in, the last two
+// falling back to the resources of the host. A numeric default may be
+// scaled, such as `defaultFn=MemoryLimit*0.25` for a cache.
+func RegisterDefaultFunc(name string, fn DefaultFunc) {
+	defaultFuncsMu.Lock()
+	defer defaultFuncsMu.Unlock()
+	defaultFuncs[strings.ToLower(name)] = fn
+}
+
+func lookupDefaultFunc(funcs map[string]DefaultFunc, name string) (DefaultFunc, bool) {
+	name = strings.ToLower(name)
+	if fn, ok := funcs[name]; ok {
+		return fn, true
+	}
+	defaultFuncsMu.RLock()
+	defer defaultFuncsMu.RUnlock()
+	fn, ok := defaultFuncs[name]
+	return fn, ok
+}
+
+func randomPort() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port), nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// DefaultProvider is implemented by configuration structs, or their
+// sections, whose defaults are computed in code. SetDefaults is called on a
+// zero value and the fields it sets are copied to the fields no provider
+// set, after the tag defaults.
+type DefaultProvider interface {
+	SetDefaults()
+}
+
+// structDefaults fills the zero fields of v that no provider set with the
+// values SetDefaults gives a fresh value of the same type.
+func structDefaults(v interface{}, si StructInfo) error {
+	if !hasSetDefaults(reflect.TypeOf(v).Elem()) {
+		return nil
+	}
+	fresh := reflect.New(reflect.TypeOf(v).Elem())
+	fsi, err := getStructInfo(fresh.Interface(), nil)
+	if err != nil {
+		return err
+	}
+	if !callSetDefaults(fresh.Elem()) {
+		return nil
+	}
+	ffields, fields := fsi.Fields(), si.Fields()
+	if len(ffields) != len(fields) {
+		return nil
+	}
+	for i, fi := range fields {
+		def := leaf(ffields[i].Value())
+		if def.IsZero() || !leaf(fi.Value()).IsZero() {
+			continue
+		}
+		if f, ok := fi.(*fieldInfo); ok && f.set {
+			continue
+		}
+		if d, ok := asWrapper(fi.Value()); ok {
+			d.store(copyValue(def))
+		} else {
+			fi.Value().Set(copyValue(def))
+		}
+	}
+	return nil
+}
+
+var (
+	defaultProviderType = reflect.TypeOf((*DefaultProvider)(nil)).Elem()
+	// setDefaultsTypes caches hasSetDefaults by struct type.
+	setDefaultsTypes sync.Map
+)
+
+// hasSetDefaults reports whether the struct type t, or a section of it,
+// implements DefaultProvider, sparing loads of types without a fresh value
+// to compare with.
+func hasSetDefaults(t reflect.Type) bool {
+	if found, ok := setDefaultsTypes.Load(t); ok {
+		return found.(bool)
+	}
+	found := findSetDefaults(t, map[reflect.Type]bool{})
+	setDefaultsTypes.Store(t, found)
+	return found
+}
+
+func findSetDefaults(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	if reflect.PointerTo(t).Implements(defaultProviderType) {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if !ft.IsExported() || ft.Type == timeType || isWrapper(ft.Type) {
+			continue
+		}
+		if st := indirect(ft.Type); st.Kind() == reflect.Struct && findSetDefaults(st, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// callSetDefaults calls SetDefaults on v and its sections, reporting
+// whether any implements DefaultProvider.
+func callSetDefaults(v reflect.Value) bool {
+	found := false
+	if dp, ok := v.Addr().Interface().(DefaultProvider); ok {
+		dp.SetDefaults()
+		found = true
+	}
+	for i := 0; i < v.NumField(); i++ {
+		fv := v.Field(i)
+		if !fv.CanSet() || fv.Type() == timeType || isWrapper(fv.Type()) {
+			continue
+		}
+		for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct && fv.CanAddr() {
+			found = callSetDefaults(fv) || found
+		}
+	}
+	return found
+}
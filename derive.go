@@ -0,0 +1,144 @@
+package configurator
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+const deriveProviderName = "derive"
+
+var (
+	deriveFuncsMu sync.RWMutex
+	deriveFuncs   = template.FuncMap{
+		"add":      func(a, b interface{}) (interface{}, error) { return arith(a, b, '+') },
+		"sub":      func(a, b interface{}) (interface{}, error) { return arith(a, b, '-') },
+		"mul":      func(a, b interface{}) (interface{}, error) { return arith(a, b, '*') },
+		"lower":    strings.ToLower,
+		"upper":    strings.ToUpper,
+		"join":     strings.Join,
+		"hostPort": func(host string, port interface{}) string { return net.JoinHostPort(host, fmt.Sprint(port)) },
+		// replaced by the runtime of the configurator, see derive
+		"runtime": func(key string) string { v, _ := DetectRuntime().Lookup(key); return v },
+	}
+)
+
+// RegisterDeriveFunc makes fn, a func as text/template takes, available to
+// `derive=` templates, such as {{metricsAddr .}}. add, sub, mul, lower,
+// upper, join, hostPort and runtime, returning a value of the Runtime such
+// as {{runtime "podIP"}}, are built in. Register funcs before loading, as
+// templates calling an unknown func fail.
+func RegisterDeriveFunc(name string, fn interface{}) {
+	deriveFuncsMu.Lock()
+	defer deriveFuncsMu.Unlock()
+	deriveFuncs[name] = fn
+}
+
+// parseDerive parses the `derive=` template of a field.
+func parseDerive(text string) (*template.Template, error) {
+	deriveFuncsMu.RLock()
+	defer deriveFuncsMu.RUnlock()
+	return template.New(deriveProviderName).Funcs(deriveFuncs).Option("missingkey=error").Parse(text)
+}
+
+// derive sets the fields tagged `derive=` that no source set to their
+// template, executed on the struct v points to, so that {{.Host}} is the
+// Host field of the root. Fields are derived in order: a field derived from
+// another derived field comes after it. An empty result leaves the field
+// unset.
+func (c *Configurator) derive(v interface{}, fields []FieldInfo, origins map[string]string) error {
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || f.tag.derive == "" || f.disabled {
+			continue
+		}
+		if _, ok := origins[f.Path()]; ok {
+			continue
+		}
+		tmpl, err := parseDerive(f.tag.derive)
+		if err != nil {
+			return fmt.Errorf("configurator/LoadContext: %w, derive: %w [%s]", ErrInvalidTagFormat, err, f.Path())
+		}
+		if rt := f.parse.runtime; rt != nil {
+			tmpl.Funcs(template.FuncMap{"runtime": func(key string) string { v, _ := rt().Lookup(key); return v }})
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, v); err != nil {
+			return fmt.Errorf("configurator/LoadContext: derive: %w [%s]", err, f.Path())
+		}
+		if b.Len() == 0 {
+			continue
+		}
+		if err := f.Set(b.String()); err != nil {
+			return fmt.Errorf("configurator/LoadContext: derive: %w", err)
+		}
+		origins[f.Path()] = deriveProviderName
+		c.logger.Debug("configurator: field set", "field", f.Path(), "provider", deriveProviderName)
+	}
+	return nil
+}
+
+// arith applies op to a and b, integers unless either is a float.
+func arith(a, b interface{}, op byte) (interface{}, error) {
+	x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+	if isFloat(x) || isFloat(y) {
+		fx, err := toFloat(x)
+		if err != nil {
+			return nil, err
+		}
+		fy, err := toFloat(y)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case '+':
+			return fx + fy, nil
+		case '-':
+			return fx - fy, nil
+		}
+		return fx * fy, nil
+	}
+	ix, err := toInt(x)
+	if err != nil {
+		return nil, err
+	}
+	iy, err := toInt(y)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case '+':
+		return ix + iy, nil
+	case '-':
+		return ix - iy, nil
+	}
+	return ix * iy, nil
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
+
+func toFloat(v reflect.Value) (float64, error) {
+	if isFloat(v) {
+		return v.Float(), nil
+	}
+	i, err := toInt(v)
+	return float64(i), err
+}
+
+func toInt(v reflect.Value) (int64, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.String:
+		return strconv.ParseInt(v.String(), 10, 64)
+	}
+	return 0, fmt.Errorf("%w: %v is not a number", ErrInvalidValue, v)
+}
@@ -0,0 +1,64 @@
+package configurator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerive(t *testing.T) {
+	RegisterDeriveFunc("domain", func(host string) string { return host[strings.Index(host, ".")+1:] })
+	type example struct {
+		Host        string `config:"env,default=api.example.com"`
+		Port        int    `config:"env,default=8080"`
+		MetricsAddr string `config:"env,derive={{hostPort .Host (add .Port 1000)}}"`
+		Domain      string `config:"derive={{domain .Host | upper}}"`
+		Cookie      string `config:"derive={{.Domain}}"`
+		Limit       int    `config:"derive={{mul .Port 2}}"`
+		Empty       string `config:"derive={{if false}}x{{end}}"`
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(nil))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "api.example.com:9080", cfg.MetricsAddr)
+	assert.Equal(t, "EXAMPLE.COM", cfg.Domain)
+	assert.Equal(t, "EXAMPLE.COM", cfg.Cookie)
+	assert.Equal(t, 16160, cfg.Limit)
+	assert.Equal(t, "", cfg.Empty)
+	assert.Equal(t, "derive", c.Provenance()["MetricsAddr"])
+
+	// a field set by a source isn't derived
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron([]string{"METRICSADDR=:9100"}))
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, ":9100", cfg.MetricsAddr)
+	assert.Equal(t, "env", c.Provenance()["MetricsAddr"])
+
+	type invalid struct {
+		Port int `config:"derive={{.Port"`
+	}
+	assert.True(t, errors.Is(NewConfigurator(WithFileProvider("")).Load(&invalid{}), ErrInvalidTagFormat))
+	type notInt struct {
+		Host string
+		Port int `config:"derive={{.Host}}"`
+	}
+	err := NewConfigurator(WithFileProvider("")).Load(&notInt{Host: "x"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `derive: strconv.ParseInt: parsing "x"`)
+	}
+	type missing struct {
+		Port int `config:"derive={{.Nope}}"`
+	}
+	err = NewConfigurator(WithFileProvider("")).Load(&missing{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "configurator/LoadContext: derive:")
+	}
+
+	type both struct {
+		Port int `config:"default=1,derive={{add 1 1}}"`
+	}
+	assert.Equal(t, []Problem{{Path: "Port", Message: "derived field has a default, so it is never derived"}}, LintTags(&both{}))
+	assert.Empty(t, LintTags(&example{}))
+}
@@ -0,0 +1,220 @@
+package configurator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	downwardProviderName = "downward"
+	// DefaultDownwardDir is where the examples of Kubernetes mount the
+	// downwardAPI volume.
+	DefaultDownwardDir = "/etc/podinfo"
+)
+
+// downwardFields are the fields of the Downward API, with the file of the
+// downwardAPI volume and the env var they are conventionally exposed as.
+var downwardFields = []struct {
+	path, file, env string
+}{
+	{"metadata.name", "name", "POD_NAME"},
+	{"metadata.namespace", "namespace", "POD_NAMESPACE"},
+	{"metadata.uid", "uid", "POD_UID"},
+	{"metadata.labels", "labels", ""},
+	{"metadata.annotations", "annotations", ""},
+	{"spec.nodeName", "nodeName", "NODE_NAME"},
+	{"spec.serviceAccountName", "serviceAccountName", "POD_SERVICE_ACCOUNT"},
+	{"status.podIP", "podIP", "POD_IP"},
+	{"status.hostIP", "hostIP", "HOST_IP"},
+	{"limits.cpu", "cpu_limit", "CPU_LIMIT"},
+	{"limits.memory", "mem_limit", "MEMORY_LIMIT"},
+	{"requests.cpu", "cpu_request", "CPU_REQUEST"},
+	{"requests.memory", "mem_request", "MEMORY_REQUEST"},
+}
+
+// DownwardAPI returns a provider setting fields from the Downward API of
+// Kubernetes, to pass to WithProvider: the files of a downwardAPI volume
+// mounted at dir, DefaultDownwardDir if empty, named after the fields
+// (name, namespace, uid, labels, annotations, nodeName, serviceAccountName,
+// podIP, hostIP, cpu_limit, mem_limit, cpu_request and mem_request), or
+// else the env vars they are conventionally exposed as, such as
+// POD_NAMESPACE. Fields tagged `downward=metadata.namespace` or
+// `downward=metadata.labels['app']` read the field of that path, a map
+// field with string keys takes all the labels or annotations, and fields
+// tagged `env=POD_NAMESPACE` and the like read the Downward API when the
+// env var isn't set.
+func DownwardAPI(dir string) *downwardProvider {
+	if dir == "" {
+		dir = DefaultDownwardDir
+	}
+	return &downwardProvider{dir: dir, lookup: os.LookupEnv}
+}
+
+type downwardProvider struct {
+	dir    string
+	lookup func(string) (string, bool)
+}
+
+func (p *downwardProvider) Provide(_ interface{}, si StructInfo) error {
+	for _, fi := range si.Fields() {
+		path := ""
+		if f, ok := fi.(*fieldInfo); ok {
+			path = f.tag.downward
+		}
+		if path == "" {
+			path = downwardPathOf(fi.ENVKey())
+		}
+		if path == "" {
+			continue
+		}
+		if err := p.set(fi, path); err != nil {
+			return fmt.Errorf("downwardProvider/Provide: %w [%s]", err, fi.Path())
+		}
+	}
+	return nil
+}
+
+func (p *downwardProvider) set(fi FieldInfo, path string) error {
+	base, key, hasKey := parseDownwardPath(path)
+	file, env := "", ""
+	for _, d := range downwardFields {
+		if d.path == base {
+			file, env = d.file, d.env
+		}
+	}
+	if file == "" {
+		return fmt.Errorf("%w, unknown downward field %q", ErrInvalidTagFormat, path)
+	}
+	if env != "" {
+		if v, ok := p.read(file); ok {
+			return fi.Set(v)
+		}
+		if v, ok := p.lookup(env); ok {
+			return fi.Set(v)
+		}
+		return nil
+	}
+
+	raw, ok := p.read(file)
+	if !ok {
+		return nil
+	}
+	m, err := parseDownwardMap(raw)
+	if err != nil {
+		return fmt.Errorf("%w, %s: %w", ErrInvalidValue, file, err)
+	}
+	if hasKey {
+		if v, ok := m[key]; ok {
+			return fi.Set(v)
+		}
+		return nil
+	}
+	typ := leaf(fi.Value()).Type()
+	if !isPrefixMap(typ) {
+		return fmt.Errorf("%w, `downward=%s` needs a map with string keys", ErrInvalidTagFormat, path)
+	}
+	var o parseOptions
+	if f, ok := fi.(*fieldInfo); ok {
+		o = f.options()
+	}
+	mv := reflect.MakeMap(typ)
+	for k, v := range m {
+		elem := reflect.New(typ.Elem()).Elem()
+		if err := o.set(elem, typ.Elem(), v); err != nil {
+			return fmt.Errorf("%w [%s]", err, k)
+		}
+		mv.SetMapIndex(reflect.ValueOf(k).Convert(typ.Key()), elem)
+	}
+	if d, ok := asWrapper(fi.Value()); ok {
+		d.store(mv)
+	} else if err := assignValue(fi.Value(), mv); err != nil {
+		return err
+	}
+	markSet(fi)
+	return nil
+}
+
+// read returns the content of a file of the volume, false when missing.
+func (p *downwardProvider) read(file string) (string, bool) {
+	b, err := os.ReadFile(filepath.Join(p.dir, file))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(b)), true
+}
+
+func (p *downwardProvider) String() string {
+	return downwardProviderName
+}
+
+// parseDownwardPath splits metadata.labels[app], the quotes of
+// metadata.labels['app'] being dropped by splitTag, into its field and key.
+func parseDownwardPath(path string) (base, key string, hasKey bool) {
+	base, key, hasKey = strings.Cut(path, "[")
+	if hasKey {
+		key = strings.Trim(strings.TrimSuffix(key, "]"), `'"`)
+	}
+	return base, key, hasKey
+}
+
+// validDownwardPath reports whether path is a field of the Downward API, a
+// key only following labels and annotations.
+func validDownwardPath(path string) bool {
+	base, key, hasKey := parseDownwardPath(path)
+	if hasKey && (key == "" || base != "metadata.labels" && base != "metadata.annotations") {
+		return false
+	}
+	for _, d := range downwardFields {
+		if d.path == base {
+			return true
+		}
+	}
+	return false
+}
+
+// isDownwardMap reports whether path is all the labels or annotations.
+func isDownwardMap(path string) bool {
+	return path == "metadata.labels" || path == "metadata.annotations"
+}
+
+// downwardPathOf returns the field of the Downward API env is
+// conventionally exposed as, "" for other env vars.
+func downwardPathOf(env string) string {
+	if env == "" {
+		return ""
+	}
+	for _, d := range downwardFields {
+		if d.env == env {
+			return d.path
+		}
+	}
+	return ""
+}
+
+// parseDownwardMap parses the labels or annotations file of a downwardAPI
+// volume, a key="value" line each, values quoted as in Go.
+func parseDownwardMap(raw string) (map[string]string, error) {
+	m := make(map[string]string)
+	s := bufio.NewScanner(strings.NewReader(raw))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		uv, err := strconv.Unquote(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", k, err)
+		}
+		m[k] = uv
+	}
+	return m, s.Err()
+}
@@ -0,0 +1,64 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownwardAPI(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"namespace":   "prod\n",
+		"labels":      "app=\"web\"\ntier=\"front\\\"end\"\n",
+		"annotations": "team=\"core\"\n",
+		"cpu_limit":   "2\n",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	type example struct {
+		Namespace string            `config:"env=POD_NAMESPACE"`
+		PodName   string            `config:"env=POD_NAME"`
+		App       string            `config:"downward=metadata.labels['app']"`
+		Tier      string            `config:"downward=metadata.labels[tier]"`
+		Missing   string            `config:"downward=metadata.labels['nope']"`
+		Labels    map[string]string `config:"downward=metadata.labels"`
+		Team      string            `config:"downward=metadata.annotations['team']"`
+		CPU       int               `config:"downward=limits.cpu"`
+		NodeName  string            `config:"downward=spec.nodeName"`
+	}
+	p := DownwardAPI(dir)
+	p.lookup = environLookup([]string{"POD_NAME=web-0", "POD_NAMESPACE=ignored", "NODE_NAME=node-1"})
+	c := NewConfigurator(WithFileProvider(""), WithProvider(p))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, example{
+		Namespace: "prod",
+		PodName:   "web-0",
+		App:       "web",
+		Tier:      `front"end`,
+		Labels:    map[string]string{"app": "web", "tier": `front"end`},
+		Team:      "core",
+		CPU:       2,
+		NodeName:  "node-1",
+	}, *cfg)
+	assert.Equal(t, "downward", c.Provenance()["Labels"])
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "labels"), []byte("app: web\n"), 0o600))
+	assert.True(t, errors.Is(c.Load(&example{}), ErrInvalidValue))
+
+	type invalid struct {
+		Name string `config:"downward=metadata.nickname"`
+	}
+	assert.True(t, errors.Is(c.Load(&invalid{}), ErrInvalidTagFormat))
+
+	type notMap struct {
+		Labels string `config:"downward=metadata.labels"`
+	}
+	assert.Equal(t, []Problem{{Path: "Labels", Message: "downward=metadata.labels needs a map with string keys, not string"}}, LintTags(&notMap{}))
+	assert.Empty(t, LintTags(&example{}))
+}
@@ -0,0 +1,56 @@
+package configurator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Drift resolves the sources again into a fresh value of v's type and
+// returns how the configuration they describe differs from the one applied
+// by the last successful load, without applying or recording anything. A
+// configuration that isn't reloaded automatically has drifted when there
+// are changes; their Old values are the applied ones. v must have been
+// loaded before.
+func (c *Configurator) Drift(ctx context.Context, v interface{}) ([]Change, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidConfig
+	}
+	c.mu.RLock()
+	applied := c.values
+	c.mu.RUnlock()
+	if applied == nil {
+		return nil, fmt.Errorf("configurator/Drift: %w, not loaded [%s]", ErrInvalidConfig, rv.Type())
+	}
+	fields, origins, err := c.load(c.activeProfile(ctx), reflect.New(rv.Elem().Type()).Interface(), true)
+	if err != nil {
+		return nil, err
+	}
+	return diff(fields, applied, origins), nil
+}
+
+// WatchDrift calls Drift every interval until ctx is done, passing the
+// result to onDrift when the configuration has drifted or the check
+// failed.
+func (c *Configurator) WatchDrift(ctx context.Context, v interface{}, interval time.Duration, onDrift func([]Change, error)) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			changes, err := c.Drift(ctx, v)
+			if err != nil {
+				c.logger.Debug("configurator: drift check failed", "error", err)
+			} else if len(changes) > 0 {
+				c.logger.Info("configurator: configuration drifted", "fields", len(changes))
+			}
+			if (err != nil || len(changes) > 0) && onDrift != nil {
+				onDrift(changes, err)
+			}
+		}
+	}
+}
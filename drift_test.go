@@ -0,0 +1,56 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrift(t *testing.T) {
+	type example struct {
+		Host     string
+		Password string `config:"secret"`
+	}
+	src := pathProvider{"Host": "db", "Password": "first"}
+	var audits int
+	c := NewConfigurator(WithFileProvider(""), WithProvider(src), WithAuditSink(AuditFunc(func(AuditRecord) { audits++ })))
+	var cfg example
+	_, err := c.Drift(context.Background(), &cfg)
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+
+	assert.NoError(t, c.Load(&cfg))
+	changes, err := c.Drift(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	src["Host"] = "replica"
+	src["Password"] = "second"
+	checksum := c.Checksum(true)
+	changes, err = c.Drift(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Key: "Host", Old: "db", New: "replica", Source: providerName(src)},
+		{Key: "Password", Old: secretMask, New: secretMask, Source: providerName(src)},
+	}, changes)
+	// nothing applied or recorded
+	assert.Equal(t, "db", cfg.Host)
+	assert.Equal(t, checksum, c.Checksum(true))
+	assert.Equal(t, 1, audits)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	drifted := make(chan []Change, 1)
+	go func() {
+		_ = c.WatchDrift(ctx, &cfg, time.Millisecond, func(changes []Change, err error) {
+			assert.NoError(t, err)
+			select {
+			case drifted <- changes:
+			default:
+			}
+		})
+	}()
+	assert.Len(t, <-drifted, 2)
+	cancel()
+}
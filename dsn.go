@@ -0,0 +1,195 @@
+package configurator
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DSN is a database connection given as a URL, a set of components or
+// both: the components override the parts of the URL, so that the URL can
+// come from a secret and the host from an env var. It is validated when
+// loaded, and prints with the password redacted. Use one of PostgresDSN,
+// MySQLDSN, RedisDSN or AMQPDSN.
+type DSN[S dsnScheme] struct {
+	URL      string `config:"env,secret"`
+	Host     string `config:"env"`
+	Port     int    `config:"env"`
+	User     string `config:"env"`
+	Password string `config:"env,secret"`
+	Database string `config:"env"`
+}
+
+type (
+	PostgresDSN = DSN[postgresScheme]
+	MySQLDSN    = DSN[mysqlScheme]
+	RedisDSN    = DSN[redisScheme]
+	AMQPDSN     = DSN[amqpScheme]
+)
+
+type dsnScheme interface {
+	schemes() []string
+	port(scheme string) int
+	check(u *url.URL) error
+	format(u *url.URL) string
+}
+
+// IsZero reports whether no part of d is set.
+func (d DSN[S]) IsZero() bool {
+	return d == DSN[S]{}
+}
+
+// DSN returns the connection string in the format of the usual driver,
+// "" if d is zero or invalid.
+func (d DSN[S]) DSN() string {
+	u, err := d.url()
+	if err != nil || d.IsZero() {
+		return ""
+	}
+	var s S
+	return s.format(u)
+}
+
+// String returns the connection URL with the password redacted.
+func (d DSN[S]) String() string {
+	u, err := d.url()
+	if err != nil || d.IsZero() {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// Validate fails when d is set but doesn't make a valid connection URL.
+func (d DSN[S]) Validate() error {
+	if d.IsZero() {
+		return nil
+	}
+	_, err := d.url()
+	return err
+}
+
+func (d DSN[S]) url() (*url.URL, error) {
+	var s S
+	schemes := s.schemes()
+	u := &url.URL{Scheme: schemes[0]}
+	if d.URL != "" {
+		parsed, err := url.Parse(d.URL)
+		if err != nil {
+			// the error quotes the URL, password included
+			return nil, fmt.Errorf("DSN/url: %w, malformed URL", ErrInvalidValue)
+		}
+		if !contains(schemes, parsed.Scheme) {
+			return nil, fmt.Errorf("DSN/url: %w scheme %q, want %s", ErrUnsupported, parsed.Scheme, strings.Join(schemes, " or "))
+		}
+		u = parsed
+	}
+
+	host, port := u.Hostname(), u.Port()
+	if d.Host != "" {
+		host = d.Host
+	}
+	if d.Port != 0 {
+		port = strconv.Itoa(d.Port)
+	}
+	if host == "" {
+		return nil, fmt.Errorf("DSN/url: %w host", ErrEmptyValue)
+	}
+	if port == "" {
+		port = strconv.Itoa(s.port(u.Scheme))
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return nil, fmt.Errorf("DSN/url: %w port %s", ErrOutOfRange, port)
+	}
+	u.Host = net.JoinHostPort(host, port)
+
+	if d.User != "" || d.Password != "" {
+		user := u.User.Username()
+		password, _ := u.User.Password()
+		if d.User != "" {
+			user = d.User
+		}
+		if d.Password != "" {
+			password = d.Password
+		}
+		u.User = url.UserPassword(user, password)
+	}
+	if d.Database != "" {
+		u.Path = "/" + d.Database
+	}
+	return u, s.check(u)
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+type postgresScheme struct{}
+
+func (postgresScheme) schemes() []string        { return []string{"postgres", "postgresql"} }
+func (postgresScheme) port(string) int          { return 5432 }
+func (postgresScheme) check(*url.URL) error     { return nil }
+func (postgresScheme) format(u *url.URL) string { return u.String() }
+
+type mysqlScheme struct{}
+
+func (mysqlScheme) schemes() []string    { return []string{"mysql"} }
+func (mysqlScheme) port(string) int      { return 3306 }
+func (mysqlScheme) check(*url.URL) error { return nil }
+
+// format returns the DSN of github.com/go-sql-driver/mysql.
+func (mysqlScheme) format(u *url.URL) string {
+	var b strings.Builder
+	if u.User != nil {
+		b.WriteString(u.User.Username())
+		if p, ok := u.User.Password(); ok {
+			b.WriteString(":" + p)
+		}
+		b.WriteString("@")
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	b.WriteString("tcp(" + u.Host + ")" + path)
+	if u.RawQuery != "" {
+		b.WriteString("?" + u.RawQuery)
+	}
+	return b.String()
+}
+
+type redisScheme struct{}
+
+func (redisScheme) schemes() []string { return []string{"redis", "rediss"} }
+func (redisScheme) port(string) int   { return 6379 }
+
+func (redisScheme) check(u *url.URL) error {
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if _, err := strconv.Atoi(db); err != nil {
+			return fmt.Errorf("DSN/url: %w, redis database %q is not a number", ErrInvalidValue, db)
+		}
+	}
+	return nil
+}
+
+func (redisScheme) format(u *url.URL) string { return u.String() }
+
+type amqpScheme struct{}
+
+func (amqpScheme) schemes() []string { return []string{"amqp", "amqps"} }
+
+func (amqpScheme) port(scheme string) int {
+	if scheme == "amqps" {
+		return 5671
+	}
+	return 5672
+}
+
+func (amqpScheme) check(*url.URL) error     { return nil }
+func (amqpScheme) format(u *url.URL) string { return u.String() }
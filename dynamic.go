@@ -0,0 +1,145 @@
+package configurator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dynamic holds a value that Reload and Watch update in place. Copies of a
+// loaded Dynamic share its storage, so a component can keep just the
+// Dynamic and Get always returns the latest reloaded value.
+//
+//	type Config struct {
+//		RateLimit configurator.Dynamic[int] `config:"env,default=100"`
+//	}
+type Dynamic[T any] struct {
+	p *atomic.Pointer[T]
+}
+
+// NewDynamic returns a Dynamic holding v.
+func NewDynamic[T any](v T) Dynamic[T] {
+	var d Dynamic[T]
+	d.Set(v)
+	return d
+}
+
+// Get returns the current value, or the zero value if it was never set.
+func (d Dynamic[T]) Get() T {
+	if d.p != nil {
+		if v := d.p.Load(); v != nil {
+			return *v
+		}
+	}
+	var zero T
+	return zero
+}
+
+// Set stores v, visible to all copies of d made after d was loaded.
+func (d *Dynamic[T]) Set(v T) {
+	d.init()
+	d.p.Store(&v)
+}
+
+func (d Dynamic[T]) String() string {
+	return fmt.Sprint(d.Get())
+}
+
+func (d Dynamic[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Get())
+}
+
+func (d *Dynamic[T]) UnmarshalJSON(b []byte) error {
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	d.Set(v)
+	return nil
+}
+
+func (d Dynamic[T]) MarshalYAML() (interface{}, error) {
+	return d.Get(), nil
+}
+
+func (d *Dynamic[T]) UnmarshalYAML(node *yaml.Node) error {
+	var v T
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	d.Set(v)
+	return nil
+}
+
+func (d *Dynamic[T]) init() {
+	if d.p == nil {
+		d.p = new(atomic.Pointer[T])
+	}
+}
+
+func (d *Dynamic[T]) elemType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (d *Dynamic[T]) load() reflect.Value {
+	v := d.Get()
+	return reflect.ValueOf(&v).Elem()
+}
+
+func (d *Dynamic[T]) store(v reflect.Value) {
+	d.Set(v.Interface().(T))
+}
+
+// wrapper is implemented by pointers to the generic field types holding a
+// single value of their type parameter, such as Dynamic and Optional.
+// Providers set and compare the value they hold instead of the wrapper.
+type wrapper interface {
+	elemType() reflect.Type
+	load() reflect.Value
+	store(reflect.Value)
+}
+
+// dynamic is implemented by *Dynamic[T] for any T.
+type dynamic interface {
+	wrapper
+	init()
+}
+
+var (
+	wrapperType = reflect.TypeOf((*wrapper)(nil)).Elem()
+	dynamicType = reflect.TypeOf((*dynamic)(nil)).Elem()
+)
+
+func isWrapper(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(wrapperType)
+}
+
+func asWrapper(v reflect.Value) (wrapper, bool) {
+	if !v.CanAddr() || !isWrapper(v.Type()) {
+		return nil, false
+	}
+	return v.Addr().Interface().(wrapper), true
+}
+
+func isDynamic(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(dynamicType)
+}
+
+func asDynamic(v reflect.Value) (dynamic, bool) {
+	if !v.CanAddr() || !isDynamic(v.Type()) {
+		return nil, false
+	}
+	return v.Addr().Interface().(dynamic), true
+}
+
+// leaf returns the value a field holds, looking through Dynamic and
+// Optional.
+func leaf(v reflect.Value) reflect.Value {
+	if w, ok := asWrapper(v); ok {
+		return w.load()
+	}
+	return v
+}
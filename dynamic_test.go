@@ -0,0 +1,172 @@
+package configurator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamic(t *testing.T) {
+	type example struct {
+		Limit   Dynamic[int]           `config:"env,default=100"`
+		Timeout Dynamic[time.Duration] `config:"env"`
+		Tags    Dynamic[[]string]      `config:"env"`
+		Name    string                 `config:"env"`
+	}
+
+	environ := []string{"TIMEOUT=3s", "TAGS=a,b", "NAME=Tom"}
+	var records []AuditRecord
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithLookupEnv(func(k string) (string, bool) { return environLookup(environ)(k) }),
+		WithDefaultProvider(),
+		WithAuditSink(AuditFunc(func(r AuditRecord) { records = append(records, r) })),
+	)
+
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, 100, cfg.Limit.Get())
+	assert.Equal(t, 3*time.Second, cfg.Timeout.Get())
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags.Get())
+	assert.Equal(t, "default", c.Provenance()["Limit"])
+	assert.Equal(t, "env", c.Provenance()["Timeout"])
+
+	limit := cfg.Limit
+	environ = []string{"LIMIT=5", "TIMEOUT=3s", "TAGS=a,b", "NAME=Jerry"}
+	nv, err := c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, limit.Get())
+	assert.Equal(t, 5, cfg.Limit.Get())
+	assert.Equal(t, "Tom", cfg.Name)
+	assert.Equal(t, "Jerry", nv.(*example).Name)
+	assert.Equal(t, []Change{
+		{Key: "Limit", Old: "100", New: "5", Source: "env"},
+		{Key: "Name", Old: "Tom", New: "Jerry", Source: "env"},
+	}, records[1].Changes)
+
+	environ = []string{"LIMIT=x"}
+	_, err = c.Reload(context.Background(), cfg)
+	assert.Error(t, err)
+	assert.Equal(t, 5, limit.Get())
+}
+
+func TestDynamic_File(t *testing.T) {
+	type example struct {
+		Limit Dynamic[int]    `json:"limit" yaml:"limit"`
+		Name  Dynamic[string] `json:"name" yaml:"name"`
+	}
+	for _, ext := range []string{"*.json", "*.yaml"} {
+		f, err := os.CreateTemp("", ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.WriteString(`{"limit":7,"name":"Tom"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+
+		cfg := &example{}
+		assert.NoError(t, NewConfigurator(WithFileProvider(f.Name())).Load(cfg))
+		assert.Equal(t, 7, cfg.Limit.Get())
+		assert.Equal(t, "Tom", cfg.Name.Get())
+
+		b, err := json.Marshal(cfg)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"limit":7,"name":"Tom"}`, string(b))
+	}
+}
+
+func TestWatch(t *testing.T) {
+	type example struct {
+		Limit Dynamic[int] `config:"env"`
+	}
+	environ := []string{"LIMIT=1"}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron(environ),
+	)
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reloads := 0
+	err := c.Watch(ctx, cfg, time.Millisecond, func(nv interface{}, err error) {
+		assert.NoError(t, err)
+		reloads++
+		if reloads == 3 {
+			cancel()
+		}
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 3, reloads)
+	assert.Equal(t, 1, cfg.Limit.Get())
+}
+
+// watchedMap is a map provider telling its watchers of every Set.
+type watchedMap struct {
+	mu     sync.Mutex
+	values map[string]string
+	change chan struct{}
+}
+
+func (m *watchedMap) Set(key, value string) {
+	m.mu.Lock()
+	m.values[key] = value
+	m.mu.Unlock()
+	m.change <- struct{}{}
+}
+
+func (m *watchedMap) Provide(_ interface{}, si StructInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, fi := range si.Fields() {
+		if v, ok := m.values[fi.Path()]; ok {
+			if err := fi.Set(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *watchedMap) Watch(ctx context.Context, onChange func()) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.change:
+			onChange()
+		}
+	}
+}
+
+func TestWatch_Watcher(t *testing.T) {
+	type example struct {
+		Limit Dynamic[int]
+	}
+	m := &watchedMap{values: map[string]string{"Limit": "1"}, change: make(chan struct{})}
+	c := NewConfigurator(WithFileProvider(""), WithProvider(m))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		// no polling, only the watcher reloads
+		done <- c.Watch(ctx, cfg, 0, func(nv interface{}, err error) {
+			assert.NoError(t, err)
+			cancel()
+		})
+	}()
+	m.Set("Limit", "2")
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, 2, cfg.Limit.Get())
+}
@@ -0,0 +1,131 @@
+package configurator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type enumType struct {
+	names  []string
+	values map[string]reflect.Value
+	byVal  map[interface{}]string
+}
+
+var (
+	enumsMu sync.RWMutex
+	enums   = map[reflect.Type]*enumType{}
+)
+
+// RegisterEnum maps the names of values to the typed constants fields of
+// type T are set to. T is formatted by its name, so int-backed enums read
+// and print as words.
+func RegisterEnum[T comparable](values map[string]T) {
+	e := &enumType{values: map[string]reflect.Value{}, byVal: map[interface{}]string{}}
+	for name, v := range values {
+		e.names = append(e.names, name)
+		e.values[name] = reflect.ValueOf(v)
+		e.byVal[v] = name
+	}
+	sort.Strings(e.names)
+	enumsMu.Lock()
+	enums[reflect.TypeOf((*T)(nil)).Elem()] = e
+	enumsMu.Unlock()
+}
+
+func lookupEnum(t reflect.Type) (*enumType, bool) {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+	e, ok := enums[t]
+	return e, ok
+}
+
+func (e *enumType) lookup(s string, fold bool) (reflect.Value, bool) {
+	if v, ok := e.values[s]; ok || !fold {
+		return v, ok
+	}
+	for name, v := range e.values {
+		if strings.EqualFold(name, s) {
+			return v, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (e *enumType) set(val reflect.Value, s string, fold bool) error {
+	v, ok := e.lookup(s, fold)
+	if !ok {
+		return fmt.Errorf("%w %q, allowed [%s]", ErrEnum, s, strings.Join(e.names, ", "))
+	}
+	val.Set(v)
+	return nil
+}
+
+func (e *enumType) name(v reflect.Value) (string, bool) {
+	name, ok := e.byVal[v.Interface()]
+	return name, ok
+}
+
+// allowed returns the values a field accepts, from its enum tag option or
+// the enum registered for its type.
+func (f *fieldInfo) allowed() []string {
+	if len(f.tag.enum) > 0 {
+		return f.tag.enum
+	}
+	if e, ok := lookupEnum(leaf(f.val).Type()); ok {
+		return e.names
+	}
+	return nil
+}
+
+// canonical returns the spelling of v in the field's enum tag option, v
+// itself if there is none.
+func (f *fieldInfo) canonical(v string) string {
+	for _, a := range f.tag.enum {
+		if strings.EqualFold(a, strings.TrimSpace(v)) {
+			return a
+		}
+	}
+	return v
+}
+
+// allowedValue returns s, spelled as in allowed when fold is set, or
+// ErrEnum when allowed, the values of an enum tag option, doesn't have it.
+func allowedValue(allowed []string, s string, fold bool) (string, error) {
+	for _, a := range allowed {
+		if a == s || fold && strings.EqualFold(a, strings.TrimSpace(s)) {
+			return a, nil
+		}
+	}
+	return s, fmt.Errorf("%w %q, allowed [%s]", ErrEnum, s, strings.Join(allowed, ", "))
+}
+
+// checkEnums fails when a field with an enum tag option holds a value not
+// in its list. Zero values are left to the required check.
+func checkEnums(fields []FieldInfo, fold bool) error {
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || len(f.tag.enum) == 0 || f.disabled {
+			continue
+		}
+		v := leaf(f.val)
+		if v.IsZero() {
+			continue
+		}
+		s := formatValue(v)
+		found := false
+		for _, a := range f.tag.enum {
+			if a == s || fold && strings.EqualFold(a, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			err := fmt.Errorf("configurator/LoadContext: %w %q, allowed [%s] [%s]", ErrEnum, s, strings.Join(f.tag.enum, ", "), f.Path())
+			return newFieldError(ErrEnum, err, s, f.Path())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLevel int
+
+const (
+	testDebug testLevel = iota + 1
+	testInfo
+)
+
+func init() {
+	RegisterEnum(map[string]testLevel{"debug": testDebug, "info": testInfo})
+}
+
+func TestEnumTag(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Mode string `config:"env,enum=debug,info,warn,default=info"`
+		Name string `config:"env"`
+	}
+
+	si, err := getStructInfo(&example{}, nil)
+	assert.NoError(t, err)
+	mode := si.Fields()[0].(*fieldInfo)
+	assert.Equal(t, []string{"debug", "info", "warn"}, mode.tag.enum)
+	assert.Equal(t, "info", mode.DefVal())
+	assert.Equal(t, []string{"debug", "info", "warn"}, fieldSchema(mode).Enum)
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron([]string{"MODE=warn"}))
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "warn", cfg.Mode)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron([]string{"MODE=trace"}))
+	err = c.Load(&example{})
+	assert.True(t, errors.Is(err, ErrEnum))
+	assert.Contains(t, err.Error(), "Mode")
+}
+
+func TestRegisterEnum(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Level  testLevel `config:"env,default=info"`
+		Levels []testLevel
+	}
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("levels: [debug, info]\n"), 0o600))
+
+	c := NewConfigurator(WithFileProvider(filename), WithENVProvider(""), WithDefaultProvider(), WithEnviron(nil))
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, testInfo, cfg.Level)
+	assert.Equal(t, []testLevel{testDebug, testInfo}, cfg.Levels)
+	assert.Equal(t, "info", formatValue(reflect.ValueOf(cfg.Level)))
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"LEVEL=verbose"}))
+	err := c.Load(&example{})
+	assert.True(t, errors.Is(err, ErrEnum))
+	assert.Contains(t, err.Error(), "debug, info")
+
+	schema, err := Describe(&example{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"debug", "info"}, schema[0].Enum)
+}
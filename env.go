@@ -1,35 +1,209 @@
 package configurator
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"reflect"
+	"strconv"
 	"strings"
 )
 
+// envProvider reads fields from env keys. Lists are read from a single key
+// holding items separated by commas, or else from numbered keys, one item
+// each: PEERS_0, PEERS_1 and so on up to the first missing index. Maps
+// tagged `envPrefix=HEADERS_` collect every env var starting with the
+// prefix, keyed by the rest of its name, lower cased with `lowerKeys`.
 type envProvider struct {
-	prefix string
+	prefix    string
+	profile   string
+	renames   renames
+	automatic bool
+	lookup    func(string) (string, bool)
+	// environ lists the env for prefix scans, nil when it can't be listed.
+	environ func() []string
+	logger  *slog.Logger
 }
 
 func NewENVProvider(prefix string) *envProvider {
 	return &envProvider{
-		prefix: strings.ToUpper(prefix),
+		prefix:  strings.ToUpper(prefix),
+		lookup:  os.LookupEnv,
+		environ: os.Environ,
+		logger:  slog.Default(),
 	}
 }
 
 func (p envProvider) Provide(v interface{}, si StructInfo) error {
 	for _, fi := range si.Fields() {
-		k := p.normalize(fi.ENVKey())
+		if f, ok := fi.(*fieldInfo); ok && f.tag.envPrefix != "" {
+			if err := p.scanPrefix(f); err != nil {
+				return fmt.Errorf("envProvider/Provide: %w [%s]", err, f.Path())
+			}
+			continue
+		}
+		k := fi.ENVKey()
+		if k == "" && p.automatic {
+			k = viperKey(fi.Path())
+		}
+		k = p.normalize(k)
 		if k == "" {
 			continue
 		}
-		val, ok := os.LookupEnv(k)
+		val, ok := p.lookupProfile(k)
+		if !ok && len(p.renames) > 0 {
+			val, ok = p.lookupRenamed(fi)
+		}
+		if !ok && isList(leaf(fi.Value()).Type()) {
+			if items, found := p.lookupList(k); found {
+				p.logger.Debug("configurator: env lookup", "key", k+"_0", "field", fi.Path(), "found", true, "items", len(items))
+				if err := setList(fi, items); err != nil {
+					return fmt.Errorf("envProvider/Provide: %w [%s_%d]", err, k, len(items)-1)
+				}
+				continue
+			}
+		}
+		p.logger.Debug("configurator: env lookup", "key", k, "field", fi.Path(), "found", ok)
 		if !ok {
 			continue
 		}
-		_ = val // TODO set value
+		if err := fi.Set(val); err != nil {
+			p.logger.Debug("configurator: env conversion failed", "key", k, "type", fi.Value().Type().String(), "error", err)
+			return fmt.Errorf("envProvider/Provide: %w [%s]", err, k)
+		}
+	}
+	return nil
+}
+
+func (p envProvider) String() string {
+	return "env"
+}
+
+// lookupProfile prefers the profile suffixed key, e.g. APP_PORT_STAGING, and
+// falls back to the base key.
+func (p envProvider) lookupProfile(key string) (string, bool) {
+	if p.profile != "" {
+		if val, ok := p.lookup(key + "_" + strings.ToUpper(p.profile)); ok {
+			return val, true
+		}
+	}
+	return p.lookup(key)
+}
+
+// lookupRenamed looks the field up under the env key derived from the path
+// it was renamed from.
+func (p envProvider) lookupRenamed(fi FieldInfo) (string, bool) {
+	old, ok := p.renames.env(fi.Path())
+	if !ok {
+		return "", false
+	}
+	k := p.normalize(viperKey(old))
+	val, ok := p.lookupProfile(k)
+	if ok {
+		p.logger.Warn("configurator: deprecated env key", "key", k, "field", fi.Path())
+	}
+	return val, ok
+}
+
+// lookupList reads the items of a list from KEY_0, KEY_1 and so on, up to
+// the first missing index.
+func (p envProvider) lookupList(key string) ([]string, bool) {
+	var items []string
+	for i := 0; ; i++ {
+		v, ok := p.lookupProfile(key + "_" + strconv.Itoa(i))
+		if !ok {
+			return items, len(items) > 0
+		}
+		items = append(items, v)
+	}
+}
+
+// isList reports whether t is a list set item by item, []byte being
+// carried as base64.
+func isList(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// setList sets a list field to items, each parsed on its own so that they
+// may hold the list separator.
+func setList(fi FieldInfo, items []string) error {
+	f, ok := fi.(*fieldInfo)
+	if !ok {
+		return fi.Set(strings.Join(items, sliceSeparator))
+	}
+	typ := leaf(f.val).Type()
+	o := f.options()
+	list := reflect.MakeSlice(typ, len(items), len(items))
+	for i, item := range items {
+		item, err := o.transform(item)
+		if err != nil {
+			return err
+		}
+		if err := o.set(list.Index(i), typ.Elem(), item); err != nil {
+			return err
+		}
+	}
+	if d, ok := asWrapper(f.val); ok {
+		d.store(list)
+	} else if err := assignValue(f.val, list); err != nil {
+		return err
 	}
+	f.explicit = true
 	return nil
 }
 
+// scanPrefix sets the map f to the env vars starting with its `envPrefix=`,
+// replacing the values of earlier sources. The field is left alone when no
+// env var matches. Profiles don't apply to prefix scans.
+func (p envProvider) scanPrefix(f *fieldInfo) error {
+	typ := leaf(f.val).Type()
+	if !isPrefixMap(typ) {
+		return fmt.Errorf("%w, `envPrefix` needs a map with string keys", ErrInvalidTagFormat)
+	}
+	if p.environ == nil {
+		p.logger.Debug("configurator: env can't be listed", "field", f.Path())
+		return nil
+	}
+	prefix := p.normalize(f.tag.envPrefix)
+	o := f.options()
+	m := reflect.MakeMap(typ)
+	for _, kv := range p.environ() {
+		k, val, ok := strings.Cut(kv, "=")
+		if !ok || len(k) <= len(prefix) || !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		key := k[len(prefix):]
+		if f.tag.lowerKeys {
+			key = strings.ToLower(key)
+		}
+		val, err := o.transform(val)
+		if err != nil {
+			return fmt.Errorf("%w [%s]", err, k)
+		}
+		elem := reflect.New(typ.Elem()).Elem()
+		if err := o.set(elem, typ.Elem(), val); err != nil {
+			return fmt.Errorf("%w [%s]", err, k)
+		}
+		m.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), elem)
+	}
+	p.logger.Debug("configurator: env prefix scan", "prefix", prefix, "field", f.Path(), "found", m.Len())
+	if m.Len() == 0 {
+		return nil
+	}
+	if d, ok := asWrapper(f.val); ok {
+		d.store(m)
+	} else if err := assignValue(f.val, m); err != nil {
+		return err
+	}
+	f.explicit = true
+	return nil
+}
+
+// isPrefixMap reports whether t is a map that `envPrefix=` can fill.
+func isPrefixMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
 func (p envProvider) normalize(key string) string {
 	if key == "" {
 		return ""
@@ -39,3 +213,45 @@ func (p envProvider) normalize(key string) string {
 	}
 	return strings.Join([]string{p.prefix, key}, "_")
 }
+
+// environLookup turns a snapshot in the form of os.Environ into a lookup func.
+func environLookup(environ []string) func(string) (string, bool) {
+	m := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			continue
+		}
+		m[kv[:i]] = kv[i+1:]
+	}
+	return func(k string) (string, bool) {
+		v, ok := m[k]
+		return v, ok
+	}
+}
+
+// foldEnvironLookup is like environLookup, matching keys ignoring case. A
+// key spelled exactly wins, then the first spelling in byte order.
+func foldEnvironLookup(environ []string) func(string) (string, bool) {
+	exact := environLookup(environ)
+	folded := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			continue
+		}
+		k := strings.ToUpper(kv[:i])
+		if cur, ok := folded[k]; !ok || kv[:i] < cur {
+			folded[k] = kv[:i]
+		}
+	}
+	return func(k string) (string, bool) {
+		if v, ok := exact(k); ok {
+			return v, true
+		}
+		if name, ok := folded[strings.ToUpper(k)]; ok {
+			return exact(name)
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,188 @@
+package configurator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestENVProvider(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Name    string        `config:"env"`
+		Port    *int          `config:"env=APP_PORT"`
+		Timeout time.Duration `config:"env"`
+		Tags    []string      `config:"env"`
+		Ratios  []float64     `config:"env"`
+		StartAt time.Time     `config:"env"`
+		Expire  *time.Time    `config:"env"`
+		Skip    string
+	}
+
+	cfg := &example{}
+	si, err := getStructInfo(cfg, nil)
+	assert.NoError(t, err)
+
+	ep := NewENVProvider("app")
+	ep.lookup = environLookup([]string{
+		"APP_NAME=Tom",
+		"APP_PORT=8080",
+		"APP_TIMEOUT=3s",
+		"APP_TAGS=foo,bar",
+		"APP_RATIOS=0.5,1",
+		"APP_STARTAT=2020-09-30T22:51:49-08:00",
+		"APP_EXPIRE=2020-09-30T22:51:49-08:00",
+		"APP_SKIP=ignored",
+	})
+	assert.NoError(t, ep.Provide(cfg, si))
+	assert.Equal(t, &example{
+		Name:    "Tom",
+		Port:    i(8080),
+		Timeout: 3 * time.Second,
+		Tags:    []string{"foo", "bar"},
+		Ratios:  []float64{0.5, 1},
+		StartAt: time.Date(2020, 9, 30, 22, 51, 49, 0, time.FixedZone("", -28800)),
+		Expire:  timePtr(time.Date(2020, 9, 30, 22, 51, 49, 0, time.FixedZone("", -28800))),
+	}, cfg)
+}
+
+func TestWithLookupEnv(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Name string `config:"env"`
+		Age  int    `config:"env"`
+	}
+
+	env := map[string]string{"NAME": "Tom", "AGE": "24"}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithLookupEnv(func(k string) (string, bool) {
+			v, ok := env[k]
+			return v, ok
+		}),
+	)
+
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{Name: "Tom", Age: 24}, cfg)
+}
+
+func TestWithEnvCaseInsensitive(t *testing.T) {
+	type example struct {
+		Path  string `config:"env"`
+		Home  string `config:"env"`
+		Temp  string `config:"env"`
+		Other string `config:"env"`
+	}
+	environ := []string{"Path=C:\\Windows", "temp=b", "TeMP=a", "Home=h1", "HOME=h2"}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ), WithEnvCaseInsensitive())
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{Path: "C:\\Windows", Home: "h2", Temp: "a"}, cfg)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ))
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{Home: "h2"}, cfg)
+
+	t.Setenv("Configurator_Test_Other", "x")
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider("configurator_test"), WithEnvCaseInsensitive())
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "x", cfg.Other)
+}
+
+func TestENVProvider_NumberedList(t *testing.T) {
+	type example struct {
+		Peers   []string            `config:"env"`
+		Ports   []int               `config:"env"`
+		Hosts   []string            `config:"env"`
+		Dynamic Dynamic[[]string]   `config:"env"`
+		Weights Optional[[]float64] `config:"env"`
+	}
+	environ := []string{
+		"PEERS_0=a:1,b", "PEERS_1=c", "PEERS_3=ignored",
+		"PORTS=80,443", "PORTS_0=8080",
+		"HOSTS_1=no-first",
+		"DYNAMIC_0=x",
+		"WEIGHTS_0=0.5", "WEIGHTS_1=1.5",
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, []string{"a:1,b", "c"}, cfg.Peers)
+	assert.Equal(t, []int{80, 443}, cfg.Ports)
+	assert.Nil(t, cfg.Hosts)
+	assert.Equal(t, []string{"x"}, cfg.Dynamic.Get())
+	assert.Equal(t, []float64{0.5, 1.5}, cfg.Weights.OrElse(nil))
+	assert.Equal(t, "env", c.Provenance()["Peers"])
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"PORTS_0=1", "PORTS_1=x"}))
+	err := c.Load(&example{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "PORTS_1")
+	}
+}
+
+func TestENVProvider_EnvPrefix(t *testing.T) {
+	type example struct {
+		Headers map[string]string           `config:"envPrefix=HEADERS_,lowerKeys"`
+		Weights map[string]int              `config:"envPrefix=WEIGHT_"`
+		Labels  Optional[map[string]string] `config:"envPrefix=LABEL_"`
+	}
+	environ := []string{
+		"APP_HEADERS_X_TRACE=on", "APP_HEADERS_ACCEPT=a=b,c", "APP_HEADERS_=empty", "HEADERS_OTHER=no",
+		"APP_WEIGHT_Fast=3", "APP_LABEL_team=core",
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider("app"), WithEnviron(environ))
+	cfg := &example{Weights: map[string]int{"slow": 1}}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, map[string]string{"x_trace": "on", "accept": "a=b,c"}, cfg.Headers)
+	assert.Equal(t, map[string]int{"Fast": 3}, cfg.Weights)
+	assert.Equal(t, map[string]string{"team": "core"}, cfg.Labels.OrElse(nil))
+	assert.Equal(t, "env", c.Provenance()["Headers"])
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"WEIGHT_A=x"}))
+	err := c.Load(&example{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "WEIGHT_A")
+	}
+
+	type invalid struct {
+		Headers []string `config:"envPrefix=HEADERS_"`
+	}
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"HEADERS_A=x"}))
+	assert.True(t, errors.Is(c.Load(&invalid{}), ErrInvalidTagFormat))
+}
+
+func TestWithEnviron_InvalidValue(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Age int `config:"env"`
+	}
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"AGE=old"}))
+	assert.Error(t, c.Load(&example{}))
+}
+
+func TestENVProvider_Profile(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Name string `config:"env"`
+		Age  int    `config:"env"`
+	}
+
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider("app"),
+		WithProfile("staging"),
+		WithEnviron([]string{"APP_NAME=Tom", "APP_NAME_STAGING=Jerry", "APP_AGE=24"}),
+	)
+
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{Name: "Jerry", Age: 24}, cfg)
+}
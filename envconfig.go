@@ -0,0 +1,39 @@
+package configurator
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// envconfigTags merges the envconfig, default and required tags of
+// github.com/kelseyhightower/envconfig into the parsed config tags.
+func envconfigTags(fields []FieldInfo) error {
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok {
+			continue
+		}
+		tag := f.field.Tag
+		if key, ok := tag.Lookup("envconfig"); ok && key == "-" {
+			continue
+		} else if !f.tag.hasENV {
+			f.tag.hasENV = true
+			f.tag.env = key
+		}
+		if def, ok := tag.Lookup("default"); ok && !f.tag.hasDefault {
+			f.tag.hasDefault = true
+			f.tag.defVal = def
+		}
+		// the env key may have changed
+		f.keys = nil
+		f.fieldKeys()
+		if req, ok := tag.Lookup("required"); ok {
+			b, err := strconv.ParseBool(req)
+			if err != nil {
+				return fmt.Errorf("%w, `required` must be a bool [%s]", ErrInvalidTagFormat, f.Path())
+			}
+			f.tag.required = f.tag.required || b
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package configurator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEnvconfigTags(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Host    string `envconfig:"DB_HOST" required:"true"`
+		Port    int    `default:"5432"`
+		Name    string `config:"env=NAME_OVERRIDE" envconfig:"DB_NAME"`
+		Ignored string `envconfig:"-"`
+		Pool    struct {
+			Size int `default:"4"`
+		}
+	}
+
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider("app"),
+		WithEnvconfigTags(),
+		WithEnviron([]string{"APP_DB_HOST=db", "APP_NAME_OVERRIDE=users", "APP_DB_NAME=x", "APP_IGNORED=x", "APP_POOL_SIZE=8"}),
+	)
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "db", cfg.Host)
+	assert.Equal(t, 5432, cfg.Port)
+	assert.Equal(t, "users", cfg.Name)
+	assert.Equal(t, "", cfg.Ignored)
+	assert.Equal(t, 8, cfg.Pool.Size)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider("app"), WithEnvconfigTags(), WithEnviron(nil))
+	assert.True(t, errors.Is(c.Load(&example{}), ErrRequired))
+}
+
+func TestWithEnvconfigTags_Disabled(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Port int `default:"5432" required:"true"`
+	}
+
+	cfg := &example{}
+	assert.NoError(t, NewConfigurator(WithFileProvider(""), WithDefaultProvider()).Load(cfg))
+	assert.Equal(t, 0, cfg.Port)
+}
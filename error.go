@@ -11,4 +11,17 @@ var (
 	ErrEmptyKey         = errors.New("empty key")
 	ErrConflictKey      = errors.New("conflict key")
 	ErrUnsupported      = errors.New("unsupported")
+	ErrOutOfRange       = errors.New("value out of range")
+	ErrPanic            = errors.New("recovered from panic")
+	ErrDegraded         = errors.New("provider degraded")
+	ErrInvalidSnapshot  = errors.New("invalid snapshot")
+	ErrSchemaVersion    = errors.New("unsupported schema version")
+	ErrRequired         = errors.New("required field not set")
+	ErrEnum             = errors.New("value not allowed")
+	ErrInvalidValue     = errors.New("invalid value")
+	ErrCycle            = errors.New("self-referential type")
+	ErrCommand          = errors.New("unknown command")
+	ErrNotFound         = errors.New("not found")
+	ErrInsecureFile     = errors.New("insecure file")
+	ErrCircuitOpen      = errors.New("circuit open")
 )
@@ -0,0 +1,166 @@
+// Package featureflag resolves configuration fields from a feature flag
+// provider through the OpenFeature SDK, so values can be rolled out
+// progressively. Fields opt in with a feature tag naming the flag:
+//
+//	type Config struct {
+//		NewCheckout bool `feature:"new-checkout"`
+//		BatchSize   int  `config:"env,default=100" feature:"batch-size"`
+//	}
+//
+// It lives in its own module to keep the SDK out of the core dependencies.
+package featureflag
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/ruosing/configurator"
+)
+
+const tagName = "feature"
+
+// Client is the part of *openfeature.Client the provider uses.
+type Client interface {
+	BooleanValueDetails(ctx context.Context, flag string, defaultValue bool, evalCtx openfeature.EvaluationContext, options ...openfeature.Option) (openfeature.BooleanEvaluationDetails, error)
+	StringValueDetails(ctx context.Context, flag string, defaultValue string, evalCtx openfeature.EvaluationContext, options ...openfeature.Option) (openfeature.StringEvaluationDetails, error)
+	FloatValueDetails(ctx context.Context, flag string, defaultValue float64, evalCtx openfeature.EvaluationContext, options ...openfeature.Option) (openfeature.FloatEvaluationDetails, error)
+	IntValueDetails(ctx context.Context, flag string, defaultValue int64, evalCtx openfeature.EvaluationContext, options ...openfeature.Option) (openfeature.IntEvaluationDetails, error)
+}
+
+var _ Client = &openfeature.Client{}
+
+type Provider struct {
+	client       Client
+	targetingKey string
+	attributes   map[string]interface{}
+	fields       map[string]string
+}
+
+var _ configurator.ContextProvider = &Provider{}
+
+type Option func(*Provider)
+
+// WithTargetingKey sets the targeting key of the evaluation context.
+func WithTargetingKey(key string) Option {
+	return func(p *Provider) {
+		p.targetingKey = key
+	}
+}
+
+// WithAttribute adds a static attribute to the evaluation context.
+func WithAttribute(name string, value interface{}) Option {
+	return func(p *Provider) {
+		p.attributes[name] = value
+	}
+}
+
+// WithFieldAttribute adds an attribute taken from the field at path, as set
+// by the providers that ran before this one, e.g. the deployment region.
+func WithFieldAttribute(name, path string) Option {
+	return func(p *Provider) {
+		p.fields[name] = path
+	}
+}
+
+func NewProvider(client Client, opts ...Option) *Provider {
+	p := &Provider{
+		client:     client,
+		attributes: make(map[string]interface{}),
+		fields:     make(map[string]string),
+	}
+	for _, fn := range opts {
+		fn(p)
+	}
+	return p
+}
+
+func (p *Provider) Provide(v interface{}, si configurator.StructInfo) error {
+	return p.ProvideContext(context.Background(), v, si)
+}
+
+func (p *Provider) ProvideContext(ctx context.Context, _ interface{}, si configurator.StructInfo) error {
+	evalCtx := p.evaluationContext(si)
+	for _, fi := range si.Fields() {
+		key := fi.StructField().Tag.Get(tagName)
+		if key == "" {
+			continue
+		}
+		if err := p.resolve(ctx, fi, key, evalCtx); err != nil {
+			return fmt.Errorf("featureflag/Provide: %w [%s]", err, key)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) String() string {
+	return "featureflag"
+}
+
+func (p *Provider) evaluationContext(si configurator.StructInfo) openfeature.EvaluationContext {
+	attrs := make(map[string]interface{}, len(p.attributes)+len(p.fields))
+	for k, v := range p.attributes {
+		attrs[k] = v
+	}
+	for _, fi := range si.Fields() {
+		for name, path := range p.fields {
+			if fi.Path() == path {
+				attrs[name] = fmt.Sprint(fi.Value().Interface())
+			}
+		}
+	}
+	return openfeature.NewEvaluationContext(p.targetingKey, attrs)
+}
+
+// resolve evaluates the flag with the matching typed call and sets the
+// field, leaving it untouched if the flag doesn't exist.
+func (p *Provider) resolve(ctx context.Context, fi configurator.FieldInfo, key string, evalCtx openfeature.EvaluationContext) error {
+	var (
+		s       string
+		err     error
+		details openfeature.EvaluationDetails
+	)
+	switch kind(fi.Value().Type()) {
+	case reflect.Bool:
+		var d openfeature.BooleanEvaluationDetails
+		d, err = p.client.BooleanValueDetails(ctx, key, false, evalCtx)
+		s, details = strconv.FormatBool(d.Value), d.EvaluationDetails
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var d openfeature.IntEvaluationDetails
+		d, err = p.client.IntValueDetails(ctx, key, 0, evalCtx)
+		s, details = strconv.FormatInt(d.Value, 10), d.EvaluationDetails
+	case reflect.Float32, reflect.Float64:
+		var d openfeature.FloatEvaluationDetails
+		d, err = p.client.FloatValueDetails(ctx, key, 0, evalCtx)
+		s, details = strconv.FormatFloat(d.Value, 'g', -1, 64), d.EvaluationDetails
+	default:
+		var d openfeature.StringEvaluationDetails
+		d, err = p.client.StringValueDetails(ctx, key, "", evalCtx)
+		s, details = d.Value, d.EvaluationDetails
+	}
+	if details.ErrorCode == openfeature.FlagNotFoundCode {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fi.Set(s)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// kind looks through pointers. Durations, times and other structs are
+// string flags parsed by the configurator.
+func kind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return reflect.String
+	}
+	return t.Kind()
+}
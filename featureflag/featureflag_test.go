@@ -0,0 +1,69 @@
+package featureflag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-feature/go-sdk/openfeature"
+	"github.com/open-feature/go-sdk/openfeature/memprovider"
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider(t *testing.T) {
+	type example struct {
+		Region      string        `config:"env"`
+		NewCheckout bool          `feature:"new-checkout"`
+		BatchSize   int           `config:"default=100" feature:"batch-size"`
+		Ratio       *float64      `feature:"ratio"`
+		Timeout     time.Duration `feature:"timeout"`
+		Missing     string        `config:"default=keep" feature:"missing"`
+	}
+
+	byRegion := func(this memprovider.InMemoryFlag, evalCtx openfeature.FlattenedContext) (interface{}, openfeature.ProviderResolutionDetail) {
+		variant := "off"
+		if evalCtx["region"] == "eu" && evalCtx["targetingKey"] == "svc-1" {
+			variant = "on"
+		}
+		return this.Variants[variant], openfeature.ProviderResolutionDetail{Variant: variant, Reason: openfeature.TargetingMatchReason}
+	}
+	flag := func(key string, v interface{}) memprovider.InMemoryFlag {
+		return memprovider.InMemoryFlag{Key: key, State: memprovider.Enabled, DefaultVariant: "on", Variants: map[string]interface{}{"on": v}}
+	}
+	assert.NoError(t, openfeature.SetNamedProviderAndWait("featureflag-test", memprovider.NewInMemoryProvider(map[string]memprovider.InMemoryFlag{
+		"new-checkout": {
+			Key: "new-checkout", State: memprovider.Enabled, DefaultVariant: "off",
+			Variants:         map[string]interface{}{"on": true, "off": false},
+			ContextEvaluator: &byRegion,
+		},
+		"batch-size": flag("batch-size", 500),
+		"ratio":      flag("ratio", 0.25),
+		"timeout":    flag("timeout", "3s"),
+	})))
+
+	cfg := &example{}
+	c := configurator.NewConfigurator(
+		configurator.WithFileProvider(""),
+		configurator.WithENVProvider(""),
+		configurator.WithEnviron([]string{"REGION=eu"}),
+		configurator.WithDefaultProvider(),
+		configurator.WithProvider(NewProvider(
+			openfeature.NewClient("featureflag-test"),
+			WithTargetingKey("svc-1"),
+			WithFieldAttribute("region", "Region"),
+		)),
+	)
+	assert.NoError(t, c.Load(cfg))
+
+	ratio := 0.25
+	assert.Equal(t, &example{
+		Region:      "eu",
+		NewCheckout: true,
+		BatchSize:   500,
+		Ratio:       &ratio,
+		Timeout:     3 * time.Second,
+		Missing:     "keep",
+	}, cfg)
+	assert.Equal(t, "featureflag", c.Provenance()["BatchSize"])
+	assert.Equal(t, "default", c.Provenance()["Missing"])
+}
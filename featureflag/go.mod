@@ -0,0 +1,19 @@
+module github.com/ruosing/configurator/featureflag
+
+go 1.21
+
+require (
+	github.com/open-feature/go-sdk v1.12.0
+	github.com/ruosing/configurator v0.0.0
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/ruosing/configurator => ../
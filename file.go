@@ -1,42 +1,337 @@
 package configurator
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 func NewFileProvider(filename string) *fileProvider {
-	return &fileProvider{filename: filename}
+	return &fileProvider{filename: filename, logger: slog.Default()}
 }
 
 type fileProvider struct {
-	filename string
+	filename   string
+	profile    string
+	migrations *Migrations
+	renames    renames
+	viper      bool
+	parse      parseOptions
+	perm       filePerm
+	verify     Verifier
+	logger     *slog.Logger
 }
 
-func (p fileProvider) Provide(v interface{}, _ StructInfo) error {
-	f, err := os.Open(p.filename)
+func (p fileProvider) Provide(v interface{}, si StructInfo) error {
+	return p.ProvideContext(context.Background(), v, si)
+}
+
+func (p fileProvider) ProvideContext(ctx context.Context, v interface{}, si StructInfo) error {
+	fp, err := p.Fetch(ctx)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
+	return fp.Provide(v, si)
+}
+
+func (p fileProvider) Fetch(ctx context.Context) (Provider, error) {
+	var modTime time.Time
+	if fi, err := os.Stat(p.filename); err == nil {
+		if err := p.perm.check(fi); err != nil {
+			return nil, fmt.Errorf("fileProvider/Fetch: %w [%s]", err, p.filename)
+		}
+		modTime = fi.ModTime()
+	}
+	b, err := readFile(ctx, p.filename)
+	if err != nil {
+		return nil, err
+	}
+	if p.verify != nil {
+		if err := verifyFile(p.verify, p.filename, b); err != nil {
+			return nil, fmt.Errorf("fileProvider/Fetch: %w [%s]", err, p.filename)
+		}
+	}
+	return fileContent{
+		filename:   p.filename,
+		content:    b,
+		modTime:    modTime,
+		profile:    p.profile,
+		migrations: p.migrations,
+		renames:    p.renames,
+		viper:      p.viper,
+		parse:      p.parse,
+		logger:     p.logger,
+	}, nil
+}
+
+func (p fileProvider) String() string {
+	return "file"
+}
+
+type fileContent struct {
+	filename   string
+	content    []byte
+	modTime    time.Time
+	profile    string
+	migrations *Migrations
+	renames    renames
+	viper      bool
+	parse      parseOptions
+	logger     *slog.Logger
+}
 
+// Version is the modification time of the file.
+func (p fileContent) Version() string {
+	if p.modTime.IsZero() {
+		return ""
+	}
+	return p.modTime.UTC().Format(time.RFC3339Nano)
+}
+
+func (p fileContent) Provide(v interface{}, si StructInfo) error {
+	raw, b, err := p.rewrite(reflect.TypeOf(v))
+	if err != nil {
+		return fmt.Errorf("fileContent/Provide: %w [%s]", err, p.filename)
+	}
+	p.content = b
+	// the decoders don't know flag.Value or nullable types, nor transforms,
+	// such values are set after them
+	var base, profile map[*fieldInfo]string
+	if hasTextValues(si) {
+		base = takeTextValues(raw, si, p.format())
+		profiles, _ := raw["profiles"].(map[string]any)
+		if section, ok := profiles[p.profile].(map[string]any); ok && p.profile != "" {
+			profile = takeTextValues(section, si, p.format())
+		}
+		if len(base)+len(profile) > 0 {
+			if p.content, err = encodeDocument(raw, p.format()); err != nil {
+				return fmt.Errorf("fileContent/Provide: %w [%s]", err, p.filename)
+			}
+		}
+	}
 	var d decoder
 	switch strings.ToLower(filepath.Ext(p.filename)) {
 	case ".json":
-		d = json.NewDecoder(f)
+		d = json.NewDecoder(bytes.NewReader(p.content))
 	case ".yaml", ".yml":
-		d = yaml.NewDecoder(f)
+		d = yaml.NewDecoder(bytes.NewReader(p.content))
 	default:
 		return fmt.Errorf("the specified file %s is %w", p.filename, ErrUnsupported)
 	}
-	return d.Decode(v)
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	markPresent(raw, si, p.format())
+	if err := setTextValues(base); err != nil {
+		return fmt.Errorf("fileContent/Provide: %w [%s]", err, p.filename)
+	}
+	if p.profile == "" {
+		return nil
+	}
+	profiles, _ := raw["profiles"].(map[string]any)
+	if section, ok := profiles[p.profile].(map[string]any); ok {
+		markPresent(section, si, p.format())
+	}
+	if err := p.decodeProfile(v); err != nil {
+		return fmt.Errorf("fileContent/Provide: %w [%s]", err, p.profile)
+	}
+	if err := setTextValues(profile); err != nil {
+		return fmt.Errorf("fileContent/Provide: %w [%s]", err, p.profile)
+	}
+	return nil
+}
+
+// isTextValue reports whether values of t are only set from their text,
+// as the decoders of files don't know them.
+func isTextValue(t reflect.Type) bool {
+	return isFlagValue(t) || isNullable(indirect(t))
+}
+
+// fromText reports whether the values of f in files are set from their
+// text, for their type or transforms.
+func (f *fieldInfo) fromText() bool {
+	return isTextValue(leaf(f.val).Type()) || len(f.tag.transforms) > 0
+}
+
+func hasTextValues(si StructInfo) bool {
+	if si == nil {
+		return false
+	}
+	for _, fi := range si.Fields() {
+		if f, ok := fi.(*fieldInfo); ok && f.fromText() {
+			return true
+		}
+	}
+	return false
+}
+
+// takeTextValues removes the scalar values of fields set from their text
+// from the document raw, and returns them by field.
+func takeTextValues(raw map[string]any, si StructInfo, format string) map[*fieldInfo]string {
+	vals := make(map[*fieldInfo]string)
+	for _, fi := range si.Fields() {
+		f, ok := fi.(*fieldInfo)
+		if !ok || !f.fromText() {
+			continue
+		}
+		m := raw
+		keys := f.fileKeys(format)
+		for _, k := range keys[:len(keys)-1] {
+			if m, ok = m[k].(map[string]any); !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		k := keys[len(keys)-1]
+		switch v := m[k].(type) {
+		case nil, map[string]any, []any:
+		case time.Time:
+			// yaml timestamps
+			vals[f] = v.Format(time.RFC3339Nano)
+			delete(m, k)
+		default:
+			vals[f] = fmt.Sprint(v)
+			delete(m, k)
+		}
+	}
+	return vals
+}
+
+func setTextValues(vals map[*fieldInfo]string) error {
+	for f, v := range vals {
+		if err := f.Set(v); err != nil {
+			return fmt.Errorf("%w [%s]", err, f.Path())
+		}
+	}
+	return nil
+}
+
+func encodeDocument(raw map[string]any, format string) ([]byte, error) {
+	if format == "json" {
+		return json.Marshal(raw)
+	}
+	return yaml.Marshal(raw)
+}
+
+// decodeProfile overlays the profiles.<profile> section of the file on top
+// of the base values already decoded into v. A missing section is not an
+// error, the base values apply as is.
+func (p fileContent) decodeProfile(v interface{}) error {
+	switch strings.ToLower(filepath.Ext(p.filename)) {
+	case ".json":
+		var doc struct {
+			Profiles map[string]json.RawMessage `json:"profiles"`
+		}
+		if err := json.Unmarshal(p.content, &doc); err != nil {
+			return err
+		}
+		if raw, ok := doc.Profiles[p.profile]; ok {
+			return json.Unmarshal(raw, v)
+		}
+	default:
+		var doc struct {
+			Profiles map[string]yaml.Node `yaml:"profiles"`
+		}
+		if err := yaml.Unmarshal(p.content, &doc); err != nil {
+			return err
+		}
+		if node, ok := doc.Profiles[p.profile]; ok {
+			return node.Decode(v)
+		}
+	}
+	return nil
+}
+
+// rewrite expands dotted keys, upgrades the document to the current schema
+// version, moves renamed keys, renames keys to the ones the decoder expects
+// for t and encodes it back in its own format.
+func (p fileContent) rewrite(t reflect.Type) (map[string]any, []byte, error) {
+	raw := map[string]any{}
+	switch p.format() {
+	case "json":
+		d := json.NewDecoder(bytes.NewReader(p.content))
+		d.UseNumber()
+		if err := d.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		if err := p.migrate(raw, t, "json"); err != nil {
+			return nil, nil, err
+		}
+		b, err := json.Marshal(raw)
+		return raw, b, err
+	case "yaml":
+		if err := yaml.Unmarshal(p.content, &raw); err != nil {
+			return nil, nil, err
+		}
+		if err := p.migrate(raw, t, "yaml"); err != nil {
+			return nil, nil, err
+		}
+		b, err := yaml.Marshal(raw)
+		return raw, b, err
+	}
+	return nil, p.content, nil
+}
+
+func (p fileContent) format() string {
+	switch strings.ToLower(filepath.Ext(p.filename)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return ""
+}
+
+func (p fileContent) migrate(raw map[string]any, t reflect.Type, format string) error {
+	if p.viper {
+		expandDottedKeys(raw)
+	}
+	if p.migrations != nil {
+		if err := p.migrations.Migrate(raw); err != nil {
+			return err
+		}
+	}
+	p.renames.file(raw, p.logger)
+	normalizeKeys(raw, t, format, p.parse)
+	if profiles, ok := raw["profiles"].(map[string]any); ok {
+		for _, section := range profiles {
+			if m, ok := section.(map[string]any); ok {
+				normalizeKeys(m, t, format, p.parse)
+			}
+		}
+	}
+	return nil
+}
+
+// readFile reads filename in the background so that a hung file system
+// doesn't block past ctx.
+func readFile(ctx context.Context, filename string) ([]byte, error) {
+	type result struct {
+		b   []byte
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		b, err := os.ReadFile(filename)
+		ch <- result{b, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("fileProvider/readFile: %w [%s]", ctx.Err(), filename)
+	case r := <-ch:
+		return r.b, r.err
+	}
 }
 
 type decoder interface {
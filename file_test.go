@@ -1,9 +1,10 @@
 package configurator
 
 import (
+	"context"
 	"errors"
-	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,7 +56,7 @@ func TestFileProvider(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			cfg := example{}
-			f, err := ioutil.TempFile("", tt.file)
+			f, err := os.CreateTemp("", tt.file)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -84,7 +85,7 @@ func TestFileLoader_PathError(t *testing.T) {
 
 func TestFileLoader_UnsupportError(t *testing.T) {
 	var cfg example
-	f, err := ioutil.TempFile("", "*.ini")
+	f, err := os.CreateTemp("", "*.ini")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -102,3 +103,90 @@ func TestFileLoader_UnsupportError(t *testing.T) {
 func int64ptr(i int64) *int64 {
 	return &i
 }
+
+func TestFileProvider_Profile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		profile string
+		expect  *example
+	}{
+		{
+			name:    "json profile",
+			file:    "*.json",
+			content: `{"name":"Tom","tags":["foo"],"profiles":{"staging":{"name":"Jerry"}}}`,
+			profile: "staging",
+			expect:  &example{Name: "Jerry", Tags: []string{"foo"}},
+		},
+		{
+			name:    "yaml profile",
+			file:    "*.yaml",
+			content: "name: Tom\ntags: [foo]\nprofiles:\n  prod:\n    tags: [bar, baz]\n",
+			profile: "prod",
+			expect:  &example{Name: "Tom", Tags: []string{"bar", "baz"}},
+		},
+		{
+			name:    "missing profile",
+			file:    "*.yaml",
+			content: "name: Tom\nprofiles:\n  prod:\n    name: Jerry\n",
+			profile: "dev",
+			expect:  &example{Name: "Tom"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.CreateTemp("", tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			if _, err = f.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := example{}
+			c := NewConfigurator(WithFileProvider(f.Name()), WithProfile(tt.profile))
+			assert.NoError(t, c.Load(&cfg))
+			assert.Equal(t, tt.expect, &cfg)
+		})
+	}
+}
+
+func TestWithLoadProfile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	content := "name: Tom\nprofiles:\n  staging:\n    name: Jerry\n  prod:\n    name: Spike\n"
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	type config struct {
+		Name   string
+		Region string `config:"env"`
+	}
+	c := NewConfigurator(
+		WithFileProvider(filename),
+		WithENVProvider(""),
+		WithEnviron([]string{"REGION=us", "REGION_STAGING=eu"}),
+		WithProfile("prod"),
+	)
+
+	cfg := &config{}
+	assert.NoError(t, c.Load(cfg, WithLoadProfile("staging")))
+	assert.Equal(t, &config{Name: "Jerry", Region: "eu"}, cfg)
+	assert.Equal(t, "staging", c.Summary().Profile)
+
+	fresh, err := c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, &config{Name: "Jerry", Region: "eu"}, fresh)
+
+	cfg = &config{}
+	assert.NoError(t, c.LoadContext(context.Background(), cfg))
+	assert.Equal(t, &config{Name: "Spike", Region: "us"}, cfg)
+	assert.Equal(t, "prod", c.Summary().Profile)
+
+	cfg = &config{}
+	assert.NoError(t, c.Load(cfg, WithLoadProfile("")))
+	assert.Equal(t, &config{Name: "Tom", Region: "us"}, cfg)
+}
@@ -0,0 +1,49 @@
+package configurator
+
+import (
+	"fmt"
+	"os"
+)
+
+// filePerm are the permissions and owner files holding configuration must
+// have. The zero value checks nothing.
+type filePerm struct {
+	// mode are the permission bits allowed, 0 for any.
+	mode     os.FileMode
+	uid      int
+	hasOwner bool
+}
+
+// WithMaxFileMode fails loads reading a config file with permission bits
+// beyond mode, such as a group or world readable file for 0600, with
+// ErrInsecureFile, so that files holding secrets aren't readable by other
+// users.
+func WithMaxFileMode(mode os.FileMode) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.filePerm.mode = mode.Perm()
+	}
+}
+
+// WithFileOwner fails loads reading a config file not owned by the user
+// uid, such as os.Getuid(), with ErrInsecureFile. Ownership isn't checked
+// on Windows.
+func WithFileOwner(uid int) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.filePerm.uid = uid
+		co.filePerm.hasOwner = true
+	}
+}
+
+func (p filePerm) check(fi os.FileInfo) error {
+	if p.mode != 0 {
+		if extra := fi.Mode().Perm() &^ p.mode; extra != 0 {
+			return fmt.Errorf("%w, mode %#o allows more than %#o", ErrInsecureFile, fi.Mode().Perm(), p.mode)
+		}
+	}
+	if p.hasOwner {
+		if uid, ok := fileOwner(fi); ok && uid != p.uid {
+			return fmt.Errorf("%w, owned by uid %d, not %d", ErrInsecureFile, uid, p.uid)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilePerm(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	type example struct {
+		Password string `yaml:"password"`
+	}
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("password: s3cret\n"), 0o600))
+
+	c := NewConfigurator(WithFileProvider(filename), WithMaxFileMode(0o600), WithFileOwner(os.Getuid()))
+	var v example
+	assert.NoError(t, c.Load(&v))
+	assert.Equal(t, "s3cret", v.Password)
+
+	assert.NoError(t, os.Chmod(filename, 0o644))
+	err := NewConfigurator(WithFileProvider(filename), WithMaxFileMode(0o600)).Load(&example{})
+	assert.True(t, errors.Is(err, ErrInsecureFile))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "mode 0644 allows more than 0600")
+	}
+	assert.NoError(t, NewConfigurator(WithFileProvider(filename), WithMaxFileMode(0o644)).Load(&example{}))
+
+	err = NewConfigurator(WithFileProvider(filename), WithFileOwner(os.Getuid()+1)).Load(&example{})
+	assert.True(t, errors.Is(err, ErrInsecureFile))
+}
@@ -1,95 +1,371 @@
 package configurator
 
 import (
-	"encoding/base64"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type flagProvider struct {
-	flags map[string]func()
+	flags  map[string]flagSetter
+	parsed bool
+	// seen are the flags given on the command line.
+	seen map[string]bool
+	// args are parsed in place of os.Args[1:] when not nil.
+	args   []string
+	logger *slog.Logger
+	// help, if set, replaces the usage of the command line.
+	help *flagHelp
+}
+
+// flagSetter applies a parsed flag to a field of typ. Flags are registered
+// and parsed once, so reloads reuse them.
+type flagSetter struct {
+	typ reflect.Type
+	set func(reflect.Value) error
 }
 
 func NewFlagProvider() *flagProvider {
 	return &flagProvider{
-		flags: make(map[string]func()),
+		flags:  make(map[string]flagSetter),
+		seen:   make(map[string]bool),
+		logger: slog.Default(),
 	}
 }
 
 func (p *flagProvider) Provide(v interface{}, si StructInfo) error {
+	vals := make(map[string]FieldInfo)
+	var fresh []string
 	for _, fi := range si.Fields() {
 		k := fi.FlagKey()
 		if k == "" {
 			continue
 		}
-		if _, ok := p.flags[k]; ok {
+		if _, ok := vals[k]; ok {
+			return fmt.Errorf("flagProvider/Provide: %w [%s]", ErrConflictKey, k)
+		}
+		vals[k] = fi
+		if fs, ok := p.flags[k]; ok {
+			if fs.typ != fi.Value().Type() {
+				return fmt.Errorf("flagProvider/Provide: %w [%s]", ErrConflictKey, k)
+			}
+			continue
+		}
+		// flag.Var panics on redefinition
+		if flag.Lookup(k) != nil {
 			return fmt.Errorf("flagProvider/Provide: %w [%s]", ErrConflictKey, k)
 		}
-		fn, err := createVarSetFunc(k, fi.Value(), fi.StructField().Type)
+		var (
+			o       parseOptions
+			allowed []string
+		)
+		if f, ok := fi.(*fieldInfo); ok {
+			o, allowed = f.options(), f.tag.enum
+		}
+		fn, err := createVarSetFunc(k, fi.Value().Type(), o, allowed)
 		if err != nil {
 			return err
 		}
-		p.flags[k] = fn
+		p.flags[k] = flagSetter{typ: fi.Value().Type(), set: fn}
+		fresh = append(fresh, k)
+		// shown by --help, the flag itself stays unset
+		if def := fi.DefVal(); def != "" {
+			flag.Lookup(k).DefValue = def
+		}
+		if p.help != nil {
+			p.help.add(k, fi)
+		}
+	}
+	args := p.args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	all := false
+	if p.help != nil {
+		// not a flag of the command line, which the application owns
+		args, all = cutHelpAll(args)
+	}
+	if !p.parsed {
+		p.parsed = true
+		if p.help != nil {
+			flag.CommandLine.Usage = p.help.usage
+		}
+		hint := unknownFlagHint(args)
+		if hint != "" {
+			// printed before the usage when the command line exits on errors
+			usage := flag.CommandLine.Usage
+			flag.CommandLine.Usage = func() {
+				fmt.Fprintln(flag.CommandLine.Output(), hint)
+				usage()
+			}
+			defer func() { flag.CommandLine.Usage = usage }()
+		}
+		// flag.Parse drops the error of a ContinueOnError command line
+		err := flag.CommandLine.Parse(args)
+		flag.Visit(func(f *flag.Flag) { p.seen[f.Name] = true })
+		if err != nil {
+			if hint != "" {
+				return fmt.Errorf("flagProvider/Provide: %w; %s", err, hint)
+			}
+			return fmt.Errorf("flagProvider/Provide: %w", err)
+		}
+		if all {
+			// as -help does, but the caller decides whether to exit
+			p.help.all = true
+			flag.CommandLine.Usage()
+			return fmt.Errorf("flagProvider/Provide: %w", flag.ErrHelp)
+		}
+	} else if len(fresh) > 0 {
+		if err := p.parseFresh(args, fresh); err != nil {
+			return fmt.Errorf("flagProvider/Provide: %w", err)
+		}
 	}
-	flag.Parse()
 
-	flag.Visit(func(f *flag.Flag) {
-		if fn, ok := p.flags[f.Name]; ok {
-			fn()
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if fi, ok := vals[f.Name]; ok && p.seen[f.Name] && err == nil {
+			p.logger.Debug("configurator: flag set", "flag", f.Name)
+			if e := p.flags[f.Name].set(fi.Value()); e != nil {
+				err = fmt.Errorf("flagProvider/Provide: %w [%s]", e, f.Name)
+				return
+			}
+			markSet(fi)
 		}
 	})
 
+	return err
+}
+
+func (p *flagProvider) String() string {
+	return "flag"
+}
+
+// parseFresh parses args again for the flags fresh, registered after the
+// command line was parsed, such as those of another struct loaded later.
+// The flags parsed already are skipped, so that lists don't grow twice.
+func (p *flagProvider) parseFresh(args []string, fresh []string) error {
+	isFresh := make(map[string]bool, len(fresh))
+	for _, k := range fresh {
+		isFresh[k] = true
+	}
+	fs := flag.NewFlagSet(flag.CommandLine.Name(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	flag.VisitAll(func(f *flag.Flag) {
+		if isFresh[f.Name] {
+			fs.Var(f.Value, f.Name, f.Usage)
+			return
+		}
+		fs.Var(ignoredFlag(isBoolFlag(f.Value)), f.Name, f.Usage)
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if isFresh[f.Name] {
+			p.seen[f.Name] = true
+		}
+	})
 	return nil
 }
 
-var (
-	durationType    = reflect.TypeOf(time.Duration(0))
-	durationPtrType = reflect.TypeOf((*time.Duration)(nil))
-)
+// ignoredFlag stands in for a flag parsed already, telling whether it is a
+// bool flag for the arguments after it to be read right.
+type ignoredFlag bool
+
+func (f ignoredFlag) String() string   { return "" }
+func (f ignoredFlag) Set(string) error { return nil }
+func (f ignoredFlag) IsBoolFlag() bool { return bool(f) }
+
+func isBoolFlag(v flag.Value) bool {
+	b, ok := v.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// cutHelpAll returns args without -help-all, and whether it asked for
+// help, scanning args as flag.Parse does.
+func cutHelpAll(args []string) ([]string, bool) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if len(a) < 2 || a[0] != '-' || a == "--" {
+			return args, false
+		}
+		name, val, hasValue := strings.Cut(strings.TrimPrefix(a[1:], "-"), "=")
+		if name == helpAllFlag {
+			all := true
+			if hasValue {
+				b, err := strconv.ParseBool(val)
+				all = err == nil && b
+			}
+			return append(args[:i:i], args[i+1:]...), all
+		}
+		if f := flag.CommandLine.Lookup(name); f != nil && !hasValue && !isBoolFlag(f.Value) {
+			i++
+		}
+	}
+	return args, false
+}
+
+// unknownFlagHint returns the flags close to the first flag of args that
+// isn't defined, as "did you mean -port?", scanning args as flag.Parse
+// does.
+func unknownFlagHint(args []string) string {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if len(a) < 2 || a[0] != '-' || a == "--" {
+			return ""
+		}
+		name, _, hasValue := strings.Cut(strings.TrimPrefix(a[1:], "-"), "=")
+		f := flag.CommandLine.Lookup(name)
+		if f == nil {
+			if name == "h" || name == "help" {
+				return ""
+			}
+			var names []string
+			flag.CommandLine.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+			near := Suggest(name, names)
+			if len(near) == 0 {
+				return ""
+			}
+			for i := range near {
+				near[i] = "-" + near[i]
+			}
+			return "did you mean " + orList(near) + "?"
+		}
+		if !hasValue && !isBoolFlag(f.Value) {
+			i++
+		}
+	}
+	return ""
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
 
-func createVarSetFunc(k string, val reflect.Value, typ reflect.Type) (func(), error) {
+// createVarSetFunc registers the flag k with the type of the field, so that
+// --help shows it and the flag package rejects values that don't parse. o
+// and allowed, the values of an enum tag option, are those of the field.
+func createVarSetFunc(k string, typ reflect.Type, o parseOptions, allowed []string) (func(reflect.Value) error, error) {
+	if isWrapper(typ) {
+		elem := reflect.New(typ).Interface().(wrapper).elemType()
+		fn, err := createVarSetFunc(k, elem, o, allowed)
+		if err != nil {
+			return nil, err
+		}
+		return func(val reflect.Value) error {
+			v := reflect.New(elem).Elem()
+			if err := fn(v); err != nil {
+				return err
+			}
+			d, ok := asWrapper(val)
+			if !ok {
+				return fmt.Errorf("flagProvider/createVarSetFunc: %w value of type [%s]", ErrUnsupported, val.Type())
+			}
+			d.store(v)
+			return nil
+		}, nil
+	}
+	if o.format != "" {
+		return typedVar(k, &typedValue{typ: typ, opts: o}, "a `"+o.format+"` document")
+	}
+	if e, ok := lookupEnum(typ); ok {
+		return typedVar(k, &typedValue{typ: typ, opts: o}, "one of `"+strings.Join(e.names, "|")+"`")
+	}
+	if len(o.transforms) > 0 {
+		// the flag package would parse raw values before the transforms ran
+		v := &typedValue{typ: typ, opts: o}
+		if len(allowed) > 0 && typ.Kind() != reflect.Slice && typ.Kind() != reflect.Ptr {
+			v.allowed = allowed
+			return typedVar(k, v, "one of `"+strings.Join(allowed, "|")+"`")
+		}
+		return typedVar(k, v, "a `"+typ.String()+"`")
+	}
+	if isFlagValue(typ) {
+		return createValueSetFunc(k, typ)
+	}
+	if n, ok := lookupNullable(typ); ok {
+		return typedVar(k, &typedValue{typ: typ, opts: o}, "`"+n.elem.String()+"`, empty for null")
+	}
+	if typ != timeType && isTextUnmarshaler(typ) {
+		return typedVar(k, &typedValue{typ: typ, opts: o}, "a `"+typ.String()+"`")
+	}
+	if len(allowed) > 0 && typ.Kind() != reflect.Slice && typ.Kind() != reflect.Ptr {
+		return typedVar(k, &typedValue{typ: typ, opts: o, allowed: allowed}, "one of `"+strings.Join(allowed, "|")+"`")
+	}
 	switch typ.Kind() {
 	case reflect.Bool:
+		if o.trim || o.fold {
+			// the flag package parses neither padded nor yes/no values
+			return typedVar(k, &typedValue{typ: typ, opts: o}, "")
+		}
 		v := flag.Bool(k, false, "")
-		return func() { val.SetBool(*v) }, nil
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return func(val reflect.Value) error { val.SetBool(*v); return nil }, nil
+	case reflect.Int:
+		if o.lenient(typ) {
+			return typedVar(k, &typedValue{typ: typ, opts: o}, "an `int`")
+		}
 		v := flag.Int(k, 0, "")
-		return func() { val.SetInt(int64(*v)) }, nil
+		return func(val reflect.Value) error { return setInt(val, int64(*v)) }, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		bits := typ.Bits()
+		usage := fmt.Sprintf("`%s` from %d to %d", typ.Kind(), -1<<(bits-1), 1<<(bits-1)-1)
+		return typedVar(k, &typedValue{typ: typ, opts: o}, usage)
 	case reflect.Int64:
+		if o.lenient(typ) {
+			name := "int64"
+			if typ == durationType {
+				name = "duration"
+			}
+			return typedVar(k, &typedValue{typ: typ, opts: o}, "a `"+name+"`")
+		}
 		if typ == durationType {
 			v := flag.Duration(k, time.Duration(0), "")
-			return func() { val.SetInt(int64(*v)) }, nil
+			return func(val reflect.Value) error { return setInt(val, int64(*v)) }, nil
 		} else {
 			v := flag.Int64(k, 0, "")
-			return func() { val.SetInt(*v) }, nil
+			return func(val reflect.Value) error { return setInt(val, *v) }, nil
+		}
+	case reflect.Uint:
+		if o.lenient(typ) {
+			return typedVar(k, &typedValue{typ: typ, opts: o}, "a `uint`")
 		}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
 		v := flag.Uint(k, 0, "")
-		return func() { val.SetUint(uint64(*v)) }, nil
+		return func(val reflect.Value) error { return setUint(val, uint64(*v)) }, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		usage := fmt.Sprintf("`%s` up to %d", typ.Kind(), uint64(1)<<typ.Bits()-1)
+		return typedVar(k, &typedValue{typ: typ, opts: o}, usage)
 	case reflect.Uint64:
+		if o.lenient(typ) {
+			return typedVar(k, &typedValue{typ: typ, opts: o}, "a `uint64`")
+		}
 		v := flag.Uint64(k, 0, "")
-		return func() { val.SetUint(*v) }, nil
-	case reflect.Float32, reflect.Float64:
+		return func(val reflect.Value) error { return setUint(val, *v) }, nil
+	case reflect.Float32:
+		return typedVar(k, &typedValue{typ: typ, opts: o}, "a `float32`")
+	case reflect.Float64:
+		if o.lenient(typ) {
+			return typedVar(k, &typedValue{typ: typ, opts: o}, "a `float`")
+		}
 		v := flag.Float64(k, 0, "")
-		return func() { val.SetFloat(*v) }, nil
+		return func(val reflect.Value) error { return setFloat(val, *v) }, nil
 	case reflect.String:
+		if o.trim {
+			return typedVar(k, &typedValue{typ: typ, opts: o}, "a `string`")
+		}
 		v := flag.String(k, "", "")
-		return func() { val.SetString(*v) }, nil
+		return func(val reflect.Value) error { val.SetString(*v); return nil }, nil
 	case reflect.Ptr:
-		return createPtrSetFunc(k, val, typ)
+		return createPtrSetFunc(k, typ, o, allowed)
 	case reflect.Slice:
-		return createSliceSetFunc(k, val, typ)
+		return createSliceSetFunc(k, typ, o)
 	case reflect.Struct:
 		if typ == timeType {
-			var v timeValue
-			flag.Var(&v, k, "")
-			return func() {
-				t := time.Time(v)
-				val.Set(reflect.ValueOf(t))
-			}, nil
+			return typedVar(k, &typedValue{typ: typ, opts: o}, "a `time` in RFC 3339 format")
 		}
 		return nil, fmt.Errorf("flagProvider/createVarSetFunc: %w type [%s]", ErrUnsupported, typ.Kind().String())
 	default:
@@ -97,311 +373,178 @@ func createVarSetFunc(k string, val reflect.Value, typ reflect.Type) (func(), er
 	}
 }
 
-func createPtrSetFunc(k string, val reflect.Value, typ reflect.Type) (func(), error) {
-	switch typ.Elem().Kind() {
-	case reflect.Bool:
-		v := flag.Bool(k, false, "")
-		return func() {
-			val.Set(reflect.ValueOf(v))
-		}, nil
-	case reflect.Int:
-		v := flag.Int(k, 0, "")
-		return func() {
-			val.Set(reflect.ValueOf(v))
-		}, nil
-	case reflect.Int8:
-		v := flag.Int(k, 0, "")
-		return func() {
-			i8 := int8(*v)
-			val.Set(reflect.ValueOf(&i8))
-		}, nil
-	case reflect.Int16:
-		v := flag.Int(k, 0, "")
-		return func() {
-			i16 := int16(*v)
-			val.Set(reflect.ValueOf(&i16))
-		}, nil
-	case reflect.Int32:
-		v := flag.Int(k, 0, "")
-		return func() {
-			i32 := int32(*v)
-			val.Set(reflect.ValueOf(&i32))
-		}, nil
-	case reflect.Int64:
-		if typ == durationPtrType {
-			v := flag.Duration(k, time.Duration(0), "")
-			return func() {
-				val.Set(reflect.ValueOf(v))
-			}, nil
+// createValueSetFunc registers a field whose type implements flag.Value as
+// is, so that its own parsing, and IsBoolFlag, apply.
+func createValueSetFunc(k string, typ reflect.Type) (func(reflect.Value) error, error) {
+	ptr := reflect.New(indirect(typ))
+	v := ptr.Interface().(flag.Value)
+	usage := ""
+	if t, ok := v.(interface{ Type() string }); ok {
+		usage = "a `" + t.Type() + "`"
+	}
+	flag.Var(v, k, usage)
+	return func(val reflect.Value) error {
+		// a copy, so that the field doesn't share the flag's value
+		cp := reflect.New(indirect(typ))
+		cp.Elem().Set(ptr.Elem())
+		if typ.Kind() == reflect.Ptr {
+			val.Set(cp)
 		} else {
-			v := flag.Int64(k, 0, "")
-			return func() {
-				val.Set(reflect.ValueOf(v))
-			}, nil
+			val.Set(cp.Elem())
 		}
-	case reflect.Uint:
-		v := flag.Uint(k, 0, "")
-		return func() {
-			val.Set(reflect.ValueOf(v))
-		}, nil
-	case reflect.Uint8:
-		v := flag.Uint(k, 0, "")
-		return func() {
-			u8 := uint8(*v)
-			val.Set(reflect.ValueOf(&u8))
-		}, nil
-	case reflect.Uint16:
-		v := flag.Uint(k, 0, "")
-		return func() {
-			u16 := uint16(*v)
-			val.Set(reflect.ValueOf(&u16))
-		}, nil
-	case reflect.Uint32:
-		v := flag.Uint(k, 0, "")
-		return func() {
-			u32 := uint32(*v)
-			val.Set(reflect.ValueOf(&u32))
-		}, nil
-	case reflect.Uint64:
-		v := flag.Uint64(k, 0, "")
-		return func() {
-			val.Set(reflect.ValueOf(v))
-		}, nil
-	case reflect.Float32:
-		v := flag.Float64(k, 0, "")
-		return func() {
-			f32 := float32(*v)
-			val.Set(reflect.ValueOf(&f32))
-		}, nil
-	case reflect.Float64:
-		v := flag.Float64(k, 0, "")
-		return func() {
-			val.Set(reflect.ValueOf(v))
-		}, nil
-	case reflect.String:
-		v := flag.String(k, "", "")
-		return func() {
-			val.Set(reflect.ValueOf(v))
-		}, nil
-	case reflect.Struct:
-		if typ == timePtrType {
-			var v timeValue
-			flag.Var(&v, k, "")
-			return func() {
-				t := time.Time(v)
-				val.Set(reflect.ValueOf(&t))
-			}, nil
-		}
-		return nil, fmt.Errorf("flagProvider/createPtrSetFunc: %w type [%s]", ErrUnsupported, typ.Kind().String())
-	default:
-		return nil, fmt.Errorf("flagProvider/createPtrSetFunc: %w type [%s]", ErrUnsupported, typ.Kind().String())
+		return nil
+	}, nil
+}
+
+func typedVar(k string, v *typedValue, usage string) (func(reflect.Value) error, error) {
+	flag.Var(v, k, usage)
+	return func(val reflect.Value) error { return assignValue(val, v.val) }, nil
+}
+
+func createPtrSetFunc(k string, typ reflect.Type, o parseOptions, allowed []string) (func(reflect.Value) error, error) {
+	fn, err := createVarSetFunc(k, typ.Elem(), o, allowed)
+	if err != nil {
+		return nil, err
 	}
+	return func(val reflect.Value) error {
+		ptr := reflect.New(typ.Elem())
+		if err := fn(ptr.Elem()); err != nil {
+			return err
+		}
+		val.Set(ptr)
+		return nil
+	}, nil
 }
 
-func createSliceSetFunc(k string, val reflect.Value, typ reflect.Type) (func(), error) {
-	switch typ.Elem().Kind() {
-	case reflect.Bool:
-		var v boolSliceValue
-		flag.Var(&v, k, "")
-		return func() { val.Set(reflect.ValueOf(v)) }, nil
-	case reflect.Int:
-		var v intSliceValue
-		flag.Var(&v, k, "")
-		return func() { val.Set(reflect.ValueOf(v)) }, nil
+// createSliceSetFunc registers a flag that is repeated for each item,
+// parsed like the items of the field.
+func createSliceSetFunc(k string, typ reflect.Type, o parseOptions) (func(reflect.Value) error, error) {
+	var usage string
+	switch elem := typ.Elem(); elem.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Uint, reflect.Uint64, reflect.Float32, reflect.String:
+		usage = "repeat the flag for each `" + elem.Kind().String() + "`"
 	case reflect.Int64:
-		if typ.Elem() == durationType {
-			var v durationSliceValue
-			flag.Var(&v, k, "")
-			return func() { val.Set(reflect.ValueOf(v)) }, nil
-		} else {
-			var v int64SliceValue
-			flag.Var(&v, k, "")
-			return func() { val.Set(reflect.ValueOf(v)) }, nil
+		usage = "repeat the flag for each `int64`"
+		if elem == durationType {
+			usage = "repeat the flag for each `duration`"
 		}
-	case reflect.Uint:
-		var v uintSliceValue
-		flag.Var(&v, k, "")
-		return func() { val.Set(reflect.ValueOf(v)) }, nil
 	case reflect.Uint8:
-		var v base64StringValue
-		flag.Var(&v, k, "")
-		return func() { val.Set(reflect.ValueOf(v)) }, nil
-	case reflect.Uint64:
-		var v uint64SliceValue
-		flag.Var(&v, k, "")
-		return func() { val.Set(reflect.ValueOf(v)) }, nil
-	case reflect.Float32:
-		var v float32SliceValue
-		flag.Var(&v, k, "")
-		return func() { val.Set(reflect.ValueOf(v)) }, nil
+		// the whole value, as setSlice reads []byte
+		return typedVar(k, &typedValue{typ: typ, opts: o}, "`base64` encoded bytes")
 	case reflect.Float64:
-		var v float64SliceValue
-		flag.Var(&v, k, "")
-		return func() { val.Set(reflect.ValueOf(v)) }, nil
-	case reflect.String:
-		var v stringSliceValue
-		flag.Var(&v, k, "")
-		return func() { val.Set(reflect.ValueOf(v)) }, nil
+		usage = "repeat the flag for each `float`"
 	case reflect.Struct:
-		if typ.Elem() == timeType {
-			var v timeSliceValue
-			flag.Var(&v, k, "")
-			return func() { val.Set(reflect.ValueOf(v)) }, nil
+		if elem != timeType {
+			return nil, fmt.Errorf("flagProvider/createSliceSetFunc: %w type [%s]", ErrUnsupported, typ.Kind().String())
 		}
-		return nil, fmt.Errorf("flagProvider/createSliceSetFunc: %w type [%s]", ErrUnsupported, typ.Kind().String())
+		usage = "repeat the flag for each `time`"
 	default:
 		return nil, fmt.Errorf("flagProvider/createSliceSetFunc: %w type [%s]", ErrUnsupported, typ.Kind().String())
 	}
+	v := &sliceValue{typ: typ, opts: o, val: reflect.MakeSlice(typ, 0, 0)}
+	flag.Var(v, k, usage)
+	return func(val reflect.Value) error { return assignValue(val, v.val) }, nil
 }
 
-type timeValue time.Time
-
-func (t *timeValue) String() string { return time.Time(*t).String() }
-
-func (t *timeValue) Set(v string) error {
-	tv, err := time.Parse(time.RFC3339, v)
-	if err != nil {
-		return err
-	}
-	*t = timeValue(tv)
-	return nil
+// typedValue is a flag of a type the flag package has no flag for, such as
+// int8 or an enum, parsed like the field.
+type typedValue struct {
+	typ     reflect.Type
+	opts    parseOptions
+	allowed []string
+	val     reflect.Value
 }
 
-type boolSliceValue []bool
-
-func (b *boolSliceValue) String() string { return fmt.Sprintf("%v", []bool(*b)) }
-
-func (b *boolSliceValue) Set(s string) error {
-	v, err := strconv.ParseBool(s)
-	if err != nil {
-		return err
+func (v *typedValue) String() string {
+	if v == nil || !v.val.IsValid() {
+		return ""
 	}
-	*b = append(*b, v)
-	return nil
+	return formatValue(v.val)
 }
 
-type intSliceValue []int
-
-func (i *intSliceValue) String() string { return fmt.Sprintf("%v", []int(*i)) }
+// IsBoolFlag lets a bool be set by the flag name alone, as flag.Bool does.
+func (v *typedValue) IsBoolFlag() bool { return v.typ.Kind() == reflect.Bool }
 
-func (i *intSliceValue) Set(s string) error {
-	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+func (v *typedValue) Set(s string) error {
+	s, err := v.opts.transform(s)
 	if err != nil {
 		return err
 	}
-	*i = append(*i, int(v))
-	return nil
-}
-
-type int64SliceValue []int64
-
-func (i *int64SliceValue) String() string { return fmt.Sprintf("%v", []int64(*i)) }
-
-func (i *int64SliceValue) Set(s string) error {
-	v, err := strconv.ParseInt(s, 0, 64)
-	if err != nil {
-		return err
+	if len(v.allowed) > 0 {
+		if s, err = allowedValue(v.allowed, s, v.opts.fold); err != nil {
+			return err
+		}
 	}
-	*i = append(*i, v)
-	return nil
-}
-
-type durationSliceValue []time.Duration
-
-func (d *durationSliceValue) String() string { return fmt.Sprintf("%v", []time.Duration(*d)) }
-
-func (d *durationSliceValue) Set(s string) error {
-	v, err := time.ParseDuration(s)
-	if err != nil {
+	val := reflect.New(v.typ).Elem()
+	if err := v.opts.set(val, v.typ, s); err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return fmt.Errorf("%w: %s overflows %s", ErrOutOfRange, s, v.typ)
+		}
 		return err
 	}
-	*d = append(*d, v)
+	v.val = val
 	return nil
 }
 
-type uintSliceValue []uint
-
-func (u *uintSliceValue) String() string { return fmt.Sprintf("%v", []uint(*u)) }
+var flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
 
-func (u *uintSliceValue) Set(s string) error {
-	v, err := strconv.ParseUint(s, 0, strconv.IntSize)
-	if err != nil {
-		return err
+// isFlagValue reports whether t, or a pointer to it, implements flag.Value,
+// as the value types of pflag do too.
+func isFlagValue(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Kind() != reflect.Ptr && t.Implements(flagValueType)
 	}
-	*u = append(*u, uint(v))
-	return nil
+	return reflect.PointerTo(t).Implements(flagValueType)
 }
 
-type uint64SliceValue []uint64
-
-func (u *uint64SliceValue) String() string { return fmt.Sprintf("%v", []uint64(*u)) }
-
-func (u *uint64SliceValue) Set(s string) error {
-	v, err := strconv.ParseUint(s, 0, 64)
-	if err != nil {
+// setFlagValue sets val, of a type for which isFlagValue holds, to v with
+// the Set method of the type, starting from its zero value.
+func setFlagValue(val reflect.Value, typ reflect.Type, v string) error {
+	ptr := reflect.New(indirect(typ))
+	if err := ptr.Interface().(flag.Value).Set(v); err != nil {
 		return err
 	}
-	*u = append(*u, v)
-	return nil
-}
-
-type float32SliceValue []float32
-
-func (f *float32SliceValue) String() string { return fmt.Sprintf("%v", []float32(*f)) }
-
-func (f *float32SliceValue) Set(s string) error {
-	v, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return err
+	if typ.Kind() == reflect.Ptr {
+		val.Set(ptr)
+	} else {
+		val.Set(ptr.Elem())
 	}
-	*f = append(*f, float32(v))
 	return nil
 }
 
-type float64SliceValue []float64
-
-func (f *float64SliceValue) String() string { return fmt.Sprintf("%v", []float64(*f)) }
-
-func (f *float64SliceValue) Set(s string) error {
-	v, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return err
+// formatFlagValue renders v, of a type for which isFlagValue holds, with
+// the String method of the type.
+func formatFlagValue(v reflect.Value) string {
+	ptr := reflect.New(indirect(v.Type()))
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
 	}
-	*f = append(*f, v)
-	return nil
+	ptr.Elem().Set(v)
+	return ptr.Interface().(flag.Value).String()
 }
 
-type stringSliceValue []string
-
-func (s *stringSliceValue) String() string { return fmt.Sprintf("%v", []string(*s)) }
-
-func (s *stringSliceValue) Set(v string) error {
-	*s = append(*s, v)
-	return nil
+// sliceValue is a flag repeated for each item of a slice.
+type sliceValue struct {
+	typ  reflect.Type
+	opts parseOptions
+	val  reflect.Value
 }
 
-type base64StringValue []byte
-
-func (b *base64StringValue) String() string { return base64.StdEncoding.EncodeToString([]byte(*b)) }
-
-func (b *base64StringValue) Set(s string) error {
-	bb, err := base64.StdEncoding.DecodeString(s)
-	if err != nil {
-		return err
+func (v *sliceValue) String() string {
+	if v == nil || !v.val.IsValid() {
+		return ""
 	}
-	*b = bb
-	return nil
+	return fmt.Sprintf("%v", v.val)
 }
 
-type timeSliceValue []time.Time
-
-func (t *timeSliceValue) String() string { return fmt.Sprintf("%v", []time.Time(*t)) }
-
-func (t *timeSliceValue) Set(s string) error {
-	tv, err := time.Parse(time.RFC3339, s)
-	if err != nil {
+func (v *sliceValue) Set(s string) error {
+	item := reflect.New(v.typ.Elem()).Elem()
+	if err := v.opts.set(item, v.typ.Elem(), s); err != nil {
 		return err
 	}
-	*t = append(*t, tv)
+	v.val = reflect.Append(v.val, item)
 	return nil
 }
@@ -1,8 +1,13 @@
 package configurator
 
 import (
+	"errors"
 	"flag"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -157,3 +162,278 @@ func s(v string) *string { return &v }
 func tptr(v time.Duration) *time.Duration { return &v }
 
 func timePtr(v time.Time) *time.Time { return &v }
+
+func TestFlagProvider_NamedTypes(t *testing.T) {
+	resetForTesting()
+	type level int8
+	type tags []string
+	type example struct {
+		Level    level  `config:"flag"`
+		LevelPtr *level `config:"flag"`
+		Tags     tags   `config:"flag"`
+		Small    int8   `config:"flag"`
+	}
+
+	tt := &example{}
+	os.Args = []string{"jhon", "-level=1", "-levelptr=2", "-tags=a", "-tags=b"}
+	si, err := getStructInfo(tt, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, NewFlagProvider().Provide(tt, si))
+	l := level(2)
+	assert.Equal(t, &example{Level: 1, LevelPtr: &l, Tags: tags{"a", "b"}}, tt)
+
+	resetForTesting()
+	os.Args = []string{"jhon", "-small=300"}
+	tt = &example{}
+	si, err = getStructInfo(tt, nil)
+	assert.NoError(t, err)
+	err = NewFlagProvider().Provide(tt, si)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `invalid value "300" for flag -small`)
+		assert.Contains(t, err.Error(), "300 overflows int8")
+	}
+}
+
+func TestFlagProvider_ParseOptions(t *testing.T) {
+	type example struct {
+		Debug bool   `config:"flag"`
+		Trace bool   `config:"flag"`
+		Name  string `config:"flag"`
+	}
+	resetForTesting()
+	flag.CommandLine.SetOutput(io.Discard)
+	os.Args = []string{"jhon", "-debug=yes"}
+	c := NewConfigurator(WithFileProvider(""), WithFlagProvider())
+	assert.Error(t, c.Load(&example{}))
+
+	resetForTesting()
+	os.Args = []string{"jhon", "-debug=Yes", "-trace", "-name= a "}
+	c = NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithCaseInsensitive(), WithTrimSpace())
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{Debug: true, Trace: true, Name: "a"}, cfg)
+}
+
+func TestFlagProvider_LenientNumbers(t *testing.T) {
+	type example struct {
+		N     int       `config:"flag"`
+		Ratio float64   `config:"flag,unit=percent"`
+		Sizes []uint64  `config:"flag"`
+		Loads []float64 `config:"flag,unit=percent"`
+	}
+	resetForTesting()
+	flag.CommandLine.SetOutput(io.Discard)
+	os.Args = []string{"jhon", "-n=1,048,576"}
+	c := NewConfigurator(WithFileProvider(""), WithFlagProvider())
+	assert.Error(t, c.Load(&example{}))
+
+	resetForTesting()
+	os.Args = []string{"jhon", "-n=1,048,576", "-ratio=85%", "-sizes=4_096", "-sizes=1,024", "-loads=50%", "-loads=0.25"}
+	c = NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithLenientNumbers())
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{N: 1048576, Ratio: 0.85, Sizes: []uint64{4096, 1024}, Loads: []float64{0.5, 0.25}}, cfg)
+}
+
+func TestFlagProvider_Help(t *testing.T) {
+	resetForTesting()
+	type color int
+	RegisterEnum(map[string]color{"red": 1, "green": 2})
+	type example struct {
+		Port    int             `config:"flag,default=8080"`
+		Timeout time.Duration   `config:"flag,default=5s"`
+		Retries uint8           `config:"flag"`
+		Mode    string          `config:"flag,enum=fast,slow,default=fast"`
+		Color   color           `config:"flag"`
+		Hosts   []string        `config:"flag"`
+		Rate    Optional[int16] `config:"flag"`
+	}
+	tt := &example{}
+	os.Args = []string{"jhon", "-rate=7", "-color=green", "-mode=slow"}
+	si, err := getStructInfo(tt, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, NewFlagProvider().Provide(tt, si))
+	assert.Equal(t, color(2), tt.Color)
+	assert.Equal(t, "slow", tt.Mode)
+	rate, ok := tt.Rate.Get()
+	assert.True(t, ok)
+	assert.Equal(t, int16(7), rate)
+
+	var help strings.Builder
+	flag.CommandLine.SetOutput(&help)
+	flag.PrintDefaults()
+	for _, want := range []string{
+		"-port int\n\t (default 8080)",
+		"-timeout duration\n\t (default 5s)",
+		"-retries uint8\n\tuint8 up to 255",
+		"-mode fast|slow\n\tone of fast|slow (default fast)",
+		"-color green|red\n\tone of green|red",
+		"-hosts string\n\trepeat the flag for each string",
+		"-rate int16\n\tint16 from -32768 to 32767",
+	} {
+		assert.Contains(t, strings.ReplaceAll(help.String(), "    \t", "\t"), want)
+	}
+
+	for _, arg := range []string{"-mode=medium", "-color=blue", "-retries=-1"} {
+		resetForTesting()
+		flag.CommandLine.SetOutput(io.Discard)
+		os.Args = []string{"jhon", arg}
+		tt = &example{}
+		si, err = getStructInfo(tt, nil)
+		assert.NoError(t, err)
+		err = NewFlagProvider().Provide(tt, si)
+		if assert.Error(t, err, arg) {
+			assert.Contains(t, err.Error(), "invalid value")
+		}
+	}
+}
+
+func TestFlagProvider_Unknown(t *testing.T) {
+	type example struct {
+		Port    int    `config:"flag"`
+		Verbose bool   `config:"flag"`
+		Name    string `config:"flag"`
+	}
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-portt=80"}, "flag provided but not defined: -portt; did you mean -port?"},
+		{[]string{"-verbose", "--nme", "web"}, "flag provided but not defined: -nme; did you mean -name?"},
+		{[]string{"-name", "-portt", "-xyz"}, "flag provided but not defined: -xyz"},
+	} {
+		resetForTesting()
+		var out strings.Builder
+		flag.CommandLine.SetOutput(&out)
+		os.Args = append([]string{"jhon"}, tc.args...)
+		tt := &example{}
+		si, err := getStructInfo(tt, nil)
+		assert.NoError(t, err)
+		err = NewFlagProvider().Provide(tt, si)
+		if assert.Error(t, err, tc.args) {
+			assert.True(t, strings.HasSuffix(err.Error(), tc.want), err.Error())
+		}
+		// the hint goes before the usage too
+		assert.Equal(t, strings.HasSuffix(tc.want, "?"), strings.Contains(out.String(), "\ndid you mean"), out.String())
+	}
+}
+
+func TestFlagProvider_Later(t *testing.T) {
+	resetForTesting()
+	flag.CommandLine.SetOutput(io.Discard)
+	type first struct {
+		Name string   `config:"flag"`
+		Tags []string `config:"flag"`
+	}
+	type second struct {
+		Port int  `config:"flag"`
+		Fast bool `config:"flag"`
+	}
+	os.Args = []string{"jhon", "-tags=a", "-port=80", "-fast", "-tags", "b"}
+
+	// -port isn't defined yet
+	fp := NewFlagProvider()
+	f := &first{}
+	si, err := getStructInfo(f, nil)
+	assert.NoError(t, err)
+	assert.Error(t, fp.Provide(f, si))
+
+	s := &second{}
+	si, err = getStructInfo(s, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, fp.Provide(s, si))
+	assert.Equal(t, &second{Port: 80, Fast: true}, s)
+}
+
+func TestFlagProvider_Reload(t *testing.T) {
+	resetForTesting()
+	type example struct {
+		Name  string         `config:"flag"`
+		Tags  []string       `config:"flag"`
+		Limit Dynamic[int64] `config:"flag"`
+	}
+	os.Args = []string{"jhon", "-name=Tom", "-tags=a", "-tags=b", "-limit=3"}
+
+	fp := NewFlagProvider()
+	for n := 0; n < 2; n++ {
+		tt := &example{}
+		si, err := getStructInfo(tt, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, fp.Provide(tt, si))
+		assert.Equal(t, "Tom", tt.Name)
+		assert.Equal(t, []string{"a", "b"}, tt.Tags)
+		assert.Equal(t, int64(3), tt.Limit.Get())
+	}
+}
+
+// hostPort is a custom flag type, as found in existing CLI code.
+type hostPort struct {
+	host string
+	port int
+}
+
+func (h *hostPort) Set(s string) error {
+	host, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return errors.New("expected host:port")
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+	*h = hostPort{host: host, port: p}
+	return nil
+}
+
+func (h *hostPort) String() string { return h.host + ":" + strconv.Itoa(h.port) }
+
+func (h *hostPort) Type() string { return "host:port" }
+
+type verbosity int
+
+func (v *verbosity) Set(s string) error {
+	if s == "true" {
+		s = "1"
+	}
+	n, err := strconv.Atoi(s)
+	*v = verbosity(n)
+	return err
+}
+
+func (v *verbosity) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *verbosity) IsBoolFlag() bool { return true }
+
+func TestFlagValue(t *testing.T) {
+	resetForTesting()
+	type example struct {
+		Listen  hostPort  `config:"env,flag"`
+		Admin   *hostPort `config:"env,default=localhost:9000"`
+		Proxy   hostPort  `yaml:"proxy"`
+		Verbose verbosity `config:"flag"`
+	}
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("listen: file:1\nproxy: proxy:3128\n"), 0o600))
+	os.Args = []string{"jhon", "-listen=flag:80", "-verbose"}
+	c := NewConfigurator(WithFileProvider(filename), WithENVProvider(""), WithFlagProvider(), WithDefaultProvider(),
+		WithEnviron([]string{"LISTEN=env:8080"}))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, hostPort{host: "flag", port: 80}, cfg.Listen)
+	assert.Equal(t, &hostPort{host: "localhost", port: 9000}, cfg.Admin)
+	assert.Equal(t, hostPort{host: "proxy", port: 3128}, cfg.Proxy)
+	assert.Equal(t, verbosity(1), cfg.Verbose)
+	assert.Equal(t, "file", c.Provenance()["Proxy"])
+	assert.Equal(t, "flag:80", c.report().Config["Listen"])
+
+	var help strings.Builder
+	flag.CommandLine.SetOutput(&help)
+	flag.PrintDefaults()
+	assert.Contains(t, help.String(), "-listen host:port")
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"ADMIN=nope"}))
+	err := c.Load(&example{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "expected host:port")
+	}
+}
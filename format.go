@@ -0,0 +1,220 @@
+package configurator
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatBase64 = "base64"
+	// formatStack separates stacked formats, as in base64+json.
+	formatStack = "+"
+)
+
+// FormatFunc decodes s, the value of a field tagged `format=name` given as
+// a single env var, flag or default, into v, a pointer to a value of the
+// field's type.
+type FormatFunc func(s string, v interface{}) error
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]FormatFunc{}
+)
+
+// the built in formats parse values as fields do, which refers back to
+// formats
+func init() {
+	formats["json"] = decodeJSON
+	formats["yaml"] = decodeYAML
+	formats["csv"] = decodeCSV
+}
+
+// RegisterFormat makes fn available to `format=name` tags, so that fields
+// of any type, such as structs or lists of structs, can be set from a
+// single string. Names are case insensitive; json, yaml and csv are built
+// in. base64 comes first in stacked formats, such as `format=base64+json`,
+// for values that must pass through systems mangling special characters,
+// such as Helm or ECS task definitions; alone, it decodes values parsed as
+// the field's type. Register formats before loading, typically from an init function, as
+// tags naming an unknown format fail.
+func RegisterFormat(name string, fn FormatFunc) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[strings.ToLower(name)] = fn
+}
+
+func lookupFormat(name string) (FormatFunc, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	fn, ok := formats[strings.ToLower(name)]
+	return fn, ok
+}
+
+// validFormat reports whether f is a format, or formats stacked on base64.
+func validFormat(f string) bool {
+	parts := strings.Split(f, formatStack)
+	for _, p := range parts[:len(parts)-1] {
+		if p != formatBase64 {
+			return false
+		}
+	}
+	last := parts[len(parts)-1]
+	if last == formatBase64 {
+		return true
+	}
+	_, ok := lookupFormat(last)
+	return ok
+}
+
+// decodeBase64 decodes standard or URL safe base64, padded or not.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+func formatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats)+1)
+	names = append(names, formatBase64)
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// decode parses v, a document in the format of o, into val.
+func (o parseOptions) decode(val reflect.Value, typ reflect.Type, v string) error {
+	for {
+		name, rest, stacked := strings.Cut(o.format, formatStack)
+		if name != formatBase64 {
+			break
+		}
+		b, err := decodeBase64(v)
+		if err != nil {
+			return fmt.Errorf("%w, invalid base64: %w", ErrInvalidValue, err)
+		}
+		if !stacked {
+			if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+				val.SetBytes(b)
+				return nil
+			}
+			// the decoded value parses as if it had no format
+			o.format = ""
+			return o.set(val, typ, string(b))
+		}
+		o.format, v = rest, string(b)
+	}
+	fn, ok := lookupFormat(o.format)
+	if !ok {
+		return fmt.Errorf("%w, unknown format %q", ErrInvalidTagFormat, o.format)
+	}
+	ptr := reflect.New(typ)
+	if err := fn(v, ptr.Interface()); err != nil {
+		return fmt.Errorf("%w, invalid %s: %w", ErrInvalidValue, o.format, err)
+	}
+	val.Set(ptr.Elem())
+	return nil
+}
+
+// decodeJSON decodes a single JSON document. Unknown keys fail, as they most
+// likely are typos.
+func decodeJSON(s string, v interface{}) error {
+	d := json.NewDecoder(strings.NewReader(s))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if d.More() {
+		return errors.New("data after the document")
+	}
+	return nil
+}
+
+// decodeYAML decodes a single YAML document, failing on unknown keys as
+// decodeJSON does.
+func decodeYAML(s string, v interface{}) error {
+	d := yaml.NewDecoder(strings.NewReader(s))
+	d.KnownFields(true)
+	return d.Decode(v)
+}
+
+// decodeCSV decodes the records of s into a list of lists, such as a matrix
+// of weights, or its only record into a list. Records must have the same
+// number of fields, and each is parsed as the field's items are.
+func decodeCSV(s string, v interface{}) error {
+	val := reflect.ValueOf(v).Elem()
+	typ := val.Type()
+	if typ.Kind() != reflect.Slice || typ.Elem().Kind() == reflect.Uint8 {
+		return fmt.Errorf("%w, csv needs a list or a list of lists, not %s", ErrUnsupported, typ)
+	}
+	r := csv.NewReader(strings.NewReader(s))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return err
+	}
+	row := func(typ reflect.Type, record []string) (reflect.Value, error) {
+		list := reflect.MakeSlice(typ, len(record), len(record))
+		for i, s := range record {
+			if err := setFieldValue(list.Index(i), typ.Elem(), s); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+		return list, nil
+	}
+	if !isList(typ.Elem()) {
+		if len(records) > 1 {
+			return fmt.Errorf("%d records for a single list", len(records))
+		}
+		if len(records) == 0 {
+			records = append(records, nil)
+		}
+		list, err := row(typ, records[0])
+		if err != nil {
+			return err
+		}
+		val.Set(list)
+		return nil
+	}
+	rows := reflect.MakeSlice(typ, len(records), len(records))
+	for i, record := range records {
+		list, err := row(typ.Elem(), record)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
+		rows.Index(i).Set(list)
+	}
+	val.Set(rows)
+	return nil
+}
+
+// hasFormat reports whether the tag of ft has a `format=` option, making
+// the field a single value even when it is a struct.
+func hasFormat(ft reflect.StructField) bool {
+	t, err := cachedTag(ft)
+	return err == nil && t.format != ""
+}
+
+// formatJSONValue renders values that only parse with `format=json`, such
+// as maps and structs.
+func formatJSONValue(v reflect.Value) string {
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(b)
+}
@@ -0,0 +1,202 @@
+package configurator
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatJSON(t *testing.T) {
+	type backend struct {
+		Host   string `json:"host"`
+		Weight int    `json:"weight"`
+	}
+	type example struct {
+		Primary  backend                    `config:"env,format=json"`
+		Fallback *backend                   `config:"env,format=json"`
+		Backends []backend                  `config:"env,format=json"`
+		Limits   map[string]int             `config:"env,format=json,default='{\"read\":10}'"`
+		Routes   Dynamic[map[string]string] `config:"env,format=json"`
+	}
+	environ := []string{
+		`PRIMARY={"host":"a","weight":2}`,
+		`FALLBACK={"host":"b"}`,
+		`BACKENDS=[{"host":"c","weight":1},{"host":"d, e"}]`,
+		`ROUTES={"/":"web"}`,
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(environ))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, backend{Host: "a", Weight: 2}, cfg.Primary)
+	assert.Equal(t, &backend{Host: "b"}, cfg.Fallback)
+	assert.Equal(t, []backend{{Host: "c", Weight: 1}, {Host: "d, e"}}, cfg.Backends)
+	assert.Equal(t, map[string]int{"read": 10}, cfg.Limits)
+	assert.Equal(t, map[string]string{"/": "web"}, cfg.Routes.Get())
+	assert.Equal(t, "env", c.Provenance()["Primary"])
+	assert.Equal(t, `{"host":"a","weight":2}`, c.report().Config["Primary"])
+
+	for _, env := range []string{`PRIMARY={"host":`, `PRIMARY={"hots":"a"}`, `PRIMARY={} {}`} {
+		c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{env}))
+		assert.True(t, errors.Is(c.Load(&example{}), ErrInvalidValue), env)
+	}
+
+	type invalid struct {
+		Primary backend `config:"env,format=toml"`
+	}
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""))
+	assert.True(t, errors.Is(c.Load(&invalid{}), ErrInvalidTagFormat))
+}
+
+func TestFormatJSON_Flag(t *testing.T) {
+	resetForTesting()
+	type example struct {
+		Weights []float64 `config:"flag,format=json"`
+	}
+	os.Args = []string{"jhon", "-weights=[0.5,1.5]"}
+	tt := &example{}
+	si, err := getStructInfo(tt, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, NewFlagProvider().Provide(tt, si))
+	assert.Equal(t, []float64{0.5, 1.5}, tt.Weights)
+
+	var help strings.Builder
+	flag.CommandLine.SetOutput(&help)
+	flag.PrintDefaults()
+	assert.Contains(t, help.String(), "-weights json\n")
+	assert.Contains(t, help.String(), "a json document")
+
+	resetForTesting()
+	flag.CommandLine.SetOutput(io.Discard)
+	os.Args = []string{"jhon", "-weights=0.5"}
+	tt = &example{}
+	si, err = getStructInfo(tt, nil)
+	assert.NoError(t, err)
+	err = NewFlagProvider().Provide(tt, si)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid json")
+	}
+}
+
+func TestFormatYAMLAndCSV(t *testing.T) {
+	type backend struct {
+		Host   string `yaml:"host"`
+		Weight int    `yaml:"weight"`
+	}
+	type example struct {
+		Backends []backend       `config:"env,format=yaml"`
+		Matrix   [][]float64     `config:"env,format=csv"`
+		Tags     []string        `config:"env,format=csv"`
+		Timeouts []time.Duration `config:"env,format=CSV"`
+	}
+	environ := []string{
+		"BACKENDS=- host: a\n  weight: 2\n- {host: b}",
+		"MATRIX=0.5, 1\n2,3.5",
+		`TAGS=a,"b,c"`,
+		"TIMEOUTS=1s,2m",
+	}
+	for i, kv := range environ {
+		environ[i] = strings.ReplaceAll(kv, `\n`, "\n")
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, []backend{{Host: "a", Weight: 2}, {Host: "b"}}, cfg.Backends)
+	assert.Equal(t, [][]float64{{0.5, 1}, {2, 3.5}}, cfg.Matrix)
+	assert.Equal(t, []string{"a", "b,c"}, cfg.Tags)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Minute}, cfg.Timeouts)
+
+	for _, env := range []string{"BACKENDS=- hots: a", "MATRIX=1,2\n3", "TAGS=a\nb", "MATRIX=1,x"} {
+		env = strings.ReplaceAll(env, `\n`, "\n")
+		c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{env}))
+		assert.True(t, errors.Is(c.Load(&example{}), ErrInvalidValue), env)
+	}
+
+	type invalid struct {
+		Weights map[string]int `config:"env,format=csv"`
+	}
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"WEIGHTS=a,1"}))
+	assert.True(t, errors.Is(c.Load(&invalid{}), ErrUnsupported))
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat("Pairs", func(s string, v interface{}) error {
+		m, ok := v.(*map[string]string)
+		if !ok {
+			return ErrUnsupported
+		}
+		*m = make(map[string]string)
+		for _, kv := range strings.Fields(s) {
+			k, val, ok := strings.Cut(kv, ":")
+			if !ok {
+				return fmt.Errorf("missing ':' in %q", kv)
+			}
+			(*m)[k] = val
+		}
+		return nil
+	})
+	type example struct {
+		Labels map[string]string `config:"env,format=pairs"`
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"LABELS=team:core tier:1"}))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, map[string]string{"team": "core", "tier": "1"}, cfg.Labels)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"LABELS=team"}))
+	err := c.Load(&example{})
+	if assert.True(t, errors.Is(err, ErrInvalidValue)) {
+		assert.Contains(t, err.Error(), `missing ':' in "team"`)
+	}
+}
+
+func TestFormatBase64(t *testing.T) {
+	type backend struct {
+		Host string `json:"host" yaml:"host"`
+	}
+	type example struct {
+		Primary backend       `config:"env,format=base64+json"`
+		Others  []backend     `config:"env,format=base64+yaml"`
+		Port    int           `config:"env,format=base64"`
+		Script  string        `config:"env,format=base64"`
+		Key     []byte        `config:"env,format=base64"`
+		Timeout time.Duration `config:"env,format=base64+base64"`
+	}
+	b64 := base64.StdEncoding.EncodeToString
+	environ := []string{
+		"PRIMARY=" + b64([]byte(`{"host":"a"}`)),
+		"OTHERS=" + base64.RawURLEncoding.EncodeToString([]byte("- host: b\n- host: c")),
+		"PORT=" + b64([]byte("8080")),
+		"SCRIPT=" + b64([]byte("echo 'a,b' && exit $?")),
+		"KEY=" + b64([]byte{0, 1, 2}),
+		"TIMEOUT=" + b64([]byte(b64([]byte("5s")))),
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, backend{Host: "a"}, cfg.Primary)
+	assert.Equal(t, []backend{{Host: "b"}, {Host: "c"}}, cfg.Others)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, "echo 'a,b' && exit $?", cfg.Script)
+	assert.Equal(t, []byte{0, 1, 2}, cfg.Key)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+
+	for _, env := range []string{"PORT=!", "PRIMARY=" + b64([]byte("{"))} {
+		c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{env}))
+		assert.True(t, errors.Is(c.Load(&example{}), ErrInvalidValue), env)
+	}
+
+	for _, format := range []string{"json+base64", "base64+", "base64+toml"} {
+		field := reflect.StructField{Name: "A", Type: reflect.TypeOf(""), Tag: reflect.StructTag(`config:"env,format=` + format + `"`)}
+		_, err := parseTag(field)
+		assert.True(t, errors.Is(err, ErrInvalidTagFormat), format)
+	}
+}
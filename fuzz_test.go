@@ -0,0 +1,68 @@
+package configurator
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzParseTag(f *testing.F) {
+	for _, s := range []string{"", "env", "flag", "default", "env=A,flag=b,default=c", "env=", "flag=,default=", ",,,", "default=a=b"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, tag string) {
+		field := reflect.StructField{
+			Name: "Field",
+			Type: reflect.TypeOf(""),
+			Tag:  reflect.StructTag(fmt.Sprintf("config:%q", tag)),
+		}
+		_, _ = parseTag(field)
+	})
+}
+
+type fuzzTarget struct {
+	B    bool
+	I8   int8
+	I    int
+	I64  int64
+	D    time.Duration
+	U8   uint8
+	U    uint
+	U64  uint64
+	F32  float32
+	F64  float64
+	S    string
+	T    time.Time
+	Bptr *bool
+	Iptr *int16
+	Tptr *time.Time
+	Is   []int
+	Bs   []byte
+	Ds   []time.Duration
+	Ts   []time.Time
+	M    map[string]string
+	Ch   chan int
+	Any  interface{}
+}
+
+func FuzzSetFieldValue(f *testing.F) {
+	for _, s := range []string{"", "1", "-1", "true", "0x10", "1e400", "300", "3s", "AQID", "1,2,3", "2020-09-30T22:51:49-08:00", ","} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		v := reflect.ValueOf(&fuzzTarget{}).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			_ = setFieldValue(v.Field(i), v.Field(i).Type(), s)
+		}
+	})
+}
+
+func TestSetFieldValue_Mismatch(t *testing.T) {
+	var i int8
+	assert.Error(t, setFieldValue(reflect.ValueOf(&i).Elem(), reflect.TypeOf(""), "1"))
+	assert.Error(t, setFieldValue(reflect.ValueOf(i), reflect.TypeOf(i), "1"))
+	assert.Error(t, setFieldValue(reflect.ValueOf(&i).Elem(), reflect.TypeOf(i), "300"))
+}
@@ -0,0 +1,89 @@
+package configurator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrFeatureGate is returned when a source sets an experimental field whose
+// feature gate isn't enabled.
+var ErrFeatureGate = errors.New("feature gate not enabled")
+
+// WithFeatureGates enables the gates of fields tagged `experimental=Gate`,
+// or `experimental` for a gate named after the field path, such as
+// "Server.HTTP3". A source setting an experimental field, or a field of an
+// experimental section, whose gate isn't enabled fails the load, or only
+// logs a warning with WithFeatureGateWarnings. Defaults always apply.
+func WithFeatureGates(gates ...string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		if co.gates == nil {
+			co.gates = make(map[string]bool)
+		}
+		for _, g := range gates {
+			co.gates[g] = true
+		}
+	}
+}
+
+// WithFeatureGateWarnings logs experimental fields set without their gate
+// as warnings, and resets them to their zero value, instead of failing the
+// load.
+func WithFeatureGateWarnings() ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.gateWarnings = true
+	}
+}
+
+// gate returns the feature gate of f, or of the nearest experimental
+// section around it.
+func (f *fieldInfo) gate() (string, bool) {
+	for p := f; p != nil; p = p.parent {
+		if p.tag.gated {
+			if p.tag.gate != "" {
+				return p.tag.gate, true
+			}
+			return p.Path(), true
+		}
+	}
+	return "", false
+}
+
+// checkGates fails when a source other than the defaults set an
+// experimental field whose gate isn't enabled, or resets it with
+// WithFeatureGateWarnings.
+func (c *Configurator) checkGates(fields []FieldInfo, origins map[string]string) error {
+	var denied, paths []string
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok {
+			continue
+		}
+		gate, ok := f.gate()
+		if !ok || c.gates[gate] {
+			continue
+		}
+		origin, ok := origins[f.Path()]
+		if !ok || origin == defaultProviderName {
+			continue
+		}
+		if !c.gateWarn {
+			denied = append(denied, fmt.Sprintf("%s needs gate %s", f.Path(), gate))
+			paths = append(paths, f.Path())
+			continue
+		}
+		c.logger.Warn("configurator: experimental field ignored", "field", f.Path(), "gate", gate, "provider", origin)
+		if d, ok := asWrapper(f.val); ok {
+			d.store(reflect.Zero(d.elemType()))
+		} else {
+			f.val.Set(reflect.Zero(f.val.Type()))
+		}
+		delete(origins, f.Path())
+	}
+	if len(denied) > 0 {
+		err := fmt.Errorf("configurator/LoadContext: %w, %s", ErrFeatureGate, strings.Join(denied, ", "))
+		return newFieldError(ErrFeatureGate, err, "", paths...)
+	}
+	return nil
+}
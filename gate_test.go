@@ -0,0 +1,61 @@
+package configurator
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureGates(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		HTTP3   bool   `config:"env,experimental=HTTP3"`
+		Policy  string `config:"env,experimental,default=lru"`
+		Tracing struct {
+			Endpoint string `config:"env"`
+		} `config:"experimental=OTel"`
+		Port int `config:"env"`
+	}
+	environ := []string{"HTTP3=true", "POLICY=lfu", "TRACING_ENDPOINT=otel:4317", "PORT=80"}
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(environ))
+	err := c.Load(&example{})
+	if assert.True(t, errors.Is(err, ErrFeatureGate)) {
+		assert.Equal(t, "configurator/LoadContext: feature gate not enabled, "+
+			"HTTP3 needs gate HTTP3, Policy needs gate Policy, Tracing.Endpoint needs gate OTel", err.Error())
+	}
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(environ),
+		WithFeatureGates("HTTP3", "Policy", "OTel"))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.True(t, cfg.HTTP3)
+	assert.Equal(t, "lfu", cfg.Policy)
+	assert.Equal(t, "otel:4317", cfg.Tracing.Endpoint)
+
+	// defaults of experimental fields apply without their gate
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(nil))
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "lru", cfg.Policy)
+
+	var logs bytes.Buffer
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(environ),
+		WithFeatureGates("OTel"), WithFeatureGateWarnings(), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.False(t, cfg.HTTP3)
+	assert.Equal(t, "", cfg.Policy)
+	assert.Equal(t, "otel:4317", cfg.Tracing.Endpoint)
+	assert.Equal(t, 80, cfg.Port)
+	assert.False(t, c.IsSet("HTTP3"))
+	assert.Contains(t, logs.String(), `experimental field ignored" field=HTTP3 gate=HTTP3 provider=env`)
+
+	type invalid struct {
+		Name string `config:"experimental="`
+	}
+	assert.True(t, errors.Is(NewConfigurator(WithFileProvider("")).Load(&invalid{}), ErrInvalidTagFormat))
+}
@@ -0,0 +1,120 @@
+package configurator
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// GoRuntimeConfig is a section tuning the Go runtime from the configuration.
+// Apply sets it once loaded, and a Watch-ed configuration applies reloads.
+// Empty fields leave the runtime alone; pair them with defaults such as
+// `defaultFn=CPUQuota` or `defaultFn=MemoryLimit*0.9` to fit the container.
+type GoRuntimeConfig struct {
+	// MaxProcs is GOMAXPROCS.
+	MaxProcs int `json:"max_procs" yaml:"max_procs" config:"env"`
+	// MemoryLimit is the soft memory limit, in bytes or as GOMEMLIMIT, such
+	// as 512MiB, or off.
+	MemoryLimit string `json:"memory_limit" yaml:"memory_limit" config:"env"`
+	// GCPercent is GOGC, a percentage or off.
+	GCPercent string `json:"gc_percent" yaml:"gc_percent" config:"env"`
+
+	// applied is shared by copies, so that Reload applies to the runtime
+	// once Apply was called.
+	applied *atomic.Bool
+}
+
+// Apply sets GOMAXPROCS, the memory limit and GOGC from g.
+func (g *GoRuntimeConfig) Apply() error {
+	if err := g.apply(); err != nil {
+		return fmt.Errorf("GoRuntimeConfig/Apply: %w", err)
+	}
+	if g.applied == nil {
+		g.applied = new(atomic.Bool)
+	}
+	g.applied.Store(true)
+	return nil
+}
+
+func (g *GoRuntimeConfig) apply() error {
+	limit, err := parseMemoryLimit(g.MemoryLimit)
+	if err != nil {
+		return err
+	}
+	gc, err := parseGCPercent(g.GCPercent)
+	if err != nil {
+		return err
+	}
+	if g.MaxProcs > 0 {
+		runtime.GOMAXPROCS(g.MaxProcs)
+	}
+	if g.MemoryLimit != "" {
+		debug.SetMemoryLimit(limit)
+	}
+	if g.GCPercent != "" {
+		debug.SetGCPercent(gc)
+	}
+	return nil
+}
+
+// Validate checks the memory limit and GOGC parse.
+func (g *GoRuntimeConfig) Validate() error {
+	if g.MaxProcs < 0 {
+		return fmt.Errorf("%w, negative MaxProcs %d", ErrInvalidValue, g.MaxProcs)
+	}
+	if _, err := parseMemoryLimit(g.MemoryLimit); err != nil {
+		return err
+	}
+	_, err := parseGCPercent(g.GCPercent)
+	return err
+}
+
+func (g *GoRuntimeConfig) reload(v interface{}) error {
+	if g.applied == nil || !g.applied.Load() {
+		return nil
+	}
+	return v.(*GoRuntimeConfig).apply()
+}
+
+var memoryUnits = []struct {
+	suffix string
+	shift  uint
+}{{"TiB", 40}, {"GiB", 30}, {"MiB", 20}, {"KiB", 10}, {"B", 0}}
+
+// parseMemoryLimit parses a limit as GOMEMLIMIT does, off meaning none.
+func parseMemoryLimit(s string) (int64, error) {
+	if s == "" || s == "off" {
+		return math.MaxInt64, nil
+	}
+	n, shift := s, uint(0)
+	for _, u := range memoryUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			n, shift = strings.TrimSuffix(s, u.suffix), u.shift
+			break
+		}
+	}
+	v, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || v < 0 || v > math.MaxInt64>>shift {
+		return 0, fmt.Errorf("%w, memory limit %q, bytes or a size such as 512MiB", ErrInvalidValue, s)
+	}
+	return v << shift, nil
+}
+
+// parseGCPercent parses GOGC, off meaning -1.
+func parseGCPercent(s string) (int, error) {
+	if s == "" {
+		return 100, nil
+	}
+	if s == "off" {
+		return -1, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%w, GC percent %q, a percentage or off", ErrInvalidValue, s)
+	}
+	return v, nil
+}
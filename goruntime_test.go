@@ -0,0 +1,53 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoRuntimeConfig(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	limit := debug.SetMemoryLimit(-1)
+	gc := debug.SetGCPercent(100)
+	defer func() {
+		runtime.GOMAXPROCS(procs)
+		debug.SetMemoryLimit(limit)
+		debug.SetGCPercent(gc)
+	}()
+
+	type example struct {
+		Runtime GoRuntimeConfig
+	}
+	environ := []string{"RUNTIME_MAXPROCS=3", "RUNTIME_MEMORYLIMIT=256MiB", "RUNTIME_GCPERCENT=50"}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.NoError(t, cfg.Runtime.Apply())
+	assert.Equal(t, 3, runtime.GOMAXPROCS(0))
+	assert.Equal(t, int64(256<<20), debug.SetMemoryLimit(-1))
+	assert.Equal(t, 50, debug.SetGCPercent(50))
+
+	// reloads apply to the runtime
+	environ = []string{"RUNTIME_MAXPROCS=2", "RUNTIME_MEMORYLIMIT=off", "RUNTIME_GCPERCENT=off"}
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ))
+	_, err := c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, runtime.GOMAXPROCS(0))
+	assert.Equal(t, int64(math.MaxInt64), debug.SetMemoryLimit(-1))
+	assert.Equal(t, -1, debug.SetGCPercent(-1))
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"RUNTIME_MEMORYLIMIT=1GB"}))
+	err = c.Load(&example{})
+	if assert.True(t, errors.Is(err, ErrInvalidValue)) {
+		assert.Contains(t, err.Error(), `memory limit "1GB"`)
+	}
+	v, err := parseMemoryLimit("1073741824")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1<<30), v)
+}
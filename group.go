@@ -0,0 +1,183 @@
+package configurator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GroupRule is how many fields of a group must be set, named after its tag
+// option.
+type GroupRule string
+
+const (
+	// GroupRequireAll fields are set together or not at all, as a cert
+	// and its key.
+	GroupRequireAll GroupRule = "requireAll"
+	// GroupRequireAny needs one field of the group set, or more.
+	GroupRequireAny GroupRule = "requireAny"
+	// GroupExactlyOne needs one field of the group set, not more, as a
+	// password or a password file.
+	GroupExactlyOne GroupRule = "exactlyOne"
+	// GroupExclusive allows one field of the group set, or none.
+	GroupExclusive GroupRule = "exclusive"
+)
+
+var groupRules = []GroupRule{GroupRequireAll, GroupRequireAny, GroupExactlyOne, GroupExclusive}
+
+// ErrGroup is returned when the fields of a group don't follow its rule.
+var ErrGroup = errors.New("field group not satisfied")
+
+// fieldGroup is a rule over fields, given by their names in the section
+// tagged with it, or their paths for WithFieldGroup.
+type fieldGroup struct {
+	rule  GroupRule
+	names []string
+}
+
+// WithFieldGroup checks rule on the fields at paths once loaded, as
+// `requireAll=CertFile|KeyFile` on the tag of a section does for its own
+// fields, for groups spanning sections or fields of the root. A path may
+// be a section, set when any of its fields is.
+func WithFieldGroup(rule GroupRule, paths ...string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.groups = append(co.groups, fieldGroup{rule: rule, names: paths})
+	}
+}
+
+// parseGroup parses the value of a group tag option, such as
+// `exactlyOne=Password|PasswordFile`.
+func parseGroup(s string) (fieldGroup, error) {
+	for _, rule := range groupRules {
+		prefix := string(rule) + "="
+		if !strings.HasPrefix(s, prefix) {
+			continue
+		}
+		names := strings.Split(strings.TrimPrefix(s, prefix), "|")
+		if len(names) < 2 || contains(names, "") {
+			return fieldGroup{}, fmt.Errorf("%w, `%sA|B` needs two field names or more", ErrInvalidTagFormat, prefix)
+		}
+		return fieldGroup{rule: rule, names: names}, nil
+	}
+	return fieldGroup{}, fmt.Errorf("%w, unknown group %q", ErrInvalidTagFormat, s)
+}
+
+func isGroupOption(s string) bool {
+	for _, rule := range groupRules {
+		if len(s) > len(rule) && s[len(rule)] == '=' && strings.HasPrefix(s, string(rule)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGroups fails when the groups of the sections of fields, or groups,
+// don't follow their rule, listing every broken group. Groups of disabled
+// sections are not checked, and disabled fields count as unset.
+func checkGroups(fields []FieldInfo, groups []fieldGroup) error {
+	byPath := make(map[string]*fieldInfo, len(fields))
+	for _, fi := range fields {
+		if f, ok := fi.(*fieldInfo); ok {
+			byPath[f.Path()] = f
+		}
+	}
+	type scoped struct {
+		fieldGroup
+		prefix string
+	}
+	all := make([]scoped, 0, len(groups))
+	for _, g := range groups {
+		all = append(all, scoped{fieldGroup: g})
+	}
+	seen := make(map[*fieldInfo]bool)
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok {
+			continue
+		}
+		for p := f.parent; p != nil && !seen[p]; p = p.parent {
+			seen[p] = true
+			if len(p.tag.groups) == 0 {
+				continue
+			}
+			if enabled, err := p.enabled(byPath); err != nil || !enabled {
+				continue
+			}
+			for _, g := range p.tag.groups {
+				all = append(all, scoped{fieldGroup: g, prefix: p.Path() + "."})
+			}
+		}
+	}
+
+	var broken, members []string
+	for _, g := range all {
+		paths := make([]string, len(g.names))
+		var set, unset []string
+		for i, name := range g.names {
+			paths[i] = g.prefix + name
+			found, isSet := memberSet(fields, paths[i])
+			if !found {
+				return fmt.Errorf("configurator/LoadContext: %w, %s refers to an unknown field [%s]", ErrInvalidTagFormat, g.rule, paths[i])
+			}
+			if isSet {
+				set = append(set, paths[i])
+			} else {
+				unset = append(unset, paths[i])
+			}
+		}
+		list := strings.Join(paths, ", ")
+		n := len(broken)
+		switch {
+		case g.rule == GroupRequireAll && len(set) > 0 && len(unset) > 0:
+			broken = append(broken, fmt.Sprintf("%s must be set together, missing %s", list, strings.Join(unset, ", ")))
+		case g.rule == GroupRequireAny && len(set) == 0:
+			broken = append(broken, fmt.Sprintf("one of %s must be set", list))
+		case g.rule == GroupExactlyOne && len(set) == 0:
+			broken = append(broken, fmt.Sprintf("exactly one of %s must be set, none is", list))
+		case (g.rule == GroupExactlyOne || g.rule == GroupExclusive) && len(set) > 1:
+			broken = append(broken, fmt.Sprintf("at most one of %s may be set, got %s", list, strings.Join(set, ", ")))
+		}
+		if len(broken) > n {
+			members = append(members, paths...)
+		}
+	}
+	if len(broken) > 0 {
+		err := fmt.Errorf("configurator/LoadContext: %w: %s", ErrGroup, strings.Join(broken, "; "))
+		return newFieldError(ErrGroup, err, "", members...)
+	}
+	return nil
+}
+
+// memberSet reports whether path is a field or a section, and whether it,
+// or a field of the section, is set.
+func memberSet(fields []FieldInfo, path string) (found, set bool) {
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || f.Path() != path && !strings.HasPrefix(f.Path(), path+".") {
+			continue
+		}
+		found = true
+		if !f.disabled && !leaf(f.val).IsZero() {
+			return true, true
+		}
+	}
+	return found, false
+}
+
+// lintGroups reports the groups of f that aren't on a section, or refer to
+// fields the section doesn't have.
+func lintGroups(f *fieldInfo, fields []FieldInfo, section bool) []Problem {
+	var problems []Problem
+	for _, g := range f.tag.groups {
+		if !section {
+			problems = append(problems, Problem{Path: f.Path(), Message: fmt.Sprintf("%s needs a section", g.rule)})
+			continue
+		}
+		for _, name := range g.names {
+			if found, _ := memberSet(fields, f.Path()+"."+name); !found {
+				problems = append(problems, Problem{Path: f.Path(), Message: fmt.Sprintf("%s refers to an unknown field %s", g.rule, name)})
+			}
+		}
+	}
+	return problems
+}
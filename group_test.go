@@ -0,0 +1,78 @@
+package configurator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldGroups(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		TLS struct {
+			CertFile string `config:"env"`
+			KeyFile  string `config:"env"`
+		} `config:"requireAll=CertFile|KeyFile"`
+		Auth struct {
+			Password     string `config:"env"`
+			PasswordFile string `config:"env"`
+		} `config:"exactlyOne=Password|PasswordFile"`
+		Cache struct {
+			Redis struct {
+				Addr string `config:"env"`
+			}
+			Memcached string `config:"env"`
+		} `config:"exclusive=Redis|Memcached"`
+		Debug bool `config:"env"`
+		Trace struct {
+			Endpoint string `config:"env"`
+			Sampler  string `config:"env"`
+		} `config:"if=Debug,requireAny=Endpoint|Sampler"`
+		Region string `config:"env"`
+	}
+	load := func(environ ...string) error {
+		c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ),
+			WithFieldGroup(GroupRequireAny, "Region", "Cache"))
+		return c.Load(&example{})
+	}
+
+	assert.NoError(t, load("AUTH_PASSWORD=x", "REGION=eu"))
+	assert.NoError(t, load("AUTH_PASSWORDFILE=/run/pw", "TLS_CERTFILE=c", "TLS_KEYFILE=k", "CACHE_MEMCACHED=m"))
+
+	err := load("TLS_CERTFILE=c", "AUTH_PASSWORD=x", "AUTH_PASSWORDFILE=/run/pw", "CACHE_REDIS_ADDR=r", "CACHE_MEMCACHED=m")
+	if assert.True(t, errors.Is(err, ErrGroup)) {
+		assert.Equal(t, "configurator/LoadContext: field group not satisfied: "+
+			"TLS.CertFile, TLS.KeyFile must be set together, missing TLS.KeyFile; "+
+			"at most one of Auth.Password, Auth.PasswordFile may be set, got Auth.Password, Auth.PasswordFile; "+
+			"at most one of Cache.Redis, Cache.Memcached may be set, got Cache.Redis, Cache.Memcached", err.Error())
+	}
+	err = load("DEBUG=true")
+	if assert.True(t, errors.Is(err, ErrGroup)) {
+		assert.Contains(t, err.Error(), "exactly one of Auth.Password, Auth.PasswordFile must be set, none is")
+		assert.Contains(t, err.Error(), "one of Trace.Endpoint, Trace.Sampler must be set")
+		assert.Contains(t, err.Error(), "one of Region, Cache must be set")
+	}
+
+	c := NewConfigurator(WithFileProvider(""), WithFieldGroup(GroupExclusive, "Region", "Nope"))
+	assert.True(t, errors.Is(c.Load(&example{}), ErrInvalidTagFormat))
+
+	type invalid struct {
+		Name string `config:"requireAll=Name"`
+	}
+	assert.True(t, errors.Is(NewConfigurator(WithFileProvider("")).Load(&invalid{}), ErrInvalidTagFormat))
+}
+
+func TestFieldGroups_Lint(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Name string `config:"requireAny=A|B"`
+		TLS  struct {
+			CertFile string
+		} `config:"requireAll=CertFile|KeyFile"`
+	}
+	assert.Equal(t, []Problem{
+		{Path: "Name", Message: "requireAny needs a section"},
+		{Path: "TLS", Message: "requireAll refers to an unknown field KeyFile"},
+	}, LintTags(&example{}))
+}
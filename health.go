@@ -0,0 +1,69 @@
+package configurator
+
+import "time"
+
+// SourceHealth is how a configured source fared in a load.
+type SourceHealth struct {
+	Name string
+	// Required sources fail the load when they can't be reached, the others
+	// are best effort: see BestEffort.
+	Required  bool
+	Reachable bool
+	// Keys is the number of fields the source set, including the ones a
+	// later source set again.
+	Keys int
+	// Version is the version the source reported, if it is a Versioner.
+	Version string
+	Fetch   time.Duration
+	Apply   time.Duration
+	Err     error
+}
+
+// HealthReport describes the last load, successful or not, source by
+// source, in the configured order.
+type HealthReport struct {
+	LoadEvent
+	Sources []SourceHealth
+}
+
+// Healthy reports whether the last load succeeded with every required
+// source reachable.
+func (r HealthReport) Healthy() bool {
+	if r.Err != nil || r.Start.IsZero() {
+		return false
+	}
+	for _, s := range r.Sources {
+		if s.Required && !s.Reachable {
+			return false
+		}
+	}
+	return true
+}
+
+// Health returns the report of the last load.
+func (c *Configurator) Health() HealthReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return HealthReport{LoadEvent: c.last, Sources: append([]SourceHealth{}, c.sources...)}
+}
+
+// BestEffort wraps p so that a load going without it, when it fails, only
+// reports a warning. Sources are required otherwise.
+func BestEffort(p Provider) Fetcher {
+	return ProviderWithPolicy(p, Policy{Failure: FailureOptional})
+}
+
+func newSources(providers []Provider) []SourceHealth {
+	sources := make([]SourceHealth, len(providers))
+	for i, p := range providers {
+		sources[i] = SourceHealth{Name: providerName(p), Required: required(p)}
+	}
+	return sources
+}
+
+// required reports whether a failing p fails the first load. A cached
+// provider has nothing to fall back on then.
+func required(p Provider) bool {
+	pp, ok := p.(*policyProvider)
+	return !ok || pp.policy.Failure != FailureOptional
+}
@@ -0,0 +1,57 @@
+package configurator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	type example struct {
+		Name string `config:"env"`
+		Port int    `config:"env,default=80"`
+	}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{"NAME=env"}),
+		WithProvider(BestEffort(&flakyFetcher{failures: 1, value: "remote"})),
+		WithDefaultProvider(),
+	)
+	assert.False(t, c.Health().Healthy())
+
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	rep := c.Health()
+	assert.True(t, rep.Healthy())
+	assert.True(t, rep.Degraded)
+	assert.Len(t, rep.Sources, 3)
+
+	env, remote, def := rep.Sources[0], rep.Sources[1], rep.Sources[2]
+	assert.True(t, env.Required)
+	assert.True(t, env.Reachable)
+	assert.Equal(t, 1, env.Keys)
+	assert.NoError(t, env.Err)
+
+	assert.False(t, remote.Required)
+	assert.False(t, remote.Reachable)
+	assert.Equal(t, 0, remote.Keys)
+	assert.Error(t, remote.Err)
+
+	assert.Equal(t, 1, def.Keys)
+
+	// reachable on the next load
+	assert.NoError(t, c.Load(&cfg))
+	rep = c.Health()
+	assert.True(t, rep.Sources[1].Reachable)
+	assert.Equal(t, 1, rep.Sources[1].Keys)
+	assert.Equal(t, "envremote", cfg.Name)
+
+	c = NewConfigurator(WithFileProvider(""), WithProvider(&flakyFetcher{failures: 1}))
+	assert.Error(t, c.Load(&cfg))
+	rep = c.Health()
+	assert.False(t, rep.Healthy())
+	assert.Len(t, rep.Sources, 1)
+	assert.True(t, rep.Sources[0].Required)
+	assert.False(t, rep.Sources[0].Reachable)
+}
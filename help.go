@@ -0,0 +1,214 @@
+package configurator
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Help is what the help of the command line shows, as passed to the
+// template of WithHelp.
+type Help struct {
+	Program  string
+	Sections []HelpSection
+	// All is set for -help-all, showing the advanced flags too.
+	All bool
+	// Advanced is the number of advanced flags the help leaves out.
+	Advanced int
+}
+
+// HelpSection is the flags of the fields of a section of the struct.
+type HelpSection struct {
+	// Name is the path of the section, empty for the fields of the struct
+	// itself.
+	Name  string
+	Flags []HelpFlag
+}
+
+// HelpFlag is a flag of the help. Default is masked for secret fields.
+type HelpFlag struct {
+	Name     string
+	Type     string
+	Usage    string
+	Path     string
+	ENV      string
+	Default  string
+	Secret   bool
+	Advanced bool
+}
+
+// WithHelp replaces the usage printed by -help, or on a bad flag, with the
+// help of the flag provider: the flags grouped by the section of their
+// field, with their env var and default, wrapped to the width of the
+// terminal set in COLUMNS, 80 columns otherwise, and in bold on a terminal
+// unless NO_COLOR is set. When tmpl isn't nil, it renders the Help
+// instead. Flags of fields tagged `advanced` are only shown by -help-all,
+// and those of fields tagged `hidden` never are. -help-all makes Load
+// return an error wrapping flag.ErrHelp, for the caller to exit.
+func WithHelp(tmpl *template.Template) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.help = &flagHelp{tmpl: tmpl}
+	}
+}
+
+// flagHelp is the help of a flag provider.
+type flagHelp struct {
+	tmpl *template.Template
+	// env is the env provider, to name the env vars of flags.
+	env   *envProvider
+	flags []HelpFlag
+	// all is set by -help-all.
+	all bool
+}
+
+const helpAllFlag = "help-all"
+
+// add records the flag k of the field fi.
+func (h *flagHelp) add(k string, fi FieldInfo) {
+	f, ok := fi.(*fieldInfo)
+	if ok && f.hidden() {
+		return
+	}
+	hf := HelpFlag{Name: k, Path: fi.Path(), Default: fi.DefVal(), Secret: fi.Secret(), Advanced: ok && f.advanced()}
+	if f := flag.Lookup(k); f != nil {
+		hf.Type, hf.Usage = flag.UnquoteUsage(f)
+	}
+	if h.env != nil {
+		hf.ENV = h.env.normalize(fi.ENVKey())
+	}
+	if hf.Secret && hf.Default != "" {
+		hf.Default = secretMask
+	}
+	h.flags = append(h.flags, hf)
+}
+
+func (h *flagHelp) help() Help {
+	help := Help{Program: flag.CommandLine.Name(), All: h.all}
+	index := make(map[string]int)
+	for _, hf := range h.flags {
+		if hf.Advanced && !h.all {
+			help.Advanced++
+			continue
+		}
+		name := ""
+		if i := strings.LastIndex(hf.Path, "."); i > 0 {
+			name = hf.Path[:i]
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(help.Sections)
+			index[name] = i
+			help.Sections = append(help.Sections, HelpSection{Name: name})
+		}
+		help.Sections[i].Flags = append(help.Sections[i].Flags, hf)
+	}
+	return help
+}
+
+// usage writes the help to the output of the command line.
+func (h *flagHelp) usage() {
+	w := flag.CommandLine.Output()
+	if h.tmpl != nil {
+		if err := h.tmpl.Execute(w, h.help()); err != nil {
+			fmt.Fprintln(w, err)
+		}
+		return
+	}
+	writeHelp(w, h.help(), helpWidth(), colorOutput(w))
+}
+
+const ansiBold = "\x1b[1m"
+
+func writeHelp(w io.Writer, help Help, width int, color bool) {
+	bold := func(s string) string {
+		if !color {
+			return s
+		}
+		return ansiBold + s + ansiReset
+	}
+	fmt.Fprintf(w, "Usage of %s:\n", help.Program)
+	for _, s := range help.Sections {
+		fmt.Fprintln(w)
+		if s.Name != "" {
+			fmt.Fprintln(w, bold(s.Name+":"))
+		}
+		for _, hf := range s.Flags {
+			line := "  " + bold("-"+hf.Name)
+			if hf.Type != "" {
+				line += " " + hf.Type
+			}
+			fmt.Fprintln(w, line)
+			var desc []string
+			if hf.Usage != "" {
+				desc = append(desc, hf.Usage)
+			}
+			if hf.Default != "" {
+				desc = append(desc, "default "+hf.Default)
+			}
+			if hf.ENV != "" {
+				desc = append(desc, "env "+hf.ENV)
+			}
+			for _, l := range wrap(strings.Join(desc, "; "), width-6) {
+				fmt.Fprintf(w, "      %s\n", l)
+			}
+		}
+	}
+	if help.Advanced > 0 {
+		fmt.Fprintf(w, "\n%d advanced flags not shown, see -%s\n", help.Advanced, helpAllFlag)
+	}
+}
+
+// hidden reports whether the flag of the field is left out of the help.
+func (f *fieldInfo) hidden() bool {
+	for p := f; p != nil; p = p.parent {
+		if p.tag.hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// advanced reports whether the flag of the field is only shown by
+// -help-all.
+func (f *fieldInfo) advanced() bool {
+	for p := f; p != nil; p = p.parent {
+		if p.tag.advanced {
+			return true
+		}
+	}
+	return false
+}
+
+// helpWidth returns the width of the terminal set in COLUMNS, or 80.
+func helpWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 20 {
+		return n
+	}
+	return 80
+}
+
+// wrap splits s into lines of at most width bytes, breaking between words.
+// A word longer than width gets a line of its own.
+func wrap(s string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(s) {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,109 @@
+package configurator
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHelp(t *testing.T) {
+	type server struct {
+		Addr    string `config:"flag,env,default=:80"`
+		Workers int    `config:"flag"`
+	}
+	type example struct {
+		Mode   string `config:"flag,env,enum=fast,slow,default=fast"`
+		Token  string `config:"flag,secret,default=changeme"`
+		Server server
+	}
+	resetForTesting()
+	var out strings.Builder
+	flag.CommandLine.SetOutput(&out)
+	os.Args = []string{"jhon", "-h"}
+	c := NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithENVProvider("APP"), WithHelp(nil))
+	err := c.Load(&example{})
+	assert.True(t, errors.Is(err, flag.ErrHelp))
+	assert.Equal(t, `Usage of jhon:
+
+  -mode fast|slow
+      one of fast|slow; default fast; env APP_MODE
+  -token string
+      default ******
+
+Server:
+  -server-addr string
+      default :80; env APP_SERVER_ADDR
+  -server-workers int
+`, out.String())
+
+	resetForTesting()
+	out.Reset()
+	flag.CommandLine.SetOutput(&out)
+	tmpl := template.Must(template.New("help").Parse(`{{range .Sections}}[{{.Name}}]{{range .Flags}} {{.Name}}{{end}}{{end}}`))
+	c = NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithHelp(tmpl))
+	assert.True(t, errors.Is(c.Load(&example{}), flag.ErrHelp))
+	assert.Equal(t, "[] mode token[Server] server-addr server-workers", out.String())
+}
+
+func TestWriteHelp(t *testing.T) {
+	help := Help{Program: "api", Sections: []HelpSection{{Name: "Server", Flags: []HelpFlag{
+		{Name: "server-tls-ciphers", Type: "string", Usage: "repeat the flag for each cipher suite of the TLS connections", ENV: "SERVER_TLS_CIPHERS"},
+	}}}}
+	var out strings.Builder
+	writeHelp(&out, help, 40, true)
+	assert.Equal(t, "Usage of api:\n\n"+
+		ansiBold+"Server:"+ansiReset+"\n"+
+		"  "+ansiBold+"-server-tls-ciphers"+ansiReset+" string\n"+
+		"      repeat the flag for each cipher\n"+
+		"      suite of the TLS connections; env\n"+
+		"      SERVER_TLS_CIPHERS\n", out.String())
+}
+
+func TestWithHelp_Tiers(t *testing.T) {
+	type tuning struct {
+		Buffer int `config:"flag"`
+	}
+	type example struct {
+		Port   int    `config:"flag"`
+		Trace  bool   `config:"flag,advanced"`
+		Debug  string `config:"flag,hidden"`
+		Tuning tuning `config:"advanced"`
+	}
+	resetForTesting()
+	var out strings.Builder
+	flag.CommandLine.SetOutput(&out)
+	os.Args = []string{"jhon", "-help"}
+	c := NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithHelp(nil))
+	assert.True(t, errors.Is(c.Load(&example{}), flag.ErrHelp))
+	assert.Equal(t, "Usage of jhon:\n\n  -port int\n\n2 advanced flags not shown, see -help-all\n", out.String())
+
+	resetForTesting()
+	out.Reset()
+	flag.CommandLine.SetOutput(&out)
+	os.Args = []string{"jhon", "-help-all"}
+	c = NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithHelp(nil))
+	assert.True(t, errors.Is(c.Load(&example{}), flag.ErrHelp))
+	assert.Equal(t, "Usage of jhon:\n\n  -port int\n  -trace\n\nTuning:\n  -tuning-buffer int\n", out.String())
+
+	resetForTesting()
+	os.Args = []string{"jhon", "-debug=on"}
+	cfg := &example{}
+	assert.NoError(t, NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithHelp(nil)).Load(cfg))
+	assert.Equal(t, "on", cfg.Debug, "hidden flags still set their field")
+
+	// -help-all is left to the caller even on a command line exiting on
+	// errors, and isn't defined on it
+	flag.CommandLine = flag.NewFlagSet("jhon", flag.ExitOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+	os.Args = []string{"jhon", "-port=80", "-help-all"}
+	defer func(fn func(int)) { exit = fn }(exit)
+	exit = func(int) { t.Fatal("exited") }
+	assert.True(t, errors.Is(NewConfigurator(WithFileProvider(""), WithFlagProvider(), WithHelp(nil)).Load(&example{}), flag.ErrHelp))
+	assert.Nil(t, flag.Lookup(helpAllFlag))
+}
@@ -0,0 +1,142 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrNoRevision is returned by Rollback for a revision not in the history.
+var ErrNoRevision = errors.New("no such revision")
+
+// Revision is a configuration applied by a successful load, as kept by
+// WithHistory. Values of fields tagged secret are masked.
+type Revision struct {
+	// ID numbers the revisions of a configurator from 1.
+	ID       int               `json:"id"`
+	Time     time.Time         `json:"time"`
+	Checksum string            `json:"checksum"`
+	Changes  []Change          `json:"changes,omitempty"`
+	Config   map[string]string `json:"config"`
+}
+
+// WithHistory keeps the last n configurations applied, for History and
+// Rollback, and the admin handler serving them.
+func WithHistory(n int) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.history = n
+	}
+}
+
+// revision is a Revision with the values to roll back to.
+type revision struct {
+	Revision
+	// raw are the strings the sources set, by path, for Rollback to set
+	// again. Fields no source set have none.
+	raw map[string]string
+	// values are copies of the values loaded, to tell those that changed.
+	values map[string]reflect.Value
+}
+
+// History returns the configurations kept by WithHistory, the last one
+// applied last.
+func (c *Configurator) History() []Revision {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	revisions := make([]Revision, len(c.revisions))
+	for i, r := range c.revisions {
+		revisions[i] = r.Revision
+	}
+	return revisions
+}
+
+// Rollback reloads v, the struct loaded by the configurator, with the
+// fields whose value differs from the revision id of the history set back
+// to it, and returns the fresh value as Reload does. The values rolled back
+// are kept as overrides, as those of the admin handler, so that later
+// reloads don't undo them until they are removed. Fields no source set in
+// the revision, such as nil pointers, are left to the sources. When the
+// reload fails nothing changes.
+func (c *Configurator) Rollback(ctx context.Context, v interface{}, id int) (interface{}, error) {
+	return c.rollback(ctx, v, id, nil)
+}
+
+func (c *Configurator) rollback(ctx context.Context, v interface{}, id int, store OverrideStore) (interface{}, error) {
+	c.patchMu.Lock()
+	defer c.patchMu.Unlock()
+
+	c.mu.RLock()
+	var target *revision
+	for i := range c.revisions {
+		if c.revisions[i].ID == id {
+			target = &c.revisions[i]
+		}
+	}
+	prev := c.overrides
+	next := make(overrides, len(prev))
+	for k, val := range prev {
+		next[k] = val
+	}
+	if target != nil {
+		for path, val := range target.raw {
+			if cur, ok := c.values[path]; !ok || !reflect.DeepEqual(cur.Interface(), target.values[path].Interface()) {
+				next[path] = val
+			}
+		}
+	}
+	c.mu.RUnlock()
+	if target == nil {
+		return nil, fmt.Errorf("configurator/Rollback: %w [%d]", ErrNoRevision, id)
+	}
+
+	if err := c.saveOverrides(ctx, store, next); err != nil {
+		return nil, fmt.Errorf("configurator/Rollback: %w", err)
+	}
+	fresh, err := c.Reload(ctx, v)
+	if err != nil {
+		if err := c.saveOverrides(ctx, store, prev); err != nil {
+			c.logger.Error("configurator: restoring overrides failed", "error", err)
+		}
+		return nil, err
+	}
+	c.logger.Info("configurator: rolled back", "revision", id)
+	return fresh, nil
+}
+
+// record adds the configuration of a load, and the values contribs the
+// sources set, to the history. c.mu is held.
+func (c *Configurator) record(r Revision, values map[string]reflect.Value, contribs []SourceValues) {
+	if c.history <= 0 {
+		return
+	}
+	r.ID = 1
+	if n := len(c.revisions); n > 0 {
+		r.ID = c.revisions[n-1].ID + 1
+	}
+	raw := make(map[string]string, len(values))
+	for _, sv := range contribs {
+		for path, v := range sv.Values {
+			raw[path] = v
+		}
+	}
+	kept := make(map[string]reflect.Value, len(values))
+	r.Config = make(map[string]string, len(values))
+	for _, s := range c.schema {
+		v, ok := values[s.Path]
+		if !ok {
+			continue
+		}
+		// a copy, as the replaced values of a load are shredded
+		kept[s.Path] = copyValue(v)
+		r.Config[s.Path] = formatValue(v)
+		if s.Secret {
+			r.Config[s.Path] = secretMask
+		}
+	}
+	c.revisions = append(c.revisions, revision{Revision: r, raw: raw, values: kept})
+	if len(c.revisions) > c.history {
+		c.revisions = append(c.revisions[:0:0], c.revisions[len(c.revisions)-c.history:]...)
+	}
+}
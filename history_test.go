@@ -0,0 +1,124 @@
+package configurator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	type example struct {
+		Workers  Dynamic[int] `config:"env"`
+		Password string       `config:"env,secret"`
+	}
+	env := map[string]string{"WORKERS": "4", "PASSWORD": "one"}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithLookupEnv(lookup), WithHistory(2))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	env["WORKERS"] = "8"
+	_, err := c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	env["WORKERS"], env["PASSWORD"] = "64", "two"
+	_, err = c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 64, cfg.Workers.Get())
+
+	history := c.History()
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, 2, history[0].ID)
+		assert.Equal(t, 3, history[1].ID)
+		assert.Equal(t, map[string]string{"Workers": "8", "Password": secretMask}, history[0].Config)
+		assert.Equal(t, []Change{
+			{Key: "Password", Old: secretMask, New: secretMask, Source: "env"},
+			{Key: "Workers", Old: "8", New: "64", Source: "env"},
+		}, history[1].Changes)
+		assert.Equal(t, c.Checksum(false), history[1].Checksum)
+	}
+
+	fresh, err := c.Rollback(context.Background(), cfg, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, cfg.Workers.Get())
+	assert.Equal(t, "one", fresh.(*example).Password)
+	_, err = c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, cfg.Workers.Get(), "reloads keep the rollback")
+
+	_, err = c.Rollback(context.Background(), cfg, 1)
+	assert.True(t, errors.Is(err, ErrNoRevision))
+}
+
+func TestRollback_Unset(t *testing.T) {
+	type example struct {
+		Workers int            `config:"env"`
+		Limit   *int           `config:"env"`
+		Timeout *time.Duration `config:"env"`
+		Hosts   []string       `config:"env"`
+		At      time.Time      `config:"env"`
+	}
+	env := map[string]string{"WORKERS": "4", "HOSTS": "a,b", "AT": "2026-10-17T03:07:46.5Z"}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithLookupEnv(lookup), WithHistory(4))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	env = map[string]string{"WORKERS": "8", "LIMIT": "5", "TIMEOUT": "1s", "HOSTS": "c", "AT": "2026-10-17T03:07:46Z"}
+	_, err := c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+
+	// the pointers unset in revision 1 are left to the sources
+	fresh, err := c.Rollback(context.Background(), cfg, 1)
+	assert.NoError(t, err)
+	limit, timeout := 5, time.Second
+	assert.Equal(t, &example{Workers: 4, Limit: &limit, Timeout: &timeout, Hosts: []string{"a", "b"},
+		At: time.Date(2026, 10, 17, 3, 7, 46, 5e8, time.UTC)}, fresh)
+}
+
+func TestHandler_History(t *testing.T) {
+	type example struct {
+		Workers Dynamic[int] `config:"env"`
+	}
+	env := map[string]string{"WORKERS": "4"}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithLookupEnv(lookup), WithHistory(5))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	env["WORKERS"] = "64"
+	_, err := c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+
+	h := c.Handler(WithPatch(cfg, nil))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?history", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var history []Revision
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &history))
+	assert.Len(t, history, 2)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?rollback=1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 4, cfg.Workers.Get())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?rollback=9", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?rollback=1", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,124 @@
+package configurator
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// LoadPlan is what a load reads, for WithPreLoad hooks to change.
+type LoadPlan struct {
+	// Providers are the sources of the load, each overriding the ones
+	// before it.
+	Providers []Provider
+	// Environ is the env the env provider, the expandenv transform and
+	// path fields read, in the form of os.Environ. It is nil, and the env
+	// is read as is, with WithLookupEnv.
+	Environ []string
+}
+
+// WithPreLoad runs fn before every load, in the order of the options, to
+// change the sources of the load or the env snapshot it reads, such as
+// adding a provider found in a manifest. Changes apply to that load only.
+func WithPreLoad(fn func(plan *LoadPlan) error) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.preLoad = append(co.preLoad, fn)
+	}
+}
+
+// WithPostLoad runs fn on the struct being loaded once every source ran,
+// and secrets and paths were resolved, before required fields, enums and
+// Validate are checked, to normalize fields or derive some from others,
+// such as AdvertiseAddr from BindAddr. An error fails the load.
+func WithPostLoad(fn func(v interface{}) error) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.postLoad = append(co.postLoad, fn)
+	}
+}
+
+// loadPlan is what a load reads once the pre-load hooks ran.
+type loadPlan struct {
+	providers []Provider
+	scopes    []string
+	lookupEnv func(string) (string, bool)
+}
+
+func (c *Configurator) plan() (loadPlan, error) {
+	lp := loadPlan{providers: c.providers, scopes: c.scopes, lookupEnv: c.lookupEnv}
+	if len(c.preLoad) == 0 {
+		return lp, nil
+	}
+	plan := &LoadPlan{Providers: append([]Provider{}, c.providers...)}
+	if c.environ != nil {
+		plan.Environ = append([]string{}, c.environ()...)
+	}
+	for _, fn := range c.preLoad {
+		if err := fn(plan); err != nil {
+			return lp, fmt.Errorf("configurator/LoadContext: pre-load: %w", err)
+		}
+	}
+
+	lp.providers = plan.Providers
+	lp.scopes = make([]string, len(lp.providers))
+	for i, p := range lp.providers {
+		for j, q := range c.providers {
+			if sameProvider(p, q) {
+				lp.scopes[i] = c.scopes[j]
+				break
+			}
+		}
+	}
+	if plan.Environ == nil {
+		return lp, nil
+	}
+	environ := plan.Environ
+	lp.lookupEnv = environLookup(environ)
+	if c.envFold {
+		lp.lookupEnv = foldEnvironLookup(environ)
+	}
+	for i, p := range lp.providers {
+		if ep, ok := p.(*envProvider); ok {
+			cp := *ep
+			cp.lookup = lp.lookupEnv
+			cp.environ = func() []string { return environ }
+			lp.providers[i] = &cp
+		}
+	}
+	return lp, nil
+}
+
+// sameProvider reports whether a and b are the same provider: equal, or
+// the same map or func.
+func sameProvider(a, b Provider) bool {
+	ta := reflect.TypeOf(a)
+	if ta != reflect.TypeOf(b) {
+		return false
+	}
+	switch ta.Kind() {
+	case reflect.Map, reflect.Func:
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+	return ta.Comparable() && a == b
+}
+
+func (c *Configurator) postLoad(v interface{}) error {
+	for _, fn := range c.postLoadFns {
+		if err := fn(v); err != nil {
+			return fmt.Errorf("configurator/LoadContext: post-load: %w", err)
+		}
+	}
+	return nil
+}
+
+// environFunc returns the func listing the env the configurator reads, nil
+// when it can't be listed.
+func environFunc(opts *ConfiguratorOptions) func() []string {
+	switch {
+	case opts.customLookup:
+		return nil
+	case opts.environ != nil:
+		environ := opts.environ
+		return func() []string { return environ }
+	}
+	return os.Environ
+}
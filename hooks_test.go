@@ -0,0 +1,101 @@
+package configurator
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPreLoad(t *testing.T) {
+	type example struct {
+		Name    string `config:"env"`
+		Region  string `config:"env,default=eu"`
+		Secrets struct {
+			Token string
+		}
+		Home string `config:"env,path"`
+	}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithSourceScope(pathProvider{"Token": "s3cret"}, "Secrets"),
+		WithDefaultProvider(),
+		WithEnviron([]string{"NAME=web", "HOME=/srv"}),
+		WithPreLoad(func(plan *LoadPlan) error {
+			assert.Len(t, plan.Providers, 3)
+			assert.Equal(t, []string{"NAME=web", "HOME=/srv"}, plan.Environ)
+			plan.Environ = append(plan.Environ, "NAME=api", "DIR=/opt")
+			plan.Providers = append(plan.Providers, pathProvider{"Region": "us"})
+			return nil
+		}),
+		WithPreLoad(func(plan *LoadPlan) error {
+			plan.Environ = append(plan.Environ, "HOME=${DIR}/app")
+			return nil
+		}),
+	)
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, "us", cfg.Region)
+	assert.Equal(t, "s3cret", cfg.Secrets.Token)
+	assert.Equal(t, "/opt/app", cfg.Home)
+
+	// the changes apply to that load only
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"NAME=web"}), WithPreLoad(func(plan *LoadPlan) error {
+		plan.Environ[0] = "NAME=api"
+		return nil
+	}))
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Equal(t, "NAME=web", c.environ()[0])
+
+	fail := errors.New("no manifest")
+	c = NewConfigurator(WithFileProvider(""), WithPreLoad(func(*LoadPlan) error { return fail }))
+	err := c.Load(&example{})
+	assert.True(t, errors.Is(err, fail))
+
+	c = NewConfigurator(WithFileProvider(""), WithLookupEnv(func(string) (string, bool) { return "", false }), WithPreLoad(func(plan *LoadPlan) error {
+		assert.Nil(t, plan.Environ)
+		return nil
+	}))
+	assert.NoError(t, c.Load(&example{}))
+}
+
+func TestWithPostLoad(t *testing.T) {
+	type example struct {
+		BindAddr      string `config:"default=0.0.0.0:8080"`
+		AdvertiseAddr string `config:"required"`
+		Mode          string `config:"enum=fast,slow"`
+	}
+	deriveAddr := func(v interface{}) error {
+		cfg := v.(*example)
+		if cfg.AdvertiseAddr != "" {
+			return nil
+		}
+		_, port, err := net.SplitHostPort(cfg.BindAddr)
+		if err != nil {
+			return err
+		}
+		cfg.AdvertiseAddr = net.JoinHostPort("10.0.0.1", port)
+		return nil
+	}
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithPostLoad(deriveAddr), WithPostLoad(func(v interface{}) error {
+		cfg := v.(*example)
+		cfg.Mode = strings.ToLower(cfg.Mode)
+		return nil
+	}))
+	cfg := &example{Mode: "FAST"}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "10.0.0.1:8080", cfg.AdvertiseAddr)
+	assert.Equal(t, "fast", cfg.Mode)
+
+	cfg = &example{BindAddr: "nowhere"}
+	c = NewConfigurator(WithFileProvider(""), WithPostLoad(deriveAddr))
+	err := c.Load(cfg)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "configurator/LoadContext: post-load: address nowhere")
+	}
+}
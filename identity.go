@@ -0,0 +1,35 @@
+package configurator
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// Identity supplies the TLS client configuration remote sources present to
+// their servers, such as a certificate from a TLSConfig or a SPIFFE SVID,
+// so that they work where every connection must be mutually authenticated.
+type Identity interface {
+	ClientTLSConfig() (*tls.Config, error)
+}
+
+var _ Identity = &TLSConfig{}
+
+// ClientTLSConfig returns Config, so that a TLSConfig section can be the
+// identity of remote sources. Rotated certificates are presented after a
+// reload.
+func (t *TLSConfig) ClientTLSConfig() (*tls.Config, error) {
+	return t.Config()
+}
+
+// NewHTTPClient returns an HTTP client connecting with the TLS
+// configuration of id, for sources built on HTTP.
+func NewHTTPClient(id Identity) (*http.Client, error) {
+	cfg, err := id.ClientTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("configurator/NewHTTPClient: %w", err)
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = cfg
+	return &http.Client{Transport: tr}, nil
+}
@@ -0,0 +1,46 @@
+package configurator
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeTestCert(t, dir, "localhost")
+	id := &TLSConfig{
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+
+	// the server requires a client certificate signed by the same CA
+	serverTLS, err := (&TLSConfig{CertFile: id.CertFile, KeyFile: id.KeyFile, CAFile: id.CAFile, ClientAuth: "require-and-verify"}).Config()
+	assert.NoError(t, err)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.TLS = serverTLS
+	srv.StartTLS()
+	defer srv.Close()
+
+	_, err = http.Get(srv.URL)
+	assert.Error(t, err)
+
+	client, err := NewHTTPClient(id)
+	assert.NoError(t, err)
+	resp, err := client.Get(srv.URL)
+	if assert.NoError(t, err) {
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	_, err = NewHTTPClient(&TLSConfig{CertFile: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
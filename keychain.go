@@ -0,0 +1,71 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const keychainProviderName = "keychain"
+
+// Keychain reads the secrets the operating system keeps for the user. Get
+// returns ErrNotFound when there is no secret for service and account.
+type Keychain interface {
+	Get(ctx context.Context, service, account string) (string, error)
+}
+
+// OSKeychain returns the keychain of the operating system: the login
+// keychain through the security tool on macOS, the Credential Manager on
+// Windows and the Secret Service through secret-tool elsewhere, finding
+// nothing where secret-tool isn't installed. Secrets are
+// looked up the way github.com/zalando/go-keyring stores them, so it can
+// be used to add them.
+func OSKeychain() Keychain {
+	return osKeychain{}
+}
+
+type keychainProvider struct {
+	keychain Keychain
+}
+
+// WithKeychain sets the fields tagged `keychain=service/account` from k, or
+// from OSKeychain when k is nil, so that secrets of developer machines
+// don't have to live in files. Fields without a secret in the keychain are
+// left to the other providers. Like WithProvider, it runs after the file,
+// env and flag providers.
+func WithKeychain(k Keychain) ConfiguratorOption {
+	if k == nil {
+		k = OSKeychain()
+	}
+	return WithProvider(&keychainProvider{keychain: k})
+}
+
+func (p *keychainProvider) Provide(v interface{}, si StructInfo) error {
+	return p.ProvideContext(context.Background(), v, si)
+}
+
+func (p *keychainProvider) ProvideContext(ctx context.Context, v interface{}, si StructInfo) error {
+	for _, fi := range si.Fields() {
+		f, ok := fi.(*fieldInfo)
+		if !ok || f.tag.keychain == "" {
+			continue
+		}
+		i := strings.LastIndex(f.tag.keychain, "/")
+		secret, err := p.keychain.Get(ctx, f.tag.keychain[:i], f.tag.keychain[i+1:])
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("keychainProvider/Provide: %w [%s]", err, f.tag.keychain)
+		}
+		if err := fi.Set(secret); err != nil {
+			return fmt.Errorf("keychainProvider/Provide: %w [%s]", err, fi.Name())
+		}
+	}
+	return nil
+}
+
+func (p *keychainProvider) String() string {
+	return keychainProviderName
+}
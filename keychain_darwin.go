@@ -0,0 +1,25 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit status of security for missing items.
+const errSecItemNotFound = 44
+
+type osKeychain struct{}
+
+func (osKeychain) Get(ctx context.Context, service, account string) (string, error) {
+	out, err := exec.CommandContext(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == errSecItemNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
@@ -0,0 +1,56 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapKeychain map[string]string
+
+func (k mapKeychain) Get(_ context.Context, service, account string) (string, error) {
+	if k == nil {
+		return "", errors.New("locked")
+	}
+	s, ok := k[service+"/"+account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s, nil
+}
+
+func TestKeychainProvider(t *testing.T) {
+	type example struct {
+		Token    string `config:"keychain=github.com/dev"`
+		Password string `config:"keychain=example.com/db/admin,default=changeme"`
+		Port     int    `config:"keychain=example.com/port"`
+	}
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithKeychain(mapKeychain{
+		"github.com/dev":       "ghp_123",
+		"example.com/db/admin": "s3cret",
+	}))
+	var v example
+	assert.NoError(t, c.Load(&v))
+	assert.Equal(t, example{Token: "ghp_123", Password: "s3cret"}, v)
+	assert.Equal(t, "keychain", c.Provenance()["Token"])
+	for _, s := range c.report().Schema {
+		assert.True(t, s.Secret, s.Path)
+	}
+
+	c = NewConfigurator(WithFileProvider(""), WithKeychain(mapKeychain{"example.com/port": "http"}))
+	assert.Error(t, c.Load(&example{}))
+
+	c = NewConfigurator(WithFileProvider(""), WithKeychain(mapKeychain(nil)))
+	err := c.Load(&example{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "locked")
+	}
+
+	type invalid struct {
+		Token string `config:"keychain=github.com"`
+	}
+	_, err = getStructInfo(&invalid{}, nil)
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+}
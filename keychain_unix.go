@@ -0,0 +1,25 @@
+//go:build !darwin && !windows
+
+package configurator
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+type osKeychain struct{}
+
+func (osKeychain) Get(ctx context.Context, service, account string) (string, error) {
+	out, err := exec.CommandContext(ctx, "secret-tool", "lookup", "service", service, "username", account).Output()
+	var exit *exec.ExitError
+	// secret-tool fails without output for missing items, and machines
+	// without it, such as containers, have no keychain
+	if errors.As(err, &exit) && len(exit.Stderr) == 0 || errors.Is(err, exec.ErrNotFound) || err == nil && len(out) == 0 {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,203 @@
+package configurator
+
+import (
+	"reflect"
+	"strings"
+)
+
+// keyTags are the tags, after the one of the file format itself, whose names
+// are accepted as file keys for a field.
+var keyTags = []string{"json", "yaml", "mapstructure"}
+
+// normalizeKeys renames the keys of a raw document to the names the format's
+// decoder expects for the fields of t. A field is found under the name in
+// its format tag, its json, yaml or mapstructure tag, or its name ignoring
+// case, in that order.
+func normalizeKeys(raw map[string]any, t reflect.Type, format string, o parseOptions) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || isWrapper(t) {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if et := indirect(f.Type); f.Anonymous && et.Kind() == reflect.Struct && inlined(f, format) {
+			// the fields of embedded structs are those of t, as getStructInfo
+			// walks them
+			normalizeKeys(raw, et, format, o)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		want := decoderKey(f, format)
+		if want == "" {
+			continue
+		}
+		k, ok := findKey(raw, want, f)
+		if !ok {
+			continue
+		}
+		if k != want {
+			if _, set := raw[want]; !set {
+				raw[want] = raw[k]
+				delete(raw, k)
+			}
+		}
+		fo := o
+		if tag, err := parseTag(f); err == nil {
+			fo.percent = tag.unit == unitPercent
+		}
+		raw[want] = normalizeValue(raw[want], f.Type, format, fo)
+	}
+}
+
+// normalizeValue normalizes the keys of nested documents, replaces the
+// names of registered enums with their values and, as far as o allows,
+// numbers written as strings with their values.
+func normalizeValue(v any, t reflect.Type, format string, o parseOptions) any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isWrapper(t) {
+		t = reflect.New(t).Interface().(wrapper).elemType()
+	}
+	if e, ok := lookupEnum(t); ok {
+		if s, ok := v.(string); ok {
+			if s == "" {
+				return reflect.Zero(t).Interface()
+			}
+			if ev, ok := e.lookup(s, o.fold); ok {
+				return ev.Interface()
+			}
+		}
+		return v
+	}
+	if s, ok := v.(string); ok && (o.numbers || o.percent) && isNumber(t) {
+		n := reflect.New(t).Elem()
+		if err := o.set(n, t, s); err == nil {
+			return n.Interface()
+		}
+		return v
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := v.(map[string]any); ok {
+			normalizeKeys(m, t, format, o)
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := v.([]any); ok {
+			for i, e := range s {
+				s[i] = normalizeValue(e, t.Elem(), format, o)
+			}
+		}
+	case reflect.Map:
+		if m, ok := v.(map[string]any); ok {
+			for k, e := range m {
+				m[k] = normalizeValue(e, t.Elem(), format, o)
+			}
+		}
+	}
+	return v
+}
+
+// decoderKey is the key the decoder of format reads f from, "" if it
+// ignores f.
+func decoderKey(f reflect.StructField, format string) string {
+	name, ok := tagKey(f, format)
+	switch {
+	case name == "-":
+		return ""
+	case ok:
+		return name
+	case format == "yaml":
+		return strings.ToLower(f.Name)
+	}
+	return f.Name
+}
+
+// inlined reports whether the decoder of format reads the fields of the
+// embedded struct f from the document of the struct embedding it.
+func inlined(f reflect.StructField, format string) bool {
+	name, opts, _ := strings.Cut(f.Tag.Get(format), ",")
+	switch format {
+	case "json":
+		return name == ""
+	case "yaml":
+		for _, o := range strings.Split(opts, ",") {
+			if o == "inline" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func findKey(raw map[string]any, want string, f reflect.StructField) (string, bool) {
+	if _, ok := raw[want]; ok {
+		return want, true
+	}
+	for _, tag := range keyTags {
+		if name, ok := tagKey(f, tag); ok && name != "-" {
+			if _, ok := raw[name]; ok {
+				return name, true
+			}
+		}
+	}
+	for k := range raw {
+		if strings.EqualFold(k, f.Name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// tagKey returns the name part of tag, reporting whether there is one.
+func tagKey(f reflect.StructField, tag string) (string, bool) {
+	name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+	return name, name != ""
+}
+
+// markPresent marks the fields of si found in the raw document as set, so
+// that a key explicitly set to a zero value counts as set.
+func markPresent(raw map[string]any, si StructInfo, format string) {
+	if si == nil {
+		return
+	}
+	for _, fi := range si.Fields() {
+		f, ok := fi.(*fieldInfo)
+		if !ok {
+			continue
+		}
+		m := raw
+		keys := f.fileKeys(format)
+		for i, k := range keys {
+			v, ok := m[k]
+			if !ok {
+				break
+			}
+			if i == len(keys)-1 {
+				markSet(f)
+				break
+			}
+			if m, ok = v.(map[string]any); !ok {
+				break
+			}
+		}
+	}
+}
+
+// fileKeys returns the keys leading to the field in documents of format.
+func (f *fieldInfo) fileKeys(format string) []string {
+	n := 0
+	for p := f; p != nil; p = p.parent {
+		n++
+	}
+	keys := make([]string, n)
+	for p := f; p != nil; p = p.parent {
+		n--
+		keys[n] = decoderKey(p.field, format)
+	}
+	return keys
+}
@@ -0,0 +1,100 @@
+package configurator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileProvider_KeyTags(t *testing.T) {
+	type pool struct {
+		MaxConns int `mapstructure:"max_conns"`
+	}
+	type example struct {
+		Name    string           `json:"service_name"`
+		Pool    pool             `yaml:"db_pool"`
+		Pools   []pool           `json:"pools"`
+		Tenants map[string]*pool `json:"tenants"`
+		ID      int64            `json:"id"`
+		Skip    string           `json:"-"`
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "yaml",
+			file: "*.yaml",
+			content: "service_name: api\ndb_pool:\n  max_conns: 10\npools:\n  - max_conns: 1\n" +
+				"tenants:\n  a:\n    MaxConns: 2\nid: 9007199254740993\n",
+		},
+		{
+			name: "json",
+			file: "*.json",
+			content: `{"service_name":"api","db_pool":{"max_conns":10},"pools":[{"max_conns":1}],` +
+				`"tenants":{"a":{"maxconns":2}},"id":9007199254740993,"skip":"x"}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.CreateTemp("", tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			if _, err = f.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &example{}
+			assert.NoError(t, NewConfigurator(WithFileProvider(f.Name())).Load(cfg))
+			assert.Equal(t, &example{
+				Name:    "api",
+				Pool:    pool{MaxConns: 10},
+				Pools:   []pool{{MaxConns: 1}},
+				Tenants: map[string]*pool{"a": {MaxConns: 2}},
+				ID:      9007199254740993,
+			}, cfg)
+		})
+	}
+}
+
+// Limits is embedded by the tests.
+type Limits struct {
+	MaxConns int `mapstructure:"max_conns"`
+	Timeout  int
+}
+
+func TestFileProvider_KeysEmbedded(t *testing.T) {
+	type jsonConfig struct {
+		Limits
+		Name string
+	}
+	type yamlConfig struct {
+		Limits `yaml:",inline"`
+		Name   string
+	}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		f := filepath.Join(dir, name)
+		if err := os.WriteFile(f, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	j := &jsonConfig{}
+	f := write("config.json", `{"max_conns":10,"TIMEOUT":5,"name":"api"}`)
+	assert.NoError(t, NewConfigurator(WithFileProvider(f)).Load(j))
+	assert.Equal(t, &jsonConfig{Limits: Limits{MaxConns: 10, Timeout: 5}, Name: "api"}, j)
+
+	y := &yamlConfig{}
+	f = write("config.yaml", "max_conns: 10\nTimeout: 5\nname: api\n")
+	assert.NoError(t, NewConfigurator(WithFileProvider(f)).Load(y))
+	assert.Equal(t, &yamlConfig{Limits: Limits{MaxConns: 10, Timeout: 5}, Name: "api"}, y)
+}
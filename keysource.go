@@ -0,0 +1,144 @@
+package configurator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const keySourceProviderName = "keysource"
+
+// KeySource is a store of values by key, such as AWS SSM Parameter Store
+// or Vault, where each lookup costs. KeySourceProvider asks it for the keys
+// of the fields of the struct being loaded only.
+type KeySource interface {
+	// Lookup returns the value of key, false when the source hasn't it.
+	Lookup(ctx context.Context, key string) (string, bool, error)
+}
+
+// BatchSource is a KeySource that looks up several keys in one call, such
+// as SSM GetParameters.
+type BatchSource interface {
+	KeySource
+	// GetKeys returns the values of the keys it has among keys.
+	GetKeys(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+// PrefixSource is a KeySource that returns every key under a prefix in
+// one call, such as SSM GetParametersByPath.
+type PrefixSource interface {
+	KeySource
+	LookupAll(ctx context.Context, prefix string) (map[string]string, error)
+}
+
+// KeySourceProvider returns a provider setting fields from src, to pass to
+// WithProvider. The key of a field is prefix followed by its keys in YAML
+// files joined by "/", such as /app/prod/database/host for Database.Host
+// with the prefix /app/prod/. Lookups are planned once per load, keys
+// deduplicated, in as few round trips as src allows: a single LookupAll of
+// the prefix for a PrefixSource, values of keys no field has being
+// dropped, GetKeys of batch keys at a time, all at once if batch is 0, for
+// a BatchSource, or else a Lookup per key.
+func KeySourceProvider(src KeySource, prefix string, batch int) *keySourceProvider {
+	return &keySourceProvider{src: src, prefix: prefix, batch: batch}
+}
+
+type keySourceProvider struct {
+	src    KeySource
+	prefix string
+	batch  int
+}
+
+func (p *keySourceProvider) Provide(v interface{}, si StructInfo) error {
+	return p.ProvideContext(context.Background(), v, si)
+}
+
+func (p *keySourceProvider) ProvideContext(ctx context.Context, _ interface{}, si StructInfo) error {
+	fields := make(map[string][]FieldInfo)
+	for _, fi := range si.Fields() {
+		if k := p.key(fi); k != "" {
+			fields[k] = append(fields[k], fi)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	values, err := p.lookup(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("keySourceProvider/Provide: %w", err)
+	}
+	for _, k := range keys {
+		val, ok := values[k]
+		if !ok {
+			continue
+		}
+		for _, fi := range fields[k] {
+			if err := fi.Set(val); err != nil {
+				return fmt.Errorf("keySourceProvider/Provide: %w [%s]", err, k)
+			}
+		}
+	}
+	return nil
+}
+
+// lookup asks src for keys, sorted, in as few calls as it can.
+func (p *keySourceProvider) lookup(ctx context.Context, keys []string) (map[string]string, error) {
+	if ps, ok := p.src.(PrefixSource); ok && p.prefix != "" {
+		return ps.LookupAll(ctx, p.prefix)
+	}
+	values := make(map[string]string, len(keys))
+	bs, ok := p.src.(BatchSource)
+	if !ok {
+		for _, k := range keys {
+			v, found, err := p.src.Lookup(ctx, k)
+			if err != nil {
+				return nil, fmt.Errorf("%w [%s]", err, k)
+			}
+			if found {
+				values[k] = v
+			}
+		}
+		return values, nil
+	}
+	batch := p.batch
+	if batch <= 0 {
+		batch = len(keys)
+	}
+	for len(keys) > 0 {
+		n := min(batch, len(keys))
+		found, err := bs.GetKeys(ctx, keys[:n])
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range found {
+			values[k] = v
+		}
+		keys = keys[n:]
+	}
+	return values, nil
+}
+
+func (p *keySourceProvider) key(fi FieldInfo) string {
+	f, ok := fi.(*fieldInfo)
+	if !ok {
+		return ""
+	}
+	keys := f.fileKeys("yaml")
+	for _, k := range keys {
+		if k == "" {
+			return ""
+		}
+	}
+	return p.prefix + strings.Join(keys, "/")
+}
+
+func (p *keySourceProvider) String() string {
+	return keySourceProviderName
+}
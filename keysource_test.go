@@ -0,0 +1,120 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingSource is a KeySource recording its lookups.
+type countingSource struct {
+	values  map[string]string
+	lookups []string
+	err     error
+}
+
+func (s *countingSource) Lookup(_ context.Context, key string) (string, bool, error) {
+	s.lookups = append(s.lookups, key)
+	v, ok := s.values[key]
+	return v, ok, s.err
+}
+
+// batchSource is a countingSource looking up keys in batches.
+type batchSource struct {
+	countingSource
+	batches [][]string
+}
+
+func (s *batchSource) GetKeys(_ context.Context, keys []string) (map[string]string, error) {
+	s.batches = append(s.batches, append([]string{}, keys...))
+	found := make(map[string]string)
+	for _, k := range keys {
+		if v, ok := s.values[k]; ok {
+			found[k] = v
+		}
+	}
+	return found, s.err
+}
+
+// pathSource is a countingSource listing keys by prefix.
+type pathSource struct {
+	countingSource
+	prefixes []string
+}
+
+func (s *pathSource) LookupAll(_ context.Context, prefix string) (map[string]string, error) {
+	s.prefixes = append(s.prefixes, prefix)
+	found := make(map[string]string)
+	for k, v := range s.values {
+		if strings.HasPrefix(k, prefix) {
+			found[k] = v
+		}
+	}
+	return found, nil
+}
+
+func TestKeySourceProvider(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Name     string
+		Database struct {
+			Host     string
+			Port     int
+			Password string `yaml:"pass"`
+		}
+		Ignored string `yaml:"-"`
+	}
+	values := map[string]string{
+		"/app/name":          "web",
+		"/app/database/host": "db",
+		"/app/database/port": "5432",
+		"/app/database/pass": "s3cret",
+		"/app/unused":        "x",
+	}
+	want := example{Name: "web"}
+	want.Database.Host, want.Database.Port, want.Database.Password = "db", 5432, "s3cret"
+
+	bs := &batchSource{countingSource: countingSource{values: values}}
+	c := NewConfigurator(WithFileProvider(""), WithProvider(KeySourceProvider(bs, "/app/", 3)))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, want, *cfg)
+	assert.Equal(t, [][]string{
+		{"/app/database/host", "/app/database/pass", "/app/database/port"},
+		{"/app/name"},
+	}, bs.batches)
+	assert.Empty(t, bs.lookups)
+	assert.Equal(t, "keysource", c.Provenance()["Database.Host"])
+
+	ps := &pathSource{countingSource: countingSource{values: values}}
+	c = NewConfigurator(WithFileProvider(""), WithProvider(KeySourceProvider(ps, "/app/", 0)))
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, want, *cfg)
+	assert.Equal(t, []string{"/app/"}, ps.prefixes)
+	assert.Empty(t, ps.lookups)
+
+	// without a prefix to list, or bulk lookups, a lookup per key
+	cs := &countingSource{values: values}
+	c = NewConfigurator(WithFileProvider(""), WithProvider(KeySourceProvider(cs, "/app/", 0)))
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, want, *cfg)
+	assert.Equal(t, []string{"/app/database/host", "/app/database/pass", "/app/database/port", "/app/name"}, cs.lookups)
+	ps = &pathSource{countingSource: countingSource{values: map[string]string{"name": "api"}}}
+	c = NewConfigurator(WithFileProvider(""), WithProvider(KeySourceProvider(ps, "", 0)))
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "api", cfg.Name)
+	assert.Empty(t, ps.prefixes)
+
+	fail := errors.New("throttled")
+	c = NewConfigurator(WithFileProvider(""), WithProvider(KeySourceProvider(&countingSource{err: fail}, "", 0)))
+	err := c.Load(&example{})
+	if assert.True(t, errors.Is(err, fail)) {
+		assert.Contains(t, err.Error(), "throttled [database/host]")
+	}
+}
@@ -0,0 +1,113 @@
+package configurator
+
+import (
+	"context"
+	"time"
+)
+
+// Expirer is implemented by the providers a Fetcher returns whose values
+// are valid for a limited time, such as short-lived credentials.
+type Expirer interface {
+	// TTL returns how long the values stay valid from the fetch, or 0 if
+	// they don't expire.
+	TTL() time.Duration
+}
+
+// LeaseResolver is a SecretResolver resolving references to secrets
+// valid for a limited time, such as the dynamic secrets of Vault.
+type LeaseResolver interface {
+	SecretResolver
+	// ResolveLease returns the secret and how long it stays valid, 0 if it
+	// doesn't expire.
+	ResolveLease(ctx context.Context, ref string) (string, time.Duration, error)
+}
+
+// Lease is the time the values of a source, or a secret, applied by the
+// last load stay valid.
+type Lease struct {
+	// Source is the source of the values, the scheme of the resolver for a
+	// secret.
+	Source string `json:"source"`
+	// Path is the field of a secret, empty for a source.
+	Path    string    `json:"path,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// WithLeaseExpired calls fn when a lease expires before Watch could
+// refresh it, with the error of the last reload, to degrade while the
+// values are invalid, such as closing the connections made with expired
+// credentials. Watch reloads once two thirds of the TTL of a lease passed,
+// and then every interval until a reload succeeds.
+func WithLeaseExpired(fn func(Lease, error)) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.onExpire = fn
+	}
+}
+
+// lease is a Lease of the configurator, refreshed from renew.
+type lease struct {
+	Lease
+	renew   time.Time
+	expired bool
+}
+
+func newLease(l Lease, now time.Time, ttl time.Duration) lease {
+	l.Expires = now.Add(ttl)
+	return lease{Lease: l, renew: now.Add(ttl * 2 / 3)}
+}
+
+// Leases returns the leases of the values of the last successful load, by
+// expiry.
+func (c *Configurator) Leases() []Lease {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	leases := make([]Lease, len(c.leases))
+	for i, l := range c.leases {
+		leases[i] = l.Lease
+	}
+	return leases
+}
+
+// nextLease returns when Watch must reload next for the leases, the zero
+// time if never: when a lease is due for renewal, or expires if it is
+// already due.
+func (c *Configurator) nextLease() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := c.now()
+	var next time.Time
+	for _, l := range c.leases {
+		at := l.renew
+		if !at.After(now) {
+			if l.expired {
+				continue
+			}
+			at = l.Expires
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// expire calls the WithLeaseExpired handler for the leases expired since
+// the last successful load, after a reload failed with err.
+func (c *Configurator) expire(err error) {
+	now := c.now()
+	var expired []Lease
+	c.mu.Lock()
+	for i, l := range c.leases {
+		if !l.expired && !l.Expires.After(now) {
+			c.leases[i].expired = true
+			expired = append(expired, l.Lease)
+		}
+	}
+	c.mu.Unlock()
+	for _, l := range expired {
+		c.logger.Warn("configurator: lease expired", "provider", l.Source, "field", l.Path, "error", err)
+		if c.onExpire != nil {
+			c.onExpire(l, err)
+		}
+	}
+}
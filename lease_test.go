@@ -0,0 +1,115 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type leasedSource struct {
+	replaySource
+	ttl time.Duration
+}
+
+func (s *leasedSource) Fetch(context.Context) (Provider, error) {
+	return s, nil
+}
+
+func (s *leasedSource) TTL() time.Duration {
+	return s.ttl
+}
+
+func TestLease_Source(t *testing.T) {
+	type example struct {
+		User string
+	}
+	src := &leasedSource{replaySource: replaySource{name: "sts", values: map[string]string{"User": "v1"}}, ttl: 30 * time.Millisecond}
+	c := NewConfigurator(WithFileProvider(""), WithProvider(src))
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	if leases := c.Leases(); assert.Len(t, leases, 1) {
+		assert.Equal(t, "sts", leases[0].Source)
+		assert.WithinDuration(t, time.Now().Add(30*time.Millisecond), leases[0].Expires, 20*time.Millisecond)
+	}
+
+	src.values = map[string]string{"User": "v2"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	var user string
+	err := c.Watch(ctx, &cfg, time.Hour, func(nv interface{}, err error) {
+		assert.NoError(t, err)
+		user = nv.(*example).User
+		cancel()
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, "v2", user)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+}
+
+func TestLease_Expired(t *testing.T) {
+	type example struct {
+		Password string `config:"env"`
+	}
+	var mu sync.Mutex
+	fail := errors.New("vault sealed")
+	var resolveErr error
+	resolver := leaseResolver(func(ctx context.Context, ref string) (string, time.Duration, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return "s3cret", 30 * time.Millisecond, resolveErr
+	})
+	var expired []Lease
+	var expiredErr error
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{"PASSWORD=vault://database/creds/app"}),
+		WithSecretResolver("vault", resolver),
+		WithLeaseExpired(func(l Lease, err error) {
+			expired = append(expired, l)
+			expiredErr = err
+		}),
+	)
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "s3cret", cfg.Password)
+	if leases := c.Leases(); assert.Len(t, leases, 1) {
+		assert.Equal(t, Lease{Source: "vault", Path: "Password", Expires: leases[0].Expires}, leases[0])
+	}
+
+	mu.Lock()
+	resolveErr = fail
+	mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reloads := 0
+	err := c.Watch(ctx, &cfg, time.Hour, func(nv interface{}, err error) {
+		assert.Error(t, err)
+		reloads++
+		if len(expired) > 0 {
+			cancel()
+		}
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 2, reloads, "reloads to renew, then once expired")
+	if assert.Len(t, expired, 1) {
+		assert.Equal(t, "Password", expired[0].Path)
+		assert.True(t, errors.Is(expiredErr, fail))
+	}
+}
+
+type leaseResolver func(ctx context.Context, ref string) (string, time.Duration, error)
+
+func (f leaseResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	s, _, err := f(ctx, ref)
+	return s, err
+}
+
+func (f leaseResolver) ResolveLease(ctx context.Context, ref string) (string, time.Duration, error) {
+	return f(ctx, ref)
+}
@@ -0,0 +1,123 @@
+package configurator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Problem is an issue LintTags found with the tags of the field at Path, or
+// Diagnose with the configuration.
+type Problem struct {
+	Path    string
+	Message string
+	// Source is the source that failed, for problems without a Path.
+	Source string
+	// Hints are ways to fix the problem.
+	Hints []string
+}
+
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// LintTags checks the config tags of the struct v points to without loading
+// it: unknown options, flags and env vars that can't be set from a shell,
+// defaults that don't parse as the field's type and required fields with a
+// default. Tags that can't be parsed at all make a single problem.
+func LintTags(v interface{}) []Problem {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return []Problem{{Message: ErrInvalidConfig.Error()}}
+	}
+	si, err := getStructInfo(reflect.New(rv.Elem().Type()).Interface(), nil)
+	if err != nil {
+		return []Problem{{Message: err.Error()}}
+	}
+	var problems []Problem
+	seen := make(map[*fieldInfo]bool)
+	for _, fi := range si.Fields() {
+		f, ok := fi.(*fieldInfo)
+		if !ok {
+			continue
+		}
+		// options of sections apply to their fields, report them once
+		for p := f.parent; p != nil && !seen[p]; p = p.parent {
+			seen[p] = true
+			problems = append(problems, lintOptions(p)...)
+			problems = append(problems, lintGroups(p, si.Fields(), true)...)
+		}
+		problems = append(problems, lintOptions(f)...)
+		problems = append(problems, lintGroups(f, si.Fields(), false)...)
+		problems = append(problems, lintField(f)...)
+	}
+	return problems
+}
+
+func lintOptions(f *fieldInfo) []Problem {
+	tokens, _ := splitTag(f.field.Tag.Get(tagName))
+	var problems []Problem
+	inList := false
+	for _, tok := range tokens {
+		if inList && (tok.quoted || !isTagOption(tok.text)) {
+			continue
+		}
+		inList = strings.HasPrefix(tok.text, enumFlagWithValue) || strings.HasPrefix(tok.text, transformWithValue)
+		if tok.text != "" && !isTagOption(tok.text) {
+			problems = append(problems, Problem{Path: f.Path(), Message: fmt.Sprintf("unknown tag option %q", tok.text)})
+		}
+	}
+	return problems
+}
+
+func lintField(f *fieldInfo) []Problem {
+	var problems []Problem
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, Problem{Path: f.Path(), Message: fmt.Sprintf(format, args...)})
+	}
+	if k := f.FlagKey(); k != "" && !validFlag(k) {
+		report("flag %q must be letters, digits, '.', '_' or '-', not starting with '-'", k)
+	}
+	if k := f.ENVKey(); k != "" {
+		if strings.ToUpper(k) != k {
+			report("env %s has lower case letters", k)
+		}
+		if strings.IndexFunc(k, func(r rune) bool { return !isEnvRune(r) }) >= 0 {
+			report("env %s must be letters, digits or '_'", k)
+		}
+	}
+	hasDefault := f.tag.defVal != "" || f.tag.defFn != ""
+	if f.tag.required && hasDefault {
+		report("required field has a default, so it is always set")
+	}
+	if f.tag.derive != "" && hasDefault {
+		report("derived field has a default, so it is never derived")
+	}
+	if f.tag.path && !isPathType(leaf(f.val).Type()) {
+		report("path needs a string field, not %s", leaf(f.val).Type())
+	}
+	if f.tag.envPrefix != "" && !isPrefixMap(leaf(f.val).Type()) {
+		report("envPrefix needs a map with string keys, not %s", leaf(f.val).Type())
+	}
+	if isDownwardMap(f.tag.downward) && !isPrefixMap(leaf(f.val).Type()) {
+		report("downward=%s needs a map with string keys, not %s", f.tag.downward, leaf(f.val).Type())
+	}
+	if err := f.checkDefault(); err != nil {
+		report("default %q: %v", f.tag.defVal, err)
+	}
+	return problems
+}
+
+func validFlag(k string) bool {
+	if k[0] == '-' {
+		return false
+	}
+	return strings.IndexFunc(k, func(r rune) bool { return !isEnvRune(r) && r != '-' && r != '.' }) < 0
+}
+
+func isEnvRune(r rune) bool {
+	return r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
@@ -0,0 +1,60 @@
+package configurator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintTags(t *testing.T) {
+	type clean struct {
+		Name    string        `config:"env,flag,default=api"`
+		Timeout time.Duration `config:"env=HTTP_TIMEOUT,flag=http.timeout,default=5s"`
+		Level   string        `config:"enum=debug,info,default=info"`
+		StartAt time.Time     `config:"default=now"`
+		Server  struct {
+			Port int `config:"env,default=80"`
+		} `config:"env=SRV"`
+	}
+	assert.Empty(t, LintTags(&clean{}))
+
+	type broken struct {
+		Name    string        `config:"envx,secrets"`
+		Port    int           `config:"flag=-port,default=eighty"`
+		Host    string        `config:"env=db_host,required,default=localhost"`
+		Key     string        `config:"env=API-KEY,flag=api key"`
+		Level   string        `config:"enum=debug,info,default=trace"`
+		Timeout time.Duration `config:"default=5"`
+		Dir     int           `config:"path"`
+		Cache   struct {
+			Size int
+		} `config:"required,prefix=C"`
+	}
+	var got []string
+	for _, p := range LintTags(&broken{}) {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		`Name: unknown tag option "envx"`,
+		`Name: unknown tag option "secrets"`,
+		`Port: flag "-port" must be letters, digits, '.', '_' or '-', not starting with '-'`,
+		`Port: default "eighty": strconv.ParseInt: parsing "eighty": invalid syntax`,
+		`Host: env db_host has lower case letters`,
+		`Host: required field has a default, so it is always set`,
+		`Key: flag "api key" must be letters, digits, '.', '_' or '-', not starting with '-'`,
+		`Key: env API-KEY must be letters, digits or '_'`,
+		`Level: default "trace": value not allowed "trace", allowed [debug, info]`,
+		`Timeout: default "5": time: missing unit in duration "5"`,
+		`Dir: path needs a string field, not int`,
+		`Cache: unknown tag option "prefix=C"`,
+	}, got)
+
+	type invalid struct {
+		Name string `config:"env="`
+	}
+	problems := LintTags(&invalid{})
+	assert.Len(t, problems, 1)
+	assert.Contains(t, problems[0].String(), "invalid tag format")
+	assert.Len(t, LintTags(invalid{}), 1)
+}
@@ -0,0 +1,104 @@
+package configurator
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+func init() {
+	RegisterEnum(map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"info":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	})
+}
+
+// LogConfig is the logging section most services carry. Its level is
+// Dynamic: a Watch-ed configuration changes the level of the loggers built
+// from it and calls the OnLevelChange funcs, e.g. to flip a zap.AtomicLevel.
+type LogConfig struct {
+	Level  Dynamic[slog.Level] `config:"env,default=info"`
+	Format string              `config:"env,enum=text,json,default=text"`
+	// Output is stdout, stderr or the name of a file to append to.
+	Output string `config:"env,default=stderr"`
+
+	// watch is shared by copies, so that Reload reaches the funcs.
+	watch *levelWatch
+}
+
+type levelWatch struct {
+	mu   sync.Mutex
+	last slog.Level
+	fns  []func(slog.Level)
+}
+
+// Leveler returns the level of l, following reloads.
+func (l LogConfig) Leveler() slog.Leveler {
+	return dynamicLevel{l.Level}
+}
+
+type dynamicLevel struct {
+	d Dynamic[slog.Level]
+}
+
+func (d dynamicLevel) Level() slog.Level {
+	return d.d.Get()
+}
+
+// Logger returns a logger writing to the output of l in its format, at its
+// level. A file output stays open for the life of the process.
+func (l LogConfig) Logger() (*slog.Logger, error) {
+	var w io.Writer
+	switch l.Output {
+	case "", "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	default:
+		f, err := os.OpenFile(l.Output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("LogConfig/Logger: %w [%s]", err, l.Output)
+		}
+		w = f
+	}
+	opts := &slog.HandlerOptions{Level: l.Leveler()}
+	switch l.Format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("LogConfig/Logger: %w format %q", ErrEnum, l.Format)
+}
+
+// OnLevelChange calls fn with the new level whenever a reload changes it.
+// Call it on the loaded configuration.
+func (l *LogConfig) OnLevelChange(fn func(slog.Level)) {
+	if l.watch == nil {
+		l.watch = &levelWatch{last: l.Level.Get()}
+	}
+	l.watch.mu.Lock()
+	defer l.watch.mu.Unlock()
+	l.watch.fns = append(l.watch.fns, fn)
+}
+
+func (l *LogConfig) reload(interface{}) error {
+	if l.watch == nil {
+		return nil
+	}
+	l.watch.mu.Lock()
+	defer l.watch.mu.Unlock()
+	level := l.Level.Get()
+	if level == l.watch.last {
+		return nil
+	}
+	l.watch.last = level
+	for _, fn := range l.watch.fns {
+		fn(level)
+	}
+	return nil
+}
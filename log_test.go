@@ -0,0 +1,49 @@
+package configurator
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogConfig(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Log LogConfig
+	}
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+	output := filepath.Join(dir, "app.log")
+	assert.NoError(t, os.WriteFile(filename, []byte("log:\n  level: warn\n  format: json\n"), 0o600))
+
+	c := NewConfigurator(WithFileProvider(filename), WithENVProvider(""), WithDefaultProvider(), WithEnviron([]string{"LOG_OUTPUT=" + output}))
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, slog.LevelWarn, cfg.Log.Level.Get())
+
+	logger, err := cfg.Log.Logger()
+	assert.NoError(t, err)
+	var changes []slog.Level
+	cfg.Log.OnLevelChange(func(l slog.Level) { changes = append(changes, l) })
+
+	logger.Info("dropped")
+	assert.NoError(t, os.WriteFile(filename, []byte("log:\n  level: debug\n  format: json\n"), 0o600))
+	_, err = c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	logger.Debug("kept")
+	_, err = c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []slog.Level{slog.LevelDebug}, changes)
+	b, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "dropped")
+	assert.Contains(t, string(b), `"msg":"kept"`)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"LOG_LEVEL=verbose"}))
+	assert.Error(t, c.Load(&example{}))
+}
@@ -0,0 +1,69 @@
+package configurator
+
+import (
+	"fmt"
+)
+
+// MapKey is what the keys of a MapSource name.
+type MapKey int
+
+const (
+	// MapKeyPath keys are field paths, such as "MySQL.Host".
+	MapKeyPath MapKey = iota
+	// MapKeyEnv keys are the env keys of fields with the env option, such
+	// as "MYSQL_HOST", without the prefix of WithENVProvider.
+	MapKeyEnv
+	// MapKeyFlag keys are the flag names of fields with the flag option,
+	// such as "mysql-host".
+	MapKeyFlag
+)
+
+// MapSource returns a provider setting fields from values, for tests and
+// programs building their configuration, to pass to WithProvider. Keys are
+// field paths, or any of keys, the first one found winning.
+func MapSource(values map[string]string, keys ...MapKey) *mapSource {
+	if len(keys) == 0 {
+		keys = []MapKey{MapKeyPath}
+	}
+	return &mapSource{values: values, keys: keys}
+}
+
+type mapSource struct {
+	values map[string]string
+	keys   []MapKey
+}
+
+func (p *mapSource) Provide(_ interface{}, si StructInfo) error {
+	for _, fi := range si.Fields() {
+		for _, k := range p.keys {
+			key := mapKey(fi, k)
+			if key == "" {
+				continue
+			}
+			val, ok := p.values[key]
+			if !ok {
+				continue
+			}
+			if err := fi.Set(val); err != nil {
+				return fmt.Errorf("mapSource/Provide: %w [%s]", err, key)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func (p *mapSource) String() string {
+	return "map"
+}
+
+func mapKey(fi FieldInfo, k MapKey) string {
+	switch k {
+	case MapKeyEnv:
+		return fi.ENVKey()
+	case MapKeyFlag:
+		return fi.FlagKey()
+	default:
+		return fi.Path()
+	}
+}
@@ -0,0 +1,39 @@
+package configurator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapSource(t *testing.T) {
+	type example struct {
+		Name  string `config:"env,flag"`
+		MySQL struct {
+			Host    string        `config:"env,flag"`
+			Timeout time.Duration `config:"default=5s"`
+		}
+	}
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider(),
+		WithProvider(MapSource(map[string]string{"Name": "api", "MySQL.Host": "db", "MYSQL_HOST": "ignored"})))
+	var v example
+	assert.NoError(t, c.Load(&v))
+	assert.Equal(t, "api", v.Name)
+	assert.Equal(t, "db", v.MySQL.Host)
+	assert.Equal(t, 5*time.Second, v.MySQL.Timeout)
+	assert.Equal(t, "map", c.Provenance()["Name"])
+
+	c = NewConfigurator(WithFileProvider(""),
+		WithProvider(MapSource(map[string]string{"MYSQL_HOST": "env-db", "mysql-host": "flag-db", "name": "api"}, MapKeyEnv, MapKeyFlag)))
+	v = example{}
+	assert.NoError(t, c.Load(&v))
+	assert.Equal(t, "api", v.Name)
+	assert.Equal(t, "env-db", v.MySQL.Host)
+
+	c = NewConfigurator(WithFileProvider(""), WithProvider(MapSource(map[string]string{"MySQL.Timeout": "soon"})))
+	err := c.Load(&example{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "[MySQL.Timeout]")
+	}
+}
@@ -0,0 +1,80 @@
+package configurator
+
+import (
+	"errors"
+)
+
+// Message is an error of a load as the catalog set with WithMessages sees
+// it, to word it for operators.
+type Message struct {
+	// Kind is the sentinel error of the problem: ErrRequired, ErrEnum,
+	// ErrGroup, ErrFeatureGate, ErrPolicy, or ErrInvalidValue for a value
+	// that doesn't parse. It is nil for the error of a Validate method.
+	Kind error
+	// Paths are the fields concerned.
+	Paths []string
+	// Value is the value rejected, for ErrEnum and ErrInvalidValue.
+	Value string
+	// Source is the source that set the value rejected, if known.
+	Source string
+	// Err is the error, whose text is the default message.
+	Err error
+}
+
+// WithMessages words the validation and parse errors of loads, and the
+// problems MustLoad reports, with catalog, to translate them or follow a
+// house style. Errors still match their sentinel errors with errors.Is.
+// An empty message keeps the default one.
+func WithMessages(catalog func(Message) string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.messages = catalog
+	}
+}
+
+// fieldError is a validation or parse error of fields, reading as err.
+type fieldError struct {
+	kind   error
+	paths  []string
+	value  string
+	source string
+	err    error
+}
+
+func (e *fieldError) Error() string { return e.err.Error() }
+
+func (e *fieldError) Unwrap() error { return e.err }
+
+func newFieldError(kind error, err error, value string, paths ...string) error {
+	return &fieldError{kind: kind, paths: paths, value: value, err: err}
+}
+
+// messageError is an error worded by a catalog.
+type messageError struct {
+	msg string
+	err error
+}
+
+func (e *messageError) Error() string { return e.msg }
+
+func (e *messageError) Unwrap() error { return e.err }
+
+// word returns err worded by the catalog of c, err itself when it isn't a
+// validation or parse error, or has no message in the catalog.
+func (c *Configurator) word(err error) error {
+	if msg := c.message(err); msg != "" {
+		return &messageError{msg: msg, err: err}
+	}
+	return err
+}
+
+func (c *Configurator) message(err error) string {
+	var fe *fieldError
+	if c.messages == nil || !errors.As(err, &fe) {
+		return ""
+	}
+	var me *messageError
+	if errors.As(err, &me) {
+		return ""
+	}
+	return c.messages(Message{Kind: fe.kind, Paths: fe.paths, Value: fe.value, Source: fe.source, Err: err})
+}
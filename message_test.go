@@ -0,0 +1,93 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// frenchMessages words required, enum and parse errors in French.
+func frenchMessages(m Message) string {
+	switch {
+	case errors.Is(m.Kind, ErrRequired):
+		return "champ obligatoire non renseigné : " + strings.Join(m.Paths, ", ")
+	case errors.Is(m.Kind, ErrEnum):
+		return fmt.Sprintf("valeur %q non autorisée pour %s", m.Value, m.Paths[0])
+	case errors.Is(m.Kind, ErrInvalidValue):
+		return fmt.Sprintf("valeur %q invalide pour %s (source %s)", m.Value, m.Paths[0], m.Source)
+	}
+	return ""
+}
+
+type messageConfig struct {
+	Name string `config:"env,required"`
+	Mode string `config:"env,enum=dev|prod"`
+	Port int    `config:"env"`
+}
+
+func (c *messageConfig) Validate() error {
+	if c.Port == 1 {
+		return errors.New("port 1 is reserved")
+	}
+	return nil
+}
+
+func TestWithMessages(t *testing.T) {
+	t.Parallel()
+	load := func(environ ...string) error {
+		c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ), WithMessages(frenchMessages))
+		return c.Load(&messageConfig{})
+	}
+	err := load()
+	assert.EqualError(t, err, "champ obligatoire non renseigné : Name")
+	assert.True(t, errors.Is(err, ErrRequired))
+
+	err = load("NAME=web", "MODE=test")
+	assert.EqualError(t, err, `valeur "test" non autorisée pour Mode`)
+	assert.True(t, errors.Is(err, ErrEnum))
+
+	err = load("NAME=web", "PORT=http")
+	assert.EqualError(t, err, `valeur "http" invalide pour Port (source env)`)
+
+	// without a message, the default one
+	assert.EqualError(t, load("NAME=web", "PORT=1"), "configurator/LoadContext: port 1 is reserved")
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"MODE=test"}), WithMessages(frenchMessages))
+	assert.Equal(t, []Problem{
+		{Path: "Name", Message: "champ obligatoire non renseigné : Name", Hints: []string{"set env NAME"}},
+		{Message: `valeur "test" non autorisée pour Mode`},
+	}, c.Diagnose(context.Background(), &messageConfig{}))
+}
+
+func TestWithMessages_Paths(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		User     string `config:"env"`
+		Password string `config:"env"`
+		Token    string `config:"env,experimental=Tokens"`
+	}
+	var got []Message
+	record := func(m Message) string {
+		got = append(got, m)
+		return ""
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"USER=u", "TOKEN=t"}),
+		WithFieldGroup(GroupRequireAll, "User", "Password"), WithMessages(record))
+	err := c.Load(&example{})
+	assert.True(t, errors.Is(err, ErrFeatureGate))
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"USER=u"}),
+		WithFieldGroup(GroupRequireAll, "User", "Password"), WithMessages(record))
+	err = c.Load(&example{})
+	assert.True(t, errors.Is(err, ErrGroup))
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, ErrFeatureGate, got[0].Kind)
+		assert.Equal(t, []string{"Token"}, got[0].Paths)
+		assert.Equal(t, ErrGroup, got[1].Kind)
+		assert.Equal(t, []string{"User", "Password"}, got[1].Paths)
+		assert.Equal(t, err.Error(), got[1].Err.Error())
+	}
+}
@@ -0,0 +1,114 @@
+package configurator
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics receives an event for every load and for every provider fetch
+// and apply. Implement it to export to Prometheus or any other system, or
+// use Stats.
+type Metrics interface {
+	ObserveLoad(LoadEvent)
+	ObserveProvider(ProviderEvent)
+}
+
+type LoadEvent struct {
+	Start    time.Time
+	Duration time.Duration
+	Degraded bool
+	Err      error
+}
+
+type Phase string
+
+const (
+	PhaseFetch Phase = "fetch"
+	PhaseApply Phase = "apply"
+)
+
+type ProviderEvent struct {
+	Provider string
+	Phase    Phase
+	Duration time.Duration
+	Err      error
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveLoad(LoadEvent) {}
+
+func (nopMetrics) ObserveProvider(ProviderEvent) {}
+
+// Stats is a Metrics keeping counters in memory.
+type Stats struct {
+	mu          sync.Mutex
+	loads       uint64
+	failures    uint64
+	lastSuccess time.Time
+	providers   map[string]ProviderStats
+}
+
+type ProviderStats struct {
+	Count    uint64
+	Errors   uint64
+	Duration time.Duration
+	Last     time.Duration
+}
+
+type StatsSnapshot struct {
+	Loads        uint64
+	LoadFailures uint64
+	LastSuccess  time.Time
+	Providers    map[string]ProviderStats
+}
+
+var _ Metrics = &Stats{}
+
+func NewStats() *Stats {
+	return &Stats{
+		providers: make(map[string]ProviderStats),
+	}
+}
+
+func (s *Stats) ObserveLoad(e LoadEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	if e.Err != nil {
+		s.failures++
+		return
+	}
+	s.lastSuccess = e.Start.Add(e.Duration)
+}
+
+func (s *Stats) ObserveProvider(e ProviderEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := e.Provider + "/" + string(e.Phase)
+	ps := s.providers[k]
+	ps.Count++
+	if e.Err != nil {
+		ps.Errors++
+	}
+	ps.Duration += e.Duration
+	ps.Last = e.Duration
+	s.providers[k] = ps
+}
+
+// Snapshot returns the current counters. Provider stats are keyed by
+// provider name and phase, e.g. "file/fetch".
+func (s *Stats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	providers := make(map[string]ProviderStats, len(s.providers))
+	for k, v := range s.providers {
+		providers[k] = v
+	}
+	return StatsSnapshot{
+		Loads:        s.loads,
+		LoadFailures: s.failures,
+		LastSuccess:  s.lastSuccess,
+		Providers:    providers,
+	}
+}
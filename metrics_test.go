@@ -0,0 +1,37 @@
+package configurator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	type example struct {
+		Name string
+		Port int `config:"env"`
+	}
+	now := time.Date(2020, 9, 30, 22, 51, 49, 0, time.UTC)
+	stats := NewStats()
+	f := &flakyFetcher{value: "a"}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{"PORT=80"}),
+		WithClock(func() time.Time { return now }),
+		WithMetrics(stats),
+		WithProvider(f),
+	)
+	assert.NoError(t, c.Load(&example{}))
+	f.failures = 100
+	assert.Error(t, c.Load(&example{}))
+
+	s := stats.Snapshot()
+	assert.Equal(t, uint64(2), s.Loads)
+	assert.Equal(t, uint64(1), s.LoadFailures)
+	assert.Equal(t, now, s.LastSuccess)
+	assert.Equal(t, ProviderStats{Count: 2, Errors: 1}, s.Providers["*configurator.flakyFetcher/fetch"])
+	assert.Equal(t, ProviderStats{Count: 1}, s.Providers["*configurator.flakyFetcher/apply"])
+	assert.Equal(t, ProviderStats{Count: 1}, s.Providers["env/apply"])
+}
@@ -0,0 +1,81 @@
+package configurator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const versionKey = "version"
+
+// Migrations upgrades raw config documents written for older schema
+// versions before they are decoded, so old files keep working after fields
+// are renamed or moved. The schema version of a document is read from its
+// top level "version" key, a missing key being version 0.
+type Migrations struct {
+	version int
+	steps   map[int]migration
+}
+
+type migration struct {
+	to int
+	fn func(raw map[string]any) error
+}
+
+// NewMigrations returns Migrations bringing documents to version.
+func NewMigrations(version int) *Migrations {
+	return &Migrations{version: version, steps: make(map[int]migration)}
+}
+
+// Register adds fn to migrate documents from one version to a later one.
+// Registering a second migration for the same from version replaces it.
+func (m *Migrations) Register(from, to int, fn func(raw map[string]any) error) *Migrations {
+	m.steps[from] = migration{to: to, fn: fn}
+	return m
+}
+
+// Migrate applies the registered migrations to raw in order until it
+// reaches the current version, then records that version in raw.
+func (m *Migrations) Migrate(raw map[string]any) error {
+	from, err := documentVersion(raw)
+	if err != nil {
+		return err
+	}
+	for from < m.version {
+		s, ok := m.steps[from]
+		if !ok || s.to <= from {
+			return fmt.Errorf("Migrations/Migrate: %w, no migration from version %d", ErrSchemaVersion, from)
+		}
+		if err := s.fn(raw); err != nil {
+			return fmt.Errorf("Migrations/Migrate: %w [%d -> %d]", err, from, s.to)
+		}
+		from = s.to
+	}
+	if from > m.version {
+		return fmt.Errorf("Migrations/Migrate: %w, version %d is newer than %d", ErrSchemaVersion, from, m.version)
+	}
+	raw[versionKey] = m.version
+	return nil
+}
+
+func documentVersion(raw map[string]any) (int, error) {
+	switch v := raw[versionKey].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	case json.Number:
+		if i, err := strconv.Atoi(string(v)); err == nil {
+			return i, nil
+		}
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("Migrations/Migrate: %w, %v", ErrSchemaVersion, raw[versionKey])
+}
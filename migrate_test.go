@@ -0,0 +1,91 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	type example struct {
+		Version int    `json:"version" yaml:"version"`
+		Host    string `json:"host" yaml:"host"`
+		Port    int    `json:"port" yaml:"port"`
+	}
+
+	m := NewMigrations(2).
+		Register(0, 1, func(raw map[string]any) error {
+			raw["host"] = raw["hostname"]
+			delete(raw, "hostname")
+			return nil
+		}).
+		Register(1, 2, func(raw map[string]any) error {
+			if _, ok := raw["port"]; !ok {
+				raw["port"] = 8080
+			}
+			return nil
+		})
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		expect  *example
+		err     error
+	}{
+		{
+			name:    "yaml without version",
+			file:    "*.yaml",
+			content: "hostname: localhost\n",
+			expect:  &example{Version: 2, Host: "localhost", Port: 8080},
+		},
+		{
+			name:    "json version 1",
+			file:    "*.json",
+			content: `{"version":1,"host":"localhost","port":80}`,
+			expect:  &example{Version: 2, Host: "localhost", Port: 80},
+		},
+		{
+			name:    "current version",
+			file:    "*.json",
+			content: `{"version":2,"hostname":"ignored","host":"localhost"}`,
+			expect:  &example{Version: 2, Host: "localhost"},
+		},
+		{
+			name:    "newer version",
+			file:    "*.yaml",
+			content: "version: 3\n",
+			err:     ErrSchemaVersion,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.CreateTemp("", tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			if _, err = f.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &example{}
+			err = NewConfigurator(WithFileProvider(f.Name()), WithMigrations(m)).Load(cfg)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, cfg)
+		})
+	}
+}
+
+func TestMigrations_Missing(t *testing.T) {
+	raw := map[string]any{"version": 1}
+	err := NewMigrations(3).Register(1, 2, func(map[string]any) error { return nil }).Migrate(raw)
+	assert.True(t, errors.Is(err, ErrSchemaVersion))
+}
@@ -0,0 +1,94 @@
+package configurator
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	modulesMu sync.RWMutex
+	modules   = map[string]interface{}{}
+)
+
+// RegisterModule registers v, a pointer to the configuration struct of a
+// library, under the namespace name, typically from an init function, for
+// LoadModules to load. The module is the section named after the namespace:
+// the fields of a "cache" module are read from the cache key of files, the
+// CACHE_ env vars and the cache- flags.
+func RegisterModule(name string, v interface{}) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	modules[name] = v
+}
+
+// WithModule registers a module with this configurator only, taking
+// precedence over RegisterModule.
+func WithModule(name string, v interface{}) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		if co.modules == nil {
+			co.modules = make(map[string]interface{})
+		}
+		co.modules[name] = v
+	}
+}
+
+// LoadModules loads every registered module in one pass, so that sources
+// are read once and the modules share one status, provenance and checksum
+// with paths such as "Cache.TTL".
+func (c *Configurator) LoadModules(ctx context.Context) error {
+	modulesMu.RLock()
+	all := make(map[string]interface{}, len(modules)+len(c.modules))
+	for name, v := range modules {
+		all[name] = v
+	}
+	modulesMu.RUnlock()
+	for name, v := range c.modules {
+		all[name] = v
+	}
+
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]reflect.StructField, len(names))
+	seen := make(map[string]string, len(names))
+	for i, name := range names {
+		v := reflect.ValueOf(all[name])
+		if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("configurator/LoadModules: %w [%s]", ErrInvalidConfig, name)
+		}
+		field := moduleField(name)
+		if !token.IsIdentifier(name) || !token.IsExported(field) {
+			return fmt.Errorf("configurator/LoadModules: %w, invalid module name [%s]", ErrInvalidConfig, name)
+		}
+		if other, ok := seen[field]; ok {
+			return fmt.Errorf("configurator/LoadModules: %w, modules %s and %s", ErrConflictKey, other, name)
+		}
+		seen[field] = name
+		fields[i] = reflect.StructField{
+			Name: field,
+			Type: v.Type(),
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:%q yaml:%q`, name, name)),
+		}
+	}
+
+	// a struct with a pointer field per module, loading them in place
+	root := reflect.New(reflect.StructOf(fields))
+	for i, name := range names {
+		root.Elem().Field(i).Set(reflect.ValueOf(all[name]))
+	}
+	return c.LoadContext(ctx, root.Interface())
+}
+
+func moduleField(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
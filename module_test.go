@@ -0,0 +1,57 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadModules(t *testing.T) {
+	type cache struct {
+		TTL  time.Duration `yaml:"ttl" config:"env,default=1m"`
+		Size int           `yaml:"size"`
+	}
+	type tracing struct {
+		Endpoint string `config:"env,required"`
+	}
+	var cacheCfg cache
+	var tracingCfg tracing
+	RegisterModule("cache", &cacheCfg)
+	defer func() {
+		modulesMu.Lock()
+		delete(modules, "cache")
+		modulesMu.Unlock()
+	}()
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("cache:\n  size: 100\n"), 0o600))
+	c := NewConfigurator(
+		WithFileProvider(filename),
+		WithENVProvider(""),
+		WithEnviron([]string{"CACHE_TTL=5m", "TRACING_ENDPOINT=collector:4317"}),
+		WithDefaultProvider(),
+		WithModule("tracing", &tracingCfg),
+	)
+	assert.NoError(t, c.LoadModules(context.Background()))
+	assert.Equal(t, cache{TTL: 5 * time.Minute, Size: 100}, cacheCfg)
+	assert.Equal(t, "collector:4317", tracingCfg.Endpoint)
+	assert.Equal(t, "env", c.Provenance()["Tracing.Endpoint"])
+
+	c = NewConfigurator(WithFileProvider(""), WithModule("tracing", &tracing{}))
+	assert.True(t, errors.Is(c.LoadModules(context.Background()), ErrRequired))
+
+	c = NewConfigurator(WithFileProvider(""), WithModule("Cache", &cache{}))
+	assert.True(t, errors.Is(c.LoadModules(context.Background()), ErrConflictKey))
+
+	for _, name := range []string{"", "http-client", "_cache"} {
+		c = NewConfigurator(WithFileProvider(""), WithModule(name, &cache{}))
+		assert.True(t, errors.Is(c.LoadModules(context.Background()), ErrInvalidConfig), name)
+	}
+	c = NewConfigurator(WithFileProvider(""), WithModule("size", cache{}))
+	assert.True(t, errors.Is(c.LoadModules(context.Background()), ErrInvalidConfig))
+}
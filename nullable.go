@@ -0,0 +1,101 @@
+package configurator
+
+import (
+	"database/sql"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// nullableType converts between a nullable type, such as sql.NullString,
+// and the type of its value.
+type nullableType struct {
+	elem reflect.Type
+	wrap func(v reflect.Value) reflect.Value
+	// unwrap returns the value, and false when null.
+	unwrap func(n reflect.Value) (reflect.Value, bool)
+}
+
+var (
+	nullablesMu sync.RWMutex
+	nullables   = map[reflect.Type]*nullableType{}
+)
+
+func init() {
+	RegisterNullable(func(v string) sql.NullString { return sql.NullString{String: v, Valid: true} },
+		func(n sql.NullString) (string, bool) { return n.String, n.Valid })
+	RegisterNullable(func(v int64) sql.NullInt64 { return sql.NullInt64{Int64: v, Valid: true} },
+		func(n sql.NullInt64) (int64, bool) { return n.Int64, n.Valid })
+	RegisterNullable(func(v int32) sql.NullInt32 { return sql.NullInt32{Int32: v, Valid: true} },
+		func(n sql.NullInt32) (int32, bool) { return n.Int32, n.Valid })
+	RegisterNullable(func(v int16) sql.NullInt16 { return sql.NullInt16{Int16: v, Valid: true} },
+		func(n sql.NullInt16) (int16, bool) { return n.Int16, n.Valid })
+	RegisterNullable(func(v byte) sql.NullByte { return sql.NullByte{Byte: v, Valid: true} },
+		func(n sql.NullByte) (byte, bool) { return n.Byte, n.Valid })
+	RegisterNullable(func(v float64) sql.NullFloat64 { return sql.NullFloat64{Float64: v, Valid: true} },
+		func(n sql.NullFloat64) (float64, bool) { return n.Float64, n.Valid })
+	RegisterNullable(func(v bool) sql.NullBool { return sql.NullBool{Bool: v, Valid: true} },
+		func(n sql.NullBool) (bool, bool) { return n.Bool, n.Valid })
+	RegisterNullable(func(v time.Time) sql.NullTime { return sql.NullTime{Time: v, Valid: true} },
+		func(n sql.NullTime) (time.Time, bool) { return n.Time, n.Valid })
+}
+
+// RegisterNullable makes fields of type N, a value of type V or null, such
+// as pgtype.Text, parse as V does: an empty value is null, any other is
+// parsed as V and made valid with wrap. unwrap returns the value and
+// whether it is valid. The types of database/sql, such as sql.NullString
+// and sql.NullTime, are built in.
+func RegisterNullable[N any, V any](wrap func(V) N, unwrap func(N) (V, bool)) {
+	n := &nullableType{
+		elem: reflect.TypeOf((*V)(nil)).Elem(),
+		wrap: func(v reflect.Value) reflect.Value {
+			return reflect.ValueOf(wrap(v.Interface().(V)))
+		},
+		unwrap: func(n reflect.Value) (reflect.Value, bool) {
+			v, ok := unwrap(n.Interface().(N))
+			return reflect.ValueOf(&v).Elem(), ok
+		},
+	}
+	t := reflect.TypeOf((*N)(nil)).Elem()
+	nullablesMu.Lock()
+	nullables[t] = n
+	nullablesMu.Unlock()
+	// fields of type N and *N are leaves from now on
+	leafTypes.Delete(t)
+	leafTypes.Delete(reflect.PointerTo(t))
+}
+
+func lookupNullable(t reflect.Type) (*nullableType, bool) {
+	nullablesMu.RLock()
+	defer nullablesMu.RUnlock()
+	n, ok := nullables[t]
+	return n, ok
+}
+
+func isNullable(t reflect.Type) bool {
+	_, ok := lookupNullable(t)
+	return ok
+}
+
+// set parses v as the value of n into val, or sets val to null when v is
+// empty.
+func (n *nullableType) set(o parseOptions, val reflect.Value, v string) error {
+	if v == "" {
+		val.Set(reflect.Zero(val.Type()))
+		return nil
+	}
+	elem := reflect.New(n.elem).Elem()
+	if err := o.set(elem, n.elem, v); err != nil {
+		return err
+	}
+	return assignValue(val, n.wrap(elem))
+}
+
+// format renders val as its value, or empty when null.
+func (n *nullableType) format(val reflect.Value) string {
+	v, ok := n.unwrap(val)
+	if !ok {
+		return ""
+	}
+	return formatValue(v)
+}
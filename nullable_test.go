@@ -0,0 +1,69 @@
+package configurator
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNullable(t *testing.T) {
+	type example struct {
+		Name    sql.NullString  `config:"env"`
+		Limit   sql.NullInt64   `config:"env,default=10"`
+		Ratio   sql.NullFloat64 `config:"env"`
+		Enabled sql.NullBool    `config:"env"`
+		Since   sql.NullTime    `config:"env"`
+		Until   sql.NullTime    `yaml:"until"`
+		Owner   *sql.NullString `config:"env"`
+		Empty   sql.NullInt32   `config:"env"`
+		Unset   sql.NullInt16   `config:"env"`
+	}
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("until: 2030-01-02T03:04:05Z\nratio: 0.5\n"), 0o600))
+	environ := []string{"NAME=app", "ENABLED=false", "SINCE=2024-01-02T03:04:05Z", "OWNER=ops", "EMPTY="}
+	c := NewConfigurator(WithFileProvider(filename), WithENVProvider(""), WithDefaultProvider(), WithEnviron(environ))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, sql.NullString{String: "app", Valid: true}, cfg.Name)
+	assert.Equal(t, sql.NullInt64{Int64: 10, Valid: true}, cfg.Limit)
+	assert.Equal(t, sql.NullFloat64{Float64: 0.5, Valid: true}, cfg.Ratio)
+	assert.Equal(t, sql.NullBool{Bool: false, Valid: true}, cfg.Enabled)
+	assert.Equal(t, sql.NullTime{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}, cfg.Since)
+	assert.Equal(t, sql.NullTime{Time: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}, cfg.Until)
+	assert.Equal(t, &sql.NullString{String: "ops", Valid: true}, cfg.Owner)
+	assert.False(t, cfg.Empty.Valid)
+	assert.False(t, cfg.Unset.Valid)
+	assert.Equal(t, "file", c.Provenance()["Until"])
+	assert.Equal(t, "app", c.report().Config["Name"])
+	assert.Equal(t, "", c.report().Config["Unset"])
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"LIMIT=ten"}))
+	err := c.Load(&example{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "LIMIT")
+	}
+}
+
+// version is a nullable type of another package, as pgtype.Int4 is.
+type version struct {
+	N     int
+	Valid bool
+}
+
+func TestRegisterNullable(t *testing.T) {
+	RegisterNullable(func(v int) version { return version{N: v, Valid: true} },
+		func(v version) (int, bool) { return v.N, v.Valid })
+	type example struct {
+		Version version `config:"env"`
+		Other   version `config:"env"`
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"VERSION=3"}))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, version{N: 3, Valid: true}, cfg.Version)
+	assert.Equal(t, version{}, cfg.Other)
+}
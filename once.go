@@ -0,0 +1,42 @@
+package configurator
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// onces holds a *loadOnce per configuration type loaded by MustLoadOnce.
+var onces sync.Map
+
+type loadOnce struct {
+	once sync.Once
+	v    interface{}
+}
+
+// MustLoadOnce loads a T with a configurator made of options on its first
+// call in the process, and returns the same pointer on every later call,
+// whatever their options, replacing a global configuration loaded in init:
+//
+//	func Config() *AppConfig { return configurator.MustLoadOnce[AppConfig]() }
+//
+// When the load fails, it reports why and exits as MustLoad does.
+func MustLoadOnce[T any](options ...ConfiguratorOption) *T {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	o, _ := onces.LoadOrStore(t, &loadOnce{})
+	lo := o.(*loadOnce)
+	first := false
+	lo.once.Do(func() {
+		first = true
+		v := new(T)
+		if NewConfigurator(options...).mustLoad(context.Background(), v, nil) {
+			lo.v = v
+		}
+	})
+	if lo.v == nil && !first {
+		// the first call reported the failure
+		exit(1)
+	}
+	v, _ := lo.v.(*T)
+	return v
+}
@@ -0,0 +1,56 @@
+package configurator
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMustLoadOnce(t *testing.T) {
+	t.Parallel()
+	type onceConfig struct {
+		Name string `config:"env,default=web"`
+	}
+	var wg sync.WaitGroup
+	got := make([]*onceConfig, 8)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = MustLoadOnce[onceConfig](WithFileProvider(""), WithDefaultProvider(), WithLookupEnv(func(string) (string, bool) { return "", false }))
+		}(i)
+	}
+	wg.Wait()
+	for _, cfg := range got {
+		assert.Same(t, got[0], cfg)
+	}
+	assert.Equal(t, "web", got[0].Name)
+	// later options are ignored
+	assert.Same(t, got[0], MustLoadOnce[onceConfig](WithFileProvider(""), WithEnviron([]string{"NAME=api"})))
+}
+
+func TestMustLoadOnce_Fails(t *testing.T) {
+	var buf bytes.Buffer
+	var codes []int
+	defer func(w io.Writer, fn func(int)) { stderr, exit = w, fn }(stderr, exit)
+	stderr, exit = &buf, func(c int) { codes = append(codes, c) }
+
+	type failingConfig struct {
+		Name string `config:"env,required"`
+	}
+	load := func(value string) *failingConfig {
+		return MustLoadOnce[failingConfig](WithFileProvider(""), WithLookupEnv(func(string) (string, bool) { return value, value != "" }))
+	}
+	assert.Nil(t, load(""))
+	assert.Equal(t, []int{1}, codes)
+	assert.Contains(t, buf.String(), "1 problem loading *configurator.failingConfig")
+	assert.Contains(t, buf.String(), "Name: required field not set")
+	// the failure is kept, not retried
+	buf.Reset()
+	assert.Nil(t, load("api"))
+	assert.Equal(t, []int{1, 1}, codes)
+	assert.Empty(t, buf.String())
+}
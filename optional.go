@@ -0,0 +1,116 @@
+package configurator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Optional holds a value that may be unset, telling a setting left out
+// apart from one set to its zero value without using a pointer:
+//
+//	type Config struct {
+//		Compression configurator.Optional[bool] `config:"env"`
+//	}
+//
+// Files set it from any value but null, env vars and flags whenever they
+// are present.
+type Optional[T any] struct {
+	v   T
+	set bool
+}
+
+// Some returns an Optional holding v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{v: v, set: true}
+}
+
+// Get returns the value and whether it is set.
+func (o Optional[T]) Get() (T, bool) {
+	return o.v, o.set
+}
+
+// OrElse returns the value if it is set, def otherwise.
+func (o Optional[T]) OrElse(def T) T {
+	if o.set {
+		return o.v
+	}
+	return def
+}
+
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+func (o *Optional[T]) Set(v T) {
+	o.v, o.set = v, true
+}
+
+// Unset clears the value.
+func (o *Optional[T]) Unset() {
+	var zero T
+	o.v, o.set = zero, false
+}
+
+func (o Optional[T]) String() string {
+	if !o.set {
+		return "<unset>"
+	}
+	return fmt.Sprint(o.v)
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.v)
+}
+
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		o.Unset()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	o.Set(v)
+	return nil
+}
+
+func (o Optional[T]) MarshalYAML() (interface{}, error) {
+	if !o.set {
+		return nil, nil
+	}
+	return o.v, nil
+}
+
+func (o *Optional[T]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		o.Unset()
+		return nil
+	}
+	var v T
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	o.Set(v)
+	return nil
+}
+
+func (o *Optional[T]) elemType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (o *Optional[T]) load() reflect.Value {
+	v := o.v
+	return reflect.ValueOf(&v).Elem()
+}
+
+func (o *Optional[T]) store(v reflect.Value) {
+	o.Set(v.Interface().(T))
+}
@@ -0,0 +1,71 @@
+package configurator
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptional(t *testing.T) {
+	var o Optional[int]
+	v, ok := o.Get()
+	assert.Equal(t, 0, v)
+	assert.False(t, ok)
+	assert.Equal(t, 5, o.OrElse(5))
+	assert.Equal(t, "<unset>", o.String())
+
+	o.Set(0)
+	assert.True(t, o.IsSet())
+	assert.Equal(t, 0, o.OrElse(5))
+
+	o.Unset()
+	assert.Equal(t, Optional[int]{}, o)
+	assert.Equal(t, "3", Some(3).String())
+}
+
+func TestOptional_Load(t *testing.T) {
+	type example struct {
+		Compress Optional[bool]          `yaml:"compress"`
+		Level    Optional[int]           `yaml:"level"`
+		Timeout  Optional[time.Duration] `config:"env"`
+		Retries  Optional[int]           `config:"env,default=3"`
+		Name     Optional[string]        `config:"env"`
+		Hosts    Optional[[]string]      `config:"env"`
+	}
+
+	f, err := os.CreateTemp("", "*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString("compress: false\nlevel: null\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfigurator(
+		WithFileProvider(f.Name()),
+		WithENVProvider(""),
+		WithEnviron([]string{"TIMEOUT=2s", "HOSTS=a,b"}),
+		WithDefaultProvider(),
+	)
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, Some(false), cfg.Compress)
+	assert.False(t, cfg.Level.IsSet())
+	assert.Equal(t, Some(2*time.Second), cfg.Timeout)
+	assert.Equal(t, Some(3), cfg.Retries)
+	assert.False(t, cfg.Name.IsSet())
+	assert.Equal(t, Some([]string{"a", "b"}), cfg.Hosts)
+	assert.True(t, c.IsSet("Compress"))
+
+	b, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Compress":false,"Level":null,"Timeout":2000000000,"Retries":3,"Name":null,"Hosts":["a","b"]}`, string(b))
+
+	var decoded example
+	assert.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, *cfg, decoded)
+}
@@ -0,0 +1,89 @@
+package configurator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// OverrideStore persists the runtime overrides applied through the admin
+// handler, e.g. to a local file or a key/value store, so that they survive
+// restarts when the store is also configured as a provider.
+type OverrideStore interface {
+	SaveOverrides(ctx context.Context, overrides map[string]string) error
+}
+
+// overrides sets fields by path, it runs after every other provider.
+type overrides map[string]string
+
+func (o overrides) Provide(_ interface{}, si StructInfo) error {
+	for _, fi := range si.Fields() {
+		val, ok := o[fi.Path()]
+		if !ok {
+			continue
+		}
+		if err := fi.Set(val); err != nil {
+			return fmt.Errorf("overrides/Provide: %w [%s]", err, fi.Path())
+		}
+	}
+	return nil
+}
+
+func (o overrides) String() string {
+	return "override"
+}
+
+// NewOverrideFile returns a provider and OverrideStore keeping overrides in
+// filename as a JSON object of field paths to values. A missing file sets
+// nothing.
+func NewOverrideFile(filename string) *overrideFile {
+	return &overrideFile{filename: filename}
+}
+
+type overrideFile struct {
+	filename string
+}
+
+var _ OverrideStore = &overrideFile{}
+
+func (p overrideFile) Provide(v interface{}, si StructInfo) error {
+	b, err := os.ReadFile(p.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("overrideFile/Provide: %w [%s]", err, p.filename)
+	}
+	var o overrides
+	if err := json.Unmarshal(b, &o); err != nil {
+		return fmt.Errorf("overrideFile/Provide: %w [%s]", err, p.filename)
+	}
+	return o.Provide(v, si)
+}
+
+func (p overrideFile) SaveOverrides(_ context.Context, o map[string]string) error {
+	b, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p.filename), filepath.Base(p.filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p.filename)
+}
+
+func (p overrideFile) String() string {
+	return "override-file"
+}
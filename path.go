@@ -0,0 +1,138 @@
+package configurator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	pathExists = "exists"
+	pathFile   = "file"
+	pathDir    = "dir"
+)
+
+// pathChecks are the values of the `path=` tag option: the path must exist,
+// or be a readable file or directory.
+var pathChecks = []string{pathExists, pathFile, pathDir}
+
+// WithPathBase makes the relative values of fields tagged path absolute
+// against dir instead of the working directory.
+func WithPathBase(dir string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.pathBase = dir
+	}
+}
+
+// expandPaths expands ~ and env vars in the string fields tagged path, and
+// makes them absolute, once every source ran.
+func (c *Configurator) expandPaths(fields []FieldInfo, lookup func(string) (string, bool)) error {
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || !f.tag.path || f.disabled {
+			continue
+		}
+		lv := leaf(f.val)
+		if !isPathType(lv.Type()) {
+			return fmt.Errorf("configurator/LoadContext: %w, `path` needs a string field [%s]", ErrInvalidTagFormat, f.Path())
+		}
+		if lv.IsZero() {
+			continue
+		}
+		if !lv.CanSet() {
+			// the value of a Dynamic or Optional is a copy, stored back below
+			cp := reflect.New(lv.Type()).Elem()
+			cp.Set(lv)
+			lv = cp
+		}
+		v := lv
+		for v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		paths := []reflect.Value{v}
+		if v.Kind() == reflect.Slice {
+			paths = paths[:0]
+			for i := 0; i < v.Len(); i++ {
+				paths = append(paths, v.Index(i))
+			}
+		}
+		for _, p := range paths {
+			if p.String() == "" {
+				continue
+			}
+			path, err := c.expandPath(p.String(), lookup)
+			if err != nil {
+				return fmt.Errorf("configurator/LoadContext: %w [%s]", err, f.Path())
+			}
+			if err := checkPath(path, f.tag.pathCheck); err != nil {
+				return fmt.Errorf("configurator/LoadContext: %w [%s]", err, f.Path())
+			}
+			p.SetString(path)
+		}
+		if d, ok := asWrapper(f.val); ok {
+			d.store(lv)
+		}
+	}
+	return nil
+}
+
+// isPathType reports whether the values of t can be paths: strings, lists
+// of strings and pointers to them.
+func isPathType(t reflect.Type) bool {
+	t = indirect(t)
+	return t.Kind() == reflect.String || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String
+}
+
+func (c *Configurator) expandPath(p string, lookup func(string) (string, bool)) (string, error) {
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		p = home + p[1:]
+	}
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	p = os.Expand(p, func(k string) string {
+		v, _ := lookup(k)
+		return v
+	})
+	if !filepath.IsAbs(p) {
+		base := c.pathBase
+		if base == "" {
+			wd, err := os.Getwd()
+			if err != nil {
+				return "", err
+			}
+			base = wd
+		}
+		p = filepath.Join(base, p)
+	}
+	return filepath.Clean(p), nil
+}
+
+func checkPath(p, check string) error {
+	if check == "" {
+		return nil
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	switch {
+	case check == pathFile && !fi.Mode().IsRegular():
+		return fmt.Errorf("%w: %s is not a file", ErrInvalidValue, p)
+	case check == pathDir && !fi.IsDir():
+		return fmt.Errorf("%w: %s is not a directory", ErrInvalidValue, p)
+	case check == pathFile || check == pathDir:
+		f, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+		}
+		f.Close()
+	}
+	return nil
+}
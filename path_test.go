@@ -0,0 +1,62 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPaths(t *testing.T) {
+	home, err := os.UserHomeDir()
+	assert.NoError(t, err)
+	base := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(base, "ca.pem"), nil, 0o600))
+
+	type example struct {
+		Cache   string           `config:"env,path"`
+		Data    *string          `config:"env,path=dir"`
+		CA      string           `config:"env,path=file"`
+		Plugins []string         `config:"env,path"`
+		Log     Dynamic[string]  `config:"env,path,default=logs/app.log"`
+		Socket  Optional[string] `config:"env,path"`
+		Name    string           `config:"env"`
+	}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithDefaultProvider(),
+		WithPathBase(base),
+		WithEnviron([]string{
+			"CACHE=~/.cache/app",
+			"DATA=${STATE}/data/..",
+			"STATE=" + base,
+			"CA=ca.pem",
+			"PLUGINS=/opt/a,./b",
+			"NAME=~/raw",
+		}),
+	)
+	var v example
+	assert.NoError(t, c.Load(&v))
+	assert.Equal(t, filepath.Join(home, ".cache/app"), v.Cache)
+	assert.Equal(t, base, *v.Data)
+	assert.Equal(t, filepath.Join(base, "ca.pem"), v.CA)
+	assert.Equal(t, []string{"/opt/a", filepath.Join(base, "b")}, v.Plugins)
+	assert.Equal(t, filepath.Join(base, "logs/app.log"), v.Log.Get())
+	assert.False(t, v.Socket.IsSet())
+	assert.Equal(t, "~/raw", v.Name)
+
+	for _, env := range []string{"CA=missing.pem", "CA=.", "DATA=ca.pem"} {
+		c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithPathBase(base), WithEnviron([]string{env}))
+		err := c.Load(&example{})
+		assert.True(t, errors.Is(err, ErrInvalidValue), env)
+	}
+
+	type invalid struct {
+		Port int `config:"path=exe"`
+	}
+	_, err = getStructInfo(&invalid{}, nil)
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+}
@@ -0,0 +1,269 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FailureMode decides what a failing provider means for the load.
+type FailureMode int
+
+const (
+	// FailureRequired fails the load.
+	FailureRequired FailureMode = iota
+	// FailureOptional reports a warning and continues without the provider.
+	FailureOptional
+	// FailureCached reports a warning and applies the last successful fetch.
+	// It fails the load when nothing has been fetched yet, and behaves like
+	// FailureRequired for providers that don't implement Fetcher.
+	FailureCached
+)
+
+// RetryPolicy retries a failing provider up to Attempts times, waiting
+// Backoff, doubled after every attempt and capped at MaxBackoff, in between.
+// Jitter randomizes each wait by up to that fraction in either direction.
+type RetryPolicy struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Jitter     float64
+}
+
+func (r RetryPolicy) delay(attempt int) time.Duration {
+	max := r.MaxBackoff
+	if max <= 0 {
+		max = math.MaxInt64 / 2
+	}
+	d := r.Backoff
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if r.Jitter > 0 {
+		d += time.Duration(float64(d) * r.Jitter * (rand.Float64()*2 - 1))
+	}
+	return d
+}
+
+func (r RetryPolicy) do(ctx context.Context, fn func() error) error {
+	var err error
+	for i := 0; i == 0 || i < r.Attempts; i++ {
+		if i > 0 {
+			t := time.NewTimer(r.delay(i))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return err
+			case <-t.C:
+			}
+		}
+		if err = fn(); err == nil || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// CircuitBreaker stops calling a provider that failed Threshold loads in a
+// row for Cooldown, failing fast with ErrCircuitOpen, or applying the last
+// fetch with FailureCached, instead. After the cooldown a single attempt
+// is let through: the circuit closes if it succeeds and opens again if it
+// fails. A zero Threshold disables the breaker.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type Policy struct {
+	Retry   RetryPolicy
+	Failure FailureMode
+	Breaker CircuitBreaker
+}
+
+// ProviderWithPolicy wraps p with a retry policy, a failure mode and a
+// circuit breaker.
+func ProviderWithPolicy(p Provider, policy Policy) Fetcher {
+	return &policyProvider{provider: p, policy: policy, now: time.Now}
+}
+
+type policyProvider struct {
+	provider Provider
+	policy   Policy
+	now      func() time.Time
+
+	mu     sync.Mutex
+	cached Provider
+	// state is that of the circuit breaker, opened at opened after
+	// failures loads failed in a row.
+	state    circuitState
+	failures int
+	opened   time.Time
+}
+
+func (p *policyProvider) Provide(v interface{}, si StructInfo) error {
+	return p.ProvideContext(context.Background(), v, si)
+}
+
+func (p *policyProvider) ProvideContext(ctx context.Context, v interface{}, si StructInfo) error {
+	fp, err := p.Fetch(ctx)
+	var w *warning
+	if errors.As(err, &w) && w.fallback != nil {
+		fp, err = w.fallback, nil
+	}
+	if err != nil {
+		return err
+	}
+	return provide(ctx, fp, v, si, 0)
+}
+
+func (p *policyProvider) Fetch(ctx context.Context) (Provider, error) {
+	f, ok := p.provider.(Fetcher)
+	if !ok {
+		return retryProvider{p}, nil
+	}
+	retry, err := p.allow()
+	if err != nil {
+		return nil, p.fail(err)
+	}
+	var fp Provider
+	err = retry.do(ctx, func() error {
+		var err error
+		fp, err = fetch(ctx, f, 0)
+		return err
+	})
+	p.record(ctx, err)
+	if err != nil {
+		return nil, p.fail(err)
+	}
+	if p.policy.Failure == FailureCached {
+		p.mu.Lock()
+		p.cached = fp
+		p.mu.Unlock()
+	}
+	return fp, nil
+}
+
+// allow returns the retry policy of the next attempt, a single try when
+// the circuit is half-open, or ErrCircuitOpen when it is open.
+func (p *policyProvider) allow() (RetryPolicy, error) {
+	b := p.policy.Breaker
+	if b.Threshold <= 0 {
+		return p.policy.Retry, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.state {
+	case circuitOpen:
+		if p.now().Sub(p.opened) < b.Cooldown {
+			return RetryPolicy{}, fmt.Errorf("configurator/Fetch: %w [%s]", ErrCircuitOpen, providerName(p))
+		}
+		p.state = circuitHalfOpen
+		return RetryPolicy{}, nil
+	case circuitHalfOpen:
+		// another load is trying the provider
+		return RetryPolicy{}, fmt.Errorf("configurator/Fetch: %w [%s]", ErrCircuitOpen, providerName(p))
+	}
+	return p.policy.Retry, nil
+}
+
+// record updates the circuit with the outcome of an attempt. Cancelled
+// attempts don't count, but let the next load try again.
+func (p *policyProvider) record(ctx context.Context, err error) {
+	b := p.policy.Breaker
+	if b.Threshold <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case err == nil:
+		p.state, p.failures = circuitClosed, 0
+		return
+	case ctx.Err() != nil:
+		if p.state == circuitHalfOpen {
+			p.state = circuitOpen
+		}
+		return
+	}
+	p.failures++
+	if p.state == circuitHalfOpen || p.failures >= b.Threshold {
+		p.state, p.opened = circuitOpen, p.now()
+	}
+}
+
+func (p *policyProvider) fail(err error) error {
+	switch p.policy.Failure {
+	case FailureOptional:
+		return &warning{reason: fmt.Sprintf("provider %s failed, skipped", providerName(p)), err: err}
+	case FailureCached:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.cached != nil {
+			return &warning{reason: fmt.Sprintf("provider %s failed, using cached data", providerName(p)), err: err, fallback: p.cached}
+		}
+	}
+	return err
+}
+
+func (p *policyProvider) String() string {
+	return providerName(p.provider)
+}
+
+// retryProvider applies a provider that can't be fetched ahead of time.
+type retryProvider struct {
+	p *policyProvider
+}
+
+func (r retryProvider) Provide(v interface{}, si StructInfo) error {
+	return r.ProvideContext(context.Background(), v, si)
+}
+
+func (r retryProvider) ProvideContext(ctx context.Context, v interface{}, si StructInfo) error {
+	retry, err := r.p.allow()
+	if err != nil {
+		return r.p.fail(err)
+	}
+	err = retry.do(ctx, func() error {
+		return provide(ctx, r.p.provider, v, si, 0)
+	})
+	r.p.record(ctx, err)
+	if err != nil {
+		return r.p.fail(err)
+	}
+	return nil
+}
+
+// warning is a provider failure that doesn't fail the load. If fallback is
+// set it is applied in place of the failed provider.
+type warning struct {
+	reason   string
+	err      error
+	fallback Provider
+}
+
+func (w *warning) Error() string {
+	return fmt.Sprintf("%s: %v", w.reason, w.err)
+}
+
+func (w *warning) Unwrap() error {
+	return w.err
+}
+
+func (w *warning) Is(target error) bool {
+	return target == ErrDegraded
+}
@@ -0,0 +1,160 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyFetcher struct {
+	failures int
+	calls    int
+	value    string
+}
+
+func (f *flakyFetcher) Provide(v interface{}, si StructInfo) error {
+	fp, err := f.Fetch(context.Background())
+	if err != nil {
+		return err
+	}
+	return fp.Provide(v, si)
+}
+
+func (f *flakyFetcher) Fetch(context.Context) (Provider, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("unavailable")
+	}
+	return fetchedName(f.value), nil
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	r := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, r.delay(1))
+	assert.Equal(t, 2*time.Second, r.delay(2))
+	assert.Equal(t, 4*time.Second, r.delay(3))
+	assert.Equal(t, 5*time.Second, r.delay(4))
+	assert.Equal(t, 5*time.Second, r.delay(100))
+
+	r = RetryPolicy{Backoff: time.Second, Jitter: 0.5}
+	for i := 0; i < 10; i++ {
+		d := r.delay(1)
+		assert.True(t, d >= 500*time.Millisecond && d <= 1500*time.Millisecond)
+	}
+}
+
+func TestProviderWithPolicy_Retry(t *testing.T) {
+	f := &flakyFetcher{failures: 2, value: "a"}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithProvider(ProviderWithPolicy(f, Policy{Retry: RetryPolicy{Attempts: 3, Backoff: time.Millisecond}})),
+	)
+	cfg := &struct{ Name string }{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "a", cfg.Name)
+	assert.Equal(t, 3, f.calls)
+}
+
+func TestProviderWithPolicy_Optional(t *testing.T) {
+	var warnings []error
+	f := &flakyFetcher{failures: 1, value: "a"}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithWarningHandler(func(err error) { warnings = append(warnings, err) }),
+		WithProvider(ProviderWithPolicy(f, Policy{Failure: FailureOptional})),
+	)
+	cfg := &struct{ Name string }{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "", cfg.Name)
+	assert.Len(t, warnings, 1)
+	assert.True(t, errors.Is(warnings[0], ErrDegraded))
+
+	c = NewConfigurator(WithFileProvider(""), WithProvider(ProviderWithPolicy(&flakyFetcher{failures: 1}, Policy{})))
+	assert.Error(t, c.Load(cfg))
+}
+
+func TestProviderWithPolicy_Cached(t *testing.T) {
+	var warnings []error
+	f := &flakyFetcher{value: "a"}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithWarningHandler(func(err error) { warnings = append(warnings, err) }),
+		WithProvider(ProviderWithPolicy(f, Policy{Failure: FailureCached})),
+	)
+	cfg := &struct{ Name string }{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "a", cfg.Name)
+
+	f.failures = 100
+	cfg.Name = ""
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "a", cfg.Name)
+	assert.Len(t, warnings, 1)
+}
+
+func TestProviderWithPolicy_Breaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	f := &flakyFetcher{failures: 100, value: "a"}
+	p := ProviderWithPolicy(f, Policy{Breaker: CircuitBreaker{Threshold: 2, Cooldown: time.Minute}}).(*policyProvider)
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// closed: failures below the threshold reach the provider
+	for i := 1; i <= 2; i++ {
+		_, err := p.Fetch(ctx)
+		assert.False(t, errors.Is(err, ErrCircuitOpen))
+		assert.Equal(t, i, f.calls)
+	}
+
+	// open: fail fast until the cooldown passed
+	_, err := p.Fetch(ctx)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 2, f.calls)
+
+	// half-open: a failing attempt opens the circuit again
+	now = now.Add(time.Minute)
+	_, err = p.Fetch(ctx)
+	assert.False(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 3, f.calls)
+	_, err = p.Fetch(ctx)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 3, f.calls)
+
+	// half-open: a successful attempt closes it
+	now = now.Add(time.Minute)
+	f.failures = 0
+	fp, err := p.Fetch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, fetchedName("a"), fp)
+	f.failures = 100
+	_, err = p.Fetch(ctx)
+	assert.False(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, 5, f.calls)
+}
+
+func TestProviderWithPolicy_BreakerCached(t *testing.T) {
+	var warnings []error
+	f := &flakyFetcher{value: "a"}
+	p := ProviderWithPolicy(f, Policy{Failure: FailureCached, Breaker: CircuitBreaker{Threshold: 1, Cooldown: time.Hour}})
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithWarningHandler(func(err error) { warnings = append(warnings, err) }),
+		WithProvider(p),
+	)
+	cfg := &struct{ Name string }{}
+	assert.NoError(t, c.Load(cfg))
+
+	f.failures = 100
+	for i := 0; i < 3; i++ {
+		cfg.Name = ""
+		assert.NoError(t, c.Load(cfg))
+		assert.Equal(t, "a", cfg.Name)
+	}
+	assert.Equal(t, 2, f.calls)
+	if assert.Len(t, warnings, 3) {
+		assert.True(t, errors.Is(warnings[2], ErrCircuitOpen))
+	}
+}
@@ -0,0 +1,110 @@
+package configurator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPolicy is returned when the configuration loaded violates a policy.
+var ErrPolicy = errors.New("policy violated")
+
+// PolicyEvaluator evaluates a policy, such as a CEL expression or a Rego
+// module compiled by the application, against input, the effective
+// configuration as a document of the keys of config files, and returns the
+// violations found, none when it holds. Values of secret fields are masked.
+type PolicyEvaluator interface {
+	Evaluate(ctx context.Context, input map[string]interface{}) ([]string, error)
+}
+
+// PolicyFunc adapts a function to a PolicyEvaluator, such as one running a
+// Rego query:
+//
+//	configurator.PolicyFunc(func(ctx context.Context, input map[string]interface{}) ([]string, error) {
+//		rs, err := query.Eval(ctx, rego.EvalInput(input))
+//		...
+//	})
+type PolicyFunc func(ctx context.Context, input map[string]interface{}) ([]string, error)
+
+func (f PolicyFunc) Evaluate(ctx context.Context, input map[string]interface{}) ([]string, error) {
+	return f(ctx, input)
+}
+
+type namedPolicy struct {
+	name string
+	e    PolicyEvaluator
+}
+
+// WithPolicy checks every configuration loaded, or reloaded, against the
+// policy named name once it is valid, and rejects it when the policy finds
+// violations, so that platform teams enforce constraints such as no
+// wildcard CORS origin outside the code of applications. An error of the
+// evaluator fails the load too.
+func WithPolicy(name string, e PolicyEvaluator) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.policies = append(co.policies, namedPolicy{name: name, e: e})
+	}
+}
+
+func (c *Configurator) checkPolicies(ctx context.Context, fields []FieldInfo) error {
+	if len(c.policies) == 0 {
+		return nil
+	}
+	input, err := policyInput(fields)
+	if err != nil {
+		return fmt.Errorf("configurator/LoadContext: %w", err)
+	}
+	for _, p := range c.policies {
+		violations, err := p.e.Evaluate(ctx, input)
+		if err != nil {
+			return fmt.Errorf("configurator/LoadContext: policy: %w [%s]", err, p.name)
+		}
+		if len(violations) > 0 {
+			err := fmt.Errorf("configurator/LoadContext: %w: %s [%s]", ErrPolicy, strings.Join(violations, "; "), p.name)
+			return newFieldError(ErrPolicy, err, "")
+		}
+	}
+	return nil
+}
+
+// policyInput returns the values of fields as a document of the keys of
+// YAML files, with the values JSON would have.
+func policyInput(fields []FieldInfo) (map[string]interface{}, error) {
+	input := make(map[string]interface{})
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || f.disabled {
+			continue
+		}
+		keys := f.fileKeys("yaml")
+		m := input
+		for _, k := range keys[:len(keys)-1] {
+			sub, ok := m[k].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				m[k] = sub
+			}
+			m = sub
+		}
+		v := leaf(f.val)
+		var val interface{}
+		switch {
+		case f.Secret() && !v.IsZero():
+			val = secretMask
+		case v.Type() == durationType || v.Type() == timeType:
+			val = formatValue(v)
+		default:
+			b, err := json.Marshal(v.Interface())
+			if err != nil {
+				return nil, fmt.Errorf("%w [%s]", err, f.Path())
+			}
+			if err := json.Unmarshal(b, &val); err != nil {
+				return nil, fmt.Errorf("%w [%s]", err, f.Path())
+			}
+		}
+		m[keys[len(keys)-1]] = val
+	}
+	return input, nil
+}
@@ -0,0 +1,71 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type policyConfig struct {
+	Server struct {
+		CORS       []string      `config:"env" yaml:"cors"`
+		MinTLS     string        `config:"env" yaml:"min_tls"`
+		Timeout    time.Duration `config:"env"`
+		Port       int           `config:"env"`
+		AdminToken string        `config:"env,secret" yaml:"admin_token"`
+	}
+}
+
+// orgPolicy stands in for a CEL or Rego policy of the platform team.
+var orgPolicy = PolicyFunc(func(_ context.Context, input map[string]interface{}) ([]string, error) {
+	server := input["server"].(map[string]interface{})
+	var violations []string
+	for _, o := range server["cors"].([]interface{}) {
+		if o == "*" {
+			violations = append(violations, "wildcard CORS origin")
+		}
+	}
+	if server["min_tls"] != "1.2" && server["min_tls"] != "1.3" {
+		violations = append(violations, fmt.Sprintf("TLS %v below 1.2", server["min_tls"]))
+	}
+	return violations, nil
+})
+
+func TestWithPolicy(t *testing.T) {
+	t.Parallel()
+	var input map[string]interface{}
+	record := PolicyFunc(func(_ context.Context, in map[string]interface{}) ([]string, error) {
+		input = in
+		return nil, nil
+	})
+	environ := []string{"SERVER_CORS=https://a.example", "SERVER_MINTLS=1.2", "SERVER_TIMEOUT=5s", "SERVER_PORT=443", "SERVER_ADMINTOKEN=t0k"}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ),
+		WithPolicy("record", record), WithPolicy("org", orgPolicy))
+	cfg := &policyConfig{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, map[string]interface{}{"server": map[string]interface{}{
+		"cors":        []interface{}{"https://a.example"},
+		"min_tls":     "1.2",
+		"timeout":     "5s",
+		"port":        float64(443),
+		"admin_token": secretMask,
+	}}, input)
+
+	// a reload breaking the policy is rejected
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"SERVER_CORS=*", "SERVER_MINTLS=1.0"}),
+		WithPolicy("org", orgPolicy))
+	_, err := c.Reload(context.Background(), cfg)
+	assert.True(t, errors.Is(err, ErrPolicy))
+	assert.EqualError(t, err, "configurator/LoadContext: policy violated: wildcard CORS origin; TLS 1.0 below 1.2 [org]")
+	assert.Equal(t, "1.2", cfg.Server.MinTLS)
+
+	broken := PolicyFunc(func(context.Context, map[string]interface{}) ([]string, error) {
+		return nil, errors.New("compile error")
+	})
+	c = NewConfigurator(WithFileProvider(""), WithPolicy("broken", broken))
+	assert.EqualError(t, c.Load(&policyConfig{}), "configurator/LoadContext: policy: compile error [broken]")
+}
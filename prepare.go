@@ -0,0 +1,78 @@
+package configurator
+
+import (
+	"context"
+	"fmt"
+)
+
+// Participant is a component that must accept a reloaded configuration
+// together with the other participants before any of them applies it, such
+// as subsystems sharing a connection pool and its settings.
+type Participant interface {
+	// Prepare checks pending, the fresh value Reload loaded, and gets ready
+	// to apply it, returning an error to keep every participant on the
+	// current configuration.
+	Prepare(ctx context.Context, pending interface{}) error
+	// Commit applies pending once every participant prepared it.
+	Commit(pending interface{})
+}
+
+// Aborter is implemented by participants releasing what Prepare acquired
+// when another participant rejects the configuration.
+type Aborter interface {
+	Abort(pending interface{})
+}
+
+// Register makes p a participant of the reloads of c: Reload runs the
+// Prepare methods of the participants, in the order they registered, once
+// the fresh value passed every check, and only when all succeed records
+// it, updates the Dynamic fields and sections of the value reloaded and
+// runs their Commit methods. A rejected reload fails, leaving everything
+// on the current configuration, and the participants that prepared it are
+// aborted.
+func (c *Configurator) Register(p Participant) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parties = append(c.parties, p)
+}
+
+type reloadKey struct{}
+
+// prepare runs the Prepare methods of the participants on the value of a
+// reload.
+func (c *Configurator) prepare(ctx context.Context, pending interface{}) error {
+	if ctx.Value(reloadKey{}) == nil {
+		return nil
+	}
+	c.mu.RLock()
+	parties := c.parties
+	c.mu.RUnlock()
+	for i, p := range parties {
+		if err := p.Prepare(ctx, pending); err != nil {
+			for _, prepared := range parties[:i] {
+				if a, ok := prepared.(Aborter); ok {
+					a.Abort(pending)
+				}
+			}
+			return fmt.Errorf("configurator/Reload: %w [%s]", err, participantName(p))
+		}
+	}
+	return nil
+}
+
+// commit runs the Commit methods of the participants once a reload applied.
+func (c *Configurator) commit(pending interface{}) {
+	c.mu.RLock()
+	parties := c.parties
+	c.mu.RUnlock()
+	for _, p := range parties {
+		p.Commit(pending)
+	}
+}
+
+func participantName(p Participant) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", p)
+}
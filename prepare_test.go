@@ -0,0 +1,76 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type party struct {
+	name      string
+	reject    error
+	prepared  []int
+	committed []int
+	aborted   int
+}
+
+func (p *party) Prepare(_ context.Context, pending interface{}) error {
+	if p.reject != nil {
+		return p.reject
+	}
+	p.prepared = append(p.prepared, pending.(*poolConfig).Size.Get())
+	return nil
+}
+
+func (p *party) Commit(pending interface{}) {
+	p.committed = append(p.committed, pending.(*poolConfig).Size.Get())
+}
+
+func (p *party) Abort(interface{}) {
+	p.aborted++
+}
+
+func (p *party) String() string {
+	return p.name
+}
+
+type poolConfig struct {
+	Size Dynamic[int] `config:"env"`
+}
+
+func TestRegister(t *testing.T) {
+	env := map[string]string{"SIZE": "4"}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithLookupEnv(lookup))
+	db, cache := &party{name: "db"}, &party{name: "cache"}
+	c.Register(db)
+	c.Register(cache)
+	cfg := &poolConfig{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Empty(t, db.prepared, "only reloads are prepared")
+
+	env["SIZE"] = "8"
+	_, err := c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{8}, db.prepared)
+	assert.Equal(t, []int{8}, cache.committed)
+	assert.Equal(t, 8, cfg.Size.Get())
+
+	env["SIZE"] = "1000"
+	cache.reject = errors.New("too many connections")
+	_, err = c.Reload(context.Background(), cfg)
+	if assert.Error(t, err) {
+		assert.True(t, errors.Is(err, cache.reject))
+		assert.Contains(t, err.Error(), "[cache]")
+	}
+	assert.Equal(t, 1, db.aborted)
+	assert.Equal(t, 0, cache.aborted)
+	assert.Equal(t, []int{8}, db.committed)
+	assert.Equal(t, 8, cfg.Size.Get())
+	assert.Equal(t, "8", c.report().Config["Size"])
+}
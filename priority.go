@@ -0,0 +1,42 @@
+package configurator
+
+import (
+	"reflect"
+)
+
+// restrictPriority undoes what step s set in fields whose `priority=`
+// option ranks the source that set them before above s. Listed sources
+// rank above the others, which keep the configured order among them.
+func (c *Configurator) restrictPriority(s step, fields []FieldInfo, before []reflect.Value, origins map[string]string) {
+	for i, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || len(f.tag.priority) == 0 {
+			continue
+		}
+		rank, prev := sourceRank(f.tag.priority, s.name), sourceRank(f.tag.priority, origins[fi.Path()])
+		if prev == len(f.tag.priority) || rank <= prev {
+			continue
+		}
+		if !f.explicit && reflect.DeepEqual(before[i].Interface(), leaf(fi.Value()).Interface()) {
+			continue
+		}
+		c.logger.Debug("configurator: field kept by priority", "field", fi.Path(), "provider", s.name, "kept", origins[fi.Path()])
+		if d, ok := asWrapper(fi.Value()); ok {
+			d.store(before[i])
+		} else {
+			fi.Value().Set(before[i])
+		}
+		f.explicit = false
+	}
+}
+
+// sourceRank is the index of name in priority, len(priority) if it isn't
+// listed.
+func sourceRank(priority []string, name string) int {
+	for i, p := range priority {
+		if p == name {
+			return i
+		}
+	}
+	return len(priority)
+}
@@ -0,0 +1,41 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	resetForTesting()
+	type example struct {
+		Disabled bool   `config:"env,flag,priority=env>flag"`
+		Mode     string `config:"env,flag,priority=env"`
+		Name     string `config:"env,flag"`
+		Region   string `config:"env,flag,priority=env>flag,default=eu"`
+	}
+	os.Args = []string{"app", "-disabled=false", "-mode=fast", "-name=flag", "-region=us"}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{"DISABLED=true", "MODE=safe", "NAME=env"}),
+		WithFlagProvider(),
+		WithDefaultProvider(),
+	)
+	var v example
+	assert.NoError(t, c.Load(&v))
+	assert.Equal(t, example{Disabled: true, Mode: "safe", Name: "flag", Region: "us"}, v)
+	p := c.Provenance()
+	assert.Equal(t, "env", p["Disabled"])
+	assert.Equal(t, "env", p["Mode"])
+	assert.Equal(t, "flag", p["Name"])
+	assert.Equal(t, "flag", p["Region"])
+
+	type invalid struct {
+		Mode string `config:"priority=env>"`
+	}
+	_, err := getStructInfo(&invalid{}, nil)
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+}
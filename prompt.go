@@ -0,0 +1,129 @@
+package configurator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	promptProviderName = "prompt"
+	promptAttempts     = 3
+)
+
+// promptProvider asks for required fields that no other source set.
+type promptProvider struct {
+	r    *bufio.Reader
+	out  io.Writer
+	echo func(on bool)
+}
+
+// WithPrompt asks on out for the required fields still unset once every
+// other source ran, reading the answers from in, typically os.Stdin and
+// os.Stderr for a CLI tool, instead of failing the load. Input of secret
+// fields isn't echoed when in is a terminal. A field is left unset, and
+// the load fails as without the prompt, when in is at its end.
+func WithPrompt(in io.Reader, out io.Writer) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.prompt = &promptProvider{r: bufio.NewReader(in), out: out, echo: terminalEcho(in)}
+	}
+}
+
+func (p *promptProvider) Provide(v interface{}, si StructInfo) error {
+	byPath := make(map[string]*fieldInfo)
+	for _, fi := range si.Fields() {
+		if f, ok := fi.(*fieldInfo); ok {
+			byPath[f.Path()] = f
+		}
+	}
+	for _, fi := range si.Fields() {
+		f, ok := fi.(*fieldInfo)
+		if !ok || !f.required() || !leaf(f.val).IsZero() {
+			continue
+		}
+		if enabled, err := f.enabled(byPath); err != nil || !enabled {
+			continue
+		}
+		if err := p.ask(f); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("promptProvider/Provide: %w [%s]", err, f.Path())
+		}
+	}
+	return nil
+}
+
+// ask prompts for f until it gets a value that parses. An empty answer
+// leaves f unset.
+func (p *promptProvider) ask(f *fieldInfo) error {
+	label := f.Path()
+	if allowed := f.allowed(); len(allowed) > 0 {
+		label += " (one of " + strings.Join(allowed, ", ") + ")"
+	}
+	var err error
+	for i := 0; i < promptAttempts; i++ {
+		fmt.Fprintf(p.out, "%s: ", label)
+		line, rerr := p.readLine(f.Secret())
+		// a last line without a newline still counts
+		if rerr != nil && (line == "" || !errors.Is(rerr, io.EOF)) {
+			return rerr
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return nil
+		}
+		if len(f.tag.enum) > 0 {
+			line, err = allowedValue(f.tag.enum, line, f.parse.fold)
+		}
+		if err == nil {
+			if err = f.Set(line); err == nil {
+				return nil
+			}
+		}
+		fmt.Fprintf(p.out, "invalid %s: %v\n", f.Path(), err)
+	}
+	return err
+}
+
+func (p *promptProvider) readLine(secret bool) (string, error) {
+	if secret && p.echo != nil {
+		p.echo(false)
+		defer func() {
+			p.echo(true)
+			// the newline typed by the user wasn't echoed
+			fmt.Fprintln(p.out)
+		}()
+	}
+	return p.r.ReadString('\n')
+}
+
+func (p *promptProvider) String() string {
+	return promptProviderName
+}
+
+// terminalEcho returns a func turning the echo of the terminal in is on or
+// off, nil when in isn't a terminal.
+func terminalEcho(in io.Reader) func(on bool) {
+	f, ok := in.(*os.File)
+	if !ok {
+		return nil
+	}
+	if fi, err := f.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return func(on bool) {
+		mode := "-echo"
+		if on {
+			mode = "echo"
+		}
+		cmd := exec.Command("stty", mode)
+		cmd.Stdin = f
+		// without stty, as on Windows, the input stays visible
+		_ = cmd.Run()
+	}
+}
@@ -0,0 +1,43 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptProvider(t *testing.T) {
+	type example struct {
+		Host     string `config:"required"`
+		Port     int    `config:"required,default=5432"`
+		Password string `config:"required,secret"`
+		Mode     string `config:"required,enum=ro,rw"`
+		Debug    bool
+	}
+	var out strings.Builder
+	in := strings.NewReader("db.local\nsecret\nappend\nrw")
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithPrompt(in, &out))
+	var v example
+	assert.NoError(t, c.LoadContext(context.Background(), &v))
+	assert.Equal(t, example{Host: "db.local", Port: 5432, Password: "secret", Mode: "rw"}, v)
+	assert.Equal(t, "prompt", c.Provenance()["Password"])
+	assert.Equal(t, "Host: Password: Mode (one of ro, rw): invalid Mode: "+
+		`value not allowed "append", allowed [ro, rw]`+"\nMode (one of ro, rw): ", out.String())
+
+	// nothing left to answer
+	c = NewConfigurator(WithFileProvider(""), WithPrompt(strings.NewReader("db.local\n"), &out))
+	err := c.LoadContext(context.Background(), &example{})
+	assert.True(t, errors.Is(err, ErrRequired))
+
+	type conditional struct {
+		TLS  bool
+		Cert string `config:"required,if=TLS"`
+	}
+	out.Reset()
+	c = NewConfigurator(WithFileProvider(""), WithPrompt(strings.NewReader(""), &out))
+	assert.NoError(t, c.LoadContext(context.Background(), &conditional{}))
+	assert.Empty(t, out.String())
+}
@@ -0,0 +1,17 @@
+module github.com/ruosing/configurator/protoconfig
+
+go 1.21
+
+require (
+	github.com/ruosing/configurator v0.0.0
+	github.com/stretchr/testify v1.8.4
+	google.golang.org/protobuf v1.34.2
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
+
+replace github.com/ruosing/configurator => ../
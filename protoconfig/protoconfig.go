@@ -0,0 +1,250 @@
+// Package protoconfig loads configuration into protobuf messages, for
+// services whose config schema is defined in proto files. Files are read
+// with protojson, so JSON and YAML documents use the proto JSON mapping,
+// including "30s" for google.protobuf.Duration and RFC 3339 for
+// google.protobuf.Timestamp. Env vars are matched against field paths:
+//
+//	message Config { Server server = 1; }
+//	message Server { int32 port = 1; google.protobuf.Duration read_timeout = 2; }
+//
+// reads APP_SERVER_PORT and APP_SERVER_READ_TIMEOUT with the prefix "app".
+//
+// It lives in its own module to keep protobuf out of the core dependencies.
+package protoconfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+const sliceSeparator = ","
+
+type options struct {
+	filename  string
+	enableENV bool
+	prefix    string
+	lookup    func(string) (string, bool)
+}
+
+type Option func(*options)
+
+// WithFile reads filename, a .json, .yaml or .yml document.
+func WithFile(filename string) Option {
+	return func(o *options) {
+		o.filename = filename
+	}
+}
+
+// WithENV reads env vars named after the upper cased field path, joined by
+// "_" and prefixed with prefix when it isn't empty.
+func WithENV(prefix string) Option {
+	return func(o *options) {
+		o.enableENV = true
+		o.prefix = strings.ToUpper(prefix)
+	}
+}
+
+// WithLookupEnv replaces os.LookupEnv.
+func WithLookupEnv(lookup func(string) (string, bool)) Option {
+	return func(o *options) {
+		o.lookup = lookup
+	}
+}
+
+// Load fills m from the file, then from env vars. Reading the file resets m,
+// as proto decoding does, so values set beforehand only survive without it.
+func Load(m proto.Message, opts ...Option) error {
+	o := &options{lookup: os.LookupEnv}
+	for _, fn := range opts {
+		fn(o)
+	}
+	if o.filename != "" {
+		if err := loadFile(m, o.filename); err != nil {
+			return fmt.Errorf("protoconfig/Load: %w [%s]", err, o.filename)
+		}
+	}
+	if o.enableENV {
+		var prefix []string
+		if o.prefix != "" {
+			prefix = []string{o.prefix}
+		}
+		if _, err := loadENV(m.ProtoReflect(), prefix, o.lookup); err != nil {
+			return fmt.Errorf("protoconfig/Load: %w", err)
+		}
+	}
+	return nil
+}
+
+func loadFile(m proto.Message, filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return err
+		}
+		if doc == nil {
+			return nil
+		}
+		if b, err = json.Marshal(doc); err != nil {
+			return err
+		}
+	default:
+		return configurator.ErrUnsupported
+	}
+	return protojson.Unmarshal(b, m)
+}
+
+// loadENV sets the fields of m found in the environment and reports whether
+// any was set, so that unset sub messages aren't created.
+func loadENV(m protoreflect.Message, prefix []string, lookup func(string) (string, bool)) (bool, error) {
+	set := false
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := append(append([]string{}, prefix...), strings.ToUpper(string(fd.Name())))
+		key := strings.Join(path, "_")
+		switch {
+		case fd.IsMap():
+			continue
+		case fd.Message() != nil && !fd.IsList() && !isWellKnown(fd.Message()):
+			var sub protoreflect.Message
+			if m.Has(fd) {
+				sub = m.Mutable(fd).Message()
+			} else {
+				sub = m.NewField(fd).Message()
+			}
+			ok, err := loadENV(sub, path, lookup)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				m.Set(fd, protoreflect.ValueOfMessage(sub))
+				set = true
+			}
+			continue
+		}
+
+		val, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		if fd.IsList() {
+			list := m.NewField(fd).List()
+			for _, s := range strings.Split(val, sliceSeparator) {
+				v, err := parseValue(fd, list.NewElement, strings.TrimSpace(s))
+				if err != nil {
+					return false, fmt.Errorf("%w [%s]", err, key)
+				}
+				list.Append(v)
+			}
+			m.Set(fd, protoreflect.ValueOfList(list))
+		} else {
+			v, err := parseValue(fd, func() protoreflect.Value { return m.NewField(fd) }, val)
+			if err != nil {
+				return false, fmt.Errorf("%w [%s]", err, key)
+			}
+			m.Set(fd, v)
+		}
+		set = true
+	}
+	return set, nil
+}
+
+func isWellKnown(md protoreflect.MessageDescriptor) bool {
+	switch md.FullName() {
+	case "google.protobuf.Duration", "google.protobuf.Timestamp":
+		return true
+	}
+	return false
+}
+
+// parseValue parses s as a value of fd, newElem returns a new message value
+// for message fields.
+func parseValue(fd protoreflect.FieldDescriptor, newElem func() protoreflect.Value, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(u)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(u), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(s)
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("%w enum value %q", configurator.ErrUnsupported, s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+	case protoreflect.MessageKind:
+		return parseWellKnown(fd.Message(), newElem(), s)
+	}
+	return protoreflect.Value{}, fmt.Errorf("%w kind %s", configurator.ErrUnsupported, fd.Kind())
+}
+
+func parseWellKnown(md protoreflect.MessageDescriptor, v protoreflect.Value, s string) (protoreflect.Value, error) {
+	var src proto.Message
+	switch md.FullName() {
+	case "google.protobuf.Duration":
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		src = durationpb.New(d)
+	case "google.protobuf.Timestamp":
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		src = timestamppb.New(t)
+	default:
+		return protoreflect.Value{}, fmt.Errorf("%w message %s", configurator.ErrUnsupported, md.FullName())
+	}
+	// v may be a dynamic message, copy field by field through reflection
+	dst := v.Message()
+	src.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		dst.Set(dst.Descriptor().Fields().ByNumber(fd.Number()), val)
+		return true
+	})
+	return v, nil
+}
@@ -0,0 +1,158 @@
+package protoconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// configDescriptor describes
+//
+//	message Server {
+//		int32 port = 1;
+//		google.protobuf.Duration read_timeout = 2;
+//		repeated string hosts = 3;
+//	}
+//	enum Level { INFO = 0; DEBUG = 1; }
+//	message Config {
+//		string name = 1;
+//		Server server = 2;
+//		Level level = 3;
+//		google.protobuf.Timestamp since = 4;
+//	}
+func configDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	opt := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	rep := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/config.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/duration.proto", "google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Level"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("INFO"), Number: proto.Int32(0)},
+				{Name: proto.String("DEBUG"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Server"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("port", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, "", opt),
+					field("read_timeout", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Duration", opt),
+					field("hosts", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", rep),
+				},
+			},
+			{
+				Name: proto.String("Config"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, "", opt),
+					field("server", 2, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".test.Server", opt),
+					field("level", 3, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".test.Level", opt),
+					field("since", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp", opt),
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd.Messages().ByName("Config")
+}
+
+func TestLoad(t *testing.T) {
+	md := configDescriptor(t)
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+	content := "name: api\nserver:\n  port: 8080\n  read_timeout: 5s\nsince: \"2024-01-02T03:04:05Z\"\n"
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{
+		"APP_SERVER_PORT":  "9090",
+		"APP_SERVER_HOSTS": "a, b",
+		"APP_LEVEL":        "DEBUG",
+	}
+
+	m := dynamicpb.NewMessage(md)
+	err := Load(m, WithFile(filename), WithENV("app"), WithLookupEnv(func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}))
+	assert.NoError(t, err)
+
+	get := func(m protoreflect.Message, name string) protoreflect.Value {
+		return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name)))
+	}
+	server := get(m, "server").Message()
+	assert.Equal(t, "api", get(m, "name").String())
+	assert.Equal(t, int64(9090), get(server, "port").Int())
+	assert.Equal(t, protoreflect.EnumNumber(1), get(m, "level").Enum())
+	hosts := get(server, "hosts").List()
+	assert.Equal(t, 2, hosts.Len())
+	assert.Equal(t, "b", hosts.Get(1).String())
+
+	d := &durationpb.Duration{}
+	proto.Merge(d, get(server, "read_timeout").Message().Interface())
+	assert.Equal(t, 5*time.Second, d.AsDuration())
+	ts := &timestamppb.Timestamp{}
+	proto.Merge(ts, get(m, "since").Message().Interface())
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), ts.AsTime())
+}
+
+func TestLoad_ENVOnly(t *testing.T) {
+	md := configDescriptor(t)
+	env := map[string]string{"SERVER_READ_TIMEOUT": "1m"}
+
+	m := dynamicpb.NewMessage(md)
+	err := Load(m, WithENV(""), WithLookupEnv(func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}))
+	assert.NoError(t, err)
+	assert.True(t, m.Has(md.Fields().ByName("server")))
+	assert.False(t, m.Has(md.Fields().ByName("since")))
+
+	env["SERVER_PORT"] = "big"
+	assert.Error(t, Load(dynamicpb.NewMessage(md), WithENV(""), WithLookupEnv(func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	})))
+}
+
+func TestLoad_Unsupported(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(filename, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := Load(dynamicpb.NewMessage(configDescriptor(t)), WithFile(filename))
+	assert.True(t, errors.Is(err, configurator.ErrUnsupported))
+}
@@ -0,0 +1,124 @@
+package configurator
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Provider interface {
+	Provide(interface{}, StructInfo) error
+}
+
+// ContextProvider is implemented by providers doing I/O that can be
+// cancelled, such as reading files or calling remote services.
+type ContextProvider interface {
+	Provider
+	ProvideContext(context.Context, interface{}, StructInfo) error
+}
+
+// Fetcher is implemented by providers whose I/O doesn't depend on the struct
+// being loaded. Fetch does the I/O and returns a provider that applies the
+// fetched data, which lets independent sources be fetched concurrently.
+type Fetcher interface {
+	Provider
+	Fetch(context.Context) (Provider, error)
+}
+
+// Versioner is implemented by providers, or the providers a Fetcher
+// returns, that can tell the version of the data they apply, such as a
+// modification time, an index or a commit.
+type Versioner interface {
+	Version() string
+}
+
+// Watcher is implemented by providers that are told when their data
+// changes, such as config centers long polling for releases. Watch calls
+// onChange on every change until ctx is done or watching fails. See
+// Configurator.Watch.
+type Watcher interface {
+	Watch(ctx context.Context, onChange func()) error
+}
+
+func providerName(p Provider) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// provide is the boundary between the configurator and providers: a panic
+// in a provider fails the load instead of crashing the process.
+func provide(ctx context.Context, p Provider, v interface{}, si StructInfo, timeout time.Duration) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("configurator/Load: %w in provider %s: %v", ErrPanic, providerName(p), r)
+		}
+	}()
+	cp, ok := p.(ContextProvider)
+	if !ok {
+		return p.Provide(v, si)
+	}
+	ctx, cancel := providerContext(ctx, p, timeout)
+	defer cancel()
+	return cp.ProvideContext(ctx, v, si)
+}
+
+func fetch(ctx context.Context, f Fetcher, timeout time.Duration) (p Provider, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("configurator/Load: %w in provider %s: %v", ErrPanic, providerName(f), r)
+		}
+	}()
+	ctx, cancel := providerContext(ctx, f, timeout)
+	defer cancel()
+	return f.Fetch(ctx)
+}
+
+func providerContext(ctx context.Context, p Provider, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := p.(*timeoutProvider); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// ProviderWithTimeout wraps p so that each call gets at most d, overriding
+// WithProviderTimeout for this provider.
+func ProviderWithTimeout(p Provider, d time.Duration) Fetcher {
+	return &timeoutProvider{provider: p, timeout: d}
+}
+
+type timeoutProvider struct {
+	provider Provider
+	timeout  time.Duration
+}
+
+func (p *timeoutProvider) Provide(v interface{}, si StructInfo) error {
+	return p.ProvideContext(context.Background(), v, si)
+}
+
+func (p *timeoutProvider) ProvideContext(ctx context.Context, v interface{}, si StructInfo) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	if cp, ok := p.provider.(ContextProvider); ok {
+		return cp.ProvideContext(ctx, v, si)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.provider.Provide(v, si)
+}
+
+func (p *timeoutProvider) Fetch(ctx context.Context) (Provider, error) {
+	f, ok := p.provider.(Fetcher)
+	if !ok {
+		return p, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return f.Fetch(ctx)
+}
+
+func (p *timeoutProvider) String() string {
+	return providerName(p.provider)
+}
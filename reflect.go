@@ -1,10 +1,13 @@
 package configurator
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,6 +17,7 @@ type StructInfo interface {
 
 type structInfo struct {
 	fields []FieldInfo
+	slab   []fieldInfo
 }
 
 var _ StructInfo = &structInfo{}
@@ -22,14 +26,22 @@ func (s *structInfo) Fields() []FieldInfo {
 	return s.fields
 }
 
+// FieldInfo describes a field of the struct being loaded. Path, the dotted
+// path of the field such as "MySQL.Host", and Set, parsing a value into the
+// field as the built-in providers do, serve providers matching fields by
+// path. Types implementing FieldInfo outside this package must provide
+// them too.
 type FieldInfo interface {
 	StructField() reflect.StructField
 	Value() reflect.Value
 	Parent() FieldInfo
 	Name() string
+	Path() string
+	Set(string) error
 	ENVKey() string
 	FlagKey() string
 	DefVal() string
+	Secret() bool
 }
 
 type fieldInfo struct {
@@ -37,6 +49,24 @@ type fieldInfo struct {
 	field  reflect.StructField
 	val    reflect.Value
 	tag    tagInfo
+	// fullPath is the dotted path, and keys the env and flag keys, of the
+	// field, computed once as providers ask for them for every field.
+	fullPath string
+	keys     *fieldKeys
+
+	// disabled is set when a surrounding `if=` condition doesn't hold.
+	disabled bool
+	// explicit is set when the running provider set the field, even to the
+	// value it already had, and set once any provider did.
+	explicit bool
+	set      bool
+	// raw is the string the running provider passed to Set, before
+	// transforms, so that snapshots replay it as is. It is nil when the
+	// provider set the field otherwise.
+	raw   *string
+	parse parseOptions
+	// resolved is set when the value was resolved from a secret reference.
+	resolved bool
 }
 
 var _ FieldInfo = &fieldInfo{}
@@ -57,32 +87,112 @@ func (f *fieldInfo) Name() string {
 	return f.field.Name
 }
 
-func (f *fieldInfo) path() []string {
-	path := []string{f.Name()}
-	for p := f.parent; p != nil; p = p.parent {
-		path = append([]string{p.Name()}, path...)
+func (f *fieldInfo) Path() string {
+	return f.fullPath
+}
+
+type fieldKeys struct {
+	env, flag string
+}
+
+func (f *fieldInfo) Set(v string) error {
+	o := f.options()
+	raw := v
+	v, err := o.transform(v)
+	if err != nil {
+		return newFieldError(ErrInvalidValue, fmt.Errorf("%s: %w", f.Path(), err), raw, f.Path())
+	}
+	if f.parse.fold {
+		v = f.canonical(v)
+	}
+	if err := o.set(f.val, f.val.Type(), v); err != nil {
+		if errors.Is(err, ErrInvalidValue) {
+			// values such as documents and IDs fail deep inside, name the field
+			err = fmt.Errorf("%s: %w", f.Path(), err)
+		}
+		return newFieldError(ErrInvalidValue, err, raw, f.Path())
+	}
+	f.explicit = true
+	f.raw = &raw
+	return nil
+}
+
+// options are the parse options of the configurator with those of the
+// field's tag.
+func (f *fieldInfo) options() parseOptions {
+	o := f.parse
+	o.percent = f.tag.unit == unitPercent
+	o.format = f.tag.format
+	o.transforms = f.tag.transforms
+	return o
+}
+
+// markSet records that a provider set fi explicitly, for values set without
+// FieldInfo.Set.
+func markSet(fi FieldInfo) {
+	if f, ok := fi.(*fieldInfo); ok {
+		f.explicit = true
 	}
-	return path
 }
 
+// ENVKey is the env var of the field, derived from its path when the tag
+// has no name. The `env` option of a struct field applies to all its
+// fields, and `env=NAME` prefixes their keys with NAME instead of the path.
 func (f *fieldInfo) ENVKey() string {
-	if f.tag.hasENV {
-		if f.tag.env == "" {
-			return strings.ToUpper(strings.Join(f.path(), "_"))
+	return f.fieldKeys().env
+}
+
+// FlagKey is the flag of the field, derived like ENVKey.
+func (f *fieldInfo) FlagKey() string {
+	return f.fieldKeys().flag
+}
+
+func (f *fieldInfo) fieldKeys() *fieldKeys {
+	if f.keys != nil {
+		return f.keys
+	}
+	k := &fieldKeys{env: f.tag.env, flag: f.tag.flag}
+	if k.env == "" {
+		if path, ok := f.keyPath(func(t *tagInfo) (string, bool) { return t.env, t.hasENV }, "_"); ok {
+			k.env = strings.ToUpper(path)
 		}
-		return f.tag.env
 	}
-	return ""
+	if k.flag == "" {
+		if path, ok := f.keyPath(func(t *tagInfo) (string, bool) { return t.flag, t.hasFlag }, "-"); ok {
+			k.flag = strings.ToLower(path)
+		}
+	}
+	f.keys = k
+	return k
 }
 
-func (f *fieldInfo) FlagKey() string {
-	if f.tag.hasFlag {
-		if f.tag.flag == "" {
-			return strings.ToLower(strings.Join(f.path(), "-"))
+// keyPath returns the path of f up to the closest section naming its key,
+// joined by sep, and whether f or one of its sections has the option.
+func (f *fieldInfo) keyPath(option func(*tagInfo) (string, bool), sep string) (string, bool) {
+	_, enabled := option(&f.tag)
+	var buf [8]string
+	// innermost first
+	names := append(buf[:0], f.Name())
+	for p := f.parent; p != nil; p = p.parent {
+		name, ok := option(&p.tag)
+		enabled = enabled || ok
+		if name != "" {
+			names = append(names, name)
+			break
 		}
-		return f.tag.flag
+		names = append(names, p.Name())
 	}
-	return ""
+	if !enabled {
+		return "", false
+	}
+	var b strings.Builder
+	for i := len(names) - 1; i >= 0; i-- {
+		b.WriteString(names[i])
+		if i > 0 {
+			b.WriteString(sep)
+		}
+	}
+	return b.String(), true
 }
 
 func (f *fieldInfo) DefVal() string {
@@ -92,12 +202,29 @@ func (f *fieldInfo) DefVal() string {
 	return ""
 }
 
+func (f *fieldInfo) Secret() bool {
+	return f.tag.secret || f.resolved
+}
+
 var (
 	timePtrType = reflect.TypeOf((*time.Time)(nil))
 	timeType    = reflect.TypeOf(time.Time{})
 )
 
+// walkLimits bound the structs walked for fields, protecting against
+// enormous types such as embedded third-party clients. Zero is no limit.
+type walkLimits struct {
+	depth  int
+	fields int
+}
+
+var defaultWalkLimits = walkLimits{depth: 32, fields: 10000}
+
 func getStructInfo(i interface{}, parent *fieldInfo) (*structInfo, error) {
+	return getStructInfoWithin(i, parent, defaultWalkLimits)
+}
+
+func getStructInfoWithin(i interface{}, parent *fieldInfo, limits walkLimits) (*structInfo, error) {
 	v := reflect.ValueOf(i)
 	for v.Kind() != reflect.Ptr {
 		return nil, ErrInvalidConfig
@@ -108,72 +235,138 @@ func getStructInfo(i interface{}, parent *fieldInfo) (*structInfo, error) {
 	}
 
 	si := &structInfo{}
+	if err := si.walk(v, parent, nil, limits); err != nil {
+		return nil, err
+	}
+	// once, before providers, possibly timed out ones, ask concurrently
+	for _, fi := range si.fields {
+		fi.(*fieldInfo).fieldKeys()
+	}
+	return si, nil
+}
+
+// walk appends the fields of the struct v to s. types are the struct types
+// being walked, outermost first, so that a type containing itself fails
+// instead of being allocated forever.
+func (s *structInfo) walk(v reflect.Value, parent *fieldInfo, types []reflect.Type, limits walkLimits) error {
 	typ := v.Type()
-	if v.Kind() == reflect.Struct {
-		n := v.NumField()
-		for i := 0; i < n; i++ {
-			fv := v.Field(i)
-			ft := typ.Field(i)
-
-			// unexported fields
-			if !fv.CanSet() {
-				continue
-			}
+	types = append(types, typ)
+	if limits.depth > 0 && len(types) > limits.depth {
+		path := typ.String()
+		if parent != nil {
+			path = parent.Path()
+		}
+		return fmt.Errorf("%w, structs nested deeper than %d levels, see WithMaxDepth [%s]", ErrOutOfRange, limits.depth, path)
+	}
+	n := v.NumField()
+	for i := 0; i < n; i++ {
+		fv := v.Field(i)
+		ft := typ.Field(i)
 
-			if ft.Type == timeType || ft.Type == timePtrType {
-				fi, err := getFieldInfo(fv, ft, parent)
-				if err != nil {
-					return nil, err
-				}
-				si.fields = append(si.fields, fi)
-				continue
+		// unexported fields
+		if !fv.CanSet() {
+			continue
+		}
+		if ft.Tag.Get(tagName) == ignoreTag {
+			continue
+		}
+
+		if d, ok := asDynamic(fv); ok {
+			d.init()
+		}
+		if isLeafType(ft.Type) || hasFormat(ft) {
+			fi, err := s.newField(fv, ft, parent)
+			if err != nil {
+				return err
+			}
+			if err := s.add(fi, limits); err != nil {
+				return err
 			}
+			continue
+		}
 
-			for fv.Kind() == reflect.Ptr {
-				if fv.IsNil() {
-					if fv.Type().Elem().Kind() != reflect.Struct {
-						// nil pointer to a non-struct: leave it alone
-						break
+		if t := indirect(ft.Type); t.Kind() == reflect.Struct {
+			for _, outer := range types {
+				if t == outer {
+					path := ft.Name
+					if parent != nil {
+						path = parent.Path() + "." + path
 					}
-					// nil pointer to struct: create a zero instance
-					fv.Set(reflect.New(fv.Type().Elem()))
+					return fmt.Errorf("%w, %s contains itself, tag it `config:\"-\"` to skip it [%s]", ErrCycle, t, path)
 				}
-				fv = fv.Elem()
 			}
+		}
 
-			fi, err := getFieldInfo(fv, ft, parent)
-			if err != nil {
-				return nil, err
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				if fv.Type().Elem().Kind() != reflect.Struct {
+					// nil pointer to a non-struct: leave it alone
+					break
+				}
+				// nil pointer to struct: create a zero instance
+				fv.Set(reflect.New(fv.Type().Elem()))
 			}
+			fv = fv.Elem()
+		}
 
-			if fv.Kind() == reflect.Struct {
-				p := fi
-				// embedded structs
-				if ft.Anonymous {
-					p = parent
-				}
-				inner, err := getStructInfo(fv.Addr().Interface(), p)
-				if err != nil {
-					return nil, err
-				}
-				si.fields = append(si.fields, inner.fields...)
-				continue
+		fi, err := s.newField(fv, ft, parent)
+		if err != nil {
+			return err
+		}
+
+		if fv.Kind() == reflect.Struct {
+			p := fi
+			// embedded structs
+			if ft.Anonymous {
+				p = parent
 			}
+			if err := s.walk(fv, p, types, limits); err != nil {
+				return err
+			}
+			continue
+		}
 
-			si.fields = append(si.fields, fi)
+		if err := s.add(fi, limits); err != nil {
+			return err
 		}
 	}
-	return si, nil
+	return nil
+}
+
+func (s *structInfo) add(fi *fieldInfo, limits walkLimits) error {
+	if limits.fields > 0 && len(s.fields) >= limits.fields {
+		return fmt.Errorf("%w, more than %d fields, see WithMaxFields [%s]", ErrOutOfRange, limits.fields, fi.Path())
+	}
+	s.fields = append(s.fields, fi)
+	return nil
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
 }
 
-func getFieldInfo(v reflect.Value, t reflect.StructField, p *fieldInfo) (*fieldInfo, error) {
-	fi := &fieldInfo{
-		field:  t,
-		val:    v,
-		parent: p,
+// newField returns the info of a field, allocated in chunks as loads walk
+// hundreds of fields.
+func (s *structInfo) newField(v reflect.Value, t reflect.StructField, p *fieldInfo) (*fieldInfo, error) {
+	if len(s.slab) == cap(s.slab) {
+		s.slab = make([]fieldInfo, 0, 32)
+	}
+	s.slab = s.slab[:len(s.slab)+1]
+	fi := &s.slab[len(s.slab)-1]
+	*fi = fieldInfo{
+		field:    t,
+		val:      v,
+		parent:   p,
+		fullPath: t.Name,
+	}
+	if p != nil {
+		fi.fullPath = p.fullPath + "." + t.Name
 	}
 
-	tag, err := parseTag(t)
+	tag, err := cachedTag(t)
 	if err != nil {
 		return nil, err
 	}
@@ -181,15 +374,71 @@ func getFieldInfo(v reflect.Value, t reflect.StructField, p *fieldInfo) (*fieldI
 	return fi, nil
 }
 
+var (
+	// tagCache holds the parsed tags by their text, and leafTypes
+	// isLeafType by type, as loads walk the same types over and over.
+	tagCache  sync.Map
+	leafTypes sync.Map
+)
+
+// cachedTag is parseTag, parsing each tag once. Errors aren't cached: a
+// transform or format registered since may fix them.
+func cachedTag(field reflect.StructField) (*tagInfo, error) {
+	if t, ok := tagCache.Load(field.Tag); ok {
+		return t.(*tagInfo), nil
+	}
+	t, err := parseTag(field)
+	if err != nil {
+		return nil, err
+	}
+	tagCache.Store(field.Tag, t)
+	return t, nil
+}
+
+// isLeafType reports whether fields of type t are set as a whole, even if
+// t is a struct.
+func isLeafType(t reflect.Type) bool {
+	if leaf, ok := leafTypes.Load(t); ok {
+		return leaf.(bool)
+	}
+	leaf := t == timeType || t == timePtrType || isWrapper(t) || isFlagValue(t) || isNullable(indirect(t)) || isTextUnmarshaler(indirect(t))
+	leafTypes.Store(t, leaf)
+	return leaf
+}
+
 const (
 	tagName              = "config"
-	tagSeparator         = ","
+	ignoreTag            = "-"
 	flagFlag             = "flag"
 	flagFlagWithValue    = "flag="
 	envFlag              = "env"
 	envFlagWithValue     = "env="
 	defaultFlag          = "default"
 	defaultFlagWithValue = "default="
+	secretFlag           = "secret"
+	ifFlagWithValue      = "if="
+	requiredFlag         = "required"
+	defaultFnWithValue   = "defaultFn="
+	enumFlagWithValue    = "enum="
+	unitFlagWithValue    = "unit="
+	unitPercent          = "percent"
+	keychainWithValue    = "keychain="
+	pathFlag             = "path"
+	pathFlagWithValue    = "path="
+	priorityWithValue    = "priority="
+	envPrefixWithValue   = "envPrefix="
+	lowerKeysFlag        = "lowerKeys"
+	formatWithValue      = "format="
+	transformWithValue   = "transform="
+	deriveWithValue      = "derive="
+	experimentalFlag     = "experimental"
+	gateWithValue        = "experimental="
+	downwardWithValue    = "downward="
+	reloadWithValue      = "reload="
+	reloadHot            = "hot"
+	reloadRestart        = "restart"
+	hiddenFlag           = "hidden"
+	advancedFlag         = "advanced"
 )
 
 type tagInfo struct {
@@ -199,14 +448,113 @@ type tagInfo struct {
 	hasENV     bool
 	defVal     string
 	hasDefault bool
+	secret     bool
+	cond       string
+	required   bool
+	defFn      string
+	enum       []string
+	unit       string
+	keychain   string
+	path       bool
+	pathCheck  string
+	priority   []string
+	envPrefix  string
+	lowerKeys  bool
+	format     string
+	transforms []string
+	derive     string
+	groups     []fieldGroup
+	gated      bool
+	gate       string
+	downward   string
+	reload     string
+	hidden     bool
+	advanced   bool
 }
 
 func parseTag(field reflect.StructField) (*tagInfo, error) {
 	t := tagInfo{}
-	val := field.Tag.Get(tagName)
-	tags := strings.Split(val, tagSeparator)
-	for _, s := range tags {
+	tags, err := splitTag(field.Tag.Get(tagName))
+	if err != nil {
+		return nil, err
+	}
+	var list *[]string
+	for _, tok := range tags {
+		s := tok.text
+		// enum=a,b,c takes every following token up to the next option, as
+		// transform= does
+		if list != nil && (tok.quoted || !isTagOption(s)) {
+			*list = append(*list, s)
+			continue
+		}
+		list = nil
 		switch {
+		case strings.HasPrefix(s, unitFlagWithValue):
+			t.unit = strings.TrimPrefix(s, unitFlagWithValue)
+			if t.unit != unitPercent {
+				return nil, fmt.Errorf("%w, unknown unit %q", ErrInvalidTagFormat, t.unit)
+			}
+		case strings.HasPrefix(s, keychainWithValue):
+			t.keychain = strings.TrimPrefix(s, keychainWithValue)
+			if i := strings.LastIndex(t.keychain, "/"); i <= 0 || i == len(t.keychain)-1 {
+				return nil, fmt.Errorf("%w, `keychain=service/account` is required", ErrInvalidTagFormat)
+			}
+			// values kept in a keychain are secrets
+			t.secret = true
+		case strings.HasPrefix(s, enumFlagWithValue):
+			t.enum = append(t.enum, strings.TrimPrefix(s, enumFlagWithValue))
+			list = &t.enum
+		case strings.HasPrefix(s, transformWithValue):
+			t.transforms = append(t.transforms, strings.TrimPrefix(s, transformWithValue))
+			list = &t.transforms
+		case strings.HasPrefix(s, envPrefixWithValue):
+			t.envPrefix = strings.TrimPrefix(s, envPrefixWithValue)
+			if t.envPrefix == "" {
+				return nil, fmt.Errorf("%w, `envPrefix=PREFIX_` is required", ErrInvalidTagFormat)
+			}
+		case strings.HasPrefix(s, formatWithValue):
+			t.format = strings.ToLower(strings.TrimPrefix(s, formatWithValue))
+			if !validFormat(t.format) {
+				return nil, fmt.Errorf("%w, `format=%s` must be one of %s, or base64+format, see RegisterFormat", ErrInvalidTagFormat, t.format, strings.Join(formatNames(), ", "))
+			}
+		case strings.HasPrefix(s, deriveWithValue):
+			t.derive = strings.TrimPrefix(s, deriveWithValue)
+			if t.derive == "" {
+				return nil, fmt.Errorf("%w, `derive={{.Field}}` needs a template", ErrInvalidTagFormat)
+			}
+			if _, err := parseDerive(t.derive); err != nil {
+				return nil, fmt.Errorf("%w, derive: %v", ErrInvalidTagFormat, err)
+			}
+		case isGroupOption(s):
+			g, err := parseGroup(s)
+			if err != nil {
+				return nil, err
+			}
+			t.groups = append(t.groups, g)
+		case s == experimentalFlag:
+			t.gated = true
+		case strings.HasPrefix(s, gateWithValue):
+			t.gated = true
+			t.gate = strings.TrimPrefix(s, gateWithValue)
+			if t.gate == "" {
+				return nil, fmt.Errorf("%w, either `experimental` or `experimental=Gate` is valid", ErrInvalidTagFormat)
+			}
+		case strings.HasPrefix(s, downwardWithValue):
+			t.downward = strings.TrimPrefix(s, downwardWithValue)
+			if !validDownwardPath(t.downward) {
+				return nil, fmt.Errorf("%w, unknown downward field %q", ErrInvalidTagFormat, t.downward)
+			}
+		case strings.HasPrefix(s, reloadWithValue):
+			t.reload = strings.TrimPrefix(s, reloadWithValue)
+			if t.reload != reloadHot && t.reload != reloadRestart {
+				return nil, fmt.Errorf("%w, either `reload=hot` or `reload=restart` is valid", ErrInvalidTagFormat)
+			}
+		case s == hiddenFlag:
+			t.hidden = true
+		case s == advancedFlag:
+			t.advanced = true
+		case s == lowerKeysFlag:
+			t.lowerKeys = true
 		case strings.HasPrefix(s, envFlag):
 			if err := parseENV(field, &t, s); err != nil {
 				return nil, err
@@ -215,16 +563,112 @@ func parseTag(field reflect.StructField) (*tagInfo, error) {
 			if err := parseFlag(field, &t, s); err != nil {
 				return nil, err
 			}
+		case strings.HasPrefix(s, defaultFnWithValue):
+			t.defFn = strings.TrimPrefix(s, defaultFnWithValue)
+			if t.defFn == "" {
+				return nil, fmt.Errorf("%w, `defaultFn=Name` is required", ErrInvalidTagFormat)
+			}
+			if _, _, err := splitDefaultFn(t.defFn); err != nil {
+				return nil, err
+			}
 		case strings.HasPrefix(s, defaultFlag):
 			if err := parseDefault(field, &t, s); err != nil {
 				return nil, err
 			}
+		case strings.HasPrefix(s, priorityWithValue):
+			t.priority = strings.Split(strings.TrimPrefix(s, priorityWithValue), ">")
+			for _, name := range t.priority {
+				if name == "" {
+					return nil, fmt.Errorf("%w, `priority=env>flag` needs source names", ErrInvalidTagFormat)
+				}
+			}
+		case s == pathFlag:
+			t.path = true
+		case strings.HasPrefix(s, pathFlagWithValue):
+			t.path = true
+			t.pathCheck = strings.TrimPrefix(s, pathFlagWithValue)
+			if !contains(pathChecks, t.pathCheck) {
+				return nil, fmt.Errorf("%w, `path=%s` must be one of %s", ErrInvalidTagFormat, t.pathCheck, strings.Join(pathChecks, ", "))
+			}
+		case s == secretFlag:
+			t.secret = true
+		case s == requiredFlag:
+			t.required = true
+		case strings.HasPrefix(s, ifFlagWithValue):
+			t.cond = strings.TrimPrefix(s, ifFlagWithValue)
+			if t.cond == "" {
+				return nil, fmt.Errorf("%w, `if=Field.Path` is required", ErrInvalidTagFormat)
+			}
+		}
+	}
+
+	for _, e := range t.enum {
+		if e == "" {
+			return nil, fmt.Errorf("%w, `enum=a,b` values must not be empty", ErrInvalidTagFormat)
+		}
+	}
+	for _, name := range t.transforms {
+		if _, ok := lookupTransform(name); !ok {
+			return nil, fmt.Errorf("%w, unknown transform %q, one of %s, see RegisterTransform", ErrInvalidTagFormat, name, strings.Join(transformNames(), ", "))
 		}
 	}
 
 	return &t, nil
 }
 
+type tagToken struct {
+	text string
+	// quoted tokens have a quoted part, so are never taken for an option.
+	quoted bool
+}
+
+// splitTag splits a tag into its comma separated options. A part of an
+// option in single quotes, such as the value of default='a,b', may hold
+// commas, and a backslash escapes the next character, such as \' or \,.
+func splitTag(tag string) ([]tagToken, error) {
+	var tokens []tagToken
+	var cur strings.Builder
+	quoted, inQuote, escaped := false, false, false
+	for _, r := range tag {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '\'':
+			inQuote = !inQuote
+			quoted = true
+		case r == ',' && !inQuote:
+			tokens = append(tokens, tagToken{text: cur.String(), quoted: quoted})
+			cur.Reset()
+			quoted = false
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if inQuote || escaped {
+		return nil, fmt.Errorf("%w, unterminated quote or escape in %q", ErrInvalidTagFormat, tag)
+	}
+	return append(tokens, tagToken{text: cur.String(), quoted: quoted}), nil
+}
+
+func isTagOption(s string) bool {
+	switch s {
+	case envFlag, flagFlag, defaultFlag, secretFlag, requiredFlag, pathFlag, lowerKeysFlag, experimentalFlag, hiddenFlag, advancedFlag:
+		return true
+	}
+	if isGroupOption(s) {
+		return true
+	}
+	for _, p := range []string{envFlagWithValue, flagFlagWithValue, defaultFlagWithValue, defaultFnWithValue, ifFlagWithValue, enumFlagWithValue, unitFlagWithValue, keychainWithValue, pathFlagWithValue, priorityWithValue, envPrefixWithValue, formatWithValue, transformWithValue, deriveWithValue, gateWithValue, downwardWithValue, reloadWithValue} {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
 func parseENV(field reflect.StructField, t *tagInfo, v string) error {
 	t.hasENV = true
 	if strings.HasPrefix(v, envFlagWithValue) {
@@ -258,16 +702,206 @@ func parseDefault(field reflect.StructField, t *tagInfo, v string) error {
 	return nil
 }
 
+// copyValue returns a deep copy of a leaf value so that in-place writes
+// through pointers or slices can be detected afterwards.
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyValue(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(copyValue(iter.Key()), copyValue(iter.Value()))
+		}
+		return c
+	default:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		return c
+	}
+}
+
+const sliceSeparator = ","
+
+const secretMask = "******"
+
+// formatValue renders a leaf value the way setFieldValue parses it.
+func formatValue(v reflect.Value) string {
+	v = leaf(v)
+	if e, ok := lookupEnum(v.Type()); ok {
+		if name, ok := e.name(v); ok {
+			return name
+		}
+	}
+	if isFlagValue(v.Type()) {
+		return formatFlagValue(v)
+	}
+	if n, ok := lookupNullable(v.Type()); ok {
+		return n.format(v)
+	}
+	if v.Type() != timeType && isTextMarshaler(v.Type()) {
+		return formatText(v)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	case reflect.Map:
+		return formatJSONValue(v)
+	case reflect.Struct:
+		if v.Type() != timeType {
+			return formatJSONValue(v)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes())
+		}
+		if k := indirect(v.Type().Elem()).Kind(); k == reflect.Map || k == reflect.Struct && indirect(v.Type().Elem()) != timeType {
+			return formatJSONValue(v)
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, sliceSeparator)
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	}
+	if v.Type() == durationType {
+		return v.Interface().(time.Duration).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func setInt(val reflect.Value, i int64) error {
+	if val.OverflowInt(i) {
+		return fmt.Errorf("%w: %d overflows %s", ErrOutOfRange, i, val.Type())
+	}
+	val.SetInt(i)
+	return nil
+}
+
+func setUint(val reflect.Value, u uint64) error {
+	if val.OverflowUint(u) {
+		return fmt.Errorf("%w: %d overflows %s", ErrOutOfRange, u, val.Type())
+	}
+	val.SetUint(u)
+	return nil
+}
+
+func setFloat(val reflect.Value, f float64) error {
+	if val.OverflowFloat(f) {
+		return fmt.Errorf("%w: %g overflows %s", ErrOutOfRange, f, val.Type())
+	}
+	val.SetFloat(f)
+	return nil
+}
+
+// assignValue sets x into val, converting between named and unnamed types
+// with the same underlying type instead of panicking.
+func assignValue(val reflect.Value, x reflect.Value) error {
+	switch {
+	case !val.CanSet():
+		return fmt.Errorf("%w: cannot set %s", ErrUnsupported, val.Type())
+	case x.Type().AssignableTo(val.Type()):
+		val.Set(x)
+	case x.Kind() == val.Kind() && x.Type().ConvertibleTo(val.Type()):
+		val.Set(x.Convert(val.Type()))
+	default:
+		return fmt.Errorf("%w: cannot assign %s to %s", ErrUnsupported, x.Type(), val.Type())
+	}
+	return nil
+}
+
+// parseOptions relax how string values are parsed.
+type parseOptions struct {
+	// trim drops surrounding whitespace, of each item for slices.
+	trim bool
+	// fold matches booleans and enum names ignoring case, and accepts
+	// yes/no, y/n and on/off as booleans.
+	fold bool
+	// numbers accepts "_" and "," digit separators.
+	numbers bool
+	// percent reads "85%" as 0.85 for floats.
+	percent bool
+	// format is the encoding of values of any type, see RegisterFormat.
+	format string
+	// transforms rewrite raw values, see RegisterTransform. They run where
+	// raw values enter, not in set, so that items aren't transformed twice.
+	transforms []string
+	// lookupEnv is the env the expandenv transform reads.
+	lookupEnv func(string) (string, bool)
+	// runtime is what `${runtime:key}` defaults read, see WithRuntime.
+	runtime func() Runtime
+}
+
 func setFieldValue(val reflect.Value, typ reflect.Type, v string) error {
+	return parseOptions{}.set(val, typ, v)
+}
+
+func (o parseOptions) set(val reflect.Value, typ reflect.Type, v string) error {
+	if !val.CanSet() || val.Type() != typ {
+		return fmt.Errorf("setFieldValue: %w value of type [%s] as [%s]", ErrUnsupported, val.Type(), typ)
+	}
+	if o.trim {
+		v = strings.TrimSpace(v)
+	}
+	if o.numbers && o.format == "" && isNumber(typ) {
+		v = digitSeparators.Replace(v)
+	}
+	if d, ok := asWrapper(val); ok {
+		elem := reflect.New(d.elemType()).Elem()
+		if err := o.set(elem, elem.Type(), v); err != nil {
+			return err
+		}
+		d.store(elem)
+		return nil
+	}
+	if o.format != "" {
+		return o.decode(val, typ, v)
+	}
+	if e, ok := lookupEnum(typ); ok {
+		return e.set(val, v, o.fold)
+	}
+	if isFlagValue(typ) {
+		return setFlagValue(val, typ, v)
+	}
+	if n, ok := lookupNullable(typ); ok {
+		return n.set(o, val, v)
+	}
+	if typ != timeType && isTextUnmarshaler(typ) {
+		return setText(val, typ, v)
+	}
 	switch typ.Kind() {
 	case reflect.Bool:
-		b, err := strconv.ParseBool(v)
+		b, err := o.parseBool(v)
 		if err != nil {
 			return err
 		}
 		val.SetBool(b)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
-		i, err := strconv.ParseInt(v, 0, strconv.IntSize)
+		i, err := strconv.ParseInt(v, 0, typ.Bits())
 		if err != nil {
 			return err
 		}
@@ -287,7 +921,7 @@ func setFieldValue(val reflect.Value, typ reflect.Type, v string) error {
 			val.SetInt(i)
 		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
-		u, err := strconv.ParseUint(v, 0, strconv.IntSize)
+		u, err := strconv.ParseUint(v, 0, typ.Bits())
 		if err != nil {
 			return err
 		}
@@ -299,24 +933,28 @@ func setFieldValue(val reflect.Value, typ reflect.Type, v string) error {
 		}
 		val.SetUint(u)
 	case reflect.Float32, reflect.Float64:
-		f, err := strconv.ParseFloat(v, 64)
+		scale := 1.0
+		if p, ok := strings.CutSuffix(v, "%"); ok && o.percent {
+			v, scale = p, 100
+		}
+		f, err := strconv.ParseFloat(v, typ.Bits())
 		if err != nil {
 			return err
 		}
-		val.SetFloat(f)
+		val.SetFloat(f / scale)
 	case reflect.String:
 		val.SetString(v)
 	case reflect.Ptr:
-		return setPtrValue(val, typ, v)
+		return o.setPtr(val, typ, v)
 	case reflect.Slice:
-		return setSliceValue(val, typ, v)
+		return o.setSlice(val, typ, v)
 	case reflect.Struct:
 		if typ == timeType {
 			t, err := time.Parse(time.RFC3339, v)
 			if err != nil {
 				return err
 			}
-			val.Set(reflect.ValueOf(t))
+			return assignValue(val, reflect.ValueOf(t))
 		}
 		return fmt.Errorf("setFieldValue: %w type [%s]", ErrUnsupported, typ.Kind().String())
 	default:
@@ -325,70 +963,67 @@ func setFieldValue(val reflect.Value, typ reflect.Type, v string) error {
 	return nil
 }
 
-func setPtrValue(val reflect.Value, typ reflect.Type, v string) error {
-	switch typ.Elem().Kind() {
-	case reflect.Bool:
-		b, err := strconv.ParseBool(v)
-		if err != nil {
-			return err
-		}
-		val.Set(reflect.ValueOf(&b))
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
-		i, err := strconv.ParseInt(v, 0, strconv.IntSize)
-		if err != nil {
-			return err
-		}
-		val.Set(reflect.ValueOf(&i))
-	case reflect.Int64:
-		if typ == durationType {
-			i, err := time.ParseDuration(v)
-			if err != nil {
-				return err
-			}
-			val.Set(reflect.ValueOf(&i))
-		} else {
-			i, err := strconv.ParseInt(v, 0, 64)
-			if err != nil {
-				return err
-			}
-			val.Set(reflect.ValueOf(&i))
-		}
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
-		u, err := strconv.ParseUint(v, 0, strconv.IntSize)
-		if err != nil {
-			return err
-		}
-		val.Set(reflect.ValueOf(&u))
-	case reflect.Uint64:
-		u, err := strconv.ParseUint(v, 0, 64)
+func (o parseOptions) setPtr(val reflect.Value, typ reflect.Type, v string) error {
+	ptr := reflect.New(typ.Elem())
+	if err := o.set(ptr.Elem(), typ.Elem(), v); err != nil {
+		return err
+	}
+	val.Set(ptr)
+	return nil
+}
+
+func (o parseOptions) setSlice(val reflect.Value, typ reflect.Type, v string) error {
+	// []byte is carried as base64, the same as the flag provider does
+	if typ.Elem().Kind() == reflect.Uint8 {
+		b, err := base64.StdEncoding.DecodeString(v)
 		if err != nil {
 			return err
 		}
-		val.Set(reflect.ValueOf(&u))
-	case reflect.Float32, reflect.Float64:
-		f, err := strconv.ParseFloat(v, 64)
-		if err != nil {
+		val.SetBytes(b)
+		return nil
+	}
+	if v == "" {
+		val.Set(reflect.MakeSlice(typ, 0, 0))
+		return nil
+	}
+	parts := strings.Split(v, sliceSeparator)
+	s := reflect.MakeSlice(typ, len(parts), len(parts))
+	for i, p := range parts {
+		if err := o.set(s.Index(i), typ.Elem(), p); err != nil {
 			return err
 		}
-		val.Set(reflect.ValueOf(&f))
-	case reflect.String:
-		val.Set(reflect.ValueOf(&v))
-	case reflect.Struct:
-		if typ == timePtrType {
-			t, err := time.Parse(time.RFC3339, v)
-			if err != nil {
-				return err
-			}
-			val.Set(reflect.ValueOf(&t))
-		}
-		return fmt.Errorf("setPtrValue: %w type [%s]", ErrUnsupported, typ.Kind().String())
-	default:
-		return fmt.Errorf("setPtrValue: %w type [%s]", ErrUnsupported, typ.Kind().String())
 	}
+	val.Set(s)
 	return nil
 }
 
-// TODO
-func setSliceValue(val reflect.Value, typ reflect.Type, v string) error {
-	return nil
+var digitSeparators = strings.NewReplacer("_", "", ",", "")
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Int64:
+		return t != durationType
+	}
+	return false
+}
+
+// lenient reports whether o accepts numbers of typ that strconv rejects.
+func (o parseOptions) lenient(typ reflect.Type) bool {
+	return o.trim || o.numbers && isNumber(typ) || o.percent && typ.Kind() == reflect.Float64
+}
+
+func (o parseOptions) parseBool(v string) (bool, error) {
+	if o.fold {
+		switch strings.ToLower(v) {
+		case "yes", "y", "on":
+			return true, nil
+		case "no", "n", "off":
+			return false, nil
+		}
+		v = strings.ToLower(v)
+	}
+	return strconv.ParseBool(v)
 }
@@ -1,6 +1,7 @@
 package configurator
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -15,6 +16,7 @@ func TestTags(t *testing.T) {
 		Value    string `config:"env,flag"`
 		NoTag    string
 		EmptyKey string `config:"default=Bar"`
+		Password string `config:"env,secret"`
 	}
 	testObj := testStruct{}
 	tests := []struct {
@@ -47,6 +49,11 @@ func TestTags(t *testing.T) {
 			field: reflect.TypeOf(&testObj).Elem().Field(4),
 			tag:   &tagInfo{hasDefault: true, defVal: "Bar"},
 		},
+		{
+			name:  "secret",
+			field: reflect.TypeOf(&testObj).Elem().Field(5),
+			tag:   &tagInfo{hasENV: true, secret: true},
+		},
 	}
 
 	for _, tt := range tests {
@@ -112,3 +119,226 @@ func TestGetStructInfo(t *testing.T) {
 	assert.Equal(t, "", si.Fields()[5].DefVal())
 	assert.True(t, si.Fields()[5].StructField().Type == timePtrType)
 }
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts parseOptions
+		in   string
+		want interface{}
+		err  bool
+	}{
+		{"strict bool", parseOptions{}, "TRUE", true, false},
+		{"strict yes", parseOptions{}, "yes", false, true},
+		{"strict space", parseOptions{}, " 8080 ", 0, true},
+		{"fold yes", parseOptions{fold: true}, "Yes", true, false},
+		{"fold off", parseOptions{fold: true}, "OFF", false, false},
+		{"fold tRuE", parseOptions{fold: true}, "tRuE", true, false},
+		{"trim int", parseOptions{trim: true}, " 8080\n", 8080, false},
+		{"trim list", parseOptions{trim: true}, " a , b ", []string{"a", "b"}, false},
+		{"trim fold", parseOptions{trim: true, fold: true}, " On ", true, false},
+		{"strict underscores", parseOptions{}, "1_000_000", 1000000, false},
+		{"strict commas", parseOptions{}, "1,048,576", 0, true},
+		{"numbers commas", parseOptions{numbers: true}, "1,048,576", 1048576, false},
+		{"numbers float", parseOptions{numbers: true}, "1_000.5", 1000.5, false},
+		{"numbers uint", parseOptions{numbers: true}, "65_535", uint16(65535), false},
+		{"percent", parseOptions{percent: true}, "85%", 0.85, false},
+		{"percent plain", parseOptions{percent: true}, "0.5", 0.5, false},
+		{"no percent", parseOptions{}, "85%", 0.0, true},
+		{"percent list", parseOptions{percent: true}, "50%,25%", []float64{0.5, 0.25}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val := reflect.New(reflect.TypeOf(tt.want)).Elem()
+			err := tt.opts.set(val, val.Type(), tt.in)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, val.Interface())
+		})
+	}
+}
+
+func TestInlineStruct(t *testing.T) {
+	type testStruct struct {
+		Server struct {
+			Port int `config:"flag"`
+			TLS  *struct {
+				Cert string `config:"env"`
+			}
+		} `config:"env=SRV,flag=srv"`
+		Worker struct {
+			Queue struct {
+				Name string
+			}
+		} `config:"env"`
+		Cache struct {
+			Size int
+		}
+	}
+
+	si, err := getStructInfo(&testStruct{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, si.Fields(), 4)
+
+	assert.Equal(t, "SRV_PORT", si.Fields()[0].ENVKey())
+	assert.Equal(t, "srv-port", si.Fields()[0].FlagKey())
+	assert.Equal(t, "SRV_TLS_CERT", si.Fields()[1].ENVKey())
+	assert.Equal(t, "srv-tls-cert", si.Fields()[1].FlagKey())
+	assert.Equal(t, "Server.TLS.Cert", si.Fields()[1].Path())
+	assert.Equal(t, "WORKER_QUEUE_NAME", si.Fields()[2].ENVKey())
+	assert.Equal(t, "", si.Fields()[2].FlagKey())
+	assert.Equal(t, "", si.Fields()[3].ENVKey())
+	assert.Equal(t, "", si.Fields()[3].FlagKey())
+}
+
+func TestGetStructInfo_Cycle(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	_, err := getStructInfo(&node{}, nil)
+	assert.True(t, errors.Is(err, ErrCycle))
+	assert.Contains(t, err.Error(), "[Next]")
+
+	type tree struct {
+		Root struct {
+			Children []node
+			Parent   *tree
+		}
+	}
+	_, err = getStructInfo(&tree{}, nil)
+	assert.True(t, errors.Is(err, ErrCycle))
+	assert.Contains(t, err.Error(), "[Root.Parent]")
+
+	type ignored struct {
+		Name string `config:"env"`
+		Next *node  `config:"-"`
+	}
+	cfg := &ignored{}
+	si, err := getStructInfo(cfg, nil)
+	assert.NoError(t, err)
+	assert.Len(t, si.Fields(), 1)
+	assert.Nil(t, cfg.Next)
+
+	// the same type twice is not a cycle
+	type pair struct {
+		A, B struct{ Host string }
+	}
+	si, err = getStructInfo(&pair{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, si.Fields(), 2)
+}
+
+func TestGetStructInfo_Limits(t *testing.T) {
+	type deep struct {
+		A struct {
+			B struct {
+				C struct {
+					Name string
+				}
+			}
+		}
+		Port int
+	}
+	_, err := getStructInfoWithin(&deep{}, nil, walkLimits{depth: 4})
+	assert.NoError(t, err)
+	_, err = getStructInfoWithin(&deep{}, nil, walkLimits{depth: 3})
+	assert.True(t, errors.Is(err, ErrOutOfRange))
+	assert.Contains(t, err.Error(), "[A.B.C]")
+
+	_, err = getStructInfoWithin(&deep{}, nil, walkLimits{fields: 2})
+	assert.NoError(t, err)
+	_, err = getStructInfoWithin(&deep{}, nil, walkLimits{fields: 1})
+	assert.True(t, errors.Is(err, ErrOutOfRange))
+	assert.Contains(t, err.Error(), "[Port]")
+
+	c := NewConfigurator(WithFileProvider(""), WithMaxDepth(2))
+	err = c.Load(&deep{})
+	assert.True(t, errors.Is(err, ErrOutOfRange))
+	assert.Contains(t, err.Error(), "WithMaxDepth")
+	c = NewConfigurator(WithFileProvider(""), WithMaxDepth(0), WithMaxFields(0))
+	assert.NoError(t, c.Load(&deep{}))
+}
+
+func TestTagGrammar(t *testing.T) {
+	tests := []struct {
+		tag    string
+		tokens []string
+		err    bool
+	}{
+		{tag: "", tokens: []string{""}},
+		{tag: "env,flag=f", tokens: []string{"env", "flag=f"}},
+		{tag: "default='a,b,c',env", tokens: []string{"default=a,b,c", "env"}},
+		{tag: "default='k=v,x=y'", tokens: []string{"default=k=v,x=y"}},
+		{tag: `default=a\,b,env`, tokens: []string{"default=a,b", "env"}},
+		{tag: `default='it\'s'`, tokens: []string{"default=it's"}},
+		{tag: `default=a\\b`, tokens: []string{`default=a\b`}},
+		{tag: "default='a,b", err: true},
+		{tag: `default=a\`, err: true},
+	}
+	for _, tt := range tests {
+		tokens, err := splitTag(tt.tag)
+		if tt.err {
+			assert.True(t, errors.Is(err, ErrInvalidTagFormat), tt.tag)
+			continue
+		}
+		assert.NoError(t, err, tt.tag)
+		var got []string
+		for _, tok := range tokens {
+			got = append(got, tok.text)
+		}
+		assert.Equal(t, tt.tokens, got, tt.tag)
+	}
+
+	type example struct {
+		Tags   []string `config:"default='a,b,c',env"`
+		Query  string   `config:"default='sslmode=disable,timeout=5'"`
+		Sep    string   `config:"default=\\,"`
+		Level  string   `config:"enum=debug,'env',default=debug"`
+		Broken string   `config:"default='x"`
+	}
+	typ := reflect.TypeOf(example{})
+	tag, err := parseTag(typ.Field(0))
+	assert.NoError(t, err)
+	assert.Equal(t, &tagInfo{defVal: "a,b,c", hasDefault: true, hasENV: true}, tag)
+	tag, err = parseTag(typ.Field(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "sslmode=disable,timeout=5", tag.defVal)
+	tag, err = parseTag(typ.Field(2))
+	assert.NoError(t, err)
+	assert.Equal(t, ",", tag.defVal)
+	tag, err = parseTag(typ.Field(3))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"debug", "env"}, tag.enum)
+	assert.False(t, tag.hasENV)
+	_, err = parseTag(typ.Field(4))
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+
+	var cfg struct {
+		Tags  []string `config:"default='a,b,c'"`
+		Query string   `config:"default='sslmode=disable,timeout=5'"`
+	}
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider())
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, []string{"a", "b", "c"}, cfg.Tags)
+	assert.Equal(t, "sslmode=disable,timeout=5", cfg.Query)
+}
+
+func BenchmarkGetStructInfo(b *testing.B) {
+	newConfig, _ := wideConfig(30)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		si, err := getStructInfo(newConfig(), nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, fi := range si.Fields() {
+			_ = fi.ENVKey()
+			_ = fi.FlagKey()
+		}
+	}
+}
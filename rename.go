@@ -0,0 +1,69 @@
+package configurator
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// renames maps deprecated dotted paths to their replacement, see WithRenames.
+type renames map[string]string
+
+// file moves values found at deprecated paths of a raw document to their new
+// path, unless the new path is already set.
+func (r renames) file(raw map[string]any, logger *slog.Logger) {
+	for old, path := range r {
+		v, ok := removePath(raw, strings.Split(old, "."))
+		if !ok {
+			continue
+		}
+		logger.Warn("configurator: deprecated key", "key", old, "replacement", path)
+		segs := strings.Split(path, ".")
+		m := raw
+		for _, s := range segs[:len(segs)-1] {
+			next, ok := lookupKey(m, s).(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				m[s] = next
+			}
+			m = next
+		}
+		if lookupKey(m, segs[len(segs)-1]) == nil {
+			m[segs[len(segs)-1]] = v
+		}
+	}
+}
+
+// env returns the deprecated path a field was renamed from.
+func (r renames) env(path string) (string, bool) {
+	for old, p := range r {
+		if strings.EqualFold(p, path) {
+			return old, true
+		}
+	}
+	return "", false
+}
+
+func removePath(m map[string]any, segs []string) (any, bool) {
+	for k, v := range m {
+		if !strings.EqualFold(k, segs[0]) {
+			continue
+		}
+		if len(segs) == 1 {
+			delete(m, k)
+			return v, true
+		}
+		if next, ok := v.(map[string]any); ok {
+			return removePath(next, segs[1:])
+		}
+	}
+	return nil, false
+}
+
+func lookupKey(m map[string]any, key string) any {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package configurator
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRenames(t *testing.T) {
+	type example struct {
+		MySQL struct {
+			Host string `yaml:"host" config:"env"`
+			Port int    `yaml:"port" config:"env"`
+		} `yaml:"mysql"`
+	}
+
+	f, err := os.CreateTemp("", "*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString("database:\n  host: db\n  port: 3306\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	c := NewConfigurator(
+		WithFileProvider(f.Name()),
+		WithENVProvider("app"),
+		WithEnviron([]string{"APP_DATABASE_PORT=3307"}),
+		WithRenames(map[string]string{"database.host": "mysql.host", "database.port": "mysql.port"}),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+	)
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "db", cfg.MySQL.Host)
+	assert.Equal(t, 3307, cfg.MySQL.Port)
+	assert.Contains(t, buf.String(), "deprecated key")
+	assert.Contains(t, buf.String(), "deprecated env key")
+}
+
+func TestRenames_File_NewPathWins(t *testing.T) {
+	raw := map[string]any{
+		"old":   "a",
+		"mysql": map[string]any{"host": "b"},
+	}
+	renames{"old": "mysql.host"}.file(raw, slog.Default())
+	assert.Equal(t, map[string]any{"mysql": map[string]any{"host": "b"}}, raw)
+}
@@ -0,0 +1,226 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// exit and stderr are where MustLoad reports, replaced by tests.
+var (
+	exit             = os.Exit
+	stderr io.Writer = os.Stderr
+)
+
+// MustLoad is like Load, but on failure prints a report of every problem
+// of the configuration, as found by Diagnose, to stderr, in color on a
+// terminal unless NO_COLOR is set, and exits with status 1.
+func (c *Configurator) MustLoad(v interface{}, opts ...LoadOption) {
+	c.MustLoadContext(context.Background(), v, opts...)
+}
+
+// MustLoadContext is like MustLoad with the context of LoadContext.
+func (c *Configurator) MustLoadContext(ctx context.Context, v interface{}, opts ...LoadOption) {
+	c.mustLoad(ctx, v, opts)
+}
+
+// mustLoad loads v, or reports why it failed and exits, reporting whether
+// v loaded for when exit returns, as in tests.
+func (c *Configurator) mustLoad(ctx context.Context, v interface{}, opts []LoadOption) bool {
+	ctx = withLoadOptions(ctx, opts)
+	err := c.LoadContext(ctx, v)
+	if err == nil {
+		return true
+	}
+	problems := c.Diagnose(ctx, v)
+	if len(problems) == 0 {
+		// the load failed on something the second pass didn't meet again
+		problems = []Problem{{Message: err.Error()}}
+	}
+	writeReport(stderr, reflect.TypeOf(v), problems, colorOutput(stderr))
+	exit(1)
+	return false
+}
+
+// Diagnose loads a new struct of the type v points to without recording
+// anything, as Drift does, going on past the failures of sources and
+// checks to return every problem rather than the first: the error of each
+// failing source, each required field left unset with the keys that set
+// it and the env vars with a name close to those, and the failed checks.
+// Validate methods run only once every other check passed. It returns nil
+// when v loads.
+func (c *Configurator) Diagnose(ctx context.Context, v interface{}) []Problem {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return []Problem{{Message: ErrInvalidConfig.Error()}}
+	}
+	col := &collector{}
+	_, _, err := c.load(context.WithValue(ctx, collectorKey{}, col), reflect.New(rv.Elem().Type()).Interface(), true)
+	var problems []Problem
+	for _, e := range col.errs {
+		if errors.Is(e.err, ErrRequired) && col.fields != nil {
+			problems = append(problems, c.missing(col.fields)...)
+			continue
+		}
+		p := Problem{Source: e.source, Message: e.err.Error()}
+		if msg := c.message(e.err); msg != "" {
+			p.Message = msg
+		}
+		problems = append(problems, p)
+	}
+	if first := col.err(); err != nil && (first == nil || !errors.Is(err, first)) {
+		problems = append(problems, Problem{Message: err.Error()})
+	}
+	return problems
+}
+
+// missing returns a problem for each required field left unset.
+func (c *Configurator) missing(fields []FieldInfo) []Problem {
+	var ep *envProvider
+	var files, flags bool
+	for _, p := range c.providers {
+		switch p := p.(type) {
+		case *envProvider:
+			ep = p
+		case *fileProvider:
+			files = true
+		case *flagProvider:
+			flags = true
+		}
+	}
+	var environ []string
+	if ep != nil && ep.environ != nil {
+		for _, kv := range ep.environ() {
+			if i := strings.Index(kv, "="); i > 0 {
+				environ = append(environ, kv[:i])
+			}
+		}
+	}
+
+	var problems []Problem
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || !f.required() || f.disabled || !leaf(f.val).IsZero() {
+			continue
+		}
+		p := Problem{Path: f.Path(), Message: ErrRequired.Error()}
+		if msg := c.message(newFieldError(ErrRequired, ErrRequired, "", f.Path())); msg != "" {
+			p.Message = msg
+		}
+		var set []string
+		if k := f.ENVKey(); ep != nil && k != "" {
+			k = ep.normalize(k)
+			set = append(set, "env "+k)
+			if near := Suggest(k, environ); len(near) > 0 {
+				p.Hints = append(p.Hints, fmt.Sprintf("env %s is set, did you mean %s?", orList(near), k))
+			}
+		}
+		if k := f.FlagKey(); flags && k != "" {
+			set = append(set, "flag -"+k)
+		}
+		if files {
+			set = append(set, "key "+strings.Join(f.fileKeys("yaml"), ".")+" of files")
+		}
+		if len(set) > 0 {
+			p.Hints = append([]string{"set " + orList(set)}, p.Hints...)
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+func orList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
+const (
+	ansiRed   = "\x1b[1;31m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+func writeReport(w io.Writer, t reflect.Type, problems []Problem, color bool) {
+	paint := func(style, s string) string {
+		if !color {
+			return s
+		}
+		return style + s + ansiReset
+	}
+	noun := "problems"
+	if len(problems) == 1 {
+		noun = "problem"
+	}
+	fmt.Fprintf(w, "%s %d %s loading %s\n\n", paint(ansiRed, "configuration error:"), len(problems), noun, t)
+	for _, p := range problems {
+		head := p.Message
+		switch {
+		case p.Path != "":
+			head = paint(ansiRed, p.Path) + ": " + head
+		case p.Source != "":
+			head = paint(ansiRed, "from "+p.Source) + ": " + head
+		}
+		fmt.Fprintf(w, "  %s\n", head)
+		for _, h := range p.Hints {
+			fmt.Fprintf(w, "      %s\n", paint(ansiDim, h))
+		}
+	}
+}
+
+// colorOutput reports whether w is a terminal taking colors.
+func colorOutput(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// collector gathers the problems of a load run by Diagnose.
+type collector struct {
+	errs []sourceError
+	// fields are the fields of the struct loaded, once the sources ran.
+	fields []FieldInfo
+}
+
+// sourceError is an error of the source named source, of a check when
+// empty.
+type sourceError struct {
+	source string
+	err    error
+}
+
+type collectorKey struct{}
+
+func collectorFrom(ctx context.Context) *collector {
+	col, _ := ctx.Value(collectorKey{}).(*collector)
+	return col
+}
+
+func (col *collector) add(source string, err error) {
+	col.errs = append(col.errs, sourceError{source: source, err: err})
+}
+
+// check returns err, or records it and returns nil when collecting.
+func (col *collector) check(err error) error {
+	if err == nil || col == nil {
+		return err
+	}
+	col.add("", err)
+	return nil
+}
+
+// err returns the first error recorded, if any.
+func (col *collector) err() error {
+	if col == nil || len(col.errs) == 0 {
+		return nil
+	}
+	return col.errs[0].err
+}
@@ -0,0 +1,89 @@
+package configurator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reportConfig struct {
+	Name     string `config:"env,flag,required"`
+	Mode     string `config:"env,enum=dev|prod"`
+	Database struct {
+		URL string `config:"env,required"`
+	}
+	Port int `config:"env,flag"`
+}
+
+func TestDiagnose(t *testing.T) {
+	t.Parallel()
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider("APP"), WithEnviron([]string{
+		"APP_PORT=x", "APP_MODE=test", "APP_DATABASE_URLL=postgres://db",
+	}))
+	problems := c.Diagnose(context.Background(), &reportConfig{})
+	assert.Equal(t, []Problem{
+		{Source: "env", Message: `envProvider/Provide: strconv.ParseInt: parsing "x": invalid syntax [APP_PORT]`},
+		{Path: "Name", Message: "required field not set", Hints: []string{"set env APP_NAME"}},
+		{Path: "Database.URL", Message: "required field not set", Hints: []string{
+			"set env APP_DATABASE_URL",
+			"env APP_DATABASE_URLL is set, did you mean APP_DATABASE_URL?",
+		}},
+		{Message: `configurator/LoadContext: value not allowed "test", allowed [dev|prod] [Mode]`},
+	}, problems)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"NAME=web", "DATABASE_URL=db"}))
+	assert.Empty(t, c.Diagnose(context.Background(), &reportConfig{}))
+}
+
+func TestWriteReport(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	writeReport(&buf, reflect.TypeOf(&reportConfig{}), []Problem{
+		{Source: "env", Message: "bad port"},
+		{Path: "Name", Message: "required field not set", Hints: []string{"set env NAME"}},
+	}, false)
+	assert.Equal(t, `configuration error: 2 problems loading *configurator.reportConfig
+
+  from env: bad port
+  Name: required field not set
+      set env NAME
+`, buf.String())
+
+	buf.Reset()
+	writeReport(&buf, reflect.TypeOf(&reportConfig{}), []Problem{{Path: "Name", Message: "unset", Hints: []string{"set it"}}}, true)
+	assert.Contains(t, buf.String(), "\x1b[1;31mName\x1b[0m: unset\n      \x1b[2mset it\x1b[0m\n")
+	assert.False(t, colorOutput(&buf))
+}
+
+func TestMustLoad(t *testing.T) {
+	var buf bytes.Buffer
+	code := -1
+	defer func(w io.Writer, fn func(int)) { stderr, exit = w, fn }(stderr, exit)
+	stderr, exit = &buf, func(c int) { code = c }
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"NAME=web", "DATABASE_URL=db"}))
+	cfg := &reportConfig{}
+	c.MustLoad(cfg)
+	assert.Equal(t, -1, code)
+	assert.Equal(t, "web", cfg.Name)
+
+	// the env var of the profile of the call sets the URL
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"NAME=web", "DATABASE_URL_STAGING=db"}))
+	cfg = &reportConfig{}
+	c.MustLoad(cfg, WithLoadProfile("staging"))
+	assert.Equal(t, -1, code)
+	assert.Equal(t, "db", cfg.Database.URL)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"NAME=web"}))
+	c.MustLoad(&reportConfig{})
+	assert.Equal(t, 1, code)
+	assert.Equal(t, `configuration error: 1 problem loading *configurator.reportConfig
+
+  Database.URL: required field not set
+      set env DATABASE_URL
+`, buf.String())
+}
@@ -0,0 +1,36 @@
+package configurator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkRequired fails when a field tagged required, or in a section tagged
+// required, is still zero after all providers ran. Fields of disabled
+// sections are not checked.
+func checkRequired(fields []FieldInfo) error {
+	var missing []string
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || !f.required() || f.disabled {
+			continue
+		}
+		if leaf(f.val).IsZero() {
+			missing = append(missing, f.Path())
+		}
+	}
+	if len(missing) > 0 {
+		err := fmt.Errorf("configurator/LoadContext: %w [%s]", ErrRequired, strings.Join(missing, ", "))
+		return newFieldError(ErrRequired, err, "", missing...)
+	}
+	return nil
+}
+
+func (f *fieldInfo) required() bool {
+	for p := f; p != nil; p = p.parent {
+		if p.tag.required {
+			return true
+		}
+	}
+	return false
+}
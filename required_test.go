@@ -0,0 +1,51 @@
+package configurator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequired(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Enabled bool
+		Host    string `config:"env,required"`
+		Redis   struct {
+			Addr string `config:"env,required"`
+		} `config:"if=Enabled"`
+	}
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(nil))
+	err := c.Load(&example{})
+	assert.True(t, errors.Is(err, ErrRequired))
+	assert.Contains(t, err.Error(), "Host")
+	assert.NotContains(t, err.Error(), "Redis.Addr")
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"HOST=db"}))
+	assert.NoError(t, c.Load(&example{}))
+
+	err = c.Load(&example{Enabled: true})
+	assert.True(t, errors.Is(err, ErrRequired))
+	assert.Contains(t, err.Error(), "Redis.Addr")
+}
+
+func TestRequired_Section(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Database struct {
+			Host string `config:"env"`
+			Port int    `config:"env,default=5432"`
+		} `config:"required"`
+	}
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(nil))
+	err := c.Load(&example{})
+	assert.True(t, errors.Is(err, ErrRequired))
+	assert.Contains(t, err.Error(), "Database.Host")
+	assert.NotContains(t, err.Error(), "Database.Port")
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron([]string{"DATABASE_HOST=db"}))
+	assert.NoError(t, c.Load(&example{}))
+}
@@ -0,0 +1,98 @@
+package configurator
+
+import (
+	"context"
+	"reflect"
+)
+
+// WithRestartHandler calls fn when a reload changes fields tagged
+// `reload=restart`, which the process only reads at startup, such as a
+// listen address: the reload keeps their current values rather than apply
+// them hot, and fn gets the changes waiting for a restart, to log them,
+// fail a health check or shut down gracefully. fn is called again only
+// when the changes waiting differ. Without a handler, the changes are
+// logged as warnings. Fields are `reload=hot` unless tagged, or in a
+// section tagged, `reload=restart`.
+func WithRestartHandler(fn func([]Change)) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.onRestart = fn
+	}
+}
+
+// RestartRequired returns the changes to `reload=restart` fields the
+// reloads kept from applying, nil if none.
+func (c *Configurator) RestartRequired() []Change {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Change(nil), c.restarts...)
+}
+
+// restart reports whether the field is read at startup only.
+func (f *fieldInfo) restart() bool {
+	for p := f; p != nil; p = p.parent {
+		if p.tag.reload != "" {
+			return p.tag.reload == reloadRestart
+		}
+	}
+	return false
+}
+
+// holdRestarts sets the `reload=restart` fields a reload changed back to
+// their current values and reports the changes waiting for a restart.
+func (c *Configurator) holdRestarts(ctx context.Context, fields []FieldInfo, origins map[string]string) {
+	if ctx.Value(reloadKey{}) == nil {
+		return
+	}
+	c.mu.RLock()
+	prev, prevOrigins := c.values, c.origins
+	reported := c.restarts
+	c.mu.RUnlock()
+	if prev == nil {
+		return
+	}
+	var held []FieldInfo
+	for _, fi := range fields {
+		if f, ok := fi.(*fieldInfo); ok && f.restart() {
+			held = append(held, fi)
+		}
+	}
+	if len(held) == 0 {
+		return
+	}
+	changes := diff(held, prev, origins)
+	for _, ch := range changes {
+		for _, fi := range held {
+			old, ok := prev[ch.Key]
+			if fi.Path() != ch.Key || !ok {
+				continue
+			}
+			// a copy, as the values of the last load get shredded
+			if w, ok := asWrapper(fi.Value()); ok {
+				w.store(copyValue(old))
+			} else {
+				fi.Value().Set(copyValue(old))
+			}
+			if src, ok := prevOrigins[ch.Key]; ok {
+				origins[ch.Key] = src
+			} else {
+				delete(origins, ch.Key)
+			}
+		}
+	}
+	if reflect.DeepEqual(changes, reported) {
+		return
+	}
+	c.mu.Lock()
+	c.restarts = changes
+	c.mu.Unlock()
+	if len(changes) == 0 {
+		return
+	}
+	if c.onRestart != nil {
+		c.onRestart(changes)
+		return
+	}
+	for _, ch := range changes {
+		c.logger.Warn("configurator: restart required", "field", ch.Key, "old", ch.Old, "new", ch.New, "provider", ch.Source)
+	}
+}
@@ -0,0 +1,64 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRestartHandler(t *testing.T) {
+	type server struct {
+		Addr    string       `config:"env"`
+		Workers Dynamic[int] `config:"env,reload=hot"`
+	}
+	type example struct {
+		Server server       `config:"reload=restart"`
+		Port   int          `config:"env,reload=restart"`
+		Limit  Dynamic[int] `config:"env"`
+	}
+	env := map[string]string{"SERVER_ADDR": ":80", "SERVER_WORKERS": "2", "PORT": "80", "LIMIT": "1"}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	var calls [][]Change
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithLookupEnv(lookup), WithRestartHandler(func(changes []Change) {
+		calls = append(calls, changes)
+	}))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+
+	env["SERVER_ADDR"], env["SERVER_WORKERS"], env["LIMIT"] = ":8080", "4", "2"
+	fresh, err := c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, ":80", fresh.(*example).Server.Addr)
+	assert.Equal(t, 4, cfg.Server.Workers.Get())
+	assert.Equal(t, 2, cfg.Limit.Get())
+	want := []Change{{Key: "Server.Addr", Old: ":80", New: ":8080", Source: "env"}}
+	assert.Equal(t, [][]Change{want}, calls)
+	assert.Equal(t, want, c.RestartRequired())
+	assert.Equal(t, ":80", c.report().Config["Server.Addr"])
+
+	_, err = c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Len(t, calls, 1, "the same changes are reported once")
+
+	env["SERVER_ADDR"] = ":80"
+	_, err = c.Reload(context.Background(), cfg)
+	assert.NoError(t, err)
+	assert.Empty(t, c.RestartRequired())
+
+	schema, err := Describe(&example{})
+	assert.NoError(t, err)
+	for _, s := range schema {
+		assert.Equal(t, s.Path == "Server.Addr" || s.Path == "Port", s.Restart, s.Path)
+	}
+
+	type invalid struct {
+		Port int `config:"reload=never"`
+	}
+	err = NewConfigurator(WithFileProvider("")).Load(&invalid{})
+	assert.True(t, errors.Is(err, ErrInvalidTagFormat))
+}
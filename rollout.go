@@ -0,0 +1,55 @@
+package configurator
+
+import (
+	"hash/fnv"
+)
+
+// Rollout is implemented by the providers a Fetcher returns for releases
+// published to a share of a fleet at a time, as the staged releases of a
+// config center.
+type Rollout interface {
+	Versioner
+	// Rollout returns the percentage, from 0 to 100, of the instances to
+	// apply the release to.
+	Rollout() float64
+}
+
+// WithRollout stages the releases of the sources implementing Rollout
+// across a fleet: a Reload, as Watch runs, applies a new release only when
+// the stable hash of instance, such as the host or pod name, falls within
+// its rollout percentage, and applies the release of the last load again
+// otherwise. Raising the percentage only adds instances, so a release
+// reaches the whole fleet at 100. The first load applies the release it
+// fetches.
+func WithRollout(instance string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.instance = instance
+	}
+}
+
+// stage returns the provider of the source name to apply, p, or the one
+// applied last when p is a release not rolled out to the instance yet.
+func (c *Configurator) stage(name string, p Provider) Provider {
+	r, ok := p.(Rollout)
+	if !ok || c.instance == "" {
+		return p
+	}
+	c.mu.RLock()
+	last := c.applied[name]
+	c.mu.RUnlock()
+	if last == nil || inRollout(c.instance, r.Rollout()) {
+		return p
+	}
+	if v, ok := last.(Versioner); !ok || v.Version() != r.Version() {
+		c.logger.Info("configurator: release held back", "provider", name, "version", r.Version(), "rollout", r.Rollout())
+	}
+	return last
+}
+
+// inRollout reports whether instance is among the percent of instances a
+// release is rolled out to.
+func inRollout(instance string, percent float64) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(instance))
+	return float64(h.Sum32()%10000) < percent*100
+}
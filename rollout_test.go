@@ -0,0 +1,81 @@
+package configurator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stagedSource struct {
+	name    string
+	percent float64
+}
+
+func (s *stagedSource) Provide(v interface{}, si StructInfo) error {
+	p, _ := s.Fetch(context.Background())
+	return p.Provide(v, si)
+}
+
+func (s *stagedSource) Fetch(context.Context) (Provider, error) {
+	return &staged{replaySource: replaySource{name: "staged", values: map[string]string{"Name": s.name}}, percent: s.percent}, nil
+}
+
+func (s *stagedSource) String() string { return "staged" }
+
+type staged struct {
+	replaySource
+	percent float64
+}
+
+func (s *staged) Version() string  { return s.values["Name"] }
+func (s *staged) Rollout() float64 { return s.percent }
+
+func TestWithRollout(t *testing.T) {
+	type example struct {
+		Name string
+	}
+	src := &stagedSource{name: "v1", percent: 0}
+	c := NewConfigurator(WithFileProvider(""), WithProvider(src), WithRollout("pod-1"))
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "v1", cfg.Name, "the first load applies any release")
+
+	src.name = "v2"
+	fresh, err := c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", fresh.(*example).Name)
+	assert.Equal(t, "v1", c.Version().Sources["staged"])
+
+	src.percent = 100
+	fresh, err = c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", fresh.(*example).Name)
+
+	src.name, src.percent = "v3", 0
+	fresh, err = c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", fresh.(*example).Name)
+
+	// without an instance releases apply right away
+	c = NewConfigurator(WithFileProvider(""), WithProvider(src))
+	assert.NoError(t, c.Load(&cfg))
+	src.name = "v4"
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "v4", cfg.Name)
+}
+
+func TestInRollout(t *testing.T) {
+	in := 0
+	for i := 0; i < 1000; i++ {
+		instance := fmt.Sprintf("pod-%d", i)
+		if inRollout(instance, 25) {
+			in++
+			assert.True(t, inRollout(instance, 50))
+		}
+		assert.False(t, inRollout(instance, 0))
+		assert.True(t, inRollout(instance, 100))
+	}
+	assert.InDelta(t, 250, in, 50)
+}
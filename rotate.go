@@ -0,0 +1,50 @@
+package configurator
+
+import (
+	"reflect"
+)
+
+// OnRotate calls fn with the new value of the field at path, such as
+// "Database.Password", each time a load changes it, so that rotated
+// credentials, such as the dynamic secrets of Vault or AWS STS refreshed by
+// Watch, rebuild the connection pools using them instead of the pools
+// failing to authenticate once the old ones expire. T is the type of the
+// field, or the type a Dynamic or Optional field holds. fn runs before the
+// load returns.
+func OnRotate[T any](c *Configurator, path string, fn func(T)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rotations = append(c.rotations, rotation{path: path, fn: func(v reflect.Value) bool {
+		t, ok := v.Interface().(T)
+		if ok {
+			fn(t)
+		}
+		return ok
+	}})
+}
+
+// rotation is a callback of OnRotate, reporting false when the field
+// isn't of its type.
+type rotation struct {
+	path string
+	fn   func(reflect.Value) bool
+}
+
+// rotate calls the OnRotate callbacks of the fields whose value changed
+// from prev.
+func (c *Configurator) rotate(prev, values map[string]reflect.Value) {
+	c.mu.RLock()
+	rotations := c.rotations
+	c.mu.RUnlock()
+	for _, r := range rotations {
+		old, ok := prev[r.path]
+		v, found := values[r.path]
+		if !ok || !found || reflect.DeepEqual(old.Interface(), v.Interface()) {
+			continue
+		}
+		c.logger.Info("configurator: rotated", "field", r.path)
+		if !r.fn(v) {
+			c.logger.Warn("configurator: OnRotate callback of another type", "field", r.path, "type", v.Type().String())
+		}
+	}
+}
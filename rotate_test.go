@@ -0,0 +1,46 @@
+package configurator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnRotate(t *testing.T) {
+	type database struct {
+		User     string `config:"env"`
+		Password string `config:"env,secret"`
+	}
+	type example struct {
+		Database database
+		Limit    Dynamic[int] `config:"env"`
+	}
+	env := map[string]string{"DATABASE_USER": "app", "DATABASE_PASSWORD": "one", "LIMIT": "1"}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithLookupEnv(lookup))
+	var passwords []string
+	OnRotate(c, "Database.Password", func(pw string) { passwords = append(passwords, pw) })
+	var limits []int
+	OnRotate(c, "Limit", func(n int) { limits = append(limits, n) })
+	var wrong int
+	OnRotate(c, "Database.User", func(n int) { wrong++ })
+
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Empty(t, passwords, "the first load rotates nothing")
+
+	_, err := c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Empty(t, passwords)
+
+	env = map[string]string{"DATABASE_USER": "other", "DATABASE_PASSWORD": "two", "LIMIT": "2"}
+	_, err = c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"two"}, passwords)
+	assert.Equal(t, []int{2}, limits)
+	assert.Equal(t, 0, wrong)
+}
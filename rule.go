@@ -0,0 +1,45 @@
+package configurator
+
+// Finding is a risky setting a rule found in a configuration that loads,
+// such as debug logging in production, reported as a warning.
+type Finding struct {
+	// Rule names the rule that found it.
+	Rule    string `json:"rule"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// WithRule runs rule on every configuration of type T the configurator
+// loads, once it is valid, to report risky combinations of settings that
+// aren't errors, such as TLS disabled on a public bind address. Findings are
+// logged, returned by Findings and served by Handler; their Rule is name.
+func WithRule[T any](name string, rule func(cfg *T) []Finding) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.rules = append(co.rules, func(v interface{}) []Finding {
+			cfg, ok := v.(*T)
+			if !ok {
+				return nil
+			}
+			findings := rule(cfg)
+			for i := range findings {
+				findings[i].Rule = name
+			}
+			return findings
+		})
+	}
+}
+
+// Findings returns what the rules found in the last successful load.
+func (c *Configurator) Findings() []Finding {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Finding{}, c.findings...)
+}
+
+func (c *Configurator) checkRules(v interface{}) []Finding {
+	var findings []Finding
+	for _, rule := range c.rules {
+		findings = append(findings, rule(v)...)
+	}
+	return findings
+}
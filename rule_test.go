@@ -0,0 +1,56 @@
+package configurator
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ruleConfig struct {
+	Profile  string `config:"env"`
+	LogLevel string `config:"env"`
+	Bind     string `config:"env"`
+	TLS      bool   `config:"env"`
+}
+
+func debugInProd(cfg *ruleConfig) []Finding {
+	if cfg.Profile == "prod" && cfg.LogLevel == "debug" {
+		return []Finding{{Path: "LogLevel", Message: "debug logging in production"}}
+	}
+	return nil
+}
+
+func plainPublic(cfg *ruleConfig) []Finding {
+	if !cfg.TLS && strings.HasPrefix(cfg.Bind, "0.0.0.0:") {
+		return []Finding{{Path: "TLS", Message: "TLS disabled on a public address " + cfg.Bind}}
+	}
+	return nil
+}
+
+func TestWithRule(t *testing.T) {
+	t.Parallel()
+	load := func(environ ...string) *Configurator {
+		c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ),
+			WithRule("debug-in-prod", debugInProd), WithRule("plain-public", plainPublic),
+			// rules of other types are skipped
+			WithRule("other", func(*struct{}) []Finding { return []Finding{{Message: "never"}} }))
+		assert.NoError(t, c.Load(&ruleConfig{}))
+		return c
+	}
+	c := load("PROFILE=prod", "LOGLEVEL=debug", "BIND=0.0.0.0:80")
+	assert.Equal(t, []Finding{
+		{Rule: "debug-in-prod", Path: "LogLevel", Message: "debug logging in production"},
+		{Rule: "plain-public", Path: "TLS", Message: "TLS disabled on a public address 0.0.0.0:80"},
+	}, c.Findings())
+
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	var rep struct{ Findings []Finding }
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rep))
+	assert.Equal(t, c.Findings(), rep.Findings)
+
+	assert.Empty(t, load("PROFILE=prod", "BIND=127.0.0.1:80").Findings())
+}
@@ -0,0 +1,153 @@
+package configurator
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Platforms DetectRuntime tells apart.
+const (
+	PlatformLocal      = "local"
+	PlatformKubernetes = "kubernetes"
+	PlatformECS        = "ecs"
+	PlatformLambda     = "lambda"
+)
+
+// serviceAccountDir is where Kubernetes mounts the service account of pods.
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Runtime describes where the process runs, for defaults such as
+// `default=${runtime:podIP}` and {{runtime "podIP"}} in derive templates.
+type Runtime struct {
+	// Platform is one of PlatformLocal, PlatformKubernetes, PlatformECS
+	// and PlatformLambda.
+	Platform string
+	// Values are keyed platform, hostname and ip everywhere; podName,
+	// podNamespace, podIP and nodeName on Kubernetes, from the env vars of
+	// the Downward API or the service account; region on AWS; and
+	// functionName, functionVersion and memoryMB on Lambda. Keys the
+	// platform doesn't tell are missing.
+	Values map[string]string
+}
+
+var detectedRuntime = sync.OnceValue(func() Runtime { return detectRuntime(os.LookupEnv) })
+
+// DetectRuntime detects the platform of the process from its env and the
+// files platforms mount, once.
+func DetectRuntime() Runtime {
+	return detectedRuntime()
+}
+
+// WithRuntime sets the runtime `${runtime:key}` defaults read in place of
+// the detected one, such as in tests.
+func WithRuntime(r Runtime) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.runtime = &r
+	}
+}
+
+// Lookup returns the value of key, such as "podIP".
+func (r Runtime) Lookup(key string) (string, bool) {
+	if key == "platform" {
+		return r.Platform, true
+	}
+	v, ok := r.Values[key]
+	return v, ok
+}
+
+func detectRuntime(lookup func(string) (string, bool)) Runtime {
+	env := func(k string) string {
+		v, _ := lookup(k)
+		return v
+	}
+	r := Runtime{Platform: PlatformLocal, Values: map[string]string{}}
+	set := func(k, v string) {
+		if v != "" {
+			r.Values[k] = v
+		}
+	}
+	hostname, _ := os.Hostname()
+	set("hostname", hostname)
+	set("ip", localIP())
+	set("region", firstOf(env("AWS_REGION"), env("AWS_DEFAULT_REGION")))
+
+	_, saErr := os.Stat(serviceAccountDir)
+	switch {
+	case env("AWS_LAMBDA_FUNCTION_NAME") != "":
+		r.Platform = PlatformLambda
+		set("functionName", env("AWS_LAMBDA_FUNCTION_NAME"))
+		set("functionVersion", env("AWS_LAMBDA_FUNCTION_VERSION"))
+		set("memoryMB", env("AWS_LAMBDA_FUNCTION_MEMORY_SIZE"))
+	case env("ECS_CONTAINER_METADATA_URI_V4") != "" || env("ECS_CONTAINER_METADATA_URI") != "":
+		r.Platform = PlatformECS
+	case env("KUBERNETES_SERVICE_HOST") != "" || saErr == nil:
+		r.Platform = PlatformKubernetes
+		namespace := env("POD_NAMESPACE")
+		if namespace == "" {
+			b, _ := os.ReadFile(serviceAccountDir + "/namespace")
+			namespace = strings.TrimSpace(string(b))
+		}
+		set("podName", firstOf(env("POD_NAME"), hostname))
+		set("podNamespace", namespace)
+		set("podIP", firstOf(env("POD_IP"), r.Values["ip"]))
+		set("nodeName", env("NODE_NAME"))
+	}
+	return r
+}
+
+// localIP returns the first address of the host that isn't a loopback.
+func localIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, a := range addrs {
+		if ip, ok := a.(*net.IPNet); ok && !ip.IP.IsLoopback() && ip.IP.To4() != nil {
+			return ip.IP.String()
+		}
+	}
+	return ""
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// runtimeRef matches ${runtime:key} and ${runtime:key:-fallback}.
+var runtimeRef = regexp.MustCompile(`\$\{runtime:([A-Za-z]+)(:-[^}]*)?\}`)
+
+// expandRuntime replaces the ${runtime:key} references of a default with
+// the values of the runtime, or their fallback when the platform doesn't
+// tell the key.
+func (o parseOptions) expandRuntime(def string) (string, error) {
+	if !strings.Contains(def, "${runtime:") {
+		return def, nil
+	}
+	r := DetectRuntime()
+	if o.runtime != nil {
+		r = o.runtime()
+	}
+	var err error
+	def = runtimeRef.ReplaceAllStringFunc(def, func(ref string) string {
+		m := runtimeRef.FindStringSubmatch(ref)
+		if !contains(runtimeKeys, m[1]) {
+			err = fmt.Errorf("%w, unknown runtime key %q, one of %s", ErrInvalidTagFormat, m[1], strings.Join(runtimeKeys, ", "))
+		}
+		if v, ok := r.Lookup(m[1]); ok {
+			return v
+		}
+		return strings.TrimPrefix(m[2], ":-")
+	})
+	return def, err
+}
+
+var runtimeKeys = []string{"functionName", "functionVersion", "hostname", "ip", "memoryMB", "nodeName", "platform", "podIP", "podName", "podNamespace", "region"}
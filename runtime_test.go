@@ -0,0 +1,73 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeDefaults(t *testing.T) {
+	type example struct {
+		BindAddr  string `config:"default=${runtime:podIP:-127.0.0.1}:8080"`
+		Namespace string `config:"default=${runtime:podNamespace:-default}"`
+		Platform  string `config:"default=${runtime:platform}"`
+		Node      string `config:"derive={{runtime \"nodeName\"}}"`
+	}
+	r := Runtime{Platform: PlatformKubernetes, Values: map[string]string{"podIP": "10.1.2.3", "podNamespace": "prod", "nodeName": "node-1"}}
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithRuntime(r))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, example{BindAddr: "10.1.2.3:8080", Namespace: "prod", Platform: "kubernetes", Node: "node-1"}, *cfg)
+
+	c = NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithRuntime(Runtime{Platform: PlatformLocal}))
+	cfg = &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, example{BindAddr: "127.0.0.1:8080", Namespace: "default", Platform: "local"}, *cfg)
+
+	type invalid struct {
+		Addr string `config:"default=${runtime:podip}"`
+	}
+	assert.True(t, errors.Is(NewConfigurator(WithFileProvider("")).Load(&invalid{}), ErrInvalidTagFormat))
+}
+
+func TestDetectRuntime(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "namespace"), []byte("staging\n"), 0o600))
+	serviceAccountDir = dir
+	defer func() { serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount" }()
+
+	r := detectRuntime(environLookup([]string{"POD_IP=10.0.0.7", "NODE_NAME=node-2", "HOSTNAME=web-0"}))
+	assert.Equal(t, PlatformKubernetes, r.Platform)
+	assert.Equal(t, "staging", r.Values["podNamespace"])
+	assert.Equal(t, "10.0.0.7", r.Values["podIP"])
+	assert.Equal(t, "node-2", r.Values["nodeName"])
+	serviceAccountDir = filepath.Join(dir, "missing")
+
+	r = detectRuntime(environLookup([]string{"AWS_LAMBDA_FUNCTION_NAME=resize", "AWS_LAMBDA_FUNCTION_MEMORY_SIZE=512", "AWS_REGION=eu-west-1"}))
+	assert.Equal(t, PlatformLambda, r.Platform)
+	assert.Equal(t, "resize", r.Values["functionName"])
+	assert.Equal(t, "512", r.Values["memoryMB"])
+	v, ok := r.Lookup("region")
+	assert.True(t, ok)
+	assert.Equal(t, "eu-west-1", v)
+
+	r = detectRuntime(environLookup([]string{"ECS_CONTAINER_METADATA_URI_V4=http://169.254.170.2/v4/x"}))
+	assert.Equal(t, PlatformECS, r.Platform)
+	_, ok = r.Lookup("podIP")
+	assert.False(t, ok)
+
+	r = detectRuntime(environLookup(nil))
+	assert.Equal(t, PlatformLocal, r.Platform)
+
+	// the env of the configurator tells the platform
+	type example struct {
+		Platform string `config:"default=${runtime:platform}"`
+	}
+	cfg := &example{}
+	c := NewConfigurator(WithFileProvider(""), WithDefaultProvider(), WithEnviron([]string{"AWS_LAMBDA_FUNCTION_NAME=resize"}))
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, PlatformLambda, cfg.Platform)
+}
@@ -0,0 +1,119 @@
+package configurator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WithSourceScope adds p as a custom provider, like WithProvider, bound to
+// the section at path, such as "Secrets": p loads the section as if it were
+// the whole configuration, so its keys and env names are relative to it,
+// and other sources, but for defaults, can't set the fields of the section.
+func WithSourceScope(p Provider, path string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.providers = append(co.providers, p)
+		co.scopes = append(co.scopes, path)
+	}
+}
+
+// provideScoped runs the provider of s on the section it is bound to, and
+// marks the fields it set explicitly in fields.
+func (c *Configurator) provideScoped(ctx context.Context, s step, v interface{}, fields []FieldInfo) error {
+	section, err := sectionOf(v, s.scope)
+	if err != nil {
+		return err
+	}
+	si, err := getStructInfoWithin(section, nil, c.limits)
+	if err != nil {
+		return err
+	}
+	for _, fi := range si.Fields() {
+		if f, ok := fi.(*fieldInfo); ok {
+			f.parse = c.parse
+		}
+	}
+	if err := provide(ctx, s.provider, section, si, c.timeout); err != nil {
+		return err
+	}
+	explicit := make(map[string]bool)
+	for _, fi := range si.Fields() {
+		if f, ok := fi.(*fieldInfo); ok && f.explicit {
+			explicit[s.scope+"."+fi.Path()] = true
+		}
+	}
+	for _, fi := range fields {
+		if explicit[fi.Path()] {
+			markSet(fi)
+		}
+	}
+	return nil
+}
+
+// restrictScopes reverts the fields s set outside of its scope, or inside
+// the scope of another source.
+func (c *Configurator) restrictScopes(s step, fields []FieldInfo, before []reflect.Value) {
+	for i, fi := range fields {
+		if !c.outOfScope(s, fi.Path()) {
+			continue
+		}
+		if reflect.DeepEqual(before[i].Interface(), leaf(fi.Value()).Interface()) {
+			if f, ok := fi.(*fieldInfo); ok {
+				f.explicit = false
+			}
+			continue
+		}
+		c.logger.Debug("configurator: field out of scope", "field", fi.Path(), "provider", s.name)
+		if d, ok := asWrapper(fi.Value()); ok {
+			d.store(before[i])
+		} else {
+			fi.Value().Set(before[i])
+		}
+		if f, ok := fi.(*fieldInfo); ok {
+			f.explicit = false
+		}
+	}
+}
+
+func (c *Configurator) outOfScope(s step, path string) bool {
+	if s.scope != "" {
+		return !inScope(path, s.scope)
+	}
+	if s.shared {
+		return false
+	}
+	for _, scope := range c.scopes {
+		if scope != "" && inScope(path, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func inScope(path, scope string) bool {
+	return path == scope || strings.HasPrefix(path, scope+".")
+}
+
+// sectionOf returns a pointer to the struct at path in the struct v points
+// to.
+func sectionOf(v interface{}, path string) (interface{}, error) {
+	rv := reflect.ValueOf(v).Elem()
+	for _, name := range strings.Split(path, ".") {
+		f, ok := rv.Type().FieldByName(name)
+		if !ok || !f.IsExported() {
+			return nil, fmt.Errorf("configurator/LoadContext: %w, no section %s", ErrInvalidConfig, path)
+		}
+		rv = rv.FieldByIndex(f.Index)
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Struct || rv.Type() == timeType || isWrapper(rv.Type()) {
+			return nil, fmt.Errorf("configurator/LoadContext: %w, no section %s", ErrInvalidConfig, path)
+		}
+	}
+	return rv.Addr().Interface(), nil
+}
@@ -0,0 +1,65 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pathProvider sets fields by their path.
+type pathProvider map[string]string
+
+func (p pathProvider) Provide(_ interface{}, si StructInfo) error {
+	for _, fi := range si.Fields() {
+		if v, ok := p[fi.Path()]; ok {
+			if err := fi.Set(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestWithSourceScope(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Name    string `config:"default=api"`
+		Secrets struct {
+			Password string `config:"secret"`
+			Token    string `config:"secret,default=none"`
+		}
+		Vault *struct {
+			Addr string
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("name: web\nsecrets:\n  password: from-file\n"), 0o600))
+
+	c := NewConfigurator(
+		WithFileProvider(filename),
+		WithSourceScope(pathProvider{"Password": "s3cret", "Name": "ignored"}, "Secrets"),
+		WithSourceScope(pathProvider{"Addr": "https://vault:8200", "Password": "stomp"}, "Vault"),
+		WithDefaultProvider(),
+	)
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "web", cfg.Name)
+	assert.Equal(t, "s3cret", cfg.Secrets.Password)
+	assert.Equal(t, "none", cfg.Secrets.Token)
+	assert.Equal(t, "https://vault:8200", cfg.Vault.Addr)
+	assert.Equal(t, "configurator.pathProvider", c.Provenance()["Secrets.Password"])
+	assert.Equal(t, "file", c.Provenance()["Name"])
+
+	// the file can't set the section even if the scoped source doesn't
+	c = NewConfigurator(WithFileProvider(filename), WithSourceScope(pathProvider{}, "Secrets"))
+	cfg = example{}
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "", cfg.Secrets.Password)
+	assert.False(t, c.IsSet("Secrets.Password"))
+
+	err := NewConfigurator(WithFileProvider(""), WithSourceScope(pathProvider{}, "Name")).Load(&example{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
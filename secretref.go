@@ -0,0 +1,122 @@
+package configurator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SecretResolver resolves a reference to a secret kept by a secret
+// manager, such as op://vault/item/field, to the secret.
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc is a SecretResolver calling the func.
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// WithSecretResolver resolves string values of the form scheme://... with
+// r once every source ran, so that files and env vars can reference
+// secrets instead of holding them. Fields set from a reference are treated
+// as secret.
+func WithSecretResolver(scheme string, r SecretResolver) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		if co.resolvers == nil {
+			co.resolvers = make(map[string]SecretResolver)
+		}
+		co.resolvers[scheme] = r
+	}
+}
+
+// OnePasswordCLI resolves op://vault/item/field references with the
+// 1Password CLI, signed in or given a service account token through
+// OP_SERVICE_ACCOUNT_TOKEN.
+func OnePasswordCLI() SecretResolver {
+	return SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		return runSecretCommand(ctx, "op", "read", "--no-newline", ref)
+	})
+}
+
+// BitwardenCLI resolves bw://item/field references with the Bitwarden CLI,
+// unlocked with BW_SESSION. field is one of the fields bw get reads, such
+// as password, username, totp or notes, and item an item name or ID.
+func BitwardenCLI() SecretResolver {
+	return SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		path := strings.TrimPrefix(ref, "bw://")
+		i := strings.LastIndex(path, "/")
+		if i <= 0 || i == len(path)-1 {
+			return "", fmt.Errorf("%w, expected bw://item/field [%s]", ErrInvalidValue, ref)
+		}
+		s, err := runSecretCommand(ctx, "bw", "get", path[i+1:], path[:i])
+		return strings.TrimSuffix(s, "\n"), err
+	})
+}
+
+func runSecretCommand(ctx context.Context, name string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && stderr.Len() > 0 {
+		return "", fmt.Errorf("%s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), err
+}
+
+// resolveSecrets replaces the secret references held by string fields with
+// the secrets they name, and returns the leases of those expiring.
+func (c *Configurator) resolveSecrets(ctx context.Context, fields []FieldInfo) ([]lease, error) {
+	if len(c.resolvers) == 0 {
+		return nil, nil
+	}
+	var leases []lease
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok || f.disabled {
+			continue
+		}
+		v := leaf(f.val)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.String {
+			continue
+		}
+		ref := v.String()
+		scheme, _, ok := strings.Cut(ref, "://")
+		r, found := c.resolvers[scheme]
+		if !ok || !found {
+			continue
+		}
+		var secret string
+		var ttl time.Duration
+		var err error
+		t := c.now()
+		if lr, ok := r.(LeaseResolver); ok {
+			secret, ttl, err = lr.ResolveLease(ctx, ref)
+		} else {
+			secret, err = r.Resolve(ctx, ref)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("configurator/LoadContext: %w, resolving %s:// reference [%s]", err, scheme, f.Path())
+		}
+		if err := f.options().set(f.val, f.val.Type(), secret); err != nil {
+			return nil, fmt.Errorf("configurator/LoadContext: %w [%s]", err, f.Path())
+		}
+		f.resolved = true
+		if ttl > 0 {
+			leases = append(leases, newLease(Lease{Source: scheme, Path: f.Path()}, t, ttl))
+		}
+	}
+	return leases, nil
+}
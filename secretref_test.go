@@ -0,0 +1,70 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretResolver(t *testing.T) {
+	var refs []string
+	op := SecretResolverFunc(func(_ context.Context, ref string) (string, error) {
+		refs = append(refs, ref)
+		if strings.HasSuffix(ref, "/missing") {
+			return "", errors.New(`"missing" isn't an item`)
+		}
+		return "secret-" + ref[strings.LastIndex(ref, "/")+1:], nil
+	})
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{
+			"PASSWORD=op://dev/db/password",
+			"TOKEN=op://dev/github/token",
+			"KEY=op://dev/api/key",
+			"HOST=https://example.com",
+			"RETRIES=3",
+		}),
+		WithSecretResolver("op", op),
+	)
+	type tagged struct {
+		Password string          `config:"env"`
+		Token    *string         `config:"env"`
+		Key      Dynamic[string] `config:"env"`
+		Host     string          `config:"env"`
+		Retries  int             `config:"env"`
+	}
+	var v tagged
+	assert.NoError(t, c.Load(&v))
+	assert.Equal(t, "secret-password", v.Password)
+	assert.Equal(t, "secret-token", *v.Token)
+	assert.Equal(t, "secret-key", v.Key.Get())
+	assert.Equal(t, "https://example.com", v.Host)
+	assert.Equal(t, []string{"op://dev/db/password", "op://dev/github/token", "op://dev/api/key"}, refs)
+	assert.Equal(t, "env", c.Provenance()["Password"])
+	secrets := map[string]bool{}
+	for _, s := range c.report().Schema {
+		secrets[s.Path] = s.Secret
+	}
+	assert.Equal(t, map[string]bool{"Password": true, "Token": true, "Key": true, "Host": false, "Retries": false}, secrets)
+
+	c = NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{"PASSWORD=op://dev/db/missing"}),
+		WithSecretResolver("op", op),
+	)
+	err := c.Load(&tagged{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "isn't an item")
+		assert.Contains(t, err.Error(), "[Password]")
+	}
+}
+
+func TestBitwardenCLI_InvalidRef(t *testing.T) {
+	_, err := BitwardenCLI().Resolve(context.Background(), "bw://item")
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+}
@@ -0,0 +1,42 @@
+package configurator
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// LoadSection loads v as the section at path, such as "Database" or
+// "Services.Cache", of a configuration struct it isn't part of: keys in
+// files, env vars and flags are derived as if v were that field, so that a
+// package can load its own section without the application's whole
+// configuration. The status of the configurator is recorded like for Load,
+// with field paths starting with path.
+func (c *Configurator) LoadSection(v interface{}, path string) error {
+	return c.LoadSectionContext(context.Background(), v, path)
+}
+
+// LoadSectionContext is like LoadSection with the context of LoadContext.
+func (c *Configurator) LoadSectionContext(ctx context.Context, v interface{}, path string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidConfig
+	}
+	names := strings.Split(path, ".")
+	for _, name := range names {
+		if !token.IsIdentifier(name) || !token.IsExported(name) {
+			return fmt.Errorf("configurator/LoadSection: %w, invalid section path [%s]", ErrInvalidConfig, path)
+		}
+	}
+	// nest v in structs with a single field per name, holding a pointer so
+	// that v is loaded in place
+	section := rv
+	for i := len(names) - 1; i >= 0; i-- {
+		parent := reflect.New(reflect.StructOf([]reflect.StructField{{Name: names[i], Type: section.Type()}}))
+		parent.Elem().Field(0).Set(section)
+		section = parent
+	}
+	return c.LoadContext(ctx, section.Interface())
+}
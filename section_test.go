@@ -0,0 +1,42 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSection(t *testing.T) {
+	type database struct {
+		Host     string `yaml:"host" config:"env,required"`
+		Port     int    `yaml:"port" config:"env,flag,default=5432"`
+		MaxConns int    `yaml:"max_conns"`
+	}
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	content := "name: api\nservices:\n  database:\n    host: file\n    max_conns: 10\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	c := NewConfigurator(
+		WithFileProvider(filename),
+		WithENVProvider(""),
+		WithEnviron([]string{"SERVICES_DATABASE_HOST=env"}),
+		WithDefaultProvider(),
+	)
+	var db database
+	assert.NoError(t, c.LoadSection(&db, "Services.Database"))
+	assert.Equal(t, database{Host: "env", Port: 5432, MaxConns: 10}, db)
+	assert.Equal(t, "env", c.Provenance()["Services.Database.Host"])
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(nil))
+	err := c.LoadSection(&database{}, "Database")
+	assert.True(t, errors.Is(err, ErrRequired))
+	assert.Contains(t, err.Error(), "Database.Host")
+
+	for _, path := range []string{"", "database", "Services..Database", "Data-base"} {
+		assert.True(t, errors.Is(c.LoadSection(&database{}, path), ErrInvalidConfig), path)
+	}
+	assert.True(t, errors.Is(c.LoadSection(database{}, "Database"), ErrInvalidConfig))
+}
@@ -0,0 +1,61 @@
+package configurator
+
+import (
+	"fmt"
+	"reflect"
+)
+
+const seedProviderName = "seed"
+
+// WithSeed uses the non-zero fields of seed, a pointer to a populated
+// struct of the type being loaded, as defaults of the fields no source
+// set, so that defaults computed by the program needn't be tag strings.
+// They win over the default tag option.
+func WithSeed(seed interface{}) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.seed = &seedProvider{seed: seed}
+	}
+}
+
+type seedProvider struct {
+	seed interface{}
+}
+
+func (p *seedProvider) Provide(v interface{}, si StructInfo) error {
+	sv := reflect.ValueOf(p.seed)
+	if sv.Kind() != reflect.Ptr || sv.IsNil() || sv.Type() != reflect.TypeOf(v) {
+		return fmt.Errorf("seedProvider/Provide: %w, seed is %T, not %T", ErrInvalidConfig, p.seed, v)
+	}
+	// walking allocates nil sections, leave the seed alone
+	cp := reflect.New(sv.Type().Elem())
+	cp.Elem().Set(sv.Elem())
+	ssi, err := getStructInfo(cp.Interface(), nil)
+	if err != nil {
+		return err
+	}
+	seeds := ssi.Fields()
+	for i, fi := range si.Fields() {
+		if f, ok := fi.(*fieldInfo); ok && f.set {
+			continue
+		}
+		val := fi.Value()
+		if i >= len(seeds) || !leaf(val).IsZero() {
+			continue
+		}
+		s := leaf(seeds[i].Value())
+		if s.IsZero() {
+			continue
+		}
+		if d, ok := asWrapper(val); ok {
+			d.store(copyValue(s))
+		} else if err := assignValue(val, copyValue(s)); err != nil {
+			return fmt.Errorf("seedProvider/Provide: %w [%s]", err, fi.Path())
+		}
+		markSet(fi)
+	}
+	return nil
+}
+
+func (p *seedProvider) String() string {
+	return seedProviderName
+}
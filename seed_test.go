@@ -0,0 +1,58 @@
+package configurator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedProvider(t *testing.T) {
+	type database struct {
+		Host    string        `config:"env=DB_HOST"`
+		Timeout time.Duration `config:"default=5s"`
+	}
+	type example struct {
+		Name    string   `config:"env,default=api"`
+		Workers int      `config:"env"`
+		Verbose bool     `config:"env"`
+		Tags    []string `config:"env"`
+		DB      *database
+		Limit   Dynamic[int]
+	}
+	seed := &example{
+		Name:    "seeded",
+		Workers: 8,
+		Verbose: true,
+		Tags:    []string{"a"},
+		DB:      &database{Host: "seed-db", Timeout: time.Second},
+		Limit:   NewDynamic(10),
+	}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithEnviron([]string{"WORKERS=2", "VERBOSE=false", "DB_HOST=db"}),
+		WithDefaultProvider(),
+		WithSeed(seed),
+	)
+	var v example
+	assert.NoError(t, c.Load(&v))
+	assert.Equal(t, "seeded", v.Name)
+	assert.Equal(t, 2, v.Workers)
+	assert.False(t, v.Verbose)
+	assert.Equal(t, []string{"a"}, v.Tags)
+	assert.Equal(t, database{Host: "db", Timeout: time.Second}, *v.DB)
+	assert.Equal(t, 10, v.Limit.Get())
+	assert.Equal(t, "seed", c.Provenance()["Name"])
+	assert.Equal(t, "env", c.Provenance()["Verbose"])
+
+	v.Tags[0] = "b"
+	assert.Equal(t, []string{"a"}, seed.Tags)
+
+	type other struct {
+		Name string
+	}
+	err := c.Load(&other{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
@@ -0,0 +1,380 @@
+// Package semver parses semantic versions and constraints on them, such as
+// a minimum supported client version, for configuration fields:
+//
+//	type Config struct {
+//		MinClient semver.Version    `config:"env,default=1.4.0"`
+//		Clients   semver.Constraint `config:"env,default='>=1.4, <3'"`
+//	}
+//
+// Both implement encoding.TextUnmarshaler, as the types of
+// Masterminds/semver do, so configurator parses them from any source.
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidVersion    = errors.New("invalid semantic version")
+	ErrInvalidConstraint = errors.New("invalid version constraint")
+)
+
+// Version is a semantic version, as defined by semver.org.
+type Version struct {
+	Major, Minor, Patch uint64
+	// Pre is the pre-release, such as "rc.1", empty for a release.
+	Pre string
+	// Build is the build metadata, ignored by comparisons.
+	Build string
+}
+
+// Parse parses a version such as 1.4.2, v2.0.0-rc.1 or 1.4+build.5. A
+// leading "v" is allowed, and a missing minor or patch number is 0.
+func Parse(s string) (Version, error) {
+	v, _, err := parse(s)
+	return v, err
+}
+
+// MustParse is like Parse but panics when s isn't a version.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// parse returns the version and how many of its numbers s has.
+func parse(s string) (Version, int, error) {
+	var v Version
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		v.Build, rest = rest[i+1:], rest[:i]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, 0, fmt.Errorf("semver/Parse: %w, invalid build metadata [%s]", ErrInvalidVersion, s)
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		v.Pre, rest = rest[i+1:], rest[:i]
+		if !validIdentifiers(v.Pre, true) {
+			return Version{}, 0, fmt.Errorf("semver/Parse: %w, invalid pre-release [%s]", ErrInvalidVersion, s)
+		}
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return Version{}, 0, fmt.Errorf("semver/Parse: %w, more than 3 numbers [%s]", ErrInvalidVersion, s)
+	}
+	nums := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := parseNumber(p)
+		if err != nil {
+			return Version{}, 0, fmt.Errorf("semver/Parse: %w, %v [%s]", ErrInvalidVersion, err, s)
+		}
+		*nums[i] = n
+	}
+	return v, len(parts), nil
+}
+
+func parseNumber(s string) (uint64, error) {
+	if s == "" {
+		return 0, errors.New("missing number")
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("leading zero in %s", s)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// validIdentifiers reports whether s is dot separated identifiers of
+// letters, digits and hyphens, numeric ones without leading zeros when
+// numbers is set, as pre-releases require.
+func validIdentifiers(s string, numbers bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" || strings.IndexFunc(id, func(r rune) bool {
+			return r != '-' && (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z')
+		}) >= 0 {
+			return false
+		}
+		if numbers && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// IsZero reports whether v is unset.
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+// Compare returns -1, 0 or +1 as v has a lower, the same or a higher
+// precedence than w. Pre-releases come before their release.
+func (v Version) Compare(w Version) int {
+	for _, c := range [][2]uint64{{v.Major, w.Major}, {v.Minor, w.Minor}, {v.Patch, w.Patch}} {
+		if c[0] != c[1] {
+			return compareUint(c[0], c[1])
+		}
+	}
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+	a, b := strings.Split(v.Pre, "."), strings.Split(w.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
+
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		x, _ := strconv.ParseUint(a, 10, 64)
+		y, _ := strconv.ParseUint(b, 10, 64)
+		return compareUint(x, y)
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (v Version) MarshalText() ([]byte, error) {
+	if v.IsZero() {
+		return nil, nil
+	}
+	return []byte(v.String()), nil
+}
+
+func (v *Version) UnmarshalText(b []byte) error {
+	p, err := Parse(string(b))
+	if err != nil {
+		return err
+	}
+	*v = p
+	return nil
+}
+
+// Constraint is a set of ranges of versions, such as ">=1.4, <3 || 4.x".
+// Comparisons in a range are separated by commas or spaces and all must
+// hold, and ranges are separated by "||". The operators are =, !=, >, >=,
+// <, <=, ~1.4 for 1.4.x and ^1.4 for 1.x; a version without operator, with
+// x or * in place of a number, matches the versions it starts. As with
+// Masterminds/semver, pre-releases only satisfy ranges with a pre-release
+// version, so that ^1.4 doesn't allow 2.0.0-rc.1. The zero Constraint
+// allows any version.
+type Constraint struct {
+	text   string
+	ranges [][]comparison
+}
+
+type comparison struct {
+	op string
+	v  Version
+	// n is how many numbers the version of the comparison has.
+	n int
+}
+
+// ParseConstraint parses a constraint such as ">=1.4, <3".
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{text: strings.TrimSpace(s)}
+	if c.text == "" {
+		return c, nil
+	}
+	for _, r := range strings.Split(c.text, "||") {
+		fields := strings.FieldsFunc(r, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(fields) == 0 {
+			return Constraint{}, fmt.Errorf("semver/ParseConstraint: %w, empty range [%s]", ErrInvalidConstraint, s)
+		}
+		var cmps []comparison
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			// an operator apart from its version, as in ">= 1.4"
+			if strings.Trim(f, "=!<>~^") == "" && i+1 < len(fields) {
+				i++
+				f += fields[i]
+			}
+			cmp, err := parseComparison(f)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("semver/ParseConstraint: %w, %v [%s]", ErrInvalidConstraint, err, s)
+			}
+			cmps = append(cmps, cmp)
+		}
+		c.ranges = append(c.ranges, cmps)
+	}
+	return c, nil
+}
+
+// MustParseConstraint is like ParseConstraint but panics when s isn't a
+// constraint.
+func MustParseConstraint(s string) Constraint {
+	c, err := ParseConstraint(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func parseComparison(s string) (comparison, error) {
+	op := s[:len(s)-len(strings.TrimLeft(s, "=!<>~^"))]
+	switch op {
+	case "", "=", "!=", ">", ">=", "<", "<=", "~", "^":
+	default:
+		return comparison{}, fmt.Errorf("unknown operator %q", op)
+	}
+	rest := s[len(op):]
+	// wildcards end the version: 1.4.x is 1.4
+	parts := strings.Split(strings.TrimPrefix(rest, "v"), ".")
+	for i, p := range parts {
+		if !isWildcard(p) {
+			continue
+		}
+		for _, q := range parts[i:] {
+			if !isWildcard(q) {
+				return comparison{}, fmt.Errorf("number after wildcard in %s", s)
+			}
+		}
+		if i == 0 {
+			return comparison{op: "*"}, nil
+		}
+		rest = strings.Join(parts[:i], ".")
+		break
+	}
+	v, n, err := parse(rest)
+	if err != nil {
+		return comparison{}, err
+	}
+	return comparison{op: op, v: v, n: n}, nil
+}
+
+func isWildcard(s string) bool {
+	return s == "x" || s == "X" || s == "*"
+}
+
+// Check reports whether v satisfies c.
+func (c Constraint) Check(v Version) bool {
+	if len(c.ranges) == 0 {
+		return true
+	}
+	for _, r := range c.ranges {
+		ok, pre := true, false
+		for _, cmp := range r {
+			ok = ok && cmp.check(v)
+			pre = pre || cmp.v.Pre != ""
+		}
+		if ok && (v.Pre == "" || pre) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparison) check(v Version) bool {
+	d := v.Compare(c.v)
+	switch c.op {
+	case "*":
+		return true
+	case "=", "":
+		if c.n < 3 {
+			return d >= 0 && v.Compare(c.next(c.n)) < 0
+		}
+		return d == 0
+	case "!=":
+		return d != 0
+	case ">":
+		return d > 0
+	case ">=":
+		return d >= 0
+	case "<":
+		return d < 0
+	case "<=":
+		return d <= 0
+	case "~":
+		// ~1.4.2 is >=1.4.2 <1.5, ~1 is >=1 <2
+		return d >= 0 && v.Compare(c.next(min(c.n, 2))) < 0
+	case "^":
+		// ^1.4 is >=1.4 <2, ^0.4 is >=0.4 <0.5, ^0.0.4 is >=0.0.4 <0.0.5
+		n := 1
+		switch {
+		case c.v.Major == 0 && c.v.Minor == 0 && c.n == 3:
+			n = 3
+		case c.v.Major == 0 && c.n >= 2:
+			n = 2
+		}
+		return d >= 0 && v.Compare(c.next(n)) < 0
+	}
+	return false
+}
+
+// next returns the first version after those starting with the first n
+// numbers of the version of c.
+func (c comparison) next(n int) Version {
+	v := Version{Major: c.v.Major, Minor: c.v.Minor, Patch: c.v.Patch}
+	switch n {
+	case 1:
+		v = Version{Major: v.Major + 1}
+	case 2:
+		v = Version{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		v.Patch++
+	}
+	return v
+}
+
+func (c Constraint) String() string {
+	return c.text
+}
+
+// IsZero reports whether c is unset, allowing any version.
+func (c Constraint) IsZero() bool {
+	return c.text == ""
+}
+
+func (c Constraint) MarshalText() ([]byte, error) {
+	return []byte(c.text), nil
+}
+
+func (c *Constraint) UnmarshalText(b []byte) error {
+	p, err := ParseConstraint(string(b))
+	if err != nil {
+		return err
+	}
+	*c = p
+	return nil
+}
@@ -0,0 +1,117 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ruosing/configurator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+		err  bool
+	}{
+		{"1.4.2", Version{Major: 1, Minor: 4, Patch: 2}, false},
+		{"v2.0.0-rc.1+build.5", Version{Major: 2, Pre: "rc.1", Build: "build.5"}, false},
+		{"1.4", Version{Major: 1, Minor: 4}, false},
+		{"1.4.2.1", Version{}, true},
+		{"01.4.2", Version{}, true},
+		{"1.4.2-rc.01", Version{}, true},
+		{"1.4.2-", Version{}, true},
+		{"1.x", Version{}, true},
+		{"", Version{}, true},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.in)
+		if tt.err {
+			assert.True(t, errors.Is(err, ErrInvalidVersion), tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, v, tt.in)
+	}
+	assert.Equal(t, "2.0.0-rc.1+build.5", MustParse("v2.0.0-rc.1+build.5").String())
+}
+
+func TestVersion_Compare(t *testing.T) {
+	// in order of precedence, from semver.org
+	order := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "2.0.0"}
+	for i := range order {
+		for j := range order {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			assert.Equal(t, want, MustParse(order[i]).Compare(MustParse(order[j])), order[i]+" "+order[j])
+		}
+	}
+	assert.Equal(t, 0, MustParse("1.0.0+a").Compare(MustParse("1.0.0+b")))
+}
+
+func TestConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allowed    []string
+		denied     []string
+	}{
+		{">=1.4, <3", []string{"1.4.0", "2.9.9"}, []string{"1.3.9", "3.0.0", "2.0.0-rc.1"}},
+		{">= 1.4 <3 || 4.x", []string{"1.5.0", "4.2.0"}, []string{"3.1.0", "5.0.0"}},
+		{"~1.4.2", []string{"1.4.2", "1.4.9"}, []string{"1.4.1", "1.5.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{"^1.4", []string{"1.4.0", "1.99.0"}, []string{"1.3.0", "2.0.0"}},
+		{"^0.4", []string{"0.4.0", "0.4.9"}, []string{"0.5.0"}},
+		{"^0.0.4", []string{"0.0.4"}, []string{"0.0.5"}},
+		{"1.4", []string{"1.4.0", "1.4.7"}, []string{"1.5.0"}},
+		{"=1.4.2", []string{"1.4.2"}, []string{"1.4.3"}},
+		{"!=1.4.2", []string{"1.4.3"}, []string{"1.4.2"}},
+		{">=2.0.0-rc.1", []string{"2.0.0-rc.2", "2.0.0"}, []string{"2.0.0-beta"}},
+		{"*", []string{"0.0.1", "9.0.0"}, nil},
+		{"", []string{"1.0.0", "1.0.0-rc.1"}, nil},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if !assert.NoError(t, err, tt.constraint) {
+			continue
+		}
+		assert.Equal(t, tt.constraint, c.String())
+		for _, v := range tt.allowed {
+			assert.True(t, c.Check(MustParse(v)), tt.constraint+" allows "+v)
+		}
+		for _, v := range tt.denied {
+			assert.False(t, c.Check(MustParse(v)), tt.constraint+" denies "+v)
+		}
+	}
+	for _, s := range []string{">>1.4", "1.4 ||", ">=1.4.x.1", "=>"} {
+		_, err := ParseConstraint(s)
+		assert.True(t, errors.Is(err, ErrInvalidConstraint), s)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	type example struct {
+		MinClient Version    `config:"env,default=1.4.0"`
+		Clients   Constraint `config:"env,default='>=1.4, <3'"`
+		Server    *Version   `config:"env"`
+	}
+	c := configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithENVProvider(""),
+		configurator.WithDefaultProvider(), configurator.WithEnviron([]string{"SERVER=v2.1.0"}))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, MustParse("1.4.0"), cfg.MinClient)
+	assert.True(t, cfg.Clients.Check(MustParse("2.0.0")))
+	assert.False(t, cfg.Clients.Check(MustParse("3.0.0")))
+	assert.Equal(t, &Version{Major: 2, Minor: 1}, cfg.Server)
+
+	c = configurator.NewConfigurator(configurator.WithFileProvider(""), configurator.WithENVProvider(""),
+		configurator.WithEnviron([]string{"CLIENTS=>>1"}))
+	err := c.Load(&example{})
+	if assert.True(t, errors.Is(err, ErrInvalidConstraint)) {
+		assert.Contains(t, err.Error(), "Clients")
+	}
+}
@@ -0,0 +1,104 @@
+package configurator
+
+import (
+	"reflect"
+)
+
+// Shred clears the secret fields of the configuration last loaded and the
+// copies of them the configurator keeps, such as on shutdown, so that
+// credentials don't linger in memory: []byte values are zeroed in place
+// and strings, which can't be, are set to "" so that nothing references
+// them anymore. Hold secrets in []byte fields for them to be zeroed. The
+// copies of secrets a load replaces are zeroed without calling Shred. The
+// secrets of the revisions kept by WithHistory are dropped, Rollback leaves
+// them to the sources.
+func (c *Configurator) Shred() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	secret := secretPaths(c.schema)
+	shredValues(c.values, secret)
+	for _, r := range c.revisions {
+		for path := range secret {
+			delete(r.raw, path)
+		}
+		shredValues(r.values, secret)
+	}
+	if c.loaded == nil {
+		return
+	}
+	si, err := getStructInfoWithin(c.loaded, nil, c.limits)
+	if err != nil {
+		return
+	}
+	for _, fi := range si.Fields() {
+		if secret[fi.Path()] {
+			shredField(fi.Value())
+		}
+	}
+}
+
+// Shred clears the fields tagged secret of v, a pointer to a configuration
+// struct, like Configurator.Shred, such as a copy replaced by Reload once
+// it isn't used anymore.
+func Shred(v interface{}) error {
+	si, err := getStructInfo(v, nil)
+	if err != nil {
+		return err
+	}
+	for _, fi := range si.Fields() {
+		if fi.Secret() {
+			shredField(fi.Value())
+		}
+	}
+	return nil
+}
+
+func secretPaths(schema []FieldSchema) map[string]bool {
+	secret := make(map[string]bool)
+	for _, s := range schema {
+		if s.Secret {
+			secret[s.Path] = true
+		}
+	}
+	return secret
+}
+
+// shredValues zeroes the secret values copied by a load.
+func shredValues(values map[string]reflect.Value, secret map[string]bool) {
+	for path, v := range values {
+		if secret[path] {
+			zeroBytes(v)
+			values[path] = reflect.Zero(v.Type())
+		}
+	}
+}
+
+func shredField(v reflect.Value) {
+	if d, ok := asWrapper(v); ok {
+		zeroBytes(d.load())
+		d.store(reflect.Zero(d.elemType()))
+		return
+	}
+	zeroBytes(v)
+	if v.CanSet() {
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
+// zeroBytes zeroes the bytes v holds, through pointers and lists.
+func zeroBytes(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			zeroBytes(v.Elem())
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			clear(v.Bytes())
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			zeroBytes(v.Index(i))
+		}
+	}
+}
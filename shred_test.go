@@ -0,0 +1,75 @@
+package configurator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShred(t *testing.T) {
+	type example struct {
+		Key     []byte            `config:"env,secret"`
+		Token   string            `config:"env,secret"`
+		Session Dynamic[[]byte]   `config:"env,secret"`
+		Backup  *[]byte           `config:"env,secret"`
+		Name    string            `config:"env"`
+		Labels  map[string]string `config:"-"`
+	}
+	environ := []string{"KEY=a2V5", "TOKEN=t0k3n", "SESSION=c2Vzcw==", "BACKUP=YmFr", "NAME=api"}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ))
+	var v example
+	assert.NoError(t, c.Load(&v))
+	key, session, backup := v.Key, v.Session.Get(), *v.Backup
+	assert.Equal(t, []byte("key"), key)
+	copied := c.values["Key"].Bytes()
+
+	c.Shred()
+	assert.Equal(t, []byte{0, 0, 0}, key)
+	assert.Equal(t, []byte{0, 0, 0, 0}, session)
+	assert.Equal(t, []byte{0, 0, 0}, backup)
+	assert.Equal(t, []byte{0, 0, 0}, copied)
+	assert.Nil(t, v.Key)
+	assert.Empty(t, v.Token)
+	assert.Nil(t, v.Session.Get())
+	assert.Nil(t, *v.Backup)
+	assert.Equal(t, "api", v.Name)
+	assert.False(t, c.values["Token"].IsValid() && c.values["Token"].String() != "")
+	assert.Equal(t, "api", c.values["Name"].String())
+
+	// the copies replaced by a reload are zeroed, the loaded values aren't
+	v = example{}
+	assert.NoError(t, c.Load(&v))
+	copied = c.values["Key"].Bytes()
+	fresh, err := c.Reload(context.Background(), &v)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0}, copied)
+	assert.Equal(t, []byte("key"), v.Key)
+	assert.Equal(t, []byte("key"), c.values["Key"].Bytes())
+
+	assert.NoError(t, Shred(&v))
+	assert.Nil(t, v.Key)
+	assert.Equal(t, []byte("key"), fresh.(*example).Key)
+}
+
+func TestShred_History(t *testing.T) {
+	type example struct {
+		Token string `config:"env,secret"`
+		Name  string `config:"env"`
+	}
+	environ := []string{"TOKEN=t0k3n", "NAME=api"}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron(environ), WithHistory(2))
+	var v example
+	assert.NoError(t, c.Load(&v))
+	_, err := c.Reload(context.Background(), &v)
+	assert.NoError(t, err)
+
+	c.Shred()
+	for _, r := range c.revisions {
+		assert.NotContains(t, r.raw, "Token")
+		assert.Equal(t, "api", r.raw["Name"])
+	}
+	_, err = c.Rollback(context.Background(), &v, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, example{Token: "t0k3n", Name: "api"}, v)
+}
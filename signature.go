@@ -0,0 +1,131 @@
+package configurator
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrSignature is returned when a config document isn't signed by the
+// expected key.
+var ErrSignature = errors.New("invalid signature")
+
+// Verifier checks a detached signature over a config document, so that a
+// compromised bucket or config center can't change what is applied.
+type Verifier interface {
+	Verify(doc, sig []byte) error
+}
+
+// Ed25519Verifier verifies Ed25519 signatures, raw or base64 encoded, made
+// with the private key of pub.
+func Ed25519Verifier(pub ed25519.PublicKey) Verifier {
+	return ed25519Verifier(pub)
+}
+
+type ed25519Verifier ed25519.PublicKey
+
+func (v ed25519Verifier) Verify(doc, sig []byte) error {
+	if len(sig) != ed25519.SignatureSize {
+		b, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSignature, err)
+		}
+		sig = b
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(ed25519.PublicKey(v), doc, sig) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// ECDSAVerifier verifies base64 encoded ASN.1 ECDSA signatures over the
+// SHA-256 digest of documents, as made by cosign sign-blob.
+func ECDSAVerifier(pub *ecdsa.PublicKey) Verifier {
+	return ecdsaVerifier{pub}
+}
+
+type ecdsaVerifier struct {
+	pub *ecdsa.PublicKey
+}
+
+func (v ecdsaVerifier) Verify(doc, sig []byte) error {
+	b, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	digest := sha256.Sum256(doc)
+	if !ecdsa.VerifyASN1(v.pub, digest[:], b) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// MinisignVerifier verifies minisign signatures with the public key, the
+// base64 line of a minisign .pub file. Only legacy signatures, made with
+// minisign -l, are supported, prehashed ones needing BLAKE2b.
+func MinisignVerifier(publicKey string) (Verifier, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != "Ed" {
+		return nil, fmt.Errorf("configurator/MinisignVerifier: %w, not a minisign public key", ErrUnsupported)
+	}
+	return minisignVerifier{keyID: b[2:10], pub: b[10:]}, nil
+}
+
+type minisignVerifier struct {
+	keyID []byte
+	pub   ed25519.PublicKey
+}
+
+// Verify checks sig, the content of a .minisig file: an untrusted comment,
+// the signature, a trusted comment and its signature, one per line.
+func (v minisignVerifier) Verify(doc, sig []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(sig)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("%w, malformed minisign signature", ErrSignature)
+	}
+	s, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(s) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("%w, malformed minisign signature", ErrSignature)
+	}
+	switch {
+	case string(s[:2]) == "ED":
+		return fmt.Errorf("%w, prehashed minisign signature", ErrUnsupported)
+	case string(s[:2]) != "Ed":
+		return fmt.Errorf("%w, minisign algorithm %q", ErrSignature, s[:2])
+	case !bytes.Equal(s[2:10], v.keyID):
+		return fmt.Errorf("%w, signed by another key", ErrSignature)
+	case !ed25519.Verify(v.pub, doc, s[10:]):
+		return ErrSignature
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	comment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	if err != nil || !ed25519.Verify(v.pub, append(s[10:], comment...), global) {
+		return fmt.Errorf("%w, trusted comment", ErrSignature)
+	}
+	return nil
+}
+
+// WithSignedFile verifies the config file against its detached signature,
+// the file of the same name with a .sig extension added, such as
+// config.yaml.sig, with v, before applying it. A missing signature fails
+// the load as a bad one.
+func WithSignedFile(v Verifier) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.verifier = v
+	}
+}
+
+// verifyFile checks the signature of the content b of filename.
+func verifyFile(v Verifier, filename string, b []byte) error {
+	sig, err := os.ReadFile(filename + ".sig")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignature, err)
+	}
+	return v.Verify(b, sig)
+}
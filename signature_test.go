@@ -0,0 +1,82 @@
+package configurator
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifier(t *testing.T) {
+	doc := []byte("name: api\n")
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	ed := Ed25519Verifier(pub)
+	assert.NoError(t, ed.Verify(doc, ed25519.Sign(priv, doc)))
+	assert.NoError(t, ed.Verify(doc, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, doc))+"\n")))
+	assert.True(t, errors.Is(ed.Verify([]byte("name: evil\n"), ed25519.Sign(priv, doc)), ErrSignature))
+	assert.True(t, errors.Is(ed.Verify(doc, []byte("!")), ErrSignature))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	digest := sha256.Sum256(doc)
+	asn, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+	cosign := ECDSAVerifier(&key.PublicKey)
+	assert.NoError(t, cosign.Verify(doc, []byte(base64.StdEncoding.EncodeToString(asn))))
+	assert.True(t, errors.Is(cosign.Verify([]byte("name: evil\n"), []byte(base64.StdEncoding.EncodeToString(asn))), ErrSignature))
+
+	keyID := []byte("12345678")
+	minisig := func(alg string, doc []byte, comment string) []byte {
+		sig := append(append([]byte(alg), keyID...), ed25519.Sign(priv, doc)...)
+		global := ed25519.Sign(priv, append(sig[10:], "timestamp:1"...))
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(sig) + "\n" +
+			"trusted comment: " + comment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+	mini, err := MinisignVerifier(base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...)))
+	assert.NoError(t, err)
+	assert.NoError(t, mini.Verify(doc, minisig("Ed", doc, "timestamp:1")))
+	assert.True(t, errors.Is(mini.Verify([]byte("name: evil\n"), minisig("Ed", doc, "timestamp:1")), ErrSignature))
+	assert.True(t, errors.Is(mini.Verify(doc, minisig("Ed", doc, "timestamp:2")), ErrSignature))
+	assert.True(t, errors.Is(mini.Verify(doc, minisig("ED", doc, "timestamp:1")), ErrUnsupported))
+	assert.True(t, errors.Is(mini.Verify(doc, []byte("garbage")), ErrSignature))
+	_, err = MinisignVerifier("garbage")
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
+
+func TestWithSignedFile(t *testing.T) {
+	type example struct {
+		Name string `yaml:"name"`
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	doc := []byte("name: api\n")
+	assert.NoError(t, os.WriteFile(filename, doc, 0o600))
+
+	c := NewConfigurator(WithFileProvider(filename), WithSignedFile(Ed25519Verifier(pub)))
+	var cfg example
+	err = c.Load(&cfg)
+	if assert.Error(t, err) {
+		assert.True(t, errors.Is(err, ErrSignature))
+		assert.Contains(t, err.Error(), filename)
+	}
+
+	assert.NoError(t, os.WriteFile(filename+".sig", []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, doc))), 0o600))
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "api", cfg.Name)
+
+	assert.NoError(t, os.WriteFile(filename, []byte("name: evil\n"), 0o600))
+	assert.True(t, errors.Is(c.Load(&example{}), ErrSignature))
+}
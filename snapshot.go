@@ -0,0 +1,141 @@
+package configurator
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type snapshot struct {
+	filename string
+	key      []byte
+}
+
+func (s *snapshot) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// snapshotData is the content of a snapshot file.
+type snapshotData struct {
+	// Sources are the values of the remote sources, by source.
+	Sources []savedSource `json:"sources"`
+}
+
+// savedSource are the values of a remote source, keyed by its position
+// among the providers as well as its name, as sources of the same type
+// share a name.
+type savedSource struct {
+	Index int `json:"index"`
+	SourceValues
+}
+
+// save writes the values of the remote sources, JSON encoded and encrypted,
+// next to the snapshot file and renames it into place so a crash never
+// leaves a partial snapshot behind.
+func (s *snapshot) save(sources []savedSource) error {
+	if sources == nil {
+		sources = []savedSource{}
+	}
+	b, err := json.Marshal(snapshotData{Sources: sources})
+	if err != nil {
+		return err
+	}
+	aead, err := s.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := aead.Seal(nonce, nonce, b, nil)
+
+	f, err := os.CreateTemp(filepath.Dir(s.filename), filepath.Base(s.filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(sealed); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.filename)
+}
+
+func (s *snapshot) load() ([]byte, error) {
+	sealed, err := os.ReadFile(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := s.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("snapshot/load: %w [%s]", ErrInvalidSnapshot, s.filename)
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	b, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot/load: %w [%s]", ErrInvalidSnapshot, s.filename)
+	}
+	return b, nil
+}
+
+// fallback replaces each remote provider that failed to fetch with the
+// values it set in the snapshot, and returns the warning to report. Other
+// providers, the config file and the remote ones fetched included, are
+// applied as fetched. It returns no steps if there is no usable snapshot or
+// a local provider failed.
+func (s *snapshot) fallback(providers []Provider, fetched []step, cause error) ([]step, *warning) {
+	b, err := s.load()
+	if err != nil {
+		return nil, nil
+	}
+	var data snapshotData
+	if err := json.Unmarshal(b, &data); err != nil || data.Sources == nil {
+		return nil, nil
+	}
+	type key struct {
+		index int
+		name  string
+	}
+	saved := make(map[key]map[string]string, len(data.Sources))
+	for _, sv := range data.Sources {
+		saved[key{sv.Index, sv.Name}] = sv.Values
+	}
+	steps := make([]step, 0, len(providers))
+	for i, p := range providers {
+		if fetched[i].provider != nil {
+			steps = append(steps, fetched[i])
+			continue
+		}
+		if !remote(p) {
+			return nil, nil
+		}
+		steps = append(steps, step{name: "snapshot", provider: &replaySource{name: "snapshot", values: saved[key{i, fetched[i].name}]}})
+	}
+	return steps, &warning{reason: fmt.Sprintf("degraded, loaded from snapshot %s", s.filename), err: cause}
+}
+
+// remote reports whether p is a Fetcher the snapshot stands in for, any
+// but the config file, which is on disk already.
+func remote(p Provider) bool {
+	if _, ok := p.(*fileProvider); ok {
+		return false
+	}
+	_, ok := p.(Fetcher)
+	return ok
+}
@@ -0,0 +1,153 @@
+package configurator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir, err := os.MkdirTemp("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "config.snap")
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	var warnings []error
+	f := &flakyFetcher{value: "remote"}
+	newConfigurator := func(key []byte) *Configurator {
+		return NewConfigurator(
+			WithFileProvider(""),
+			WithENVProvider(""),
+			WithEnviron([]string{"PORT=8080"}),
+			WithSnapshot(filename, key),
+			WithWarningHandler(func(err error) { warnings = append(warnings, err) }),
+			WithProvider(f),
+		)
+	}
+	type example struct {
+		Name string
+		Port int `config:"env"`
+	}
+
+	cfg := &example{}
+	assert.NoError(t, newConfigurator(key).Load(cfg))
+	assert.Equal(t, &example{Name: "remote", Port: 8080}, cfg)
+	assert.Empty(t, warnings)
+	b, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "remote")
+
+	f.failures = 100
+	cfg = &example{}
+	c := newConfigurator(key)
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{Name: "remote", Port: 8080}, cfg)
+	assert.Len(t, warnings, 1)
+	assert.True(t, errors.Is(warnings[0], ErrDegraded))
+	assert.Equal(t, "snapshot", c.Provenance()["Name"])
+	assert.Equal(t, "env", c.Provenance()["Port"])
+
+	assert.Error(t, newConfigurator([]byte("fedcba9876543210fedcba9876543210")).Load(&example{}))
+}
+
+func TestSnapshot_Remote(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.snap")
+	config := filepath.Join(dir, "config.yaml")
+	key := []byte("0123456789abcdef")
+	if err := os.WriteFile(config, []byte("level: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &flakyFetcher{value: "remote"}
+	newConfigurator := func() *Configurator {
+		return NewConfigurator(
+			WithFileProvider(config),
+			WithENVProvider(""),
+			WithEnviron([]string{"PORT=8080"}),
+			WithSnapshot(filename, key),
+			WithWarningHandler(func(error) {}),
+			WithProvider(f),
+		)
+	}
+	type example struct {
+		Name  string
+		Level string
+		Port  int `config:"env"`
+	}
+
+	assert.NoError(t, newConfigurator().Load(&example{}))
+	b, err := (&snapshot{filename: filename, key: key}).load()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"sources":[{"index":2,"name":"*configurator.flakyFetcher","values":{"Name":"remote"}}]}`, string(b))
+
+	// the config file is read again, only the failing remote source is
+	// taken from the snapshot
+	if err := os.WriteFile(config, []byte("level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f.failures = 100
+	cfg := &example{}
+	c := newConfigurator()
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{Name: "remote", Level: "debug", Port: 8080}, cfg)
+	assert.Equal(t, "snapshot", c.Provenance()["Name"])
+	assert.NotEqual(t, "snapshot", c.Provenance()["Level"])
+
+	// a failing config file is not covered by the snapshot
+	assert.NoError(t, os.WriteFile(config, []byte("level: [\n"), 0o600))
+	assert.Error(t, newConfigurator().Load(&example{}))
+}
+
+// mapFetcher fetches values, or fails with err.
+type mapFetcher struct {
+	values map[string]string
+	err    error
+}
+
+func (f *mapFetcher) Provide(v interface{}, si StructInfo) error {
+	return MapSource(f.values).Provide(v, si)
+}
+
+func (f *mapFetcher) Fetch(context.Context) (Provider, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return MapSource(f.values), nil
+}
+
+func TestSnapshot_Partial(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.snap")
+	key := []byte("0123456789abcdef")
+	one := &mapFetcher{values: map[string]string{"Name": "v1"}}
+	two := &mapFetcher{values: map[string]string{"Region": "eu", "At": "2026-10-17T03:07:46.123456789Z"}}
+	newConfigurator := func() *Configurator {
+		return NewConfigurator(WithFileProvider(""), WithSnapshot(filename, key), WithWarningHandler(func(error) {}),
+			WithProvider(one), WithProvider(two))
+	}
+	type example struct {
+		Name   string
+		Region string
+		At     time.Time
+	}
+	assert.NoError(t, newConfigurator().Load(&example{}))
+
+	// both sources share a name, only the failing one is taken from the
+	// snapshot, its time to the nanosecond
+	one.values = map[string]string{"Name": "v2"}
+	two.err = errors.New("unavailable")
+	c := newConfigurator()
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, &example{Name: "v2", Region: "eu", At: time.Date(2026, 10, 17, 3, 7, 46, 123456789, time.UTC)}, cfg)
+	assert.Equal(t, "*configurator.mapFetcher", c.Provenance()["Name"])
+	assert.Equal(t, "snapshot", c.Provenance()["Region"])
+}
@@ -0,0 +1,190 @@
+package configurator
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// encryptedPrefix marks the values of secret fields encrypted in a
+// SourceSnapshot.
+const encryptedPrefix = "enc:"
+
+// SourceSnapshot is what each source contributed to a load, as written by
+// Snapshot, to reproduce how a configuration was resolved elsewhere.
+type SourceSnapshot struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Sources are in the order they were applied.
+	Sources []SourceValues `json:"sources"`
+}
+
+// SourceValues are the raw values a source set, by field path, as the
+// source would set them again. Values of fields tagged secret, listed in
+// Secrets, are masked, or encrypted when Snapshot is given a key.
+type SourceValues struct {
+	Name    string            `json:"name"`
+	Values  map[string]string `json:"values"`
+	Secrets []string          `json:"secrets,omitempty"`
+}
+
+func (sv *SourceValues) add(fi FieldInfo) {
+	if sv.Values == nil {
+		sv.Values = make(map[string]string)
+	}
+	if _, ok := sv.Values[fi.Path()]; !ok && fi.Secret() {
+		sv.Secrets = append(sv.Secrets, fi.Path())
+	}
+	if f, ok := fi.(*fieldInfo); ok && f.raw != nil {
+		sv.Values[fi.Path()] = *f.raw
+		return
+	}
+	sv.Values[fi.Path()] = formatValue(fi.Value())
+}
+
+// Snapshot writes, as JSON, the values each source set in the last
+// successful load, before secrets were resolved, paths expanded or fields
+// derived, for LoadFromSnapshot to replay them. Values of secret fields are
+// masked, or encrypted with key, 16, 24 or 32 bytes selecting AES-128,
+// AES-192 or AES-256, when it isn't nil.
+func (c *Configurator) Snapshot(w io.Writer, key []byte) error {
+	c.mu.RLock()
+	snap := SourceSnapshot{Time: c.last.Start, Sources: make([]SourceValues, len(c.contribs))}
+	if c.loaded != nil {
+		snap.Type = reflect.TypeOf(c.loaded).String()
+	}
+	for i, sv := range c.contribs {
+		values := make(map[string]string, len(sv.Values))
+		for k, v := range sv.Values {
+			values[k] = v
+		}
+		snap.Sources[i] = SourceValues{Name: sv.Name, Values: values, Secrets: sv.Secrets}
+	}
+	c.mu.RUnlock()
+
+	var seal func(string) (string, error)
+	if key != nil {
+		aead, err := (&snapshot{key: key}).aead()
+		if err != nil {
+			return fmt.Errorf("configurator/Snapshot: %w", err)
+		}
+		seal = func(v string) (string, error) {
+			nonce := make([]byte, aead.NonceSize())
+			if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+				return "", err
+			}
+			return encryptedPrefix + base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(v), nil)), nil
+		}
+	}
+	for _, sv := range snap.Sources {
+		for _, path := range sv.Secrets {
+			if seal == nil {
+				sv.Values[path] = secretMask
+				continue
+			}
+			sealed, err := seal(sv.Values[path])
+			if err != nil {
+				return fmt.Errorf("configurator/Snapshot: %w [%s]", err, path)
+			}
+			sv.Values[path] = sealed
+		}
+	}
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(snap)
+}
+
+// LoadFromSnapshot loads v as LoadContext does, but from the values of a
+// snapshot written by Snapshot in place of the sources of c, each set
+// under the name of its source. Encrypted secrets are decrypted with key,
+// masked ones left unset. Like Diagnose, it records nothing on c: its
+// values, history, audit trail and WithSnapshot file stay those of the last
+// load.
+func (c *Configurator) LoadFromSnapshot(ctx context.Context, r io.Reader, key []byte, v interface{}) error {
+	var snap SourceSnapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("configurator/LoadFromSnapshot: %w: %v", ErrInvalidSnapshot, err)
+	}
+	var open func(string) (string, error)
+	if key != nil {
+		aead, err := (&snapshot{key: key}).aead()
+		if err != nil {
+			return fmt.Errorf("configurator/LoadFromSnapshot: %w", err)
+		}
+		open = func(v string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, encryptedPrefix))
+			if err != nil || len(b) < aead.NonceSize() {
+				return "", ErrInvalidSnapshot
+			}
+			plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+			if err != nil {
+				return "", ErrInvalidSnapshot
+			}
+			return string(plain), nil
+		}
+	}
+	providers := make([]Provider, len(snap.Sources))
+	for i, sv := range snap.Sources {
+		values := make(map[string]string, len(sv.Values))
+		for k, v := range sv.Values {
+			values[k] = v
+		}
+		for _, path := range sv.Secrets {
+			val, ok := values[path]
+			switch {
+			case !ok:
+			case !strings.HasPrefix(val, encryptedPrefix):
+				delete(values, path)
+			case open == nil:
+				return fmt.Errorf("configurator/LoadFromSnapshot: %w, encrypted secret without a key [%s]", ErrInvalidSnapshot, path)
+			default:
+				plain, err := open(val)
+				if err != nil {
+					return fmt.Errorf("configurator/LoadFromSnapshot: %w [%s]", err, path)
+				}
+				values[path] = plain
+			}
+		}
+		providers[i] = &replaySource{name: sv.Name, values: values}
+	}
+	_, _, err := c.load(context.WithValue(ctx, replayKey{}, providers), v, true)
+	return err
+}
+
+// replaySource sets the values a source set in a snapshot.
+type replaySource struct {
+	name   string
+	values map[string]string
+}
+
+func (p *replaySource) Provide(_ interface{}, si StructInfo) error {
+	for _, fi := range si.Fields() {
+		val, ok := p.values[fi.Path()]
+		if !ok {
+			continue
+		}
+		if err := fi.Set(val); err != nil {
+			return fmt.Errorf("replaySource/Provide: %w [%s]", err, fi.Path())
+		}
+	}
+	return nil
+}
+
+func (p *replaySource) String() string {
+	return p.name
+}
+
+type replayKey struct{}
+
+// replayFrom returns the providers replaying a snapshot in place of the
+// sources of the configurator, nil when not replaying.
+func replayFrom(ctx context.Context) []Provider {
+	providers, _ := ctx.Value(replayKey{}).([]Provider)
+	return providers
+}
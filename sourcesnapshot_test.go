@@ -0,0 +1,88 @@
+package configurator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type snapshotConfig struct {
+	Name     string `config:"env,default=api"`
+	Port     int    `config:"env,default=8080"`
+	Tags     []string
+	Password string `config:"env,secret"`
+}
+
+func TestSourceSnapshot(t *testing.T) {
+	t.Parallel()
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(),
+		WithEnviron([]string{"PORT=9090", "PASSWORD=s3cret"}),
+		WithProvider(MapSource(map[string]string{"Tags": "a,b", "Port": "7070"})))
+	cfg := &snapshotConfig{}
+	assert.NoError(t, c.Load(cfg))
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Snapshot(&buf, nil))
+	var snap SourceSnapshot
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &snap))
+	assert.Equal(t, "*configurator.snapshotConfig", snap.Type)
+	assert.Equal(t, []SourceValues{
+		{Name: "env", Values: map[string]string{"Port": "9090", "Password": secretMask}, Secrets: []string{"Password"}},
+		{Name: "map", Values: map[string]string{"Tags": "a,b", "Port": "7070"}},
+		{Name: "default", Values: map[string]string{"Name": "api"}},
+	}, snap.Sources)
+
+	// replayed without the sources, masked secrets left unset
+	replay := NewConfigurator(WithFileProvider(""))
+	got := &snapshotConfig{}
+	assert.NoError(t, replay.LoadFromSnapshot(context.Background(), bytes.NewReader(buf.Bytes()), nil, got))
+	assert.Equal(t, snapshotConfig{Name: "api", Port: 7070, Tags: []string{"a", "b"}}, *got)
+	assert.Empty(t, replay.Provenance())
+
+	key := bytes.Repeat([]byte{7}, 32)
+	buf.Reset()
+	assert.NoError(t, c.Snapshot(&buf, key))
+	assert.NotContains(t, buf.String(), "s3cret")
+	got = &snapshotConfig{}
+	assert.NoError(t, replay.LoadFromSnapshot(context.Background(), bytes.NewReader(buf.Bytes()), key, got))
+	assert.Equal(t, "s3cret", got.Password)
+
+	err := replay.LoadFromSnapshot(context.Background(), bytes.NewReader(buf.Bytes()), nil, &snapshotConfig{})
+	assert.True(t, errors.Is(err, ErrInvalidSnapshot))
+	err = replay.LoadFromSnapshot(context.Background(), bytes.NewReader(buf.Bytes()), bytes.Repeat([]byte{8}, 32), &snapshotConfig{})
+	assert.True(t, errors.Is(err, ErrInvalidSnapshot))
+	err = replay.LoadFromSnapshot(context.Background(), strings.NewReader("{"), nil, &snapshotConfig{})
+	assert.True(t, errors.Is(err, ErrInvalidSnapshot))
+}
+
+func TestLoadFromSnapshot_Dry(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.snap")
+	key := []byte("0123456789abcdef")
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"PORT=9090"}),
+		WithSnapshot(filename, key), WithHistory(4), WithProvider(&flakyFetcher{value: "remote"}))
+	cfg := &snapshotConfig{}
+	assert.NoError(t, c.Load(cfg))
+	saved, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, json.NewEncoder(&buf).Encode(SourceSnapshot{Sources: []SourceValues{
+		{Name: "replayed", Values: map[string]string{"Name": "old", "Port": "1"}},
+	}}))
+	got := &snapshotConfig{}
+	assert.NoError(t, c.LoadFromSnapshot(context.Background(), &buf, nil, got))
+	assert.Equal(t, snapshotConfig{Name: "old", Port: 1}, *got)
+
+	b, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, saved, b)
+	assert.Len(t, c.History(), 1)
+	assert.Equal(t, map[string]string{"Name": "*configurator.flakyFetcher", "Port": "env"}, c.Provenance())
+}
@@ -0,0 +1,62 @@
+// Package spiffe makes the X.509 SVID of the workload the identity of the
+// remote sources of configurator, so they can reach config services behind
+// a zero-trust network. The SVID comes from the SPIFFE Workload API, as
+// served by SPIRE, and is rotated by it without a reload. Consul Connect
+// certificates written to files work with configurator.TLSConfig instead.
+//
+// It lives in its own module to keep go-spiffe out of the core
+// dependencies.
+package spiffe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/ruosing/configurator"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+)
+
+// Identity presents an SVID to servers and accepts those whose SPIFFE ID
+// the authorizer allows.
+type Identity struct {
+	svid       x509svid.Source
+	bundles    x509bundle.Source
+	authorizer tlsconfig.Authorizer
+	source     *workloadapi.X509Source
+}
+
+var _ configurator.Identity = &Identity{}
+
+// NewIdentity connects to the Workload API at SPIFFE_ENDPOINT_SOCKET, or
+// the address given with workloadapi.WithClientOptions, and waits for the
+// first SVID. Close it when done.
+//
+//	id, err := spiffe.NewIdentity(ctx, tlsconfig.AuthorizeID(spiffeid.RequireFromString("spiffe://example.org/config")))
+func NewIdentity(ctx context.Context, authorizer tlsconfig.Authorizer, opts ...workloadapi.X509SourceOption) (*Identity, error) {
+	source, err := workloadapi.NewX509Source(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("spiffe/NewIdentity: %w", err)
+	}
+	return &Identity{svid: source, bundles: source, authorizer: authorizer, source: source}, nil
+}
+
+// FromSources returns an identity using sources managed by the caller.
+func FromSources(svid x509svid.Source, bundles x509bundle.Source, authorizer tlsconfig.Authorizer) *Identity {
+	return &Identity{svid: svid, bundles: bundles, authorizer: authorizer}
+}
+
+func (i *Identity) ClientTLSConfig() (*tls.Config, error) {
+	return tlsconfig.MTLSClientConfig(i.svid, i.bundles, i.authorizer), nil
+}
+
+// Close closes the Workload API connection of an identity from NewIdentity.
+func (i *Identity) Close() error {
+	if i.source == nil {
+		return nil
+	}
+	return i.source.Close()
+}
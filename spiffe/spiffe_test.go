@@ -0,0 +1,111 @@
+package spiffe
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ruosing/configurator"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/assert"
+)
+
+type ca struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCA(t *testing.T) *ca {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: "example.org"}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &ca{cert: cert, key: key}
+}
+
+func (c *ca) svid(t *testing.T, id spiffeid.ID) *x509svid.SVID {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		URIs:         []*url.URL{id.URL()},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, c.cert, &key.PublicKey, c.key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	svid, err := x509svid.Parse(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	)
+	assert.NoError(t, err)
+	return svid
+}
+
+func TestIdentity(t *testing.T) {
+	ca := newCA(t)
+	td := spiffeid.RequireTrustDomainFromString("example.org")
+	bundle := x509bundle.FromX509Authorities(td, []*x509.Certificate{ca.cert})
+	serverID := spiffeid.RequireFromString("spiffe://example.org/config")
+	clientID := spiffeid.RequireFromString("spiffe://example.org/api")
+
+	// httptest would add its own certificate, which wins over the SVID
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsconfig.MTLSServerConfig(ca.svid(t, serverID), bundle, tlsconfig.AuthorizeID(clientID)))
+	assert.NoError(t, err)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := x509svid.IDFromCert(r.TLS.PeerCertificates[0])
+			assert.NoError(t, err)
+			_, _ = io.WriteString(w, id.String())
+		}),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go func() { _ = srv.Serve(ln) }()
+	defer srv.Close()
+	addr := "https://" + ln.Addr().String()
+
+	id := FromSources(ca.svid(t, clientID), bundle, tlsconfig.AuthorizeID(serverID))
+	defer id.Close()
+	client, err := configurator.NewHTTPClient(id)
+	assert.NoError(t, err)
+	resp, err := client.Get(addr)
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, clientID.String(), string(b))
+	}
+
+	// a server with another SPIFFE ID is rejected
+	other := FromSources(ca.svid(t, clientID), bundle, tlsconfig.AuthorizeID(spiffeid.RequireFromString("spiffe://example.org/other")))
+	client, err = configurator.NewHTTPClient(other)
+	assert.NoError(t, err)
+	_, err = client.Get(addr)
+	assert.Error(t, err)
+}
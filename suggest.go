@@ -0,0 +1,56 @@
+package configurator
+
+import (
+	"sort"
+	"strings"
+)
+
+// Suggest returns the candidates close to key, closest first, to tell what
+// an unknown key, likely a typo, was meant to be.
+func Suggest(key string, candidates []string) []string {
+	limit := 2
+	if len(key) < 5 {
+		limit = 1
+	}
+	dist := make(map[string]int)
+	var near []string
+	for _, cand := range candidates {
+		if cand == key {
+			continue
+		}
+		if d := editDistance(strings.ToLower(key), strings.ToLower(cand)); d <= limit {
+			if _, ok := dist[cand]; !ok {
+				near = append(near, cand)
+			}
+			dist[cand] = d
+		}
+	}
+	sort.SliceStable(near, func(i, j int) bool {
+		if dist[near[i]] != dist[near[j]] {
+			return dist[near[i]] < dist[near[j]]
+		}
+		return near[i] < near[j]
+	})
+	return near
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,15 @@
+package configurator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"port", "PORTS"}, Suggest("PORT", []string{"PORTS", "PORT", "HOST", "port"}))
+	assert.Equal(t, []string{"DATABASE_URL", "DATABSE_URL"}, Suggest("DATABASE_URI", []string{"DATABSE_URL", "DATABASE_URL", "URL"}))
+	assert.Empty(t, Suggest("PORT", []string{"HOST"}))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
@@ -0,0 +1,121 @@
+package configurator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// Summary describes the configuration applied by the last successful load
+// in a few lines, for services to log at startup.
+type Summary struct {
+	Type     string          `json:"type"`
+	Profile  string          `json:"profile,omitempty"`
+	Checksum string          `json:"checksum"`
+	Sources  []SummarySource `json:"sources"`
+	// Settings are the fields a source other than the defaults set, by
+	// path. Values of fields tagged secret are masked.
+	Settings []Setting `json:"settings"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// SummarySource is a source of a Summary.
+type SummarySource struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Keys    int    `json:"keys"`
+}
+
+// Setting is a field of a Summary and the source that set it.
+type Setting struct {
+	Path   string `json:"path"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// WithSummary writes the Summary of the first successful load to w in
+// format, "text" for people or "json" for a single line to ingest, in place
+// of the blocks printing settings services carry.
+func WithSummary(w io.Writer, format string) ConfiguratorOption {
+	return func(co *ConfiguratorOptions) {
+		co.banner = &banner{w: w, format: format}
+	}
+}
+
+type banner struct {
+	w      io.Writer
+	format string
+	once   sync.Once
+}
+
+// Summary returns the summary of the configuration applied by the last
+// successful load.
+func (c *Configurator) Summary() Summary {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	s := Summary{Profile: c.active, Findings: append([]Finding(nil), c.findings...)}
+	if c.loaded != nil {
+		s.Type = reflect.TypeOf(c.loaded).String()
+	}
+	if c.values != nil {
+		s.Checksum = c.checksum(false)
+	}
+	for _, src := range c.sources {
+		s.Sources = append(s.Sources, SummarySource{Name: src.Name, Version: src.Version, Keys: src.Keys})
+	}
+	for _, fs := range c.schema {
+		origin, ok := c.origins[fs.Path]
+		if !ok || origin == defaultProviderName {
+			continue
+		}
+		val := formatValue(c.values[fs.Path])
+		if fs.Secret {
+			val = secretMask
+		}
+		s.Settings = append(s.Settings, Setting{Path: fs.Path, Value: val, Source: origin})
+	}
+	sort.Slice(s.Settings, func(i, j int) bool { return s.Settings[i].Path < s.Settings[j].Path })
+	return s
+}
+
+// WriteSummary writes the Summary to w in format, "text" or "json".
+func (c *Configurator) WriteSummary(w io.Writer, format string) error {
+	s := c.Summary()
+	switch format {
+	case "json":
+		return json.NewEncoder(w).Encode(s)
+	case "text":
+	default:
+		return fmt.Errorf("configurator/WriteSummary: %w format [%s]", ErrUnsupported, format)
+	}
+
+	head := fmt.Sprintf("configuration %s", s.Type)
+	if len(s.Checksum) > 12 {
+		head += " " + s.Checksum[:12]
+	}
+	if s.Profile != "" {
+		head += ", profile " + s.Profile
+	}
+	sources := make([]string, len(s.Sources))
+	for i, src := range s.Sources {
+		sources[i] = fmt.Sprintf("%s (%d keys)", src.Name, src.Keys)
+		if src.Version != "" {
+			sources[i] = fmt.Sprintf("%s (%s, %d keys)", src.Name, src.Version, src.Keys)
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, head)
+	fmt.Fprintf(tw, "  sources\t%s\n", strings.Join(sources, ", "))
+	for _, st := range s.Settings {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", st.Path, st.Value, st.Source)
+	}
+	for _, f := range s.Findings {
+		fmt.Fprintf(tw, "  warning\t%s: %s\t%s\n", f.Path, f.Message, f.Rule)
+	}
+	return tw.Flush()
+}
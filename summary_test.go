@@ -0,0 +1,55 @@
+package configurator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	type example struct {
+		Port     int    `config:"env,default=8080"`
+		Host     string `config:"env,default=localhost"`
+		Password string `config:"env,secret"`
+	}
+	var out bytes.Buffer
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithENVProvider(""),
+		WithDefaultProvider(),
+		WithEnviron([]string{"PORT=9090", "PASSWORD=s3cret"}),
+		WithProfile("prod"),
+		WithSummary(&out, "text"),
+	)
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+
+	s := c.Summary()
+	assert.Equal(t, "*configurator.example", s.Type)
+	assert.Equal(t, "prod", s.Profile)
+	assert.Equal(t, c.Checksum(false), s.Checksum)
+	assert.Equal(t, []Setting{
+		{Path: "Password", Value: secretMask, Source: "env"},
+		{Path: "Port", Value: "9090", Source: "env"},
+	}, s.Settings)
+
+	text := out.String()
+	assert.Contains(t, text, "configuration *configurator.example "+s.Checksum[:12]+", profile prod\n")
+	assert.Contains(t, text, "env (2 keys)")
+	assert.Regexp(t, `Port +9090 +env`, text)
+	assert.NotContains(t, text, "s3cret")
+
+	out.Reset()
+	assert.NoError(t, c.Load(cfg))
+	assert.Empty(t, out.String(), "only the first load prints")
+
+	assert.NoError(t, c.WriteSummary(&out, "json"))
+	var decoded Summary
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, c.Summary(), decoded)
+
+	assert.True(t, errors.Is(c.WriteSummary(&out, "xml"), ErrUnsupported))
+}
@@ -0,0 +1,46 @@
+package configurator
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isTextUnmarshaler reports whether a pointer to t implements
+// encoding.TextUnmarshaler, as uuid.UUID, ulid.ULID or netip.Addr do, so
+// that values of t parse with UnmarshalText. Pointers are parsed as the
+// type they point to.
+func isTextUnmarshaler(t reflect.Type) bool {
+	return t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func isTextMarshaler(t reflect.Type) bool {
+	return t.Kind() != reflect.Ptr && (t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType))
+}
+
+// setText sets val, of a type for which isTextUnmarshaler holds, to v.
+func setText(val reflect.Value, typ reflect.Type, v string) error {
+	ptr := reflect.New(typ)
+	if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(v)); err != nil {
+		return fmt.Errorf("%w, not a %s: %w", ErrInvalidValue, typ, err)
+	}
+	val.Set(ptr.Elem())
+	return nil
+}
+
+// formatText renders v, of a type for which isTextMarshaler holds, with
+// MarshalText.
+func formatText(v reflect.Value) string {
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	b, err := ptr.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(b)
+}
@@ -0,0 +1,61 @@
+package configurator
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testUUID parses as uuid.UUID does.
+type testUUID [16]byte
+
+func (u *testUUID) UnmarshalText(b []byte) error {
+	s := strings.ReplaceAll(string(b), "-", "")
+	if len(s) != 32 {
+		return fmt.Errorf("invalid UUID length: %d", len(b))
+	}
+	_, err := hex.Decode(u[:], []byte(s))
+	return err
+}
+
+func (u testUUID) MarshalText() ([]byte, error) {
+	s := hex.EncodeToString(u[:])
+	return []byte(s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]), nil
+}
+
+func TestTextUnmarshaler(t *testing.T) {
+	type tenant struct {
+		ID testUUID `config:"env"`
+	}
+	type example struct {
+		Instance testUUID   `config:"env"`
+		Previous *testUUID  `config:"env"`
+		Peers    []testUUID `config:"env"`
+		Addr     netip.Addr `config:"env,default=127.0.0.1"`
+		Tenant   tenant
+	}
+	id := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	environ := []string{"INSTANCE=" + id, "PREVIOUS=" + id, "PEERS=" + id + "," + id, "TENANT_ID=" + id}
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(environ))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	var want testUUID
+	assert.NoError(t, want.UnmarshalText([]byte(id)))
+	assert.Equal(t, want, cfg.Instance)
+	assert.Equal(t, &want, cfg.Previous)
+	assert.Equal(t, []testUUID{want, want}, cfg.Peers)
+	assert.Equal(t, netip.MustParseAddr("127.0.0.1"), cfg.Addr)
+	assert.Equal(t, want, cfg.Tenant.ID)
+	assert.Equal(t, id, c.report().Config["Instance"])
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"TENANT_ID=abc"}))
+	err := c.Load(&example{})
+	if assert.True(t, errors.Is(err, ErrInvalidValue)) {
+		assert.Contains(t, err.Error(), "Tenant.ID: invalid value, not a configurator.testUUID: invalid UUID length: 3")
+	}
+}
@@ -0,0 +1,134 @@
+package configurator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// TLSConfig is the TLS section most services carry. Use it as a field of
+// the configuration struct and turn it into a *tls.Config with Config.
+// Certificates are read again on every Reload, so rotated files are served
+// by a Watch-ed configuration without a restart.
+type TLSConfig struct {
+	CertFile   string `json:"cert_file" yaml:"cert_file" config:"env"`
+	KeyFile    string `json:"key_file" yaml:"key_file" config:"env"`
+	CAFile     string `json:"ca_file" yaml:"ca_file" config:"env"`
+	MinVersion string `json:"min_version" yaml:"min_version" config:"env,enum=1.0,1.1,1.2,1.3,default=1.2"`
+	ClientAuth string `json:"client_auth" yaml:"client_auth" config:"env,enum=none,request,require,verify-if-given,require-and-verify,default=none"`
+
+	// loaded is shared by copies, so that Reload updates the one in use.
+	loaded *tlsLoaded
+}
+
+type tlsLoaded struct {
+	atomic.Pointer[tlsState]
+}
+
+type tlsState struct {
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+var tlsVersions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var tlsClientAuth = map[string]tls.ClientAuthType{
+	"":                   tls.NoClientCert,
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// Config returns a *tls.Config for servers and clients alike: the
+// certificate is presented through GetCertificate and GetClientCertificate,
+// and the CA file is used both to verify clients and as the root CAs of
+// clients. Servers pick up a reloaded CA file too, clients only the
+// certificate.
+func (t *TLSConfig) Config() (*tls.Config, error) {
+	version, ok := tlsVersions[t.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("TLSConfig/Config: %w min version %q", ErrEnum, t.MinVersion)
+	}
+	auth, ok := tlsClientAuth[t.ClientAuth]
+	if !ok {
+		return nil, fmt.Errorf("TLSConfig/Config: %w client auth %q", ErrEnum, t.ClientAuth)
+	}
+	if t.loaded == nil {
+		s, err := loadTLSState(t.CertFile, t.KeyFile, t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		t.loaded = &tlsLoaded{}
+		t.loaded.Store(s)
+	}
+	loaded := t.loaded
+
+	cfg := &tls.Config{
+		MinVersion: version,
+		ClientAuth: auth,
+		RootCAs:    loaded.Load().pool,
+		ClientCAs:  loaded.Load().pool,
+	}
+	if t.CertFile != "" {
+		cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return loaded.Load().cert, nil
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return loaded.Load().cert, nil
+		}
+	}
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := cfg.Clone()
+		c.GetConfigForClient = nil
+		c.ClientCAs = loaded.Load().pool
+		return c, nil
+	}
+	return cfg, nil
+}
+
+// reload reads the files of fresh into t, if t is in use. On error t keeps
+// serving what it had.
+func (t *TLSConfig) reload(v interface{}) error {
+	fresh := v.(*TLSConfig)
+	if t.loaded == nil {
+		return nil
+	}
+	s, err := loadTLSState(fresh.CertFile, fresh.KeyFile, fresh.CAFile)
+	if err != nil {
+		return err
+	}
+	t.loaded.Store(s)
+	return nil
+}
+
+func loadTLSState(certFile, keyFile, caFile string) (*tlsState, error) {
+	s := &tlsState{}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("TLSConfig/Config: %w [%s]", err, certFile)
+		}
+		s.cert = &cert
+	}
+	if caFile != "" {
+		b, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("TLSConfig/Config: %w [%s]", err, caFile)
+		}
+		s.pool = x509.NewCertPool()
+		if !s.pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("TLSConfig/Config: %w, no certificates [%s]", ErrEmptyValue, caFile)
+		}
+	}
+	return s, nil
+}
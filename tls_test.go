@@ -0,0 +1,100 @@
+package configurator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestCert(t *testing.T, dir, name string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tls.crt"), certPEM, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), certPEM, 0o600))
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Parallel()
+	type example struct {
+		Server struct {
+			TLS TLSConfig
+		}
+	}
+	dir := t.TempDir()
+	writeTestCert(t, dir, "first")
+	env := []string{
+		"SERVER_TLS_CERTFILE=" + filepath.Join(dir, "tls.crt"),
+		"SERVER_TLS_KEYFILE=" + filepath.Join(dir, "tls.key"),
+		"SERVER_TLS_CAFILE=" + filepath.Join(dir, "ca.crt"),
+		"SERVER_TLS_CLIENTAUTH=require-and-verify",
+	}
+
+	c := NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithDefaultProvider(), WithEnviron(env))
+	var cfg example
+	assert.NoError(t, c.Load(&cfg))
+	assert.Equal(t, "1.2", cfg.Server.TLS.MinVersion)
+
+	tc, err := cfg.Server.TLS.Config()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), tc.MinVersion)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tc.ClientAuth)
+	assert.NotNil(t, tc.ClientCAs)
+	cert, err := tc.GetCertificate(nil)
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "first", leaf.Subject.CommonName)
+
+	// rotated certificates are served after a reload
+	writeTestCert(t, dir, "second")
+	_, err = c.Reload(context.Background(), &cfg)
+	assert.NoError(t, err)
+	cert, err = tc.GetCertificate(nil)
+	assert.NoError(t, err)
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "second", leaf.Subject.CommonName)
+
+	// a broken rotation keeps the previous certificate
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tls.key"), []byte("garbage"), 0o600))
+	_, err = c.Reload(context.Background(), &cfg)
+	assert.Error(t, err)
+	cert, _ = tc.GetCertificate(nil)
+	leaf, _ = x509.ParseCertificate(cert.Certificate[0])
+	assert.Equal(t, "second", leaf.Subject.CommonName)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"SERVER_TLS_MINVERSION=1.4"}))
+	assert.Error(t, c.Load(&example{}))
+}
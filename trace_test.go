@@ -0,0 +1,60 @@
+package configurator
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []string
+}
+
+type recordedSpan struct {
+	t    *recordingTracer
+	name string
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, recordedSpan{t: t, name: name}
+}
+
+func (s recordedSpan) End(err error) {
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	if err != nil {
+		s.t.spans = append(s.t.spans, s.name+" error")
+		return
+	}
+	s.t.spans = append(s.t.spans, s.name)
+}
+
+func TestWithTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	c := NewConfigurator(
+		WithFileProvider(""),
+		WithTracer(tracer),
+		WithProvider(&flakyFetcher{value: "a"}),
+	)
+	assert.NoError(t, c.Load(&struct{ Name string }{}))
+	assert.Equal(t, []string{
+		"configurator.fetch *configurator.flakyFetcher",
+		"configurator.merge",
+		"configurator.Load",
+	}, tracer.spans)
+
+	tracer.spans = nil
+	c = NewConfigurator(
+		WithFileProvider(""),
+		WithTracer(tracer),
+		WithProvider(&flakyFetcher{failures: 1}),
+	)
+	assert.Error(t, c.Load(&struct{ Name string }{}))
+	assert.Equal(t, []string{
+		"configurator.fetch *configurator.flakyFetcher error",
+		"configurator.Load error",
+	}, tracer.spans)
+}
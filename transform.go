@@ -0,0 +1,83 @@
+package configurator
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TransformFunc rewrites the raw value of a field tagged
+// `transform=name` before it is parsed.
+type TransformFunc func(s string) (string, error)
+
+const transformExpandEnv = "expandenv"
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]TransformFunc{
+		"trim":  func(s string) (string, error) { return strings.TrimSpace(s), nil },
+		"lower": func(s string) (string, error) { return strings.ToLower(s), nil },
+		"upper": func(s string) (string, error) { return strings.ToUpper(s), nil },
+		// expanded with the env of the configurator, see parseOptions.transform
+		transformExpandEnv: nil,
+	}
+)
+
+// RegisterTransform makes fn available to `transform=a,b` tags, which run
+// the named transforms in order on the raw values of a field, from env
+// vars, flags, defaults and the scalars of files, before they are parsed.
+// Names are case insensitive; trim, lower, upper and expandenv, expanding
+// $VAR and ${VAR} from the env the configurator reads, are built in.
+// Register transforms before loading, as tags naming an unknown transform
+// fail.
+func RegisterTransform(name string, fn TransformFunc) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	transforms[strings.ToLower(name)] = fn
+}
+
+func lookupTransform(name string) (TransformFunc, bool) {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	fn, ok := transforms[strings.ToLower(name)]
+	return fn, ok
+}
+
+func transformNames() []string {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// transform runs the transforms of o on v.
+func (o parseOptions) transform(v string) (string, error) {
+	for _, name := range o.transforms {
+		if strings.EqualFold(name, transformExpandEnv) {
+			lookup := o.lookupEnv
+			if lookup == nil {
+				lookup = os.LookupEnv
+			}
+			v = os.Expand(v, func(k string) string {
+				s, _ := lookup(k)
+				return s
+			})
+			continue
+		}
+		fn, ok := lookupTransform(name)
+		if !ok {
+			return "", fmt.Errorf("%w, unknown transform %q", ErrInvalidTagFormat, name)
+		}
+		var err error
+		if v, err = fn(v); err != nil {
+			return "", fmt.Errorf("%w, transform %s: %w", ErrInvalidValue, name, err)
+		}
+	}
+	return v, nil
+}
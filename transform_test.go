@@ -0,0 +1,65 @@
+package configurator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	RegisterTransform("trimSlash", func(s string) (string, error) { return strings.TrimRight(s, "/"), nil })
+	RegisterTransform("nonEmpty", func(s string) (string, error) {
+		if s == "" {
+			return "", errors.New("empty")
+		}
+		return s, nil
+	})
+	type example struct {
+		Mode    string   `config:"env,transform=trim,lower,enum=fast,slow"`
+		BaseURL string   `config:"env,transform=expandenv,trimslash,default=http://${HOST}/"`
+		Hosts   []string `config:"env,transform=upper"`
+		Region  string   `yaml:"region" config:"transform=trim,upper"`
+		Name    string   `config:"env,transform=nonempty"`
+	}
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("region: ' eu-west-1 '\n"), 0o600))
+	environ := []string{"MODE= Fast\n", "HOST=example.com", "HOSTS_0=a", "HOSTS_1=b", "NAME=app"}
+	c := NewConfigurator(WithFileProvider(filename), WithENVProvider(""), WithDefaultProvider(), WithEnviron(environ))
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "fast", cfg.Mode)
+	assert.Equal(t, "http://example.com", cfg.BaseURL)
+	assert.Equal(t, []string{"A", "B"}, cfg.Hosts)
+	assert.Equal(t, "EU-WEST-1", cfg.Region)
+
+	c = NewConfigurator(WithFileProvider(""), WithENVProvider(""), WithEnviron([]string{"NAME="}))
+	err := c.Load(&example{})
+	if assert.True(t, errors.Is(err, ErrInvalidValue)) {
+		assert.Contains(t, err.Error(), "Name: invalid value, transform nonempty: empty")
+	}
+
+	type invalid struct {
+		Name string `config:"env,transform=trim,reverse"`
+	}
+	assert.True(t, errors.Is(NewConfigurator(WithFileProvider("")).Load(&invalid{}), ErrInvalidTagFormat))
+	assert.Empty(t, LintTags(&example{}))
+}
+
+func TestTransform_Flag(t *testing.T) {
+	resetForTesting()
+	type example struct {
+		Mode string `config:"flag,transform=lower,enum=fast,slow"`
+		Port int    `config:"flag,transform=trim"`
+	}
+	os.Args = []string{"jhon", "-mode=SLOW", "-port= 80 "}
+	tt := &example{}
+	si, err := getStructInfo(tt, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, NewFlagProvider().Provide(tt, si))
+	assert.Equal(t, "slow", tt.Mode)
+	assert.Equal(t, 80, tt.Port)
+}
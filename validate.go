@@ -0,0 +1,79 @@
+package configurator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Validator is implemented by configuration structs, or their sections,
+// that check their own values. Validate is called after all providers ran,
+// on the sections first.
+type Validator interface {
+	Validate() error
+}
+
+// callValidate calls Validate on the sections of fields, deepest first, and
+// then on v, skipping the sections switched off by `if=`. The sections are
+// those the loader walks: the fields of embedded structs belong to the
+// struct embedding them, which their Validate is promoted to.
+func callValidate(v reflect.Value, fields []FieldInfo) error {
+	var sections []*fieldInfo
+	seen := make(map[*fieldInfo]bool)
+	for _, fi := range fields {
+		f, ok := fi.(*fieldInfo)
+		if !ok {
+			continue
+		}
+		for p := f.parent; p != nil && !seen[p]; p = p.parent {
+			seen[p] = true
+			sections = append(sections, p)
+		}
+	}
+	sort.SliceStable(sections, func(i, j int) bool { return depth(sections[i]) > depth(sections[j]) })
+	for _, s := range sections {
+		if s.off() {
+			continue
+		}
+		if err := validate(s.val, s.Path()); err != nil {
+			return err
+		}
+	}
+	return validate(v, "")
+}
+
+func validate(v reflect.Value, path string) error {
+	if !v.CanAddr() {
+		return nil
+	}
+	val, ok := v.Addr().Interface().(Validator)
+	if !ok {
+		return nil
+	}
+	if err := val.Validate(); err != nil {
+		if path == "" {
+			return newFieldError(nil, fmt.Errorf("configurator/LoadContext: %w", err), "")
+		}
+		return newFieldError(nil, fmt.Errorf("configurator/LoadContext: %w [%s]", err, path), "", path)
+	}
+	return nil
+}
+
+func depth(f *fieldInfo) int {
+	n := 0
+	for p := f.parent; p != nil; p = p.parent {
+		n++
+	}
+	return n
+}
+
+// off reports whether the section f, or one around it, is switched off by
+// `if=`.
+func (f *fieldInfo) off() bool {
+	for p := f; p != nil; p = p.parent {
+		if p.disabled {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package configurator
+
+// ConfigVersion identifies the configuration applied by a load, for health
+// endpoints and logs.
+type ConfigVersion struct {
+	// Checksum is the checksum of the configuration without secrets.
+	Checksum string
+	// Sources are the versions reported by the sources implementing
+	// Versioner, by source name.
+	Sources map[string]string
+}
+
+// Checksum returns a SHA-256 of the configuration applied by the last
+// successful load, stable across processes and field order. The values of
+// fields tagged secret only count with secrets, so that rotating a secret
+// changes the checksum or not.
+func (c *Configurator) Checksum(secrets bool) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checksum(secrets)
+}
+
+// Version returns the version of the configuration applied by the last
+// successful load.
+func (c *Configurator) Version() ConfigVersion {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v := ConfigVersion{Checksum: c.checksum(false), Sources: make(map[string]string, len(c.versions))}
+	for k, s := range c.versions {
+		v.Sources[k] = s
+	}
+	return v
+}
+
+func (c *Configurator) checksum(secrets bool) string {
+	exclude := make(map[string]bool)
+	for _, s := range c.schema {
+		if s.Secret && !secrets {
+			exclude[s.Path] = true
+		}
+	}
+	return checksum(c.values, exclude)
+}
@@ -0,0 +1,41 @@
+package configurator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion(t *testing.T) {
+	type example struct {
+		Name     string `yaml:"name"`
+		Password string `yaml:"password" config:"env,secret"`
+	}
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(filename, []byte("name: api\n"), 0o600))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(filename, modTime, modTime))
+
+	load := func(password string) *Configurator {
+		c := NewConfigurator(WithFileProvider(filename), WithENVProvider(""), WithEnviron([]string{"PASSWORD=" + password}))
+		assert.NoError(t, c.Load(&example{}))
+		return c
+	}
+	c := load("first")
+	v := c.Version()
+	assert.Equal(t, c.Checksum(false), v.Checksum)
+	assert.NotEqual(t, c.Checksum(true), v.Checksum)
+	assert.Equal(t, map[string]string{"file": "2024-01-02T03:04:05Z"}, v.Sources)
+	assert.Equal(t, "2024-01-02T03:04:05Z", c.Health().Sources[0].Version)
+
+	// stable across configurators, secrets only count when asked
+	rotated := load("second")
+	assert.Equal(t, v.Checksum, rotated.Checksum(false))
+	assert.NotEqual(t, c.Checksum(true), rotated.Checksum(true))
+
+	assert.NoError(t, os.WriteFile(filename, []byte("name: web\n"), 0o600))
+	assert.NotEqual(t, v.Checksum, load("first").Checksum(false))
+}
@@ -0,0 +1,43 @@
+package configurator
+
+import "strings"
+
+// expandDottedKeys turns Viper style flat keys such as "mysql.host: db" into
+// nested maps. Keys that are already nested take precedence over dotted ones.
+func expandDottedKeys(raw map[string]any) {
+	for k, v := range raw {
+		if m, ok := v.(map[string]any); ok {
+			expandDottedKeys(m)
+		}
+		if !strings.Contains(k, ".") {
+			continue
+		}
+		delete(raw, k)
+		segs := strings.Split(k, ".")
+		m := raw
+		for _, s := range segs[:len(segs)-1] {
+			next, ok := m[s].(map[string]any)
+			if !ok {
+				if _, set := m[s]; set {
+					m = nil
+					break
+				}
+				next = map[string]any{}
+				m[s] = next
+			}
+			m = next
+		}
+		if m == nil {
+			continue
+		}
+		if _, set := m[segs[len(segs)-1]]; !set {
+			m[segs[len(segs)-1]] = v
+		}
+	}
+}
+
+// viperKey derives the env key Viper's AutomaticEnv with a "." to "_" key
+// replacer would use for the field at path.
+func viperKey(path string) string {
+	return strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
@@ -0,0 +1,53 @@
+package configurator
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithViperCompat(t *testing.T) {
+	type example struct {
+		Name  string
+		MySQL struct {
+			Host string
+			Port int
+		}
+	}
+
+	f, err := os.CreateTemp("", "*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString("name: app\nmysql.host: db\nmysql:\n  port: 3306\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewConfigurator(
+		WithFileProvider(f.Name()),
+		WithViperCompat("app"),
+		WithEnviron([]string{"APP_MYSQL_PORT=3307"}),
+	)
+	cfg := &example{}
+	assert.NoError(t, c.Load(cfg))
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, "db", cfg.MySQL.Host)
+	assert.Equal(t, 3307, cfg.MySQL.Port)
+}
+
+func TestExpandDottedKeys(t *testing.T) {
+	raw := map[string]any{
+		"a.b": 1,
+		"a":   map[string]any{"c": 2, "d.e": 3},
+		"x":   4,
+		"x.y": 5,
+		"a.c": 6,
+	}
+	expandDottedKeys(raw)
+	assert.Equal(t, map[string]any{
+		"a": map[string]any{"b": 1, "c": 2, "d": map[string]any{"e": 3}},
+		"x": 4,
+	}, raw)
+}
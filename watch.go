@@ -0,0 +1,173 @@
+package configurator
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Reload loads the configuration into a fresh value of v's type and
+// returns it. v itself is left alone, so readers of v never race with the
+// reload, except for its Dynamic fields which are updated in place and its
+// TLSConfig and LogConfig sections which apply the reload. v must have been
+// loaded before. The participants registered with Register accept or
+// reject the reload first.
+func (c *Configurator) Reload(ctx context.Context, v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, ErrInvalidConfig
+	}
+	fresh := reflect.New(rv.Elem().Type()).Interface()
+	if err := c.LoadContext(context.WithValue(c.activeProfile(ctx), reloadKey{}, true), fresh); err != nil {
+		return nil, err
+	}
+	if err := updateDynamic(v, fresh); err != nil {
+		return nil, err
+	}
+	if err := reloadSections(reflect.ValueOf(v).Elem(), reflect.ValueOf(fresh).Elem()); err != nil {
+		return nil, err
+	}
+	c.commit(fresh)
+	c.logger.Debug("configurator: reloaded", "type", rv.Type().String())
+	return fresh, nil
+}
+
+// Watch calls Reload every interval, or never if interval is 0, and every
+// time a provider implementing Watcher reports a change, until ctx is done,
+// passing the result to onReload. It also reloads to refresh the leases of
+// expiring values, see WithLeaseExpired.
+func (c *Configurator) Watch(ctx context.Context, v interface{}, interval time.Duration, onReload func(interface{}, error)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	for _, p := range c.providers {
+		if w, ok := p.(Watcher); ok {
+			wg.Add(1)
+			go func(p Provider) {
+				defer wg.Done()
+				c.watch(ctx, w, providerName(p), interval, changed)
+			}(p)
+		}
+	}
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		var due <-chan time.Time
+		var timer *time.Timer
+		if next := c.nextLease(); !next.IsZero() {
+			timer = time.NewTimer(next.Sub(c.now()))
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-tick:
+		case <-due:
+		case <-changed:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		nv, err := c.Reload(ctx, v)
+		if err != nil {
+			c.logger.Debug("configurator: reload failed", "error", err)
+			c.expire(err)
+		}
+		if onReload != nil {
+			onReload(nv, err)
+		}
+	}
+}
+
+// watch runs w until ctx is done, signalling changed on every change, and
+// watches again after retry, or a second, when watching fails.
+func (c *Configurator) watch(ctx context.Context, w Watcher, name string, retry time.Duration, changed chan<- struct{}) {
+	if retry <= 0 {
+		retry = time.Second
+	}
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	for {
+		err := w.Watch(ctx, notify)
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("configurator: watch failed", "source", name, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// updateDynamic copies the values of the Dynamic fields of src into dst.
+// Both are pointers to the same struct type.
+func updateDynamic(dst, src interface{}) error {
+	dsi, err := getStructInfo(dst, nil)
+	if err != nil {
+		return err
+	}
+	ssi, err := getStructInfo(src, nil)
+	if err != nil {
+		return err
+	}
+	sfields := ssi.Fields()
+	for i, fi := range dsi.Fields() {
+		d, ok := asDynamic(fi.Value())
+		if !ok {
+			continue
+		}
+		s, _ := asDynamic(sfields[i].Value())
+		d.store(s.load())
+	}
+	return nil
+}
+
+// reloader is implemented by the helper sections that act on a reload,
+// given the section of the fresh value.
+type reloader interface {
+	reload(fresh interface{}) error
+}
+
+// reloadSections calls reload on the sections of dst implementing
+// reloader, after the Dynamic fields were updated. dst and src are
+// values of the same struct type.
+func reloadSections(dst, src reflect.Value) error {
+	for dst.Kind() == reflect.Ptr {
+		if dst.IsNil() || src.IsNil() {
+			return nil
+		}
+		dst, src = dst.Elem(), src.Elem()
+	}
+	if dst.Kind() != reflect.Struct || !dst.CanAddr() {
+		return nil
+	}
+	if r, ok := dst.Addr().Interface().(reloader); ok {
+		return r.reload(src.Addr().Interface())
+	}
+	for i := 0; i < dst.NumField(); i++ {
+		if !dst.Field(i).CanSet() {
+			continue
+		}
+		if err := reloadSections(dst.Field(i), src.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}